@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"yt-text/config"
+	"yt-text/models"
+	"yt-text/repository"
+	"yt-text/repository/sqlite"
+	"yt-text/services/video"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// pollInterval is how often a one-off transcription checks on its job
+const pollInterval = time.Second
+
+// runTranscribe transcribes one URL in this process, without the HTTP
+// server, and writes the transcript to stdout or a file. A transcript
+// already stored for the URL is printed without running again.
+func runTranscribe(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("transcribe", flag.ExitOnError)
+	source := flags.String("source", "auto", "Transcript source: auto, captions_only, whisper_only, captions_then_whisper or whisper_if_captions_poor")
+	input := flags.String("type", "video", "What the URL points at: video, or audio_url for a direct MP3/WAV link")
+	language := flags.String("language", "", "Audio language code; detected if unset")
+	task := flags.String("task", "", "transcribe, or translate to English")
+	asJSON := flags.Bool("json", false, "Print the transcription resource as JSON instead of the text")
+	output := flags.String("o", "", "Write the transcript to this file instead of stdout")
+	verbose := flags.Bool("v", false, "Log progress")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: yt-text transcribe [flags] <url>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	pref, ok := video.ParseSourcePreference(*source)
+	if !ok {
+		return fmt.Errorf("unknown source %q", *source)
+	}
+	if *input != string(video.InputVideo) && *input != string(video.InputAudioURL) {
+		return fmt.Errorf("unknown type %q", *input)
+	}
+	setupCommandLogging(*verbose)
+
+	a, err := newApplication(cfg, video.ModeAll)
+	if err != nil {
+		return err
+	}
+	defer a.db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	v, err := a.videos.Transcribe(ctx, flags.Arg(0), video.TranscribeOptions{
+		Source: pref,
+		Input:  video.InputType(*input),
+		Whisper: models.WhisperOptions{
+			Language: *language,
+			Task:     models.Task(*task),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	v, err = waitForTranscription(ctx, a.videos, v)
+	if err != nil {
+		return err
+	}
+	if v.IsFailed() {
+		return fmt.Errorf("transcription failed: %s", v.Error)
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(models.NewVideoResponse(v))
+	}
+
+	text, err := a.videos.TranscriptionText(ctx, v.ID, v.Source)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, text); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out)
+	return err
+}
+
+// waitForTranscription polls a video until its job finishes. Interrupting
+// the command cancels the job.
+func waitForTranscription(ctx context.Context, service video.Service, v *models.Video) (*models.Video, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for v.IsProcessing() {
+		select {
+		case <-ctx.Done():
+			if _, err := service.CancelJob(context.Background(), v.ID); err != nil {
+				log.Error().Err(err).Str("video_id", v.ID).Msg("Failed to cancel job")
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		var err error
+		if v, err = service.GetTranscription(ctx, v.ID); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// runCleanup runs one pass of the cleanup the server does in the
+// background: deleting expired videos and orphaned transcripts, and tiering
+// cold transcripts
+func runCleanup(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	retention := flags.Duration("retention", cfg.Video.Retention, "Delete finished, unpinned videos not updated for this long; 0 only deletes those past their own expiry")
+	tierAfter := flags.Duration("tier-after", cfg.Storage.TierAfter, "Move transcripts not read for this long to storage; 0 skips tiering")
+	maxVideos := flags.Int("max", cfg.Video.CleanupMaxPerRun, "Delete at most this many videos; 0 for no limit")
+	dryRun := flags.Bool("dry-run", cfg.Video.CleanupDryRun, "Only count the expired videos and orphaned transcripts")
+	verbose := flags.Bool("v", false, "Log each step")
+	flags.Parse(args)
+	setupCommandLogging(*verbose)
+
+	cfg.Video.CleanupMaxPerRun = *maxVideos
+	a, err := newApplication(cfg, video.ModeAll)
+	if err != nil {
+		return err
+	}
+	defer a.db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	deleted, err := a.videos.CleanupExpired(ctx, *retention, *dryRun)
+	if err != nil {
+		return err
+	}
+	tiered, ok := a.repo.(*repository.OffloadRepository)
+	if *dryRun {
+		fmt.Printf("Would delete %d expired videos\n", deleted)
+		if ok {
+			orphans, err := tiered.ReapOrphans(ctx, true)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Would delete %d orphaned transcripts\n", orphans)
+		}
+		return nil
+	}
+	fmt.Printf("Deleted %d expired videos\n", deleted)
+
+	if ok {
+		orphans, err := tiered.ReapOrphans(ctx, false)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %d orphaned transcripts\n", orphans)
+	}
+
+	if ok && *tierAfter > 0 {
+		moved, err := tiered.TierColdTranscripts(ctx, time.Now().Add(-*tierAfter))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Moved %d cold transcripts to storage\n", moved)
+	}
+
+	return nil
+}
+
+// runMigrate brings the database schema up to date. Opening a database
+// applies its pending migrations, so the server would do the same on
+// start; running it first keeps a slow migration out of a deploy's
+// startup. A SQLite schema can also be taken back to an earlier version.
+func runMigrate(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	to := flags.Int("to", -1, "Migrate a SQLite database to this schema version, reverting newer migrations; the latest by default")
+	flags.Parse(args)
+	setupCommandLogging(false)
+
+	if cfg.Database.Driver == config.DriverSQLite {
+		from, current, err := sqlite.Migrate(cfg.Database.Path, *to, cfg.Database.BusyTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		if from == current {
+			fmt.Printf("Database schema is up to date (sqlite, version %d)\n", current)
+		} else {
+			fmt.Printf("Migrated database schema from version %d to %d (sqlite)\n", from, current)
+		}
+		return nil
+	}
+	if *to >= 0 {
+		return fmt.Errorf("only SQLite schemas can be migrated to a given version")
+	}
+
+	_, db, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	defer db.Close()
+
+	fmt.Printf("Database schema is up to date (%s)\n", cfg.Database.Driver)
+	return nil
+}
+
+// setupCommandLogging sends logs to stderr, keeping stdout for a command's
+// output. Only warnings and errors are logged unless verbose is set.
+func setupCommandLogging(verbose bool) {
+	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}).
+		With().
+		Timestamp().
+		Logger()
+	if !verbose {
+		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	}
+}
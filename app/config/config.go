@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,10 +18,20 @@ type Config struct {
 	IdleTimeout  time.Duration `json:"idle_timeout"`
 	Debug        bool          `json:"debug"`
 
+	// Mode is ModeAll, ModeAPI or ModeWorker
+	Mode string `json:"mode"`
+
+	// File is the YAML file settings were read from, if any
+	File string `json:"file,omitempty"`
+
 	// Application paths
 	LogDir  string `json:"log_dir"`
 	TempDir string `json:"temp_dir"`
 
+	// StaticDir serves the frontend from a directory instead of the copy
+	// embedded in the binary, so it can be edited without rebuilding
+	StaticDir string `json:"static_dir"`
+
 	// Middleware settings
 	Middleware MiddlewareConfig `json:"middleware"`
 
@@ -33,15 +44,49 @@ type Config struct {
 	// Database settings
 	Database DatabaseConfig `json:"database"`
 
+	// Transcript storage outside the database
+	Storage StorageConfig `json:"storage"`
+
 	// Video configurations
 	Video VideoConfig `json:"video"`
 
+	// Transcript summaries
+	Summary SummaryConfig `json:"summary"`
+
+	// Questions about transcripts
+	Ask AskConfig `json:"ask"`
+
+	// Embeddings for semantic search
+	Embeddings EmbeddingsConfig `json:"embeddings"`
+
+	// Admin API settings
+	Admin AdminConfig `json:"admin"`
+
+	// User accounts
+	Accounts AccountsConfig `json:"accounts"`
+
+	// Expiring links to transcripts
+	Sharing SharingConfig `json:"sharing"`
+
+	// API versioning
+	API APIConfig `json:"api"`
+
+	// Maintenance mode
+	Maintenance MaintenanceConfig `json:"maintenance"`
+
+	// YouTube integration
+	YouTube YouTubeConfig `json:"youtube"`
+
 	// Application version
 	Version string `json:"version"`
 
 	// Request and shutdown timeouts
 	RequestTimeout  time.Duration `json:"request_timeout"`
 	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+
+	// DrainTimeout is how long shutdown waits for running transcriptions
+	// before interrupting them; interrupted ones run again after a restart
+	DrainTimeout time.Duration `json:"drain_timeout"`
 }
 
 type MiddlewareConfig struct {
@@ -56,21 +101,322 @@ type MiddlewareConfig struct {
 	EnableDebugMode bool `json:"enable_debug_mode"`
 }
 
+// Server modes selectable with SERVER_MODE or serve --mode. Several API
+// and worker processes can share a postgres database.
+const (
+	ModeAll    = "all"    // API server and workers in one process
+	ModeAPI    = "api"    // API server only; stored jobs wait for workers
+	ModeWorker = "worker" // Workers only, claiming stored jobs
+)
+
+// Database drivers selectable with DATABASE_DRIVER
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory" // Nothing is kept across restarts; for demos
+)
+
 type DatabaseConfig struct {
+	// Driver is DriverSQLite, stored at Path, DriverPostgres, reached at
+	// URL, which lets several replicas share one store, or DriverMemory
+	Driver string `json:"driver"`
+	URL    string `json:"url"`
+
 	Path               string        `json:"path"`
 	MaxConnections     int           `json:"max_connections"`
 	MaxIdleConnections int           `json:"max_idle_connections"`
 	ConnMaxLifetime    time.Duration `json:"conn_max_lifetime"`
+
+	// BusyTimeout is how long a SQLite connection waits for a lock held by
+	// another process
+	BusyTimeout time.Duration `json:"busy_timeout"`
+
+	// Transcripts of at least CompressMinSize bytes are stored zstd-compressed
+	CompressTranscripts bool `json:"compress_transcripts"`
+	CompressMinSize     int  `json:"compress_min_size"`
+
+	// In-process cache of recently used videos; CacheSize 0 disables it
+	CacheSize     int `json:"cache_size"`
+	CacheMaxBytes int `json:"cache_max_bytes"`
+
+	// TranscriptCacheMaxBytes bounds the in-process cache of transcripts
+	// streamed as text; 0 disables it
+	TranscriptCacheMaxBytes int `json:"transcript_cache_max_bytes"`
+
+	// MaintenanceInterval is how often a SQLite database is checkpointed,
+	// analyzed and vacuumed; 0 leaves it to the admin endpoint
+	MaintenanceInterval time.Duration `json:"maintenance_interval"`
+}
+
+// What STORAGE_QUOTA_POLICY does when the local backend reaches
+// STORAGE_MAX_BYTES
+const (
+	QuotaEvict  = "evict"  // Delete the least recently read videos to make room
+	QuotaReject = "reject" // Turn away new submissions until there is room
+)
+
+// How STORAGE_COMPRESSION stores transcripts in the backend
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+type StorageConfig struct {
+	// Backend is "db" to keep transcripts inline, "local" for files under
+	// Path, or "spaces" for an S3-compatible bucket
+	Backend string `json:"backend"`
+	Path    string `json:"path"`
+
+	// Transcripts smaller than MinSize bytes stay in the database
+	MinSize int `json:"min_size"`
+
+	// TierAfter keeps transcripts in the database until they haven't been
+	// read for this long; zero moves them to the backend right away
+	TierAfter time.Duration `json:"tier_after"`
+
+	// MaxBytes caps the bytes the local backend may hold, 0 for no cap;
+	// QuotaPolicy decides what happens at the cap
+	MaxBytes    int64  `json:"max_bytes"`
+	QuotaPolicy string `json:"quota_policy"`
+
+	// Compression is how transcripts are compressed in the backend. Those
+	// already stored are read whatever it is set to.
+	Compression string `json:"compression"`
+
+	SpacesEndpoint  string `json:"spaces_endpoint"`
+	SpacesRegion    string `json:"spaces_region"`
+	SpacesBucket    string `json:"spaces_bucket"`
+	SpacesAccessKey string `json:"-"`
+	SpacesSecretKey string `json:"-"`
 }
 
 type VideoConfig struct {
 	ProcessTimeout time.Duration `json:"process_timeout"`
 	MaxDuration    time.Duration `json:"max_duration"`
-	// MaxFileSize    int64         `json:"max_file_size"`
-	DefaultModel string   `json:"default_model"`
-	PythonPath   string   `json:"python_path"`
-	ScriptsPath  string   `json:"scripts_path"`
-	Environment  []string `json:"environment"`
+	MaxFileSize    int64         `json:"max_file_size"` // Largest audio download, checked before queueing when the site reports sizes
+	DefaultModel   string        `json:"default_model"`
+	PythonPath     string        `json:"python_path"`
+	ScriptsPath    string        `json:"scripts_path"`
+	Environment    []string      `json:"environment"`
+	Workers        int           `json:"workers"`
+	QueueSize      int           `json:"queue_size"`
+	MaxBatchSize   int           `json:"max_batch_size"`
+
+	// ValidateTimeout bounds the metadata lookup done before queueing
+	ValidateTimeout time.Duration `json:"validate_timeout"`
+
+	// ModelRoutes maps audio languages to models; "*" matches other languages
+	ModelRoutes map[string]string `json:"model_routes"`
+
+	// MemoryLimitMB is the memory the running jobs may use together; 0
+	// uses the memory available at startup and a negative value disables
+	// the limit. ModelMemoryMB overrides the memory a job with a given
+	// model is expected to need.
+	MemoryLimitMB int            `json:"memory_limit_mb"`
+	ModelMemoryMB map[string]int `json:"model_memory_mb"`
+
+	// Retention is how long unpinned transcripts without their own expiry
+	// are kept; 0 keeps them forever
+	Retention time.Duration `json:"retention"`
+
+	// CleanupInterval is how often expired videos are deleted, each pass
+	// delayed by up to CleanupJitter so replicas don't run together. A pass
+	// deletes CleanupBatchSize videos at a time and at most CleanupMaxPerRun
+	// in all, 0 for no limit. CleanupDryRun only logs what would go.
+	CleanupInterval  time.Duration `json:"cleanup_interval"`
+	CleanupJitter    time.Duration `json:"cleanup_jitter"`
+	CleanupBatchSize int           `json:"cleanup_batch_size"`
+	CleanupMaxPerRun int           `json:"cleanup_max_per_run"`
+	CleanupDryRun    bool          `json:"cleanup_dry_run"`
+
+	// Platforms lists the platforms whose URLs are accepted
+	Platforms []string `json:"platforms"`
+	// AllowOtherSites passes URLs of unlisted sites on to yt-dlp
+	AllowOtherSites bool `json:"allow_other_sites"`
+
+	// AllowPrivateNetworks lets submitted URLs of other sites and audio
+	// links lead to private and loopback addresses, for deployments that
+	// transcribe media from their own network
+	AllowPrivateNetworks bool `json:"allow_private_networks"`
+
+	// LiveRetryInterval is how often a job for a live stream or upcoming
+	// premiere checks whether it has ended; 0 rejects such videos instead
+	LiveRetryInterval time.Duration `json:"live_retry_interval"`
+	// LiveRetryLimit is how long such a job waits before it fails
+	LiveRetryLimit time.Duration `json:"live_retry_limit"`
+
+	// SourcePolicy decides between captions and Whisper for requests with
+	// the auto source
+	SourcePolicy string `json:"source_policy"`
+
+	// LookupCacheTTL is how long the lookup of a submitted video is reused
+	// for later submissions of it; 0 looks every submission up
+	LookupCacheTTL time.Duration `json:"lookup_cache_ttl"`
+
+	// Enrich extracts keywords, names and links from completed transcripts
+	// so transcriptions can be listed by them
+	Enrich bool `json:"enrich"`
+}
+
+// KnownPlatforms are the platforms with their own URL validation
+var KnownPlatforms = []string{"youtube", "vimeo", "twitch", "soundcloud"}
+
+// SourcePolicies are the accepted values of VIDEO_SOURCE_POLICY
+var SourcePolicies = []string{"captions_only", "whisper_only", "captions_then_whisper", "whisper_if_captions_poor"}
+
+// ComparePicks are the accepted values of YOUTUBE_COMPARE_PICK
+var ComparePicks = []string{"whisper", "captions", "captions_if_good"}
+
+type SummaryConfig struct {
+	// Provider is "local" for the summarization script or "api" for an
+	// OpenAI-compatible endpoint
+	Provider     string        `json:"provider"`
+	Timeout      time.Duration `json:"timeout"` // Local script runs
+	MaxSentences int           `json:"max_sentences"`
+
+	APIBaseURL       string        `json:"api_base_url"`
+	APIKey           string        `json:"-"`
+	APIModel         string        `json:"api_model"`
+	APITimeout       time.Duration `json:"api_timeout"`
+	APIMaxInputChars int           `json:"api_max_input_chars"`
+
+	// Prices in US dollars per million tokens, for cost logging
+	APIInputCost  float64 `json:"api_input_cost"`
+	APIOutputCost float64 `json:"api_output_cost"`
+}
+
+// Summary providers
+const (
+	SummaryProviderLocal = "local"
+	SummaryProviderAPI   = "api"
+)
+
+// AskConfig configures answering questions about transcripts. The api
+// provider's settings default to the summary API's.
+type AskConfig struct {
+	// Provider is "local" to answer with the best-matching passage or "api"
+	// for an OpenAI-compatible endpoint
+	Provider        string        `json:"provider"`
+	Passages        int           `json:"passages"`
+	PassageDuration time.Duration `json:"passage_duration"`
+
+	APIBaseURL string        `json:"api_base_url"`
+	APIKey     string        `json:"-"`
+	APIModel   string        `json:"api_model"`
+	APITimeout time.Duration `json:"api_timeout"`
+
+	// Prices in US dollars per million tokens, for cost logging
+	APIInputCost  float64 `json:"api_input_cost"`
+	APIOutputCost float64 `json:"api_output_cost"`
+}
+
+// Question answering providers
+const (
+	AskProviderLocal = "local"
+	AskProviderAPI   = "api"
+)
+
+// EmbeddingsConfig enables semantic search over transcripts. Passages are
+// embedded with an OpenAI-compatible /embeddings endpoint, whose settings
+// default to the summary API's; point it at a local server such as Ollama
+// to keep transcripts on the machine.
+type EmbeddingsConfig struct {
+	Enabled         bool          `json:"enabled"`
+	PassageDuration time.Duration `json:"passage_duration"`
+	SweepInterval   time.Duration `json:"sweep_interval"`
+
+	APIBaseURL string        `json:"api_base_url"`
+	APIKey     string        `json:"-"`
+	APIModel   string        `json:"api_model"`
+	APITimeout time.Duration `json:"api_timeout"`
+}
+
+type AdminConfig struct {
+	// Token authorizes operator endpoints; empty disables them
+	Token string `json:"-"`
+}
+
+// AccountsConfig enables sign-in once a provider has a client ID
+type AccountsConfig struct {
+	// BaseURL is where browsers reach the server; providers redirect to
+	// BaseURL/auth/<provider>/callback
+	BaseURL string `json:"base_url"`
+
+	// SessionSecret signs session cookies, which last SessionTTL
+	SessionSecret string        `json:"-"`
+	SessionTTL    time.Duration `json:"session_ttl"`
+
+	GoogleClientID     string `json:"google_client_id"`
+	GoogleClientSecret string `json:"-"`
+	GitHubClientID     string `json:"github_client_id"`
+	GitHubClientSecret string `json:"-"`
+}
+
+// Enabled reports whether any login provider is configured
+func (c AccountsConfig) Enabled() bool {
+	return c.GoogleClientID != "" || c.GitHubClientID != ""
+}
+
+// SharingConfig enables share links once Secret is set. A link is valid
+// for DefaultTTL unless its request asks for another lifetime, up to MaxTTL.
+type SharingConfig struct {
+	Secret     string        `json:"-"`
+	DefaultTTL time.Duration `json:"default_ttl"`
+	MaxTTL     time.Duration `json:"max_ttl"`
+}
+
+// Enabled reports whether share links can be issued
+func (c SharingConfig) Enabled() bool {
+	return c.Secret != ""
+}
+
+type MaintenanceConfig struct {
+	// Enabled rejects write requests with 503 while reads keep working
+	Enabled    bool          `json:"enabled"`
+	RetryAfter time.Duration `json:"retry_after"`
+}
+
+type APIConfig struct {
+	// V1Sunset is announced in the Sunset header of unversioned /api routes
+	V1Sunset time.Time `json:"v1_sunset"`
+
+	// Keys are required to start transcriptions and follow their progress;
+	// empty leaves those routes open
+	Keys []string `json:"-"`
+
+	// AllowedOrigins are the browser origins besides the server's own that
+	// may start transcriptions and follow their progress; empty allows any
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+type YouTubeConfig struct {
+	// OEmbedPrecheck confirms a video exists via oEmbed before running the
+	// validation script
+	OEmbedPrecheck bool          `json:"oembed_precheck"`
+	RequestTimeout time.Duration `json:"request_timeout"`
+
+	// Data API settings; the caption fast path needs an API key
+	APIKey           string        `json:"-"`
+	DailyQuota       int           `json:"daily_quota"`
+	MetadataCacheTTL time.Duration `json:"metadata_cache_ttl"`
+	CaptionsCacheTTL time.Duration `json:"captions_cache_ttl"`
+	CaptionsEnabled  bool          `json:"captions_enabled"`
+
+	// CompareCaptions runs Whisper alongside captions to measure their
+	// accuracy; CaptionWERThreshold is the error rate considered good enough
+	CompareCaptions     bool    `json:"compare_captions"`
+	CaptionWERThreshold float64 `json:"caption_wer_threshold"`
+
+	// ComparePick is which of the two compared transcripts becomes the
+	// primary one
+	ComparePick string `json:"compare_pick"`
+
+	// Outbound throttling shared by every request to YouTube, including the
+	// yt-dlp scripts, to avoid IP bans under large batches
+	RequestsPerMinute int           `json:"requests_per_minute"`
+	Burst             int           `json:"burst"`
+	MaxBackoff        time.Duration `json:"max_backoff"`
 }
 
 type CORSConfig struct {
@@ -87,6 +433,17 @@ type RateLimitConfig struct {
 	Enabled           bool `json:"enabled"`
 	RequestsPerMinute int  `json:"requests_per_minute"`
 	BurstSize         int  `json:"burst_size"`
+
+	// Trusted callers that bypass rate limiting
+	ExemptAPIKeys  []string `json:"-"`
+	ExemptCIDRs    []string `json:"exempt_cidrs"`
+	InternalHeader string   `json:"internal_header"`
+	InternalSecret string   `json:"-"`
+
+	// Store is "memory", or "redis" to share counts between replicas
+	// through the server at RedisURL
+	Store    string `json:"store"`
+	RedisURL string `json:"-"`
 }
 
 // Default configurations
@@ -118,8 +475,16 @@ func defaultProdConfig() MiddlewareConfig {
 	}
 }
 
-// Load reads configuration from environment variables
-func Load() (*Config, error) {
+// Load reads configuration from environment variables, falling back to the
+// YAML file at path for variables that aren't set. An empty path reads the
+// environment only.
+func Load(path string) (*Config, error) {
+	if path != "" {
+		if err := applyFile(path); err != nil {
+			return nil, err
+		}
+	}
+
 	cfg := &Config{
 		// Server settings
 		ServerPort:   getEnv("SERVER_PORT", "8080"),
@@ -127,17 +492,21 @@ func Load() (*Config, error) {
 		WriteTimeout: getEnvAsDuration("WRITE_TIMEOUT", 15*time.Second),
 		IdleTimeout:  getEnvAsDuration("IDLE_TIMEOUT", 60*time.Second),
 		Debug:        getEnvAsBool("DEBUG", false),
+		Mode:         getEnv("SERVER_MODE", ModeAll),
 
 		// Application paths
 		LogDir:  getEnv("LOG_DIR", "/var/log/yt-text"),
 		TempDir: getEnv("TEMP_DIR", "/tmp/yt-text"),
 
+		StaticDir: getEnv("STATIC_DIR", ""),
+
 		// Application version
 		Version: getEnv("VERSION", "1.0.0"),
 
 		// Request and shutdown timeouts
 		RequestTimeout:  getEnvAsDuration("REQUEST_TIMEOUT", 60*time.Minute),
 		ShutdownTimeout: getEnvAsDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		DrainTimeout:    getEnvAsDuration("DRAIN_TIMEOUT", 2*time.Minute),
 
 		// CORS Configuration
 		CORS: CORSConfig{
@@ -147,7 +516,7 @@ func Load() (*Config, error) {
 				"CORS_ALLOWED_METHODS",
 				[]string{"GET", "POST", "OPTIONS"},
 			),
-			AllowedHeaders:   getEnvAsStringSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type"}),
+			AllowedHeaders:   getEnvAsStringSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type", "X-API-Key"}),
 			ExposedHeaders:   getEnvAsStringSlice("CORS_EXPOSED_HEADERS", []string{}),
 			AllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", false),
 			MaxAge:           getEnvAsInt("CORS_MAX_AGE", 86400),
@@ -158,22 +527,197 @@ func Load() (*Config, error) {
 			Enabled:           getEnvAsBool("RATE_LIMIT_ENABLED", true),
 			RequestsPerMinute: getEnvAsInt("RATE_LIMIT_RPM", 60),
 			BurstSize:         getEnvAsInt("RATE_LIMIT_BURST", 10),
+
+			ExemptAPIKeys:  getEnvAsStringSlice("RATE_LIMIT_EXEMPT_KEYS", nil),
+			ExemptCIDRs:    getEnvAsStringSlice("RATE_LIMIT_EXEMPT_CIDRS", nil),
+			InternalHeader: getEnv("RATE_LIMIT_INTERNAL_HEADER", "X-Internal-Token"),
+			InternalSecret: getEnv("RATE_LIMIT_INTERNAL_SECRET", ""),
+
+			Store:    getEnv("RATE_LIMIT_STORE", "memory"),
+			RedisURL: getEnv("RATE_LIMIT_REDIS_URL", ""),
 		},
 
 		// Database
 		Database: DatabaseConfig{
-			Path:           getEnv("DB_PATH", "/var/lib/yt-text/data.db"),
-			MaxConnections: getEnvAsInt("DB_MAX_CONNECTIONS", 10),
+			Driver:             getEnv("DATABASE_DRIVER", DriverSQLite),
+			URL:                getEnv("DATABASE_URL", ""),
+			Path:               getEnv("DB_PATH", "/var/lib/yt-text/data.db"),
+			MaxConnections:     getEnvAsInt("DB_MAX_CONNECTIONS", 10),
+			MaxIdleConnections: getEnvAsInt("DB_MAX_IDLE_CONNECTIONS", 5),
+			ConnMaxLifetime:    getEnvAsDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+			BusyTimeout:        getEnvAsDuration("DB_BUSY_TIMEOUT", 5*time.Second),
+
+			CompressTranscripts: getEnvAsBool("DB_COMPRESS_TRANSCRIPTS", false),
+			CompressMinSize:     getEnvAsInt("DB_COMPRESS_MIN_SIZE", 4096),
+
+			CacheSize:     getEnvAsInt("DB_CACHE_SIZE", 256),
+			CacheMaxBytes: getEnvAsInt("DB_CACHE_MAX_BYTES", 64<<20),
+
+			TranscriptCacheMaxBytes: getEnvAsInt("DB_TRANSCRIPT_CACHE_MAX_BYTES", 32<<20),
+
+			MaintenanceInterval: getEnvAsDuration("DB_MAINTENANCE_INTERVAL", 24*time.Hour),
+		},
+
+		// Transcript storage
+		Storage: StorageConfig{
+			Backend: getEnv("STORAGE_BACKEND", "db"),
+			Path:    getEnv("STORAGE_PATH", "/var/lib/yt-text/transcripts"),
+			MinSize: getEnvAsInt("STORAGE_MIN_SIZE", 64<<10),
+
+			TierAfter: getEnvAsDuration("STORAGE_TIER_AFTER", 0),
+
+			MaxBytes:    getEnvAsInt64("STORAGE_MAX_BYTES", 0),
+			QuotaPolicy: getEnv("STORAGE_QUOTA_POLICY", QuotaEvict),
+
+			Compression: getEnv("STORAGE_COMPRESSION", CompressionNone),
+
+			SpacesEndpoint:  getEnv("SPACES_ENDPOINT", ""),
+			SpacesRegion:    getEnv("SPACES_REGION", ""),
+			SpacesBucket:    getEnv("SPACES_BUCKET", ""),
+			SpacesAccessKey: getEnv("SPACES_ACCESS_KEY", ""),
+			SpacesSecretKey: getEnv("SPACES_SECRET_KEY", ""),
 		},
 
 		// Video Service
 		Video: VideoConfig{
 			ProcessTimeout: getEnvAsDuration("VIDEO_PROCESS_TIMEOUT", 30*time.Minute),
 			MaxDuration:    getEnvAsDuration("VIDEO_MAX_DURATION", 4*time.Hour),
-			// MaxFileSize:    getEnvAsInt64("VIDEO_MAX_FILE_SIZE", 100*1024*1024), // 100MB
-			DefaultModel: getEnv("WHISPER_MODEL", "base.en"),
-			PythonPath:   getEnv("PYTHON_PATH", "python3"),
-			ScriptsPath:  getEnv("SCRIPTS_PATH", "./scripts"),
+			MaxFileSize:    getEnvAsInt64("VIDEO_MAX_FILE_SIZE", 100*1024*1024), // 100MB
+			DefaultModel:   getEnv("WHISPER_MODEL", "base.en"),
+			PythonPath:     getEnv("PYTHON_PATH", "python3"),
+			ScriptsPath:    getEnv("SCRIPTS_PATH", "./scripts"),
+			Workers:        getEnvAsInt("VIDEO_WORKERS", 2),
+			QueueSize:      getEnvAsInt("VIDEO_QUEUE_SIZE", 100),
+			MaxBatchSize:   getEnvAsInt("VIDEO_MAX_BATCH_SIZE", 50),
+
+			ValidateTimeout: getEnvAsDuration("VIDEO_VALIDATE_TIMEOUT", time.Minute),
+
+			ModelRoutes: getEnvAsMap("WHISPER_MODEL_ROUTES", map[string]string{
+				"en":        "base.en",
+				anyLanguage: "base",
+			}),
+
+			MemoryLimitMB: getEnvAsInt("VIDEO_MEMORY_LIMIT_MB", 0),
+			ModelMemoryMB: getEnvAsIntMap("WHISPER_MODEL_MEMORY_MB", nil),
+
+			Retention: getEnvAsDuration("VIDEO_RETENTION", 0),
+
+			CleanupInterval:  getEnvAsDuration("VIDEO_CLEANUP_INTERVAL", time.Hour),
+			CleanupJitter:    getEnvAsDuration("VIDEO_CLEANUP_JITTER", 5*time.Minute),
+			CleanupBatchSize: getEnvAsInt("VIDEO_CLEANUP_BATCH_SIZE", 500),
+			CleanupMaxPerRun: getEnvAsInt("VIDEO_CLEANUP_MAX_PER_RUN", 0),
+			CleanupDryRun:    getEnvAsBool("VIDEO_CLEANUP_DRY_RUN", false),
+
+			Platforms:       getEnvAsStringSlice("VIDEO_PLATFORMS", KnownPlatforms),
+			AllowOtherSites: getEnvAsBool("VIDEO_ALLOW_OTHER_SITES", true),
+
+			AllowPrivateNetworks: getEnvAsBool("VIDEO_ALLOW_PRIVATE_NETWORKS", false),
+
+			LiveRetryInterval: getEnvAsDuration("VIDEO_LIVE_RETRY_INTERVAL", 0),
+			LiveRetryLimit:    getEnvAsDuration("VIDEO_LIVE_RETRY_LIMIT", 48*time.Hour),
+			SourcePolicy:      getEnv("VIDEO_SOURCE_POLICY", "captions_then_whisper"),
+
+			LookupCacheTTL: getEnvAsDuration("VIDEO_LOOKUP_CACHE_TTL", time.Hour),
+
+			Enrich: getEnvAsBool("VIDEO_ENRICH", false),
+		},
+
+		// Summaries
+		Summary: SummaryConfig{
+			Provider:     getEnv("SUMMARY_PROVIDER", SummaryProviderLocal),
+			Timeout:      getEnvAsDuration("SUMMARY_TIMEOUT", 2*time.Minute),
+			MaxSentences: getEnvAsInt("SUMMARY_MAX_SENTENCES", 5),
+
+			APIBaseURL:       getEnv("SUMMARY_API_BASE_URL", "https://api.openai.com/v1"),
+			APIKey:           getEnv("SUMMARY_API_KEY", ""),
+			APIModel:         getEnv("SUMMARY_API_MODEL", "gpt-4o-mini"),
+			APITimeout:       getEnvAsDuration("SUMMARY_API_TIMEOUT", 60*time.Second),
+			APIMaxInputChars: getEnvAsInt("SUMMARY_API_MAX_INPUT_CHARS", 100000),
+			APIInputCost:     getEnvAsFloat("SUMMARY_API_INPUT_COST", 0),
+			APIOutputCost:    getEnvAsFloat("SUMMARY_API_OUTPUT_COST", 0),
+		},
+
+		// Questions
+		Ask: AskConfig{
+			Provider:        getEnv("ASK_PROVIDER", AskProviderLocal),
+			Passages:        getEnvAsInt("ASK_PASSAGES", 5),
+			PassageDuration: getEnvAsDuration("ASK_PASSAGE_DURATION", 45*time.Second),
+
+			APIBaseURL:    getEnv("ASK_API_BASE_URL", getEnv("SUMMARY_API_BASE_URL", "https://api.openai.com/v1")),
+			APIKey:        getEnv("ASK_API_KEY", getEnv("SUMMARY_API_KEY", "")),
+			APIModel:      getEnv("ASK_API_MODEL", getEnv("SUMMARY_API_MODEL", "gpt-4o-mini")),
+			APITimeout:    getEnvAsDuration("ASK_API_TIMEOUT", 60*time.Second),
+			APIInputCost:  getEnvAsFloat("ASK_API_INPUT_COST", getEnvAsFloat("SUMMARY_API_INPUT_COST", 0)),
+			APIOutputCost: getEnvAsFloat("ASK_API_OUTPUT_COST", getEnvAsFloat("SUMMARY_API_OUTPUT_COST", 0)),
+		},
+
+		// Semantic search
+		Embeddings: EmbeddingsConfig{
+			Enabled:         getEnvAsBool("EMBEDDINGS_ENABLED", false),
+			PassageDuration: getEnvAsDuration("EMBEDDINGS_PASSAGE_DURATION", 45*time.Second),
+			SweepInterval:   getEnvAsDuration("EMBEDDINGS_SWEEP_INTERVAL", 5*time.Minute),
+
+			APIBaseURL: getEnv("EMBEDDINGS_API_BASE_URL", getEnv("SUMMARY_API_BASE_URL", "https://api.openai.com/v1")),
+			APIKey:     getEnv("EMBEDDINGS_API_KEY", getEnv("SUMMARY_API_KEY", "")),
+			APIModel:   getEnv("EMBEDDINGS_API_MODEL", "text-embedding-3-small"),
+			APITimeout: getEnvAsDuration("EMBEDDINGS_API_TIMEOUT", 60*time.Second),
+		},
+
+		// API versioning
+		API: APIConfig{
+			V1Sunset:       getEnvAsDate("API_V1_SUNSET", time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)),
+			Keys:           getEnvAsStringSlice("API_KEYS", nil),
+			AllowedOrigins: getEnvAsStringSlice("API_ALLOWED_ORIGINS", nil),
+		},
+
+		// Admin API
+		Admin: AdminConfig{
+			Token: getEnv("ADMIN_TOKEN", ""),
+		},
+
+		// User accounts
+		Accounts: AccountsConfig{
+			BaseURL:       getEnv("AUTH_BASE_URL", ""),
+			SessionSecret: getEnv("SESSION_SECRET", ""),
+			SessionTTL:    getEnvAsDuration("SESSION_TTL", 30*24*time.Hour),
+
+			GoogleClientID:     getEnv("OAUTH_GOOGLE_CLIENT_ID", ""),
+			GoogleClientSecret: getEnv("OAUTH_GOOGLE_CLIENT_SECRET", ""),
+			GitHubClientID:     getEnv("OAUTH_GITHUB_CLIENT_ID", ""),
+			GitHubClientSecret: getEnv("OAUTH_GITHUB_CLIENT_SECRET", ""),
+		},
+
+		// Share links
+		Sharing: SharingConfig{
+			Secret:     getEnv("SHARE_SECRET", ""),
+			DefaultTTL: getEnvAsDuration("SHARE_TTL", 7*24*time.Hour),
+			MaxTTL:     getEnvAsDuration("SHARE_MAX_TTL", 30*24*time.Hour),
+		},
+
+		// YouTube integration
+		YouTube: YouTubeConfig{
+			OEmbedPrecheck: getEnvAsBool("YOUTUBE_OEMBED_PRECHECK", true),
+			RequestTimeout: getEnvAsDuration("YOUTUBE_REQUEST_TIMEOUT", 5*time.Second),
+
+			APIKey:           getEnv("YOUTUBE_API_KEY", ""),
+			DailyQuota:       getEnvAsInt("YOUTUBE_DAILY_QUOTA", 10000),
+			MetadataCacheTTL: getEnvAsDuration("YOUTUBE_METADATA_CACHE_TTL", 6*time.Hour),
+			CaptionsCacheTTL: getEnvAsDuration("YOUTUBE_CAPTIONS_CACHE_TTL", time.Hour),
+			CaptionsEnabled:  getEnvAsBool("YOUTUBE_CAPTIONS_ENABLED", true),
+
+			CompareCaptions:     getEnvAsBool("YOUTUBE_COMPARE_CAPTIONS", false),
+			CaptionWERThreshold: getEnvAsFloat("YOUTUBE_CAPTION_WER_THRESHOLD", 0.15),
+			ComparePick:         getEnv("YOUTUBE_COMPARE_PICK", "whisper"),
+
+			RequestsPerMinute: getEnvAsInt("YOUTUBE_REQUESTS_PER_MINUTE", 60),
+			Burst:             getEnvAsInt("YOUTUBE_BURST", 5),
+			MaxBackoff:        getEnvAsDuration("YOUTUBE_MAX_BACKOFF", 10*time.Minute),
+		},
+
+		// Maintenance mode
+		Maintenance: MaintenanceConfig{
+			Enabled:    getEnvAsBool("MAINTENANCE_MODE", false),
+			RetryAfter: getEnvAsDuration("MAINTENANCE_RETRY_AFTER", 10*time.Minute),
 		},
 
 		// Middleware
@@ -184,6 +728,8 @@ func Load() (*Config, error) {
 		cfg.Middleware = defaultProdConfig()
 	}
 
+	cfg.File = path
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -212,13 +758,16 @@ func (c *Config) Validate() error {
 }
 
 func validatePaths(c *Config) error {
-	paths := []struct {
+	type dir struct {
 		path string
 		name string
-	}{
+	}
+	paths := []dir{
 		{c.LogDir, "log directory"},
 		{c.TempDir, "temp directory"},
-		{filepath.Dir(c.Database.Path), "database directory"},
+	}
+	if c.Database.Driver == DriverSQLite {
+		paths = append(paths, dir{filepath.Dir(c.Database.Path), "database directory"})
 	}
 
 	for _, p := range paths {
@@ -227,6 +776,12 @@ func validatePaths(c *Config) error {
 		}
 	}
 
+	if c.StaticDir != "" {
+		if info, err := os.Stat(c.StaticDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("static directory %s is not a directory", c.StaticDir)
+		}
+	}
+
 	return nil
 }
 
@@ -237,13 +792,146 @@ func validateTimeouts(c *Config) error {
 	if c.WriteTimeout <= 0 {
 		return fmt.Errorf("write timeout must be positive")
 	}
+	if c.DrainTimeout < 0 {
+		return fmt.Errorf("drain timeout must not be negative")
+	}
+	if c.Video.ValidateTimeout < 0 {
+		return fmt.Errorf("validate timeout must not be negative")
+	}
+	if c.Database.BusyTimeout < 0 {
+		return fmt.Errorf("database busy timeout must not be negative")
+	}
+	if c.Database.MaintenanceInterval < 0 {
+		return fmt.Errorf("database maintenance interval must not be negative")
+	}
+	if c.Video.LookupCacheTTL < 0 {
+		return fmt.Errorf("lookup cache ttl must not be negative")
+	}
 	return nil
 }
 
 func validateServices(c *Config) error {
+	switch c.Mode {
+	case ModeAll, ModeAPI, ModeWorker:
+	default:
+		return fmt.Errorf("unknown server mode %q", c.Mode)
+	}
 	if c.Video.MaxDuration <= 0 {
 		return fmt.Errorf("max video duration must be positive")
 	}
+	if c.Video.Workers <= 0 {
+		return fmt.Errorf("video workers must be positive")
+	}
+	if c.Video.QueueSize <= 0 {
+		return fmt.Errorf("video queue size must be positive")
+	}
+	if c.Video.CleanupInterval <= 0 || c.Video.CleanupJitter < 0 {
+		return fmt.Errorf("cleanup interval must be positive and cleanup jitter not negative")
+	}
+	if c.Video.CleanupBatchSize <= 0 || c.Video.CleanupMaxPerRun < 0 {
+		return fmt.Errorf("cleanup batch size must be positive and the per-run limit not negative")
+	}
+	if c.Video.LiveRetryInterval < 0 || c.Video.LiveRetryInterval > 0 && c.Video.LiveRetryLimit <= 0 {
+		return fmt.Errorf("live retry interval can't be negative, and needs a positive retry limit")
+	}
+	if !slices.Contains(SourcePolicies, c.Video.SourcePolicy) {
+		return fmt.Errorf("unknown source policy %q, expected one of: %s", c.Video.SourcePolicy, strings.Join(SourcePolicies, ", "))
+	}
+	if !slices.Contains(ComparePicks, c.YouTube.ComparePick) {
+		return fmt.Errorf("unknown compare pick %q, expected one of: %s", c.YouTube.ComparePick, strings.Join(ComparePicks, ", "))
+	}
+	for _, platform := range c.Video.Platforms {
+		if !slices.Contains(KnownPlatforms, strings.ToLower(strings.TrimSpace(platform))) {
+			return fmt.Errorf("unknown platform %q in VIDEO_PLATFORMS", platform)
+		}
+	}
+	switch c.Summary.Provider {
+	case SummaryProviderLocal:
+		if c.Summary.Timeout <= 0 {
+			return fmt.Errorf("summary timeout must be positive")
+		}
+	case SummaryProviderAPI:
+		if c.Summary.APIBaseURL == "" || c.Summary.APIModel == "" {
+			return fmt.Errorf("SUMMARY_API_BASE_URL and SUMMARY_API_MODEL are required for the api summary provider")
+		}
+		if c.Summary.APITimeout <= 0 {
+			return fmt.Errorf("summary api timeout must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown summary provider %q", c.Summary.Provider)
+	}
+	if c.Summary.MaxSentences <= 0 {
+		return fmt.Errorf("summary length must be positive")
+	}
+	switch c.Ask.Provider {
+	case AskProviderLocal:
+	case AskProviderAPI:
+		if c.Ask.APIBaseURL == "" || c.Ask.APIModel == "" {
+			return fmt.Errorf("ASK_API_BASE_URL and ASK_API_MODEL are required for the api ask provider")
+		}
+		if c.Ask.APITimeout <= 0 {
+			return fmt.Errorf("ask api timeout must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown ask provider %q", c.Ask.Provider)
+	}
+	if c.Ask.Passages <= 0 || c.Ask.PassageDuration <= 0 {
+		return fmt.Errorf("ask passages and passage duration must be positive")
+	}
+	if c.Embeddings.Enabled {
+		if c.Embeddings.APIBaseURL == "" || c.Embeddings.APIModel == "" {
+			return fmt.Errorf("EMBEDDINGS_API_BASE_URL and EMBEDDINGS_API_MODEL are required for semantic search")
+		}
+		if c.Embeddings.APITimeout <= 0 || c.Embeddings.PassageDuration <= 0 || c.Embeddings.SweepInterval <= 0 {
+			return fmt.Errorf("embeddings api timeout, passage duration and sweep interval must be positive")
+		}
+	}
+	if c.Storage.MaxBytes < 0 {
+		return fmt.Errorf("storage max bytes must not be negative")
+	}
+	if c.Storage.MaxBytes > 0 && c.Storage.Backend != "local" {
+		return fmt.Errorf("STORAGE_MAX_BYTES only applies to the local storage backend")
+	}
+	if c.Storage.QuotaPolicy != QuotaEvict && c.Storage.QuotaPolicy != QuotaReject {
+		return fmt.Errorf("unknown storage quota policy %q, expected %s or %s", c.Storage.QuotaPolicy, QuotaEvict, QuotaReject)
+	}
+	switch c.Storage.Compression {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return fmt.Errorf("unknown storage compression %q, expected %s, %s or %s", c.Storage.Compression, CompressionNone, CompressionGzip, CompressionZstd)
+	}
+	switch c.Database.Driver {
+	case DriverSQLite:
+	case DriverPostgres:
+		if c.Database.URL == "" {
+			return fmt.Errorf("DATABASE_URL is required for the postgres driver")
+		}
+	case DriverMemory:
+		if c.Mode != ModeAll {
+			return fmt.Errorf("the memory driver can't be shared between processes, so it needs server mode %s", ModeAll)
+		}
+	default:
+		return fmt.Errorf("unknown database driver %q", c.Database.Driver)
+	}
+	if c.Accounts.Enabled() {
+		if c.Accounts.BaseURL == "" {
+			return fmt.Errorf("AUTH_BASE_URL is required for user accounts")
+		}
+		if len(c.Accounts.SessionSecret) < 32 {
+			return fmt.Errorf("SESSION_SECRET must be at least 32 characters for user accounts")
+		}
+		if c.Accounts.SessionTTL <= 0 {
+			return fmt.Errorf("session ttl must be positive")
+		}
+	}
+	if c.Sharing.Enabled() {
+		if len(c.Sharing.Secret) < 32 {
+			return fmt.Errorf("SHARE_SECRET must be at least 32 characters")
+		}
+		if c.Sharing.DefaultTTL <= 0 || c.Sharing.MaxTTL < c.Sharing.DefaultTTL {
+			return fmt.Errorf("share link ttl must be positive and at most the max ttl")
+		}
+	}
 	return nil
 }
 
@@ -291,6 +979,65 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsDate parses a YYYY-MM-DD date; an empty value yields the zero time
+func getEnvAsDate(key string, defaultValue time.Time) time.Time {
+	if value, exists := os.LookupEnv(key); exists {
+		if value == "" {
+			return time.Time{}
+		}
+		if date, err := time.Parse(time.DateOnly, value); err == nil {
+			return date
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsMap parses comma separated key=value pairs, e.g. "en=base.en,*=base"
+// anyLanguage is the model route key matching every language without a
+// route of its own
+const anyLanguage = "*"
+
+func getEnvAsMap(key string, defaultValue map[string]string) map[string]string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	result := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v); ok && k != "" && v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// getEnvAsIntMap reads key=value pairs like getEnvAsMap, skipping pairs
+// whose value isn't a positive number
+func getEnvAsIntMap(key string, defaultValue map[string]int) map[string]int {
+	if _, exists := os.LookupEnv(key); !exists {
+		return defaultValue
+	}
+
+	result := make(map[string]int)
+	for k, v := range getEnvAsMap(key, nil) {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			result[k] = n
+		}
+	}
+	return result
+}
+
 func getEnvAsStringSlice(key string, defaultValue []string) []string {
 	if value, exists := os.LookupEnv(key); exists {
 		if value = strings.TrimSpace(value); value != "" {
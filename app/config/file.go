@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileKeys are the variables the config file set, which a reload may
+// change; the rest of the environment is fixed for the process's life
+var (
+	fileKeysMu sync.Mutex
+	fileKeys   = map[string]bool{}
+)
+
+// applyFile reads a YAML config file of settings named like the environment
+// variables, e.g. "SERVER_PORT: 8080", and sets those the environment
+// doesn't already, so environment variables override the file. Lists are
+// joined with commas and maps become key=value pairs, matching how the
+// variables are parsed. Applying the file again replaces the values it set
+// before.
+func applyFile(path string) error {
+	fileKeysMu.Lock()
+	defer fileKeysMu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(settings))
+	for key, value := range settings {
+		key = strings.ToUpper(strings.TrimSpace(key))
+		text, err := settingValue(value)
+		if err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+		values[key] = text
+	}
+
+	for key := range fileKeys {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+			delete(fileKeys, key)
+		}
+	}
+	for key, text := range values {
+		if _, exists := os.LookupEnv(key); exists && !fileKeys[key] {
+			continue
+		}
+		if err := os.Setenv(key, text); err != nil {
+			return err
+		}
+		fileKeys[key] = true
+	}
+	return nil
+}
+
+// settingValue formats a YAML value the way the environment variable
+// holding it would be written
+func settingValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			text, err := scalarValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = text
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for k, item := range v {
+			text, err := scalarValue(item)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, k+"="+text)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	default:
+		return scalarValue(v)
+	}
+}
+
+func scalarValue(value interface{}) (string, error) {
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		return "", fmt.Errorf("nested values are not supported")
+	case nil:
+		return "", nil
+	}
+	return fmt.Sprint(value), nil
+}
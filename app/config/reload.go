@@ -0,0 +1,72 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+)
+
+// reloadable are the settings a reload applies. The rest, such as ports,
+// paths and the database, only change on restart.
+var reloadable = []struct {
+	name  string
+	field func(c *Config) interface{} // Pointer to the setting
+}{
+	{"rate_limit.requests_per_minute", func(c *Config) interface{} { return &c.RateLimit.RequestsPerMinute }},
+	{"video.retention", func(c *Config) interface{} { return &c.Video.Retention }},
+	{"video.default_model", func(c *Config) interface{} { return &c.Video.DefaultModel }},
+	{"video.model_routes", func(c *Config) interface{} { return &c.Video.ModelRoutes }},
+	{"video.platforms", func(c *Config) interface{} { return &c.Video.Platforms }},
+	{"video.allow_other_sites", func(c *Config) interface{} { return &c.Video.AllowOtherSites }},
+}
+
+// Reloader re-reads the configuration while the server runs and passes the
+// settings that changed to the services holding them
+type Reloader struct {
+	mu        sync.Mutex
+	current   *Config
+	listeners []func(*Config)
+}
+
+func NewReloader(cfg *Config) *Reloader {
+	return &Reloader{current: cfg}
+}
+
+// OnReload registers fn to receive the configuration after each reload that
+// changed a setting
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Reload loads the configuration again and applies the reloadable settings
+// that changed, returning their names. Invalid configuration leaves the
+// current one in place.
+func (r *Reloader) Reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := Load(r.current.File)
+	if err != nil {
+		return nil, err
+	}
+
+	next := *r.current
+	changed := []string{}
+	for _, setting := range reloadable {
+		from, to := reflect.ValueOf(setting.field(&next)).Elem(), reflect.ValueOf(setting.field(loaded)).Elem()
+		if !reflect.DeepEqual(from.Interface(), to.Interface()) {
+			from.Set(to)
+			changed = append(changed, setting.name)
+		}
+	}
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	r.current = &next
+	for _, fn := range r.listeners {
+		fn(r.current)
+	}
+	return changed, nil
+}
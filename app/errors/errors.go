@@ -3,6 +3,7 @@ package errors
 import (
 	"fmt"
 	"net/http"
+	"time"
 )
 
 type AppError struct {
@@ -10,6 +11,18 @@ type AppError struct {
 	Message string `json:"error"`
 	Op      string `json:"-"`
 	Err     error  `json:"-"`
+
+	// Details carries structured context such as field-level validation
+	// errors and is included in the response body
+	Details interface{} `json:"details,omitempty"`
+
+	// RetryAfter, when set, is sent as the Retry-After header
+	RetryAfter time.Duration `json:"-"`
+
+	// Limit is the capacity that ran out for a 429, such as the job queue's
+	// size. Unless the rate limiter already described the caller's budget,
+	// it is sent as X-RateLimit-Limit, with none remaining until RetryAfter.
+	Limit int `json:"-"`
 }
 
 func (e *AppError) Error() string {
@@ -49,3 +62,48 @@ func Internal(op string, err error, message string) *AppError {
 		Err:     err,
 	}
 }
+
+func Unauthorized(op string, err error, message string) *AppError {
+	return &AppError{
+		Code:    http.StatusUnauthorized,
+		Message: message,
+		Op:      op,
+		Err:     err,
+	}
+}
+
+func Forbidden(op string, err error, message string) *AppError {
+	return &AppError{
+		Code:    http.StatusForbidden,
+		Message: message,
+		Op:      op,
+		Err:     err,
+	}
+}
+
+func Unavailable(op string, err error, message string) *AppError {
+	return &AppError{
+		Code:    http.StatusServiceUnavailable,
+		Message: message,
+		Op:      op,
+		Err:     err,
+	}
+}
+
+func TooManyRequests(op string, err error, message string) *AppError {
+	return &AppError{
+		Code:    http.StatusTooManyRequests,
+		Message: message,
+		Op:      op,
+		Err:     err,
+	}
+}
+
+func Conflict(op string, err error, message string) *AppError {
+	return &AppError{
+		Code:    http.StatusConflict,
+		Message: message,
+		Op:      op,
+		Err:     err,
+	}
+}
@@ -0,0 +1,68 @@
+// Package events carries job notifications from the video service to the
+// parts of the process that react to them, such as progress streams.
+package events
+
+import (
+	"sync"
+	"yt-text/models"
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind before
+// further events are dropped for it
+const subscriberBuffer = 64
+
+// Bus fans job events out to every subscriber. Publishing never blocks: a
+// subscriber that doesn't keep up misses events rather than stalling jobs,
+// so subscribers should treat an event as a cue to read current state.
+// The bus is in-process only; processes don't see each other's events.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives events published after it was created
+type Subscription struct {
+	C <-chan models.JobEvent
+
+	bus *Bus
+	ch  chan models.JobEvent
+}
+
+// Subscribe starts receiving events. Close the subscription when done.
+func (b *Bus) Subscribe() *Subscription {
+	ch := make(chan models.JobEvent, subscriberBuffer)
+	sub := &Subscription{C: ch, bus: b, ch: ch}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Close stops delivery and closes C. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subscribers[s]; ok {
+		delete(s.bus.subscribers, s)
+		close(s.ch)
+	}
+}
+
+// Publish delivers event to every subscriber with room for it
+func (b *Bus) Publish(event models.JobEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+	"yt-text/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+// hubRefreshInterval is how often every watched job is re-read regardless of
+// events, which covers dropped events and jobs run by other processes (the
+// repository doesn't cache unfinished videos when it shares the database)
+const hubRefreshInterval = 15 * time.Second
+
+// ProgressFunc reads a job's current progress
+type ProgressFunc func(ctx context.Context, videoID string) (*models.ProgressUpdate, error)
+
+// Hub shares progress updates between every client watching the same job.
+// Each job's progress is read once per change, however many clients watch
+// it, and broadcast to all of them. Watchers are closed once their job is
+// done.
+type Hub struct {
+	progress ProgressFunc
+
+	mu   sync.Mutex
+	jobs map[string]*watchedJob // by video ID
+}
+
+type watchedJob struct {
+	last     models.ProgressUpdate
+	watchers map[*Watcher]struct{}
+}
+
+// NewHub starts a hub reading progress with progress whenever bus reports
+// a change that could affect a watched job
+func NewHub(bus *Bus, progress ProgressFunc) *Hub {
+	h := &Hub{
+		progress: progress,
+		jobs:     make(map[string]*watchedJob),
+	}
+	go h.run(bus.Subscribe())
+	return h
+}
+
+// Watcher receives a job's progress updates. C always holds the latest
+// update, so a slow reader skips intermediate ones. C is closed after the
+// final update, or without one when the job can no longer be read.
+type Watcher struct {
+	C <-chan models.ProgressUpdate
+
+	hub     *Hub
+	videoID string
+	ch      chan models.ProgressUpdate
+}
+
+// Watch subscribes to a job's progress, starting with its current state.
+// Close the watcher when done with it.
+func (h *Hub) Watch(ctx context.Context, videoID string) (*Watcher, error) {
+	update, err := h.progress(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan models.ProgressUpdate, 1)
+	w := &Watcher{C: ch, hub: h, videoID: videoID, ch: ch}
+	ch <- *update
+	if update.Done() {
+		close(ch)
+		return w, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	job, ok := h.jobs[videoID]
+	if !ok {
+		job = &watchedJob{last: *update, watchers: make(map[*Watcher]struct{})}
+		h.jobs[videoID] = job
+	}
+	job.watchers[w] = struct{}{}
+	return w, nil
+}
+
+// Close stops updates and closes C if the hub hasn't already. It is safe to
+// call more than once.
+func (w *Watcher) Close() {
+	h := w.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	job, ok := h.jobs[w.videoID]
+	if !ok {
+		return
+	}
+	if _, ok := job.watchers[w]; !ok {
+		return
+	}
+	delete(job.watchers, w)
+	close(w.ch)
+	if len(job.watchers) == 0 {
+		delete(h.jobs, w.videoID)
+	}
+}
+
+// run refreshes watched jobs as events arrive: a job's own events, and any
+// event while it waits, since that may move it up the queue
+func (h *Hub) run(sub *Subscription) {
+	ticker := time.NewTicker(hubRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-sub.C:
+			h.refresh(func(id string, last models.ProgressUpdate) bool {
+				return id == event.VideoID || last.QueuePosition > 0
+			})
+		case <-ticker.C:
+			h.refresh(func(string, models.ProgressUpdate) bool { return true })
+		}
+	}
+}
+
+// refresh re-reads the watched jobs selected by match and broadcasts those
+// that changed
+func (h *Hub) refresh(match func(id string, last models.ProgressUpdate) bool) {
+	h.mu.Lock()
+	var ids []string
+	for id, job := range h.jobs {
+		if match(id, job.last) {
+			ids = append(ids, id)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, id := range ids {
+		update, err := h.progress(context.Background(), id)
+		if err != nil {
+			log.Warn().Err(err).Str("video_id", id).Msg("Stopped watching job")
+		}
+		h.broadcast(id, update)
+	}
+}
+
+// broadcast sends a changed update to a job's watchers, and closes them
+// once the job is done or can't be read
+func (h *Hub) broadcast(id string, update *models.ProgressUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	job, ok := h.jobs[id]
+	if !ok {
+		return
+	}
+
+	if update != nil && *update != job.last {
+		job.last = *update
+		for w := range job.watchers {
+			// Replace an update the watcher hasn't read yet. Only the hub
+			// sends, under mu, so there's always room afterwards.
+			select {
+			case <-w.ch:
+			default:
+			}
+			w.ch <- *update
+		}
+	}
+
+	if update == nil || update.Done() {
+		for w := range job.watchers {
+			close(w.ch)
+		}
+		delete(h.jobs, id)
+	}
+}
@@ -0,0 +1,158 @@
+// Package formats renders transcripts in the formats they can be downloaded
+// in: plain text, SubRip and WebVTT subtitles, and Markdown. JSON is left to
+// the API's own response envelope.
+package formats
+
+import (
+	stderrors "errors"
+	"mime"
+	"strings"
+	"yt-text/models"
+)
+
+// Format is a transcript representation, named as in ?format=
+type Format string
+
+const (
+	JSON     Format = "json"
+	Text     Format = "text"
+	SRT      Format = "srt"
+	VTT      Format = "vtt"
+	Markdown Format = "markdown"
+)
+
+// ErrNoTimestamps is returned when rendering subtitles of a transcript
+// stored without timing
+var ErrNoTimestamps = stderrors.New("no timestamps stored for this transcript")
+
+type formatInfo struct {
+	mediaType string
+	extension string
+	download  bool // Sent as an attachment rather than displayed
+}
+
+// all lists the formats in order of preference when a client accepts
+// several equally
+var all = []Format{JSON, Text, Markdown, SRT, VTT}
+
+var infos = map[Format]formatInfo{
+	JSON:     {"application/json", ".json", false},
+	Text:     {"text/plain", ".txt", false},
+	Markdown: {"text/markdown", ".md", true},
+	SRT:      {"application/x-subrip", ".srt", true},
+	VTT:      {"text/vtt", ".vtt", true},
+}
+
+// Names lists the format names accepted by Parse
+func Names() []string {
+	names := make([]string, len(all))
+	for i, f := range all {
+		names[i] = string(f)
+	}
+	return names
+}
+
+// Parse looks a format up by name, also accepting its file extension
+func Parse(name string) (Format, bool) {
+	name = strings.ToLower(strings.TrimPrefix(name, "."))
+	for _, f := range all {
+		if string(f) == name || infos[f].extension == "."+name {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// MediaTypes lists the media types of every format, for negotiating with
+// an Accept header
+func MediaTypes() []string {
+	types := make([]string, len(all))
+	for i, f := range all {
+		types[i] = infos[f].mediaType
+	}
+	return types
+}
+
+// ByMediaType looks a format up by media type, ignoring parameters
+func ByMediaType(contentType string) (Format, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	for _, f := range all {
+		if infos[f].mediaType == mediaType {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// ContentType is the Content-Type header for the format
+func (f Format) ContentType() string {
+	if strings.HasPrefix(infos[f].mediaType, "text/") || f == SRT {
+		return infos[f].mediaType + "; charset=utf-8"
+	}
+	return infos[f].mediaType
+}
+
+// Extension is the file extension for the format, with its dot
+func (f Format) Extension() string {
+	return infos[f].extension
+}
+
+// Download reports whether the format is meant to be saved as a file
+// rather than displayed
+func (f Format) Download() bool {
+	return infos[f].download
+}
+
+// Transcript is one stored transcript of a video, with what the formats
+// show alongside its text
+type Transcript struct {
+	Title    string
+	URL      string
+	Source   models.Source
+	Language string
+	Text     string
+	Segments []models.Segment
+	Chapters []models.Chapter // With the text of each
+}
+
+// FromVideo returns the video's transcript from source. It reports false
+// when no transcript from that source is stored.
+func FromVideo(v *models.Video, source models.Source) (Transcript, bool) {
+	text, ok := v.TranscriptionFrom(source)
+	if !ok {
+		return Transcript{}, false
+	}
+	segments, _ := v.SegmentsFrom(source)
+	return Transcript{
+		Title:    v.Title,
+		URL:      v.URL,
+		Source:   source,
+		Language: v.Language,
+		Text:     text,
+		Segments: segments,
+		Chapters: v.ChaptersFrom(source),
+	}, true
+}
+
+// Render writes a transcript in any format but JSON
+func Render(f Format, t Transcript) (string, error) {
+	switch f {
+	case Text:
+		return t.Text, nil
+	case Markdown:
+		return MarkdownDocument(t), nil
+	case SRT, VTT:
+		if len(t.Segments) == 0 {
+			return "", ErrNoTimestamps
+		}
+		if f == SRT {
+			return SRTSubtitles(t.Segments), nil
+		}
+		return VTTSubtitles(t.Segments), nil
+	default:
+		return "", stderrors.New("format " + string(f) + " is not rendered as text")
+	}
+}
@@ -0,0 +1,66 @@
+package formats
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"yt-text/models"
+)
+
+// MarkdownDocument renders a transcript under its title and link, with a
+// section per chapter when the video has them
+func MarkdownDocument(t Transcript) string {
+	var b strings.Builder
+
+	title := t.Title
+	if title == "" {
+		title = t.URL
+	}
+	fmt.Fprintf(&b, "# %s\n\n", markdownLine(title))
+	if t.URL != "" {
+		fmt.Fprintf(&b, "<%s>\n\n", t.URL)
+	}
+	details := []string{"Source: " + string(t.Source)}
+	if t.Language != "" {
+		details = append(details, "Language: "+t.Language)
+	}
+	fmt.Fprintf(&b, "*%s*\n\n", strings.Join(details, " · "))
+
+	if len(t.Chapters) == 0 {
+		b.WriteString(strings.TrimSpace(t.Text))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	b.WriteString(MarkdownChapters(t.Chapters))
+	return b.String()
+}
+
+// MarkdownChapters renders a section per chapter, headed by its title and
+// start time, with the chapter's text below
+func MarkdownChapters(chapters []models.Chapter) string {
+	var b strings.Builder
+	for _, ch := range chapters {
+		fmt.Fprintf(&b, "## %s (%s)\n\n", markdownLine(ch.Title), clockTime(ch.Start))
+		if text := strings.TrimSpace(ch.Text); text != "" {
+			b.WriteString(text)
+			b.WriteString("\n\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// markdownLine keeps text on one line, since a heading ends at a newline
+func markdownLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// clockTime formats seconds as H:MM:SS, or M:SS under an hour, the way
+// video players show positions
+func clockTime(seconds float64) string {
+	s := int64(math.Max(seconds, 0))
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
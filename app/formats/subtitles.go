@@ -0,0 +1,50 @@
+package formats
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"yt-text/models"
+)
+
+// SRTSubtitles renders numbered SubRip cues
+func SRTSubtitles(segments []models.Segment) string {
+	var b strings.Builder
+	for i, seg := range segments {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n",
+			i+1,
+			subtitleTimestamp(seg.Start, ','),
+			subtitleTimestamp(seg.End, ','),
+			cueText(seg.Text),
+		)
+	}
+	return b.String()
+}
+
+// VTTSubtitles renders a WebVTT document
+func VTTSubtitles(segments []models.Segment) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, seg := range segments {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+			subtitleTimestamp(seg.Start, '.'),
+			subtitleTimestamp(seg.End, '.'),
+			cueText(seg.Text),
+		)
+	}
+	return b.String()
+}
+
+// cueText drops blank lines, which would end a cue early in both formats
+func cueText(text string) string {
+	lines := strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' })
+	return strings.Join(lines, "\n")
+}
+
+// subtitleTimestamp formats seconds as HH:MM:SS followed by sep and
+// milliseconds, which is the timing syntax of both formats
+func subtitleTimestamp(seconds float64, sep byte) string {
+	ms := int64(math.Round(math.Max(seconds, 0) * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d",
+		ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
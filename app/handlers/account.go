@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+	"yt-text/errors"
+	"yt-text/middleware"
+	"yt-text/services/account"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// stateCookie carries the OAuth state from the login redirect to the
+// callback, so a callback can't be forged from another site
+const stateCookie = "oauth_state"
+
+// loginTimeout is how long a user has to sign in at the provider
+const loginTimeout = 10 * time.Minute
+
+type AccountHandler struct {
+	service account.Service
+	secure  bool // Cookies only go over HTTPS
+}
+
+func NewAccountHandler(service account.Service, secure bool) *AccountHandler {
+	return &AccountHandler{service: service, secure: secure}
+}
+
+// Login sends the browser to the provider's sign-in page
+func (h *AccountHandler) Login(c *fiber.Ctx) error {
+	const op = "AccountHandler.Login"
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return errors.Internal(op, err, "Failed to start login")
+	}
+	state := base64.RawURLEncoding.EncodeToString(b)
+
+	url, err := h.service.LoginURL(c.Params("provider"), state)
+	if err != nil {
+		return err
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/auth",
+		Expires:  time.Now().Add(loginTimeout),
+		Secure:   h.secure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect(url, fiber.StatusFound)
+}
+
+// Callback finishes a login the provider redirected back from and signs
+// the browser in
+func (h *AccountHandler) Callback(c *fiber.Ctx) error {
+	const op = "AccountHandler.Callback"
+
+	state := c.Cookies(stateCookie)
+	c.Cookie(&fiber.Cookie{Name: stateCookie, Path: "/auth", Expires: time.Unix(0, 0), HTTPOnly: true})
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		return errors.InvalidInput(op, nil, "Login expired, please sign in again")
+	}
+
+	user, err := h.service.SignIn(requestContext(c), c.Params("provider"), c.Query("code"))
+	if err != nil {
+		return err
+	}
+
+	token, expires := h.service.NewSession(user.ID)
+	c.Cookie(&fiber.Cookie{
+		Name:     middleware.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   h.secure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.Redirect("/", fiber.StatusFound)
+}
+
+// Logout signs the browser out
+func (h *AccountHandler) Logout(c *fiber.Ctx) error {
+	c.Cookie(&fiber.Cookie{
+		Name:     middleware.SessionCookie,
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		Secure:   h.secure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Me returns the signed-in user
+func (h *AccountHandler) Me(c *fiber.Ctx) error {
+	const op = "AccountHandler.Me"
+
+	userID := middleware.UserID(c)
+	if userID == "" {
+		return errors.NotFound(op, nil, "User accounts are not enabled")
+	}
+
+	user, err := h.service.GetUser(requestContext(c), userID)
+	if err != nil {
+		return err
+	}
+	return respond(c, user)
+}
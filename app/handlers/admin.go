@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"yt-text/errors"
+	"yt-text/services/video"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminHandler serves operator endpoints for controlling the job queue
+type AdminHandler struct {
+	service video.Service
+}
+
+func NewAdminHandler(service video.Service) *AdminHandler {
+	return &AdminHandler{service: service}
+}
+
+func (h *AdminHandler) PrioritizeJob(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	position, err := h.service.PrioritizeJob(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, fiber.Map{
+		"id":       id,
+		"priority": true,
+		"position": position,
+	})
+}
+
+// DeprioritizeJob moves a prioritized job back to the normal lane
+func (h *AdminHandler) DeprioritizeJob(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	if err := h.service.DeprioritizeJob(requestContext(c), id); err != nil {
+		return err
+	}
+
+	return respond(c, fiber.Map{
+		"id":       id,
+		"priority": false,
+	})
+}
+
+// ListJobs lists running and waiting jobs along with the queue's state
+func (h *AdminHandler) ListJobs(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	return respond(c, fiber.Map{
+		"queue": h.service.QueueStatus(ctx),
+		"jobs":  h.service.ListJobs(ctx),
+	})
+}
+
+// CancelJob stops a waiting or running job. Waiting jobs are failed
+// immediately; a running job stops shortly after, so 202 is returned.
+func (h *AdminHandler) CancelJob(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	running, err := h.service.CancelJob(requestContext(c), id)
+	if err != nil {
+		return err
+	}
+
+	if running {
+		c.Status(fiber.StatusAccepted)
+	}
+	return respond(c, fiber.Map{
+		"id":          id,
+		"cancelled":   true,
+		"was_running": running,
+	})
+}
+
+// JobLogs returns the captured backend output of a job's last failed run
+func (h *AdminHandler) JobLogs(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	video, err := h.service.GetTranscription(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, fiber.Map{
+		"id":     video.ID,
+		"status": video.Status,
+		"error":  video.Error,
+		"logs":   video.FailureLog,
+	})
+}
+
+func (h *AdminHandler) QueueStatus(c *fiber.Ctx) error {
+	return respond(c, h.service.QueueStatus(c.Context()))
+}
+
+func (h *AdminHandler) PauseQueue(c *fiber.Ctx) error {
+	scope, err := pauseScope(c)
+	if err != nil {
+		return err
+	}
+	if err := h.service.PauseQueue(c.Context(), scope); err != nil {
+		return err
+	}
+	return h.QueueStatus(c)
+}
+
+func (h *AdminHandler) ResumeQueue(c *fiber.Ctx) error {
+	scope, err := pauseScope(c)
+	if err != nil {
+		return err
+	}
+	if err := h.service.ResumeQueue(c.Context(), scope); err != nil {
+		return err
+	}
+	return h.QueueStatus(c)
+}
+
+// pauseScope reads the requested scope, defaulting to the whole queue
+func pauseScope(c *fiber.Ctx) (video.PauseScope, error) {
+	var req PauseQueueRequest
+	if err := bind(c, &req); err != nil {
+		return "", err
+	}
+	if req.Scope == "" {
+		return video.PauseAll, nil
+	}
+	return video.PauseScope(req.Scope), nil
+}
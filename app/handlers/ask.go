@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"yt-text/services/ask"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AskHandler struct {
+	service ask.Service
+}
+
+func NewAskHandler(service ask.Service) *AskHandler {
+	return &AskHandler{service: service}
+}
+
+// Ask answers a question about a completed transcription, citing the
+// passages the answer was drawn from
+func (h *AskHandler) Ask(c *fiber.Ctx) error {
+	var req AskRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	result, err := h.service.Ask(requestContext(c), c.Params("id"), req.Question)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, result)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"strings"
+	"yt-text/errors"
+	"yt-text/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bind fills req from the query string and then the request body (JSON or
+// form encoded), and validates it against its `validate` tags. Validation
+// failures become a 400 listing every invalid field.
+func bind(c *fiber.Ctx, req interface{}) error {
+	const op = "Handler.bind"
+
+	if err := c.QueryParser(req); err != nil {
+		return errors.InvalidInput(op, err, "Malformed query string")
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(req); err != nil {
+			return errors.InvalidInput(op, err, "Malformed request body")
+		}
+	}
+
+	fieldErrors := validation.Struct(req)
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(fieldErrors))
+	for i, fe := range fieldErrors {
+		messages[i] = fe.Field + " " + fe.Message
+	}
+
+	appErr := errors.InvalidInput(op, nil, "Invalid request: "+strings.Join(messages, "; "))
+	appErr.Details = fieldErrors
+	return appErr
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"yt-text/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReloadFunc re-reads the configuration and returns the names of the
+// settings that changed
+type ReloadFunc func() ([]string, error)
+
+// ConfigHandler serves operator endpoints for the running configuration
+type ConfigHandler struct {
+	reload ReloadFunc
+}
+
+func NewConfigHandler(reload ReloadFunc) *ConfigHandler {
+	return &ConfigHandler{reload: reload}
+}
+
+// Reload applies changed reloadable settings without a restart, the same as
+// sending the process SIGHUP
+func (h *ConfigHandler) Reload(c *fiber.Ctx) error {
+	const op = "ConfigHandler.Reload"
+
+	changed, err := h.reload()
+	if err != nil {
+		return errors.InvalidInput(op, err, "Invalid configuration: "+err.Error())
+	}
+
+	return respond(c, fiber.Map{"changed": changed})
+}
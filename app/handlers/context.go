@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"context"
+	"yt-text/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestContext returns the request's context annotated with its request ID
+// so it can be correlated with background jobs and backend logs
+func requestContext(c *fiber.Ctx) context.Context {
+	ctx := context.Context(c.Context())
+	if id, ok := c.Locals("requestid").(string); ok && id != "" {
+		ctx = logger.WithRequestID(ctx, id)
+	}
+	return ctx
+}
+
+// requestID returns the ID the requestid middleware gave the request, which
+// is the client's X-Request-ID when it sent one
+func requestID(c *fiber.Ctx) string {
+	if id, ok := c.Locals("requestid").(string); ok {
+		return id
+	}
+	return c.Get("X-Request-ID")
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"time"
+	"yt-text/models"
+	"yt-text/services/video"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed templates/dashboard.html
+var templateFS embed.FS
+
+// recentFailureLimit caps the failures listed on the dashboard
+const recentFailureLimit = 20
+
+var dashboardTemplate = template.Must(
+	template.New("dashboard.html").Funcs(template.FuncMap{
+		"bytes":    formatBytes,
+		"duration": formatDuration,
+	}).ParseFS(templateFS, "templates/dashboard.html"),
+)
+
+// DashboardHandler renders a server-side HTML overview for operators
+type DashboardHandler struct {
+	service video.Service
+}
+
+func NewDashboardHandler(service video.Service) *DashboardHandler {
+	return &DashboardHandler{service: service}
+}
+
+type dashboardData struct {
+	GeneratedAt    time.Time
+	Stats          *video.Stats
+	ActiveJobs     []video.ActiveJob
+	RecentFailures []*models.Video
+}
+
+func (h *DashboardHandler) Dashboard(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	stats, err := h.service.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	failures, err := h.service.RecentFailures(ctx, recentFailureLimit)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = dashboardTemplate.Execute(&buf, dashboardData{
+		GeneratedAt:    time.Now(),
+		Stats:          stats,
+		ActiveJobs:     h.service.ActiveJobs(ctx),
+		RecentFailures: failures,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(buf.Bytes())
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
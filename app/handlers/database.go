@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"yt-text/errors"
+	"yt-text/repository/sqlite"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaintainFunc runs a database maintenance pass, vacuuming the whole file
+// when full is set
+type MaintainFunc func(ctx context.Context, full bool) (*sqlite.MaintenanceResult, error)
+
+// DatabaseHandler serves operator endpoints for the database
+type DatabaseHandler struct {
+	maintain MaintainFunc
+}
+
+// NewDatabaseHandler takes the maintenance pass of the database, or nil
+// when it needs none
+func NewDatabaseHandler(maintain MaintainFunc) *DatabaseHandler {
+	return &DatabaseHandler{maintain: maintain}
+}
+
+// Maintain checkpoints, analyzes and vacuums a SQLite database now, rather
+// than waiting for the scheduled pass
+func (h *DatabaseHandler) Maintain(c *fiber.Ctx) error {
+	const op = "DatabaseHandler.Maintain"
+
+	if h.maintain == nil {
+		return errors.InvalidInput(op, nil, "Only SQLite databases are maintained by the server")
+	}
+
+	var req MaintenanceRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	result, err := h.maintain(requestContext(c), req.Vacuum)
+	if err != nil {
+		return errors.Internal(op, err, "Database maintenance failed")
+	}
+	return respond(c, result)
+}
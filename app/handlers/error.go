@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"math"
+	"strconv"
 	"yt-text/errors"
+	"yt-text/middleware"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
@@ -10,23 +13,31 @@ import (
 func ErrorHandler(c *fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
 	message := "Internal Server Error"
+	var details interface{}
 
-	if e, ok := err.(*errors.AppError); ok {
+	switch e := err.(type) {
+	case *errors.AppError:
+		code = e.Code
+		message = e.Message
+		details = e.Details
+		if e.RetryAfter > 0 {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+		}
+		if e.Limit > 0 && c.GetRespHeader(middleware.HeaderRateLimitLimit) == "" {
+			middleware.SetRateLimit(c, e.Limit, 0, e.RetryAfter)
+		}
+	case *fiber.Error:
 		code = e.Code
 		message = e.Message
 	}
 
 	log.Error().
-		Str("request_id", c.Get("X-Request-ID")).
+		Str("request_id", requestID(c)).
 		Str("path", c.Path()).
 		Str("method", c.Method()).
 		Int("status", code).
 		Err(err).
 		Msg("Request error")
 
-	return c.Status(code).JSON(fiber.Map{
-		"success":    false,
-		"error":      message,
-		"request_id": c.Get("X-Request-ID"),
-	})
+	return respondError(c, code, message, details)
 }
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+	"yt-text/errors"
+	"yt-text/events"
+	"yt-text/logger"
+	"yt-text/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// eventsKeepAlive is the longest a stream stays silent; proxies tend to
+// close idle connections
+const eventsKeepAlive = 15 * time.Second
+
+// Events streams progress updates for a transcription as Server-Sent
+// Events, for clients and proxies that can't hold a WebSocket. Each update
+// is a "progress" event; while Whisper runs, each segment it produces is
+// also sent as a "transcript_chunk" event. The stream ends after the job
+// completes or fails. Streams of the same job share one watch on it.
+func (h *VideoHandler) Events(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	// Report a missing video as a normal error before the stream starts
+	watcher, err := h.hub.Watch(requestContext(c), id)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Disable nginx response buffering
+
+	// The stream outlives the handler, so it must not touch c
+	ctx := logger.WithRequestID(context.Background(), logger.RequestID(requestContext(c)))
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer watcher.Close()
+		h.streamProgress(ctx, &eventWriter{w: w, conn: conn}, id, watcher)
+	})
+	return nil
+}
+
+// streamProgress writes the watcher's updates, and the transcript segments
+// they announce, until the job is done or the client goes away
+func (h *VideoHandler) streamProgress(ctx context.Context, w *eventWriter, id string, watcher *events.Watcher) {
+	ticker := time.NewTicker(eventsKeepAlive)
+	defer ticker.Stop()
+
+	var last models.ProgressUpdate
+	sent := 0 // Transcript segments written so far
+	for {
+		var err error
+		select {
+		case update, ok := <-watcher.C:
+			if !ok {
+				if !last.Done() {
+					_ = w.event("error", fiber.Map{"message": "Transcription is no longer available"})
+				}
+				return
+			}
+			if update.Segments > sent {
+				sent, err = h.writeChunks(ctx, w, id, sent)
+				if err != nil {
+					return
+				}
+			}
+			err = w.event("progress", update)
+			last = update
+		case <-ticker.C:
+			err = w.comment("keep-alive")
+		}
+		if err != nil {
+			return // Client disconnected
+		}
+	}
+}
+
+// transcriptChunk is a "transcript_chunk" event. Index counts segments
+// from 0, so clients can tell whether they missed one.
+type transcriptChunk struct {
+	Index int `json:"index"`
+	models.Segment
+}
+
+// writeChunks writes the partial transcript segments from offset on and
+// returns the new offset. A job that just finished has none left to write.
+func (h *VideoHandler) writeChunks(ctx context.Context, w *eventWriter, id string, offset int) (int, error) {
+	segments, err := h.service.PartialTranscript(ctx, id, offset)
+	if err != nil {
+		return offset, nil
+	}
+
+	for _, segment := range segments {
+		if err := w.event("transcript_chunk", transcriptChunk{Index: offset, Segment: segment}); err != nil {
+			return offset, err
+		}
+		offset++
+	}
+	return offset, nil
+}
+
+// eventWriter writes SSE messages, flushing each one. The server's write
+// timeout covers the whole response, so every message extends the
+// connection's deadline instead.
+type eventWriter struct {
+	w    *bufio.Writer
+	conn net.Conn
+}
+
+// event writes one event with a JSON payload
+func (e *eventWriter) event(name string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return e.write("event: %s\ndata: %s\n\n", name, payload)
+}
+
+// comment writes a comment line, which clients ignore
+func (e *eventWriter) comment(text string) error {
+	return e.write(": %s\n\n", text)
+}
+
+func (e *eventWriter) write(format string, args ...interface{}) error {
+	if err := e.conn.SetWriteDeadline(time.Now().Add(2 * eventsKeepAlive)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(e.w, format, args...); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"yt-text/logger"
+	"yt-text/middleware"
+	"yt-text/services/video"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// Export streams completed transcriptions as a ZIP archive. The archive is
+// written as it's read from the store, so an error partway through can
+// only cut it short; the missing central directory makes that evident to
+// unzip.
+func (h *VideoHandler) Export(c *fiber.Ctx) error {
+	var req ExportRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Attachment("transcriptions.zip")
+
+	opts := video.ExportOptions{Language: req.Language, UserID: middleware.UserID(c)}
+
+	// The stream outlives the handler, so it must not touch c
+	ctx := logger.WithRequestID(context.Background(), logger.RequestID(requestContext(c)))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		err := h.service.ExportTranscriptions(ctx, w, opts)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			log.Error().Err(err).Str("request_id", logger.RequestID(ctx)).Msg("Export failed")
+		}
+	})
+	return nil
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"strings"
+	"yt-text/errors"
+	"yt-text/formats"
+	"yt-text/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// transcriptFormat picks the format a transcription is sent in: the one
+// named by ?format=, otherwise the best match for the Accept header, with
+// JSON when the client didn't ask
+func transcriptFormat(c *fiber.Ctx) (formats.Format, error) {
+	if name := c.Query("format"); name != "" {
+		format, ok := formats.Parse(name)
+		if !ok {
+			return "", &errors.AppError{
+				Code:    fiber.StatusBadRequest,
+				Message: "Format must be one of: " + strings.Join(formats.Names(), ", "),
+			}
+		}
+		return format, nil
+	}
+
+	c.Vary(fiber.HeaderAccept)
+	if c.Get(fiber.HeaderAccept) == "" {
+		return formats.JSON, nil
+	}
+	format, ok := formats.ByMediaType(c.Accepts(formats.MediaTypes()...))
+	if !ok {
+		return "", &errors.AppError{
+			Code:    fiber.StatusNotAcceptable,
+			Message: "Transcriptions are available as: " + strings.Join(formats.MediaTypes(), ", "),
+		}
+	}
+	return format, nil
+}
+
+// storedEncodings are the content codings transcripts may be stored with
+var storedEncodings = []string{"zstd", "gzip"}
+
+// acceptedEncodings returns the stored encodings the Accept-Encoding header
+// allows. Without the header the client gets plain text.
+func acceptedEncodings(c *fiber.Ctx) []string {
+	c.Vary(fiber.HeaderAcceptEncoding)
+	if c.Get(fiber.HeaderAcceptEncoding) == "" {
+		return nil
+	}
+
+	var accepted []string
+	for _, encoding := range storedEncodings {
+		if c.AcceptsEncodings(encoding) != "" {
+			accepted = append(accepted, encoding)
+		}
+	}
+	return accepted
+}
+
+// sendTranscript writes the transcript from source in a format other than
+// JSON. Formats meant to be saved are sent as attachments.
+func sendTranscript(c *fiber.Ctx, video *models.Video, source models.Source, format formats.Format) error {
+	if !video.IsCompleted() {
+		return &errors.AppError{
+			Code:    fiber.StatusConflict,
+			Message: "Transcription is not completed yet",
+		}
+	}
+
+	transcript, ok := formats.FromVideo(video, source)
+	if !ok {
+		return &errors.AppError{
+			Code:    fiber.StatusNotFound,
+			Message: "No transcript stored from source " + string(source),
+		}
+	}
+	body, err := formats.Render(format, transcript)
+	if stderrors.Is(err, formats.ErrNoTimestamps) {
+		return &errors.AppError{
+			Code:    fiber.StatusNotFound,
+			Message: "No timestamps stored for this transcription",
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	if format.Download() {
+		c.Attachment(video.ID + format.Extension())
+	}
+	return c.SendString(body)
+}
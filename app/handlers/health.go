@@ -1,14 +1,88 @@
 package handlers
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// readinessTimeout bounds each readiness check, so one hung dependency
+// doesn't hold the probe past its own timeout
+const readinessTimeout = 5 * time.Second
+
+// HealthCheck reports that the process is up. It's the liveness probe and
+// checks nothing else, so a failing dependency doesn't get the process
+// restarted.
 func HealthCheck(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"status":    "ok",
 		"timestamp": time.Now().UTC(),
 	})
 }
+
+// ReadinessCheck checks one dependency, returning nil when it's usable
+type ReadinessCheck func(ctx context.Context) error
+
+// CheckResult is the outcome of one readiness check
+type CheckResult struct {
+	Status     string  `json:"status"` // "ok" or "error"
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+type HealthHandler struct {
+	checks map[string]ReadinessCheck
+}
+
+// NewHealthHandler creates a handler running the given checks, by name, for
+// the readiness probe
+func NewHealthHandler(checks map[string]ReadinessCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Ready runs every check concurrently and reports each dependency's status,
+// answering 503 when any of them fails
+func (h *HealthHandler) Ready(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(requestContext(c), readinessTimeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(h.checks))
+		ready   = true
+	)
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check(ctx)
+			result := CheckResult{Status: "ok", DurationMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			ready = ready && err == nil
+		}()
+	}
+	wg.Wait()
+
+	status := "ok"
+	if !ready {
+		status = "unavailable"
+		c.Status(fiber.StatusServiceUnavailable)
+	}
+	return c.JSON(fiber.Map{
+		"status":    status,
+		"timestamp": time.Now().UTC(),
+		"checks":    results,
+	})
+}
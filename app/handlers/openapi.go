@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+	"yt-text/models"
+	"yt-text/openapi"
+	"yt-text/repository/sqlite"
+	"yt-text/services/video"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Security schemes of the API description
+const (
+	securityAPIKey = "apiKey"
+	securityAdmin  = "adminToken"
+
+	securitySession = "session"
+)
+
+// APISpec describes the routes mounted by the server's route registration,
+// relative to /api/v2. Request and response schemas come from the types the
+// handlers use, so only routes and their wiring need updating here.
+func APISpec(version string) *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "yt-text API",
+		Version:     version,
+		Description: "Transcribes videos from YouTube and other sites, using existing captions or Whisper.",
+	}, dataEnvelope, ErrorResponse{})
+
+	b.Server("/api/v2", "Current API, with {data, meta} responses")
+	b.Server("/api/v1", "Deprecated v1 API, with {success, data} responses and {success, error, request_id} errors")
+	b.Server("/api", "Unprefixed alias of the v1 API")
+
+	b.Tag("transcriptions", "Submitting and reading transcriptions")
+	b.Tag("summaries", "Summaries of and questions about completed transcriptions")
+	b.Tag("admin", "Operator endpoints, disabled unless ADMIN_TOKEN is set")
+	b.Tag("accounts", "Signed-in users, enabled when an OAuth provider is configured")
+
+	b.SecurityScheme(securityAPIKey, &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "X-API-Key",
+		Description: "Needed only when API_KEYS is set and the request doesn't come from an allowed origin; may also be passed as ?api_key=",
+	})
+	b.SecurityScheme(securityAdmin, &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "ADMIN_TOKEN, also accepted as the Basic auth password or in X-Admin-Token",
+	})
+	b.SecurityScheme(securitySession, &openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "cookie",
+		Name:        "session",
+		Description: "Set by signing in at /auth/{provider}/login; needed only when user accounts are enabled",
+	})
+
+	for _, route := range apiRoutes {
+		b.Add(route)
+	}
+	return b.Document()
+}
+
+// dataEnvelope wraps response data in the v2 envelope written by respond
+func dataEnvelope(data *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"data": data,
+			"meta": {Ref: "#/components/schemas/Meta"},
+		},
+		Required: []string{"data", "meta"},
+	}
+}
+
+// Error statuses most routes share
+var (
+	readErrors   = []int{http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError}
+	submitErrors = []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusServiceUnavailable}
+	adminErrors  = []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}
+)
+
+// apiRoutes lists every route registered under an API version
+var apiRoutes = []openapi.Route{
+	{
+		Method: http.MethodPost, Path: "/transcribe", OperationID: "transcribe", Tag: "transcriptions",
+		Summary:     "Transcribe a video",
+		Description: "Returns the stored transcript if there is one, otherwise queues a job and answers 202 with a Location to poll. Playlist and channel URLs become a batch instead.",
+		Security:    securityAPIKey, Body: TranscribeRequest{}, Response: models.VideoResponse{},
+		Status: http.StatusAccepted, Errors: append([]int{http.StatusConflict}, submitErrors...),
+	},
+	{
+		Method: http.MethodPost, Path: "/transcribe/batch", OperationID: "transcribeBatch", Tag: "transcriptions",
+		Summary:  "Transcribe several videos",
+		Security: securityAPIKey, Body: BatchTranscribeRequest{}, Response: models.BatchResponse{},
+		Status: http.StatusAccepted, Errors: submitErrors,
+	},
+	{
+		Method: http.MethodPost, Path: "/transcribe/estimate", OperationID: "estimate", Tag: "transcriptions",
+		Summary:  "Estimate processing time and queue wait without queueing",
+		Security: securityAPIKey, Body: TranscribeRequest{}, Response: models.Estimate{},
+		Errors: submitErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/transcribe/batch/:id", OperationID: "getBatch", Tag: "transcriptions",
+		Summary:  "Get a batch and the state of its items",
+		Response: models.BatchResponse{}, Errors: readErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/transcriptions", OperationID: "listTranscriptions", Tag: "transcriptions",
+		Summary:     "List transcriptions",
+		Description: "With user accounts enabled, lists the signed-in user's transcriptions, most recently submitted first.",
+		Security:    securitySession, Query: ListTranscriptionsRequest{}, Response: models.VideoListResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests},
+	},
+	{
+		Method: http.MethodGet, Path: "/export", OperationID: "export", Tag: "transcriptions",
+		Summary:     "Download completed transcriptions as a ZIP archive",
+		Description: "With user accounts enabled, exports only the signed-in user's transcriptions.",
+		Security:    securityAPIKey, Query: ExportRequest{}, ContentType: "application/zip",
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests},
+	},
+	{
+		Method: http.MethodGet, Path: "/transcribe/:id", OperationID: "getTranscription", Tag: "transcriptions",
+		Summary:     "Get a transcription",
+		Description: "?format= or the Accept header selects text, Markdown or subtitles instead of JSON; ?source= a stored transcript other than the primary one; ?segments=true adds timing. With user accounts enabled, only answers for videos the signed-in user submitted; others need a share link.",
+		Security:    securitySession, Response: models.VideoResponse{},
+		Errors: append([]int{http.StatusUnauthorized}, readErrors...),
+	},
+	{
+		Method: http.MethodGet, Path: "/transcribe/:id/text", OperationID: "getTranscriptionText", Tag: "transcriptions",
+		Summary:     "Stream a transcript as plain text",
+		Description: "With user accounts enabled, only answers for videos the signed-in user submitted.",
+		Security:    securitySession, ContentType: fiber.MIMETextPlainCharsetUTF8,
+		Errors: append([]int{http.StatusUnauthorized}, readErrors...),
+	},
+	{
+		Method: http.MethodGet, Path: "/transcribe/:id/events", OperationID: "transcriptionEvents", Tag: "transcriptions",
+		Summary:     "Follow a job's progress and partial transcript as Server-Sent Events",
+		Security:    securityAPIKey,
+		ContentType: "text/event-stream", Errors: readErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/transcribe/:id/history", OperationID: "getJobHistory", Tag: "transcriptions",
+		Summary:     "List the state changes of a video's jobs",
+		Description: "With user accounts enabled, only answers for videos the signed-in user submitted.",
+		Security:    securitySession, Response: models.JobHistoryResponse{},
+		Errors: append([]int{http.StatusUnauthorized}, readErrors...),
+	},
+	{
+		Method: http.MethodPost, Path: "/transcribe/:id/retry", OperationID: "retryTranscription", Tag: "transcriptions",
+		Summary:  "Retry a failed transcription, optionally with another source or model",
+		Security: securityAPIKey, Body: RetryRequest{}, Response: models.VideoResponse{},
+		Status: http.StatusAccepted, Errors: append([]int{http.StatusNotFound, http.StatusConflict}, submitErrors...),
+	},
+	{
+		Method: http.MethodPost, Path: "/transcribe/:id/share", OperationID: "shareTranscription", Tag: "transcriptions",
+		Summary:     "Create an expiring link to a transcription",
+		Description: "The link reads the transcription without an API key or session until it expires. With user accounts enabled, only users who submitted the video can share it. Answers 404 unless SHARE_SECRET is set.",
+		Security:    securityAPIKey, Body: ShareRequest{}, Response: models.ShareResponse{},
+		Status: http.StatusCreated, Errors: append([]int{http.StatusNotFound}, submitErrors...),
+	},
+	{
+		Method: http.MethodGet, Path: "/shared/:token", OperationID: "getSharedTranscription", Tag: "transcriptions",
+		Summary:     "Get a transcription through a share link",
+		Description: "Takes the same query parameters as getTranscription.",
+		Response:    models.VideoResponse{}, Errors: append([]int{http.StatusForbidden}, readErrors...),
+	},
+	{
+		Method: http.MethodGet, Path: "/shared/:token/text", OperationID: "getSharedTranscriptionText", Tag: "transcriptions",
+		Summary:     "Stream a transcript through a share link",
+		ContentType: fiber.MIMETextPlainCharsetUTF8, Errors: append([]int{http.StatusForbidden}, readErrors...),
+	},
+	{
+		Method: http.MethodDelete, Path: "/transcribe/:id", OperationID: "deleteTranscription", Tag: "transcriptions",
+		Summary:     "Delete a transcription",
+		Description: "With user accounts enabled, takes the transcription off the signed-in user's list, and deletes it once no other user has it.",
+		Security:    securityAPIKey,
+		Status:      http.StatusNoContent, Errors: []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict},
+	},
+	{
+		Method: http.MethodPatch, Path: "/transcribe/:id", OperationID: "updateTranscription", Tag: "transcriptions",
+		Summary:     "Pin a transcription or change how long it's kept",
+		Description: "With user accounts enabled, only users who submitted the video can change it. Requests with the admin token can change any video.",
+		Security:    securityAPIKey, Body: UpdateTranscriptionRequest{}, Response: models.VideoResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusTooManyRequests},
+	},
+	{
+		Method: http.MethodPatch, Path: "/transcribe/:id/transcript", OperationID: "correctTranscript", Tag: "transcriptions",
+		Summary:     "Correct a completed transcript, storing it as a new revision",
+		Description: "Either text replaces the whole transcript, its words fitted onto the existing segment timing, or segments replace the text of single segments. The transcript as transcribed is kept as a revision before the first correction. With user accounts enabled, only users who submitted the video can correct it. base_revision refuses the correction with 409 if the transcript was corrected since.",
+		Security:    securityAPIKey, Body: CorrectTranscriptRequest{}, Response: models.Revision{},
+		Status: http.StatusCreated, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests},
+	},
+	{
+		Method: http.MethodGet, Path: "/transcribe/:id/revisions", OperationID: "listRevisions", Tag: "transcriptions",
+		Summary:     "List the revisions of a transcript",
+		Description: "With user accounts enabled, only answers for videos the signed-in user submitted.",
+		Security:    securitySession, Response: models.RevisionList{},
+		Errors: append([]int{http.StatusUnauthorized}, readErrors...),
+	},
+	{
+		Method: http.MethodGet, Path: "/transcribe/:id/revisions/:revision", OperationID: "getRevision", Tag: "transcriptions",
+		Summary:     "Get a revision of a transcript and its word changes",
+		Description: "Changes are counted against the revision before, or the one set by ?against=. With user accounts enabled, only answers for videos the signed-in user submitted.",
+		Security:    securitySession, Query: RevisionRequest{}, Response: models.RevisionDiff{},
+		Errors: append([]int{http.StatusBadRequest, http.StatusUnauthorized}, readErrors...),
+	},
+	{
+		Method: http.MethodPost, Path: "/summarize", OperationID: "summarize", Tag: "summaries",
+		Summary:     "Summarize a completed transcription",
+		Description: "style picks an abstract paragraph (the default), bullet points, or a short abstract per chapter. Each style is made once per model and transcript.",
+		Security:    securityAPIKey, Body: SummarizeRequest{}, Response: models.Summary{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests},
+	},
+	{
+		Method: http.MethodGet, Path: "/summary/:id", OperationID: "getSummary", Tag: "summaries",
+		Summary: "Get the summary of a transcription",
+		Query:   GetSummaryRequest{}, Response: models.Summary{},
+		Errors: append([]int{http.StatusBadRequest}, readErrors...),
+	},
+	{
+		Method: http.MethodPost, Path: "/transcribe/:id/ask", OperationID: "ask", Tag: "summaries",
+		Summary:     "Ask a question about a completed transcription",
+		Description: "Answers from the passages of the transcript that match the question best, and cites them with their timestamps as [n]. The local provider answers with the best passage itself; ASK_PROVIDER=api uses a language model.",
+		Security:    securityAPIKey, Body: AskRequest{}, Response: models.Answer{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests},
+	},
+	{
+		Method: http.MethodGet, Path: "/search/semantic", OperationID: "semanticSearch", Tag: "transcriptions",
+		Summary:     "Find transcript passages by meaning",
+		Description: "Compares the query's embedding with those of every indexed passage and returns the closest, best first, with their videos and timestamps. Answers 404 unless EMBEDDINGS_ENABLED is set.",
+		Security:    securityAPIKey, Query: SemanticSearchRequest{}, Response: models.SearchResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	},
+	{
+		Method: http.MethodGet, Path: "/jobs/:id/logs", OperationID: "jobLogs", Tag: "admin",
+		Summary:  "Get the captured output of a job's last failed run",
+		Security: securityAdmin, Response: jobLogs{}, Errors: adminErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/queue", OperationID: "queueStatus", Tag: "admin",
+		Summary:  "Get the queue's depth and pause state",
+		Security: securityAdmin, Response: video.QueueStatus{}, Errors: adminErrors,
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/queue/pause", OperationID: "pauseQueue", Tag: "admin",
+		Summary:  "Pause intake, workers or both",
+		Security: securityAdmin, Body: PauseQueueRequest{}, Response: video.QueueStatus{}, Errors: adminErrors,
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/queue/resume", OperationID: "resumeQueue", Tag: "admin",
+		Summary:  "Resume intake, workers or both",
+		Security: securityAdmin, Body: PauseQueueRequest{}, Response: video.QueueStatus{}, Errors: adminErrors,
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/jobs", OperationID: "listJobs", Tag: "admin",
+		Summary:  "List running and waiting jobs",
+		Security: securityAdmin, Response: jobList{}, Errors: adminErrors,
+	},
+	{
+		Method: http.MethodDelete, Path: "/admin/jobs/:id", OperationID: "cancelJob", Tag: "admin",
+		Summary:     "Cancel a waiting or running job",
+		Description: "A running job stops shortly after, so 202 is returned for it.",
+		Security:    securityAdmin, Response: jobCancellation{}, Errors: append([]int{http.StatusConflict}, adminErrors...),
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/jobs/:id/priority", OperationID: "prioritizeJob", Tag: "admin",
+		Summary:  "Move a waiting job into the priority lane",
+		Security: securityAdmin, Response: jobPriority{}, Errors: adminErrors,
+	},
+	{
+		Method: http.MethodDelete, Path: "/admin/jobs/:id/priority", OperationID: "deprioritizeJob", Tag: "admin",
+		Summary:  "Move a prioritized job back to the normal lane",
+		Security: securityAdmin, Response: jobPriority{}, Errors: adminErrors,
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/config/reload", OperationID: "reloadConfig", Tag: "admin",
+		Summary:  "Re-read the configuration file",
+		Security: securityAdmin, Response: configReload{}, Errors: adminErrors,
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/database/maintenance", OperationID: "maintainDatabase", Tag: "admin",
+		Summary:  "Checkpoint, analyze and vacuum the SQLite database",
+		Security: securityAdmin, Body: MaintenanceRequest{}, Response: sqlite.MaintenanceResult{},
+		Errors: append([]int{http.StatusBadRequest, http.StatusInternalServerError}, adminErrors...),
+	},
+	{
+		Method: http.MethodGet, Path: "/stats", OperationID: "stats", Tag: "transcriptions",
+		Summary:  "Get transcription and storage statistics",
+		Response: video.Stats{}, Errors: []int{http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/me", OperationID: "getCurrentUser", Tag: "accounts",
+		Summary:  "Get the signed-in user",
+		Security: securitySession, Response: models.User{},
+		Errors: []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests},
+	},
+}
+
+// Shapes of the responses the admin handlers build as maps
+type (
+	jobPriority struct {
+		ID       string `json:"id"`
+		Priority bool   `json:"priority"`
+		Position int    `json:"position,omitempty"`
+	}
+	jobLogs struct {
+		ID     string        `json:"id"`
+		Status models.Status `json:"status"`
+		Error  string        `json:"error"`
+		Logs   string        `json:"logs"`
+	}
+	jobList struct {
+		Queue video.QueueStatus `json:"queue"`
+		Jobs  []video.JobInfo   `json:"jobs"`
+	}
+	jobCancellation struct {
+		ID         string `json:"id"`
+		Cancelled  bool   `json:"cancelled"`
+		WasRunning bool   `json:"was_running"`
+	}
+	configReload struct {
+		Changed []string `json:"changed"`
+	}
+)
+
+//go:embed templates/docs.html
+var docsPage []byte
+
+// DocsHandler serves the API description and a Swagger UI page for it
+type DocsHandler struct {
+	spec *openapi.Document
+}
+
+func NewDocsHandler(spec *openapi.Document) *DocsHandler {
+	return &DocsHandler{spec: spec}
+}
+
+// Spec returns the OpenAPI document
+func (h *DocsHandler) Spec(c *fiber.Ctx) error {
+	return c.JSON(h.spec)
+}
+
+// UI renders Swagger UI, which loads its assets from a CDN
+func (h *DocsHandler) UI(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(docsPage)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import "yt-text/models"
+
+// TranscribeRequest is the body of POST /transcribe
+type TranscribeRequest struct {
+	URL    string `json:"url" form:"url" query:"url" validate:"required,url,max=2048"`
+	Type   string `json:"type" form:"type" query:"type" validate:"omitempty,oneof=video audio_url"`
+	Source string `json:"source" form:"source" query:"source" validate:"omitempty,oneof=auto captions_only whisper_only captions_then_whisper whisper_if_captions_poor"`
+	WhisperParams
+	CaptionParams
+}
+
+// WhisperParams are the optional Whisper settings of a transcription
+// request. The service validates them, since their bounds depend on the
+// chosen source.
+type WhisperParams struct {
+	Language    string   `json:"language" form:"language" query:"language"`
+	Task        string   `json:"task" form:"task" query:"task"`
+	Temperature *float64 `json:"temperature" form:"temperature" query:"temperature"`
+	BeamSize    int      `json:"beam_size" form:"beam_size" query:"beam_size"`
+}
+
+func (p WhisperParams) options() models.WhisperOptions {
+	return models.WhisperOptions{
+		Language:    p.Language,
+		Task:        models.Task(p.Task),
+		Temperature: p.Temperature,
+		BeamSize:    p.BeamSize,
+	}
+}
+
+// CaptionParams choose which caption tracks a transcription may use.
+// Languages are in order of preference; auto-generated captions are
+// accepted unless AllowAutoCaptions is false.
+type CaptionParams struct {
+	CaptionLanguages  []string `json:"caption_languages" form:"caption_languages" query:"caption_languages"`
+	AllowAutoCaptions *bool    `json:"allow_auto_captions" form:"allow_auto_captions" query:"allow_auto_captions"`
+}
+
+func (p CaptionParams) captionOptions() models.CaptionOptions {
+	return models.CaptionOptions{
+		Languages:  p.CaptionLanguages,
+		ManualOnly: p.AllowAutoCaptions != nil && !*p.AllowAutoCaptions,
+	}
+}
+
+// RetryRequest is the optional body of POST /transcribe/:id/retry
+type RetryRequest struct {
+	Source string `json:"source" form:"source" query:"source" validate:"omitempty,oneof=auto captions_only whisper_only captions_then_whisper whisper_if_captions_poor"`
+	Model  string `json:"model" form:"model" query:"model" validate:"max=32"`
+	WhisperParams
+	CaptionParams
+}
+
+// BatchTranscribeRequest is the body of POST /transcribe/batch. Each URL is
+// validated when it is submitted, and rejected URLs are reported per item.
+type BatchTranscribeRequest struct {
+	URLs   []string `json:"urls" form:"urls" validate:"required,min=1"`
+	Type   string   `json:"type" form:"type" query:"type" validate:"omitempty,oneof=video audio_url"`
+	Source string   `json:"source" form:"source" query:"source" validate:"omitempty,oneof=auto captions_only whisper_only captions_then_whisper whisper_if_captions_poor"`
+	WhisperParams
+	CaptionParams
+}
+
+// ListTranscriptionsRequest is the query of GET /transcriptions
+type ListTranscriptionsRequest struct {
+	Status   string `json:"status" query:"status" validate:"omitempty,oneof=processing completed failed"`
+	Language string `json:"language" query:"language" validate:"max=35"`
+	Page     int    `json:"page" query:"page" validate:"omitempty,min=1"`
+	PageSize int    `json:"page_size" query:"page_size" validate:"omitempty,min=1,max=100"`
+	Keyword  string `json:"keyword" query:"keyword" validate:"max=100"`
+	Entity   string `json:"entity" query:"entity" validate:"max=200"`
+}
+
+// UpdateTranscriptionRequest is the body of PATCH /transcribe/:id. Omitted
+// fields are left unchanged.
+type UpdateTranscriptionRequest struct {
+	Pinned *bool `json:"pinned"`
+	// TTL is how many seconds from now the transcription is kept, in place
+	// of the retention window; 0 clears it
+	TTL *int64 `json:"ttl"`
+}
+
+// maxTTLSeconds bounds UpdateTranscriptionRequest.TTL at ten years
+const maxTTLSeconds = 10 * 365 * 24 * 60 * 60
+
+// CorrectTranscriptRequest is the body of PATCH /transcribe/:id/transcript.
+// Either text replaces the whole transcript, or segments replace the text of
+// the segments at their indexes.
+type CorrectTranscriptRequest struct {
+	Text     *string                    `json:"text"`
+	Segments []SegmentCorrectionRequest `json:"segments" validate:"max=10000"`
+	Note     string                     `json:"note" validate:"max=500"`
+
+	// BaseRevision is the revision the correction was made against, from
+	// the transcription's revision field; the correction is refused with 409
+	// when the transcript was corrected since
+	BaseRevision *int `json:"base_revision"`
+}
+
+// SegmentCorrectionRequest is the new text of one segment; empty text
+// removes it
+type SegmentCorrectionRequest struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+// RevisionRequest is the query of GET /transcribe/:id/revisions/:revision
+type RevisionRequest struct {
+	// Against is the earlier revision to compare with, by default the one
+	// before
+	Against int `json:"against" query:"against" validate:"min=0"`
+}
+
+// ExportRequest is the query of GET /export
+type ExportRequest struct {
+	Language string `json:"language" query:"language" validate:"max=35"`
+}
+
+// PauseQueueRequest is the body of the queue pause and resume endpoints
+type PauseQueueRequest struct {
+	Scope string `json:"scope" form:"scope" query:"scope" validate:"omitempty,oneof=intake workers all"`
+}
+
+// MaintenanceRequest is the body of the database maintenance endpoint.
+// Vacuum rewrites the whole file, blocking writes while it runs.
+type MaintenanceRequest struct {
+	Vacuum bool `json:"vacuum" form:"vacuum" query:"vacuum"`
+}
+
+// SummarizeRequest is the body of POST /summarize
+type SummarizeRequest struct {
+	ID    string `json:"id" form:"id" query:"id" validate:"required,max=64"`
+	Style string `json:"style" form:"style" query:"style" validate:"omitempty,oneof=abstract bullets chapters"`
+}
+
+// GetSummaryRequest is the query of GET /summary/:id. Without a model, the
+// newest summary of the style is returned.
+type GetSummaryRequest struct {
+	Style string `json:"style" query:"style" validate:"omitempty,oneof=abstract bullets chapters"`
+	Model string `json:"model" query:"model" validate:"max=128"`
+}
+
+// AskRequest is the body of POST /transcribe/:id/ask
+type AskRequest struct {
+	Question string `json:"question" form:"question" query:"question" validate:"required,max=1000"`
+}
+
+// SemanticSearchRequest is the query of GET /search/semantic
+type SemanticSearchRequest struct {
+	Q     string `json:"q" query:"q" validate:"required,max=1000"`
+	Limit int    `json:"limit" query:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+// defaultSearchLimit is how many passages a search returns without a limit
+const defaultSearchLimit = 10
+
+// ShareRequest is the body of POST /transcribe/:id/share
+type ShareRequest struct {
+	// TTL is how many seconds the link is valid for, in place of the
+	// configured default
+	TTL *int64 `json:"ttl" form:"ttl" query:"ttl"`
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"yt-text/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiV2 is the version tag of /api/v2 routes
+const apiV2 = "v2"
+
+// Meta accompanies every v2 response
+type Meta struct {
+	RequestID string `json:"request_id"`
+	Timestamp string `json:"timestamp"` // RFC 3339, UTC
+}
+
+// ErrorResponse is the body of a failed v2 request
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+	Meta  Meta      `json:"meta"`
+}
+
+// ErrorBody describes what went wrong. Code is a stable snake_case form of
+// the status, e.g. "not_found"; details, such as field-level validation
+// errors, are only present when there are any.
+type ErrorBody struct {
+	Status  int         `json:"status"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// respond writes a successful response in the envelope of the route's API
+// version. v1, under /api/v1 and the unprefixed /api, wraps data as
+// {success, data}; v2 as {data, meta}. Every API response goes through
+// respond or respondError, so each version has one envelope.
+func respond(c *fiber.Ctx, data interface{}) error {
+	if middleware.RequestAPIVersion(c) == apiV2 {
+		return c.JSON(fiber.Map{
+			"data": data,
+			"meta": responseMeta(c),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// respondError writes an error in the envelope of the route's API version.
+// details, such as field-level validation errors, are omitted when nil.
+func respondError(c *fiber.Ctx, status int, message string, details interface{}) error {
+	if middleware.RequestAPIVersion(c) == apiV2 {
+		return c.Status(status).JSON(ErrorResponse{
+			Error: ErrorBody{
+				Status:  status,
+				Code:    statusCode(status),
+				Message: message,
+				Details: details,
+			},
+			Meta: responseMeta(c),
+		})
+	}
+
+	body := fiber.Map{
+		"success":    false,
+		"error":      message,
+		"request_id": requestID(c),
+	}
+	if details != nil {
+		body["details"] = details
+	}
+	return c.Status(status).JSON(body)
+}
+
+func responseMeta(c *fiber.Ctx) Meta {
+	return Meta{
+		RequestID: requestID(c),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// statusCode turns an HTTP status into a stable snake_case error code, e.g.
+// 404 into "not_found"
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
+
+// apiPath builds a path to another resource under the same API prefix the
+// request came in on
+func apiPath(c *fiber.Ctx, path string) string {
+	if middleware.RequestAPIVersion(c) == apiV2 {
+		return "/api/v2" + path
+	}
+	if strings.HasPrefix(c.Path(), "/api/v1/") {
+		return "/api/v1" + path
+	}
+	return "/api" + path
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"strconv"
+	"yt-text/errors"
+	"yt-text/middleware"
+	"yt-text/services/video"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CorrectTranscript stores a corrected transcript as a new revision and
+// answers with it, without its text
+func (h *VideoHandler) CorrectTranscript(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	var req CorrectTranscriptRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	correction := video.Correction{
+		Text:         req.Text,
+		Note:         req.Note,
+		BaseRevision: req.BaseRevision,
+		UserID:       middleware.UserID(c),
+	}
+	for _, seg := range req.Segments {
+		correction.Segments = append(correction.Segments, video.SegmentCorrection{Index: seg.Index, Text: seg.Text})
+	}
+	revision, err := h.service.CorrectTranscript(requestContext(c), id, correction)
+	if err != nil {
+		return err
+	}
+
+	c.Location(apiPath(c, "/transcribe/"+id+"/revisions/"+strconv.Itoa(revision.Number)))
+	c.Status(fiber.StatusCreated)
+	return respond(c, revision)
+}
+
+func (h *VideoHandler) ListRevisions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	revisions, err := h.service.ListRevisions(requestContext(c), id, middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, revisions)
+}
+
+// GetRevision returns a revision of a transcript with the words changed
+// since an earlier one
+func (h *VideoHandler) GetRevision(c *fiber.Ctx) error {
+	const op = "VideoHandler.GetRevision"
+
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+	number, err := strconv.Atoi(c.Params("revision"))
+	if err != nil || number < 1 {
+		return errors.InvalidInput(op, err, "Revision must be a positive number")
+	}
+
+	var req RevisionRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	diff, err := h.service.CompareRevisions(requestContext(c), id, middleware.UserID(c), number, req.Against)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, diff)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"yt-text/services/search"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SearchHandler struct {
+	service search.Service
+}
+
+func NewSearchHandler(service search.Service) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// Semantic returns the transcript passages closest in meaning to a query,
+// across every completed transcription
+func (h *SearchHandler) Semantic(c *fiber.Ctx) error {
+	var req SemanticSearchRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSearchLimit
+	}
+
+	result, err := h.service.Search(requestContext(c), req.Q, req.Limit)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, result)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+	"yt-text/errors"
+	"yt-text/middleware"
+	"yt-text/models"
+	"yt-text/services/video"
+	"yt-text/signing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ShareHandler issues links that let anyone holding one read a transcript
+// until it expires. The links are read by the transcript handlers, mounted
+// behind middleware.SharedLink.
+type ShareHandler struct {
+	service    video.Service
+	links      *signing.Signer // nil when sharing is disabled
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+}
+
+func NewShareHandler(service video.Service, links *signing.Signer, defaultTTL, maxTTL time.Duration) *ShareHandler {
+	return &ShareHandler{service: service, links: links, defaultTTL: defaultTTL, maxTTL: maxTTL}
+}
+
+// Share returns a link to a transcript that is valid for the requested
+// number of seconds
+func (h *ShareHandler) Share(c *fiber.Ctx) error {
+	const op = "ShareHandler.Share"
+
+	if h.links == nil {
+		return errors.NotFound(op, nil, "Share links are not enabled")
+	}
+
+	var req ShareRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	ttl := h.defaultTTL
+	if req.TTL != nil {
+		ttl = time.Duration(*req.TTL) * time.Second
+		if *req.TTL <= 0 || ttl > h.maxTTL {
+			return errors.InvalidInput(op, nil, "TTL must be between 1 and "+strconv.FormatInt(int64(h.maxTTL/time.Second), 10)+" seconds")
+		}
+	}
+
+	// Only existing videos can be shared, by users who submitted them; the
+	// link keeps working while the transcript is being made
+	result, err := h.service.GetUserTranscription(requestContext(c), c.Params("id"), middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	expires := time.Now().Add(ttl)
+	token := h.links.Sign(result.ID, expires)
+
+	c.Status(fiber.StatusCreated)
+	return respond(c, &models.ShareResponse{
+		VideoID:   result.ID,
+		URL:       c.BaseURL() + apiPath(c, "/shared/"+token),
+		ExpiresAt: expires.UTC().Format(time.RFC3339),
+	})
+}
+
+// VerifyLink returns the video a share link was issued for. Without a
+// signer every link is rejected.
+func (h *ShareHandler) VerifyLink(token string) (string, bool) {
+	if h.links == nil {
+		return "", false
+	}
+	videoID, _, ok := h.links.Verify(token)
+	return videoID, ok
+}
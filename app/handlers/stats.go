@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"yt-text/services/video"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StatsHandler struct {
+	service video.Service
+}
+
+func NewStatsHandler(service video.Service) *StatsHandler {
+	return &StatsHandler{service: service}
+}
+
+func (h *StatsHandler) Stats(c *fiber.Ctx) error {
+	stats, err := h.service.Stats(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, stats)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/services/summary"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SummaryHandler struct {
+	service summary.Service
+}
+
+func NewSummaryHandler(service summary.Service) *SummaryHandler {
+	return &SummaryHandler{service: service}
+}
+
+// Summarize returns the summary of a completed transcription, producing it
+// on first request
+func (h *SummaryHandler) Summarize(c *fiber.Ctx) error {
+	var req SummarizeRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	result, err := h.service.Summarize(requestContext(c), req.ID, summary.Options{
+		Style: models.SummaryStyle(req.Style),
+	})
+	if err != nil {
+		return err
+	}
+
+	return respond(c, result)
+}
+
+func (h *SummaryHandler) GetSummary(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	var req GetSummaryRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	result, err := h.service.GetSummary(requestContext(c), id, summary.Options{
+		Style: models.SummaryStyle(req.Style),
+		Model: req.Model,
+	})
+	if err != nil {
+		return err
+	}
+
+	return respond(c, result)
+}
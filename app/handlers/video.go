@@ -1,43 +1,188 @@
 package handlers
 
 import (
+	"time"
 	"yt-text/errors"
+	"yt-text/events"
+	"yt-text/formats"
+	"yt-text/middleware"
 	"yt-text/models"
 	"yt-text/services/video"
+	"yt-text/youtube"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type VideoHandler struct {
 	service video.Service
+	hub     *events.Hub // Shares progress between streams of the same job
 }
 
-func NewVideoHandler(service video.Service) *VideoHandler {
-	return &VideoHandler{service: service}
+func NewVideoHandler(service video.Service, hub *events.Hub) *VideoHandler {
+	return &VideoHandler{service: service, hub: hub}
 }
 
 func (h *VideoHandler) Transcribe(c *fiber.Ctx) error {
-	url := c.FormValue("url")
-	if url == "" {
+	var req TranscribeRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	source, _ := video.ParseSourcePreference(req.Source)
+	opts := video.TranscribeOptions{
+		Source:   source,
+		Input:    video.InputType(req.Type),
+		Whisper:  req.options(),
+		Captions: req.captionOptions(),
+		UserID:   middleware.UserID(c),
+	}
+
+	// Playlists and channels become a batch of their videos
+	if opts.Input != video.InputAudioURL && youtube.IsPlaylistURL(req.URL) {
+		batch, err := h.service.TranscribePlaylist(requestContext(c), req.URL, opts)
+		if err != nil {
+			return err
+		}
+		c.Location(apiPath(c, "/transcribe/batch/"+batch.ID))
+		c.Status(fiber.StatusAccepted)
+		return respond(c, models.NewBatchResponse(batch))
+	}
+
+	result, err := h.service.Transcribe(requestContext(c), req.URL, opts)
+	if err != nil {
+		return err
+	}
+
+	// A finished transcript is returned as is; otherwise the job is still
+	// running and the client should poll the status resource
+	if !result.IsCompleted() {
+		c.Location(apiPath(c, "/transcribe/"+result.ID))
+		c.Status(fiber.StatusAccepted)
+	}
+
+	// Use NewVideoResponse for consistency
+	return respond(c, models.NewVideoResponse(result))
+}
+
+// Estimate validates a URL like Transcribe would, without queueing it, and
+// returns how long transcribing it is expected to take
+func (h *VideoHandler) Estimate(c *fiber.Ctx) error {
+	var req TranscribeRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	source, _ := video.ParseSourcePreference(req.Source)
+	estimate, err := h.service.Estimate(requestContext(c), req.URL, video.TranscribeOptions{
+		Source:   source,
+		Input:    video.InputType(req.Type),
+		Whisper:  req.options(),
+		Captions: req.captionOptions(),
+	})
+	if err != nil {
+		return err
+	}
+	return respond(c, estimate)
+}
+
+// TranscribeBatch accepts several URLs at once and returns a batch to poll
+func (h *VideoHandler) TranscribeBatch(c *fiber.Ctx) error {
+	var req BatchTranscribeRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	source, _ := video.ParseSourcePreference(req.Source)
+	batch, err := h.service.TranscribeBatch(requestContext(c), req.URLs, video.TranscribeOptions{
+		Source:   source,
+		Input:    video.InputType(req.Type),
+		Whisper:  req.options(),
+		Captions: req.captionOptions(),
+		UserID:   middleware.UserID(c),
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Location(apiPath(c, "/transcribe/batch/"+batch.ID))
+	c.Status(fiber.StatusAccepted)
+	return respond(c, models.NewBatchResponse(batch))
+}
+
+func (h *VideoHandler) GetBatch(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
 		return &errors.AppError{
 			Code:    fiber.StatusBadRequest,
-			Message: "URL is required",
+			Message: "ID is required",
 		}
 	}
 
-	video, err := h.service.Transcribe(c.Context(), url)
+	batch, err := h.service.GetBatch(requestContext(c), id)
 	if err != nil {
 		return err
 	}
 
-	// Use NewVideoResponse for consistency
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    models.NewVideoResponse(video),
+	return respond(c, models.NewBatchResponse(batch))
+}
+
+// ListTranscriptions returns a page of stored transcriptions, newest first.
+// With accounts, only the signed-in user's are listed.
+func (h *VideoHandler) ListTranscriptions(c *fiber.Ctx) error {
+	var req ListTranscriptionsRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = video.DefaultPageSize
+	}
+
+	videos, total, err := h.service.ListTranscriptions(requestContext(c), video.ListOptions{
+		Status:   models.Status(req.Status),
+		Language: req.Language,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		UserID:   middleware.UserID(c),
+		Keyword:  req.Keyword,
+		Entity:   req.Entity,
 	})
+	if err != nil {
+		return err
+	}
+
+	return respond(c, models.NewVideoListResponse(videos, req.Page, req.PageSize, total))
 }
 
-func (h *VideoHandler) GetTranscription(c *fiber.Ctx) error {
+// GetTranscriptionText streams the transcript as plain text using chunked
+// transfer encoding, which keeps large transcripts out of memory. Transcripts
+// stored compressed go out as they are to clients accepting the encoding.
+func (h *VideoHandler) GetTranscriptionText(c *fiber.Ctx) error {
+	id, userID := transcriptID(c)
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	text, encoding, err := h.service.EncodedTranscriptionText(requestContext(c), id, userID, models.Source(c.Query("source")), acceptedEncodings(c))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	if encoding != "" {
+		c.Set(fiber.HeaderContentEncoding, encoding)
+	}
+	return c.SendStream(text)
+}
+
+// GetJobHistory lists the state changes of a video's jobs with the time
+// elapsed since the first one
+func (h *VideoHandler) GetJobHistory(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
 		return &errors.AppError{
@@ -46,13 +191,162 @@ func (h *VideoHandler) GetTranscription(c *fiber.Ctx) error {
 		}
 	}
 
-	video, err := h.service.GetTranscription(c.Context(), id)
+	events, err := h.service.JobHistory(requestContext(c), id, middleware.UserID(c))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, models.NewJobHistoryResponse(id, events))
+}
+
+// transcriptID returns the video a read route is for and the user reading
+// it: the video a share link grants access to, readable by anyone, or the
+// :id parameter, readable by the signed-in user if they submitted it
+func transcriptID(c *fiber.Ctx) (id, userID string) {
+	if id := middleware.SharedVideoID(c); id != "" {
+		return id, ""
+	}
+	return c.Params("id"), middleware.UserID(c)
+}
+
+func (h *VideoHandler) GetTranscription(c *fiber.Ctx) error {
+	id, userID := transcriptID(c)
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	// ?format= or the Accept header picks text, Markdown or subtitles
+	// instead of JSON
+	format, err := transcriptFormat(c)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    models.NewVideoResponse(video),
+	result, err := h.service.GetUserTranscription(c.Context(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	// ?source= selects a stored transcript other than the primary one
+	source := models.Source(c.Query("source"))
+	if source == "" {
+		source = result.Source
+	}
+
+	if format != formats.JSON {
+		return sendTranscript(c, result, source, format)
+	}
+
+	resp := models.NewVideoResponse(result)
+	if source != result.Source {
+		var ok bool
+		if resp, ok = models.NewVideoResponseFrom(result, source); !ok {
+			return &errors.AppError{
+				Code:    fiber.StatusNotFound,
+				Message: "No transcript stored from source " + string(source),
+			}
+		}
+	}
+
+	// ?segments=true adds the transcript's timing for timestamped display,
+	// flagging the segments Whisper was unsure of
+	if c.QueryBool("segments") {
+		segments, _ := result.SegmentsFrom(source)
+		resp.Segments = models.FlagLowConfidence(segments)
+	}
+
+	return respond(c, resp)
+}
+
+// RetryTranscription resubmits a failed transcription, optionally with a
+// different source preference or Whisper model
+func (h *VideoHandler) RetryTranscription(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	var req RetryRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	source, _ := video.ParseSourcePreference(req.Source)
+	result, err := h.service.RetryTranscription(requestContext(c), id, video.TranscribeOptions{
+		Source:   source,
+		Model:    req.Model,
+		Whisper:  req.options(),
+		Captions: req.captionOptions(),
 	})
+	if err != nil {
+		return err
+	}
+
+	c.Location(apiPath(c, "/transcribe/"+result.ID))
+	c.Status(fiber.StatusAccepted)
+	return respond(c, models.NewVideoResponse(result))
+}
+
+// DeleteTranscription removes a transcription. Processing and pinned
+// transcriptions are refused with 409. A signed-in user only removes it
+// from their list while it is shared or can't be removed.
+func (h *VideoHandler) DeleteTranscription(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	if err := h.service.DeleteTranscription(requestContext(c), id, middleware.UserID(c)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// UpdateTranscription pins a transcription or sets how long it's kept.
+// Signed-in users can only change their own; the admin can change any.
+func (h *VideoHandler) UpdateTranscription(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return &errors.AppError{
+			Code:    fiber.StatusBadRequest,
+			Message: "ID is required",
+		}
+	}
+
+	var req UpdateTranscriptionRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	update := video.RetentionUpdate{Pinned: req.Pinned}
+	if req.TTL != nil {
+		if *req.TTL < 0 || *req.TTL > maxTTLSeconds {
+			return &errors.AppError{
+				Code:    fiber.StatusBadRequest,
+				Message: "TTL must be between 0 and 315360000 seconds",
+			}
+		}
+		ttl := time.Duration(*req.TTL) * time.Second
+		update.TTL = &ttl
+	}
+	userID := middleware.UserID(c)
+	if middleware.IsAdmin(c) {
+		userID = ""
+	}
+	result, err := h.service.UpdateRetention(requestContext(c), id, userID, update)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, models.NewVideoResponse(result))
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"yt-text/config"
+	"yt-text/handlers"
+)
+
+// readinessChecks lists the dependencies a server needs to take requests
+func readinessChecks(cfg *config.Config, a *application) map[string]handlers.ReadinessCheck {
+	return map[string]handlers.ReadinessCheck{
+		"database": a.db.PingContext,
+		"scripts":  a.scripts.Check,
+		"temp_dir": func(ctx context.Context) error {
+			return checkWritable(cfg.TempDir)
+		},
+		"queue": func(ctx context.Context) error {
+			status := a.videos.QueueStatus(ctx)
+			switch {
+			case status.IntakePaused:
+				return fmt.Errorf("job intake is paused")
+			case status.Waiting >= status.Capacity:
+				return fmt.Errorf("job queue is full (%d waiting)", status.Waiting)
+			}
+			return nil
+		},
+	}
+}
+
+// checkWritable creates and removes a file in dir
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".health-*")
+	if err != nil {
+		return fmt.Errorf("not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Embed returns a vector for each of inputs, in order, and the tokens they
+// took, from the endpoint's /embeddings
+func (c *Client) Embed(ctx context.Context, inputs []string) ([][]float32, int, error) {
+	body, err := json.Marshal(embeddingRequest{Model: c.config.Model, Input: inputs})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	endpoint := strings.TrimSuffix(c.config.BaseURL, "/") + "/embeddings"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var result embeddingResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != nil && result.Error.Message != "" {
+			return nil, 0, fmt.Errorf("embeddings api: %s: %s", resp.Status, result.Error.Message)
+		}
+		return nil, 0, fmt.Errorf("embeddings api: %s", resp.Status)
+	}
+	if decodeErr != nil {
+		return nil, 0, fmt.Errorf("failed to decode embeddings api response: %w", decodeErr)
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(inputs) {
+			return nil, 0, fmt.Errorf("embeddings api returned an embedding for input %d of %d", d.Index, len(inputs))
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, 0, fmt.Errorf("embeddings api returned no embedding for input %d", i)
+		}
+	}
+	return vectors, result.Usage.PromptTokens, nil
+}
@@ -0,0 +1,142 @@
+// Package llm talks to OpenAI-compatible chat completions endpoints, for
+// the features that can use a remote language model
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Config configures a chat completions endpoint
+type Config struct {
+	BaseURL string // e.g. https://api.openai.com/v1
+	APIKey  string
+	Model   string
+	Timeout time.Duration
+
+	// Prices in US dollars per million tokens, for cost logging
+	InputCost  float64
+	OutputCost float64
+}
+
+// Client sends chat requests to one model
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+func New(cfg Config) *Client {
+	return &Client{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Model names the model requests are sent to
+func (c *Client) Model() string { return c.config.Model }
+
+// Message is one turn of a chat
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Completion is the model's reply
+type Completion struct {
+	Text  string
+	Model string // As reported by the endpoint, which may name a version
+
+	// Token usage and its estimated cost in US dollars
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+type chatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+}
+
+type chatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Complete returns the model's reply to messages
+func (c *Client) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	body, err := json.Marshal(chatRequest{Model: c.config.Model, Messages: messages})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(c.config.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var chat chatResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&chat)
+	if resp.StatusCode != http.StatusOK {
+		if chat.Error != nil && chat.Error.Message != "" {
+			return nil, fmt.Errorf("chat api: %s: %s", resp.Status, chat.Error.Message)
+		}
+		return nil, fmt.Errorf("chat api: %s", resp.Status)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode chat api response: %w", decodeErr)
+	}
+	if len(chat.Choices) == 0 || strings.TrimSpace(chat.Choices[0].Message.Content) == "" {
+		return nil, fmt.Errorf("chat api returned no reply")
+	}
+
+	model := chat.Model
+	if model == "" {
+		model = c.config.Model
+	}
+	return &Completion{
+		Text:             strings.TrimSpace(chat.Choices[0].Message.Content),
+		Model:            model,
+		PromptTokens:     chat.Usage.PromptTokens,
+		CompletionTokens: chat.Usage.CompletionTokens,
+		Cost: (float64(chat.Usage.PromptTokens)*c.config.InputCost +
+			float64(chat.Usage.CompletionTokens)*c.config.OutputCost) / 1e6,
+	}, nil
+}
+
+// Truncate cuts text to at most max bytes on a rune boundary, to fit a
+// model's context. A non-positive max keeps the whole text.
+func Truncate(text string, max int) string {
+	if max <= 0 || len(text) <= max {
+		return text
+	}
+	for max > 0 && !utf8.RuneStart(text[max]) {
+		max--
+	}
+	return text[:max]
+}
@@ -2,57 +2,141 @@ package main
 
 import (
 	"context"
-	"net/http"
+	"fmt"
+	"io"
 	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 	"yt-text/config"
-	"yt-text/handlers"
-	"yt-text/logger"
+	"yt-text/events"
+	"yt-text/llm"
+	"yt-text/repository"
+	"yt-text/repository/memory"
+	"yt-text/repository/postgres"
 	"yt-text/repository/sqlite"
 	"yt-text/scripts"
+	"yt-text/services/account"
+	"yt-text/services/ask"
+	"yt-text/services/search"
+	"yt-text/services/summary"
 	"yt-text/services/video"
+	"yt-text/storage"
 	"yt-text/validation"
+	"yt-text/youtube"
 
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/compress"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/etag"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
-	"github.com/gofiber/fiber/v2/middleware/recover"
-	"github.com/gofiber/fiber/v2/middleware/requestid"
-	"github.com/gofiber/fiber/v2/middleware/timeout"
-	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
+// command is a subcommand of the binary. args are the arguments after the
+// command's name.
+type command struct {
+	run     func(cfg *config.Config, args []string) error
+	summary string
+}
+
+var commands = map[string]command{
+	"serve":      {runServe, "Run the HTTP server and transcription workers (default)"},
+	"transcribe": {runTranscribe, "Transcribe a URL and print the transcript"},
+	"cleanup":    {runCleanup, "Delete expired transcripts and move cold ones to storage"},
+	"migrate":    {runMigrate, "Apply database migrations"},
+}
+
 func main() {
+	// Without a command the server starts, as it did before subcommands
+	configFile, args := configFlag(os.Args[1:])
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		usage()
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(configFile)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to load configuration")
 	}
 
-	// Initialize logger
-	appLogger, err := logger.NewLogger(cfg.LogDir)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize logger")
+	if err := cmd.run(cfg, args); err != nil {
+		log.Fatal().Err(err).Str("command", name).Msg("Command failed")
 	}
-	log.Logger = appLogger.Logger // Set global logger
+}
 
-	// Initialize database
-	db, err := sqlite.NewDB(cfg.Database.Path)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize database")
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: yt-text <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range []string{"serve", "transcribe", "cleanup", "migrate"} {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run yt-text <command> -h for a command's flags.")
+	fmt.Fprintln(os.Stderr, "Every command accepts --config=<file> to read settings from a YAML file.")
+}
+
+// configFlag removes --config from args, wherever it appears, and returns
+// its value. CONFIG_FILE is used when the flag is absent.
+func configFlag(args []string) (string, []string) {
+	path := os.Getenv("CONFIG_FILE")
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		path = value
 	}
-	defer db.Close()
+	return path, rest
+}
+
+// application holds the services shared by the server and the one-off
+// commands
+type application struct {
+	repo      repository.VideoRepository // Uncached, for background maintenance
+	db        database
+	scripts   *scripts.ScriptRunner
+	validator *validation.Validator
+	videos    video.Service
+	summaries summary.Service
+	questions ask.Service
+	search    search.Service
+	accounts  account.Service
+	events    *events.Bus // Job notifications published by videos
+}
+
+// database is the connection behind a repository
+type database interface {
+	io.Closer
+	PingContext(ctx context.Context) error
+}
 
-	// Initialize repository
-	repo, err := sqlite.NewRepository(db)
+// newApplication opens the repository and builds the services on top of it.
+// mode decides whether the video service runs the jobs it accepts.
+func newApplication(cfg *config.Config, mode video.Mode) (*application, error) {
+	// Initialize database and repository
+	repo, db, err := openRepository(cfg)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize repository")
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
 	// Initialize script runner
@@ -61,219 +145,243 @@ func main() {
 		ScriptsPath: cfg.Video.ScriptsPath,
 		Timeout:     cfg.Video.ProcessTimeout,
 		TempDir:     cfg.TempDir,
+
+		ValidateTimeout: cfg.Video.ValidateTimeout,
 	})
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize script runner")
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize script runner: %w", err)
 	}
 
 	// Initialize validator
 	validator := validation.NewValidator(cfg)
 
-	// Initialize video service
+	// Initialize YouTube client
+	youtubeClient := youtube.NewClient(youtube.Config{
+		Timeout:           cfg.YouTube.RequestTimeout,
+		APIKey:            cfg.YouTube.APIKey,
+		DailyQuota:        cfg.YouTube.DailyQuota,
+		MetadataCacheTTL:  cfg.YouTube.MetadataCacheTTL,
+		CaptionsCacheTTL:  cfg.YouTube.CaptionsCacheTTL,
+		RequestsPerMinute: cfg.YouTube.RequestsPerMinute,
+		Burst:             cfg.YouTube.Burst,
+		MaxBackoff:        cfg.YouTube.MaxBackoff,
+	})
+
+	// Initialize video service. Only this process's saves drop cache
+	// entries, so they expire when other processes share the database
+	var cacheTTL time.Duration
+	if mode != video.ModeAll || cfg.Database.Driver == config.DriverPostgres {
+		cacheTTL = repository.SharedCacheTTL
+	}
+	cachedRepo := repository.NewCachedRepository(repo, cfg.Database.CacheSize, cfg.Database.CacheMaxBytes, cfg.Database.TranscriptCacheMaxBytes, cacheTTL)
+	bus := events.NewBus()
 	videoService := video.NewService(
-		repo,
+		cachedRepo,
 		scriptRunner,
 		validator,
+		youtubeClient,
+		bus,
 		video.Config{
-			ProcessTimeout: cfg.Video.ProcessTimeout,
-			MaxDuration:    cfg.Video.MaxDuration,
-			DefaultModel:   cfg.Video.DefaultModel,
+			ProcessTimeout:      cfg.Video.ProcessTimeout,
+			MaxDuration:         cfg.Video.MaxDuration,
+			MaxFileSize:         cfg.Video.MaxFileSize,
+			TempDir:             cfg.TempDir,
+			DefaultModel:        cfg.Video.DefaultModel,
+			ModelRoutes:         cfg.Video.ModelRoutes,
+			Workers:             cfg.Video.Workers,
+			QueueSize:           cfg.Video.QueueSize,
+			MemoryLimitMB:       cfg.Video.MemoryLimitMB,
+			ModelMemoryMB:       cfg.Video.ModelMemoryMB,
+			MaxBatchSize:        cfg.Video.MaxBatchSize,
+			OEmbedPrecheck:      cfg.YouTube.OEmbedPrecheck,
+			CaptionsEnabled:     cfg.YouTube.CaptionsEnabled,
+			CompareCaptions:     cfg.YouTube.CompareCaptions,
+			CaptionWERThreshold: cfg.YouTube.CaptionWERThreshold,
+			ComparePick:         video.ComparePick(cfg.YouTube.ComparePick),
+			Retention:           cfg.Video.Retention,
+			CleanupInterval:     cfg.Video.CleanupInterval,
+			CleanupJitter:       cfg.Video.CleanupJitter,
+			CleanupBatchSize:    cfg.Video.CleanupBatchSize,
+			CleanupMaxPerRun:    cfg.Video.CleanupMaxPerRun,
+			CleanupDryRun:       cfg.Video.CleanupDryRun,
+			LiveRetryInterval:   cfg.Video.LiveRetryInterval,
+			LiveRetryLimit:      cfg.Video.LiveRetryLimit,
+			SourcePolicy:        video.SourcePreference(cfg.Video.SourcePolicy),
+			LookupCacheTTL:      cfg.Video.LookupCacheTTL,
+			Enrich:              cfg.Video.Enrich,
+			Mode:                mode,
 		},
 	)
 
-	// Initialize Fiber app
-	app := fiber.New(fiber.Config{
-		ReadTimeout:  cfg.ReadTimeout,
-		WriteTimeout: cfg.WriteTimeout,
-		IdleTimeout:  cfg.IdleTimeout,
-		ErrorHandler: handlers.ErrorHandler,
-		// Optional additional configurations
-		DisableStartupMessage: !cfg.Debug,
-		StrictRouting:         true,
-		CaseSensitive:         true,
-		AppName:               "yt-text " + cfg.Version,
+	// Initialize summary service
+	summaryService := summary.NewService(cachedRepo, summaryProvider(cfg, scriptRunner), summary.Config{
+		MaxSentences: cfg.Summary.MaxSentences,
 	})
 
-	// Setup middleware
-	setupMiddleware(app, cfg, appLogger)
-
-	// Setup routes
-	videoHandler := handlers.NewVideoHandler(videoService)
-
-	// API routes
-	app.Post("/api/transcribe", videoHandler.Transcribe)
-	app.Get("/api/transcribe/:id", videoHandler.GetTranscription)
-
-	// Health check
-	app.Get("/health", handlers.HealthCheck)
-
-	// Static files
-	app.Static("/static", "/app/static")
-	app.Static("/", "/app/static")
-
-	// Graceful shutdown setup
-	shutdownChan := make(chan os.Signal, 1)
-	signal.Notify(shutdownChan, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		<-shutdownChan
-		log.Info().Msg("Shutting down server...")
-
-		// Create shutdown context with timeout
-		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
-		defer cancel()
-
-		if err := app.ShutdownWithContext(ctx); err != nil {
-			log.Error().Err(err).Msg("Server shutdown error")
-		}
+	// Initialize question answering
+	askService := ask.NewService(cachedRepo, askProvider(cfg), ask.Config{
+		Passages:        cfg.Ask.Passages,
+		PassageDuration: cfg.Ask.PassageDuration,
+	})
 
-		// Close any other resources
-		if err := db.Close(); err != nil {
-			log.Error().Err(err).Msg("Database shutdown error")
-		}
-	}()
+	// Initialize semantic search
+	searchService := search.NewService(cachedRepo, embedder(cfg), bus, search.Config{
+		PassageDuration: cfg.Embeddings.PassageDuration,
+		SweepInterval:   cfg.Embeddings.SweepInterval,
+	})
 
-	// Start server
-	serverAddr := ":" + cfg.ServerPort
-	if cfg.Debug {
-		log.Info().Str("addr", "http://localhost"+serverAddr).Msg("Server starting")
-	}
+	// Initialize account service
+	accountService := account.NewService(cachedRepo, account.Config{
+		BaseURL:       cfg.Accounts.BaseURL,
+		SessionSecret: cfg.Accounts.SessionSecret,
+		SessionTTL:    cfg.Accounts.SessionTTL,
+		Providers:     oauthProviders(cfg),
+	})
 
-	if err := app.Listen(serverAddr); err != nil && err != http.ErrServerClosed {
-		log.Fatal().Err(err).Msg("Server error")
-	}
+	return &application{
+		repo:      repo,
+		db:        db,
+		scripts:   scriptRunner,
+		validator: validator,
+		videos:    videoService,
+		summaries: summaryService,
+		questions: askService,
+		search:    searchService,
+		accounts:  accountService,
+		events:    bus,
+	}, nil
 }
 
-func setupMiddleware(app *fiber.App, cfg *config.Config, logger *logger.Logger) {
-	if cfg.Middleware.EnableRecover {
-		app.Use(recover.New(recover.Config{
-			EnableStackTrace: cfg.Debug,
-		}))
-	}
-
-	if cfg.Middleware.EnableRequestID {
-		app.Use(requestid.New(requestid.Config{
-			Header: "X-Request-ID",
-			Generator: func() string {
-				return uuid.New().String()
-			},
-		}))
+// oauthProviders returns the login providers with a client ID configured
+func oauthProviders(cfg *config.Config) []*account.Provider {
+	var providers []*account.Provider
+	if cfg.Accounts.GoogleClientID != "" {
+		providers = append(providers, account.Google(cfg.Accounts.GoogleClientID, cfg.Accounts.GoogleClientSecret))
 	}
-
-	if cfg.Middleware.EnableLogger {
-		app.Use(logger.Middleware())
-	}
-
-	if cfg.Middleware.EnableTimeout {
-		app.Use(timeout.New(func(c *fiber.Ctx) error {
-			return c.Next()
-		}, cfg.RequestTimeout))
+	if cfg.Accounts.GitHubClientID != "" {
+		providers = append(providers, account.GitHub(cfg.Accounts.GitHubClientID, cfg.Accounts.GitHubClientSecret))
 	}
+	return providers
+}
 
-	if cfg.Middleware.EnableCORS {
-		app.Use(cors.New(cors.Config{
-			AllowOrigins:     strings.Join(cfg.CORS.AllowedOrigins, ","),
-			AllowMethods:     strings.Join(cfg.CORS.AllowedMethods, ","),
-			AllowHeaders:     strings.Join(cfg.CORS.AllowedHeaders, ","),
-			ExposeHeaders:    strings.Join(cfg.CORS.ExposedHeaders, ","),
-			AllowCredentials: cfg.CORS.AllowCredentials,
-			MaxAge:           cfg.CORS.MaxAge,
-		}))
+// summaryProvider returns the summarizer selected by SUMMARY_PROVIDER
+func summaryProvider(cfg *config.Config, scriptRunner *scripts.ScriptRunner) summary.Provider {
+	if cfg.Summary.Provider == config.SummaryProviderAPI {
+		return summary.NewAPIProvider(summary.APIConfig{
+			BaseURL:       cfg.Summary.APIBaseURL,
+			APIKey:        cfg.Summary.APIKey,
+			Model:         cfg.Summary.APIModel,
+			Timeout:       cfg.Summary.APITimeout,
+			MaxInputChars: cfg.Summary.APIMaxInputChars,
+			InputCost:     cfg.Summary.APIInputCost,
+			OutputCost:    cfg.Summary.APIOutputCost,
+		})
 	}
+	return summary.NewScriptProvider(scriptRunner, cfg.Summary.Timeout)
+}
 
-	if cfg.Middleware.EnableRateLimit && cfg.RateLimit.Enabled {
-		app.Use(limiter.New(limiter.Config{
-			Max:        cfg.RateLimit.RequestsPerMinute,
-			Expiration: time.Minute,
-			KeyGenerator: func(c *fiber.Ctx) string {
-				return c.IP()
-			},
-			LimitReached: func(c *fiber.Ctx) error {
-				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-					"error": "Rate limit exceeded",
-				})
-			},
-		}))
+// askProvider returns the question answerer selected by ASK_PROVIDER
+func askProvider(cfg *config.Config) ask.Provider {
+	if cfg.Ask.Provider == config.AskProviderAPI {
+		return ask.NewAPIProvider(llm.Config{
+			BaseURL:    cfg.Ask.APIBaseURL,
+			APIKey:     cfg.Ask.APIKey,
+			Model:      cfg.Ask.APIModel,
+			Timeout:    cfg.Ask.APITimeout,
+			InputCost:  cfg.Ask.APIInputCost,
+			OutputCost: cfg.Ask.APIOutputCost,
+		})
 	}
+	return ask.NewLocalProvider()
+}
 
-	if cfg.Middleware.EnableCompress {
-		app.Use(compress.New(compress.Config{
-			Level: compress.LevelDefault,
-		}))
+// embedder returns the embeddings client for semantic search, or nil when
+// EMBEDDINGS_ENABLED is off
+func embedder(cfg *config.Config) search.Embedder {
+	if !cfg.Embeddings.Enabled {
+		return nil
 	}
+	return llm.New(llm.Config{
+		BaseURL: cfg.Embeddings.APIBaseURL,
+		APIKey:  cfg.Embeddings.APIKey,
+		Model:   cfg.Embeddings.APIModel,
+		Timeout: cfg.Embeddings.APITimeout,
+	})
+}
 
-	if cfg.Middleware.EnableETag {
-		app.Use(etag.New())
+// openRepository connects to the database selected by DATABASE_DRIVER and
+// keeps large transcripts in the backend selected by STORAGE_BACKEND. The
+// returned database holds the connection.
+func openRepository(cfg *config.Config) (repository.VideoRepository, database, error) {
+	backend, err := storage.New(storage.Config{
+		Backend:  cfg.Storage.Backend,
+		Path:     cfg.Storage.Path,
+		MaxBytes: cfg.Storage.MaxBytes,
+		Spaces: storage.SpacesConfig{
+			Endpoint:  cfg.Storage.SpacesEndpoint,
+			Region:    cfg.Storage.SpacesRegion,
+			Bucket:    cfg.Storage.SpacesBucket,
+			AccessKey: cfg.Storage.SpacesAccessKey,
+			SecretKey: cfg.Storage.SpacesSecretKey,
+		},
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if cfg.Middleware.EnableDebugMode && cfg.Debug {
-		app.Use(func(c *fiber.Ctx) error {
-			c.Set("X-Debug-Mode", "true")
-			return c.Next()
-		})
+	repo, db, err := openDatabase(cfg)
+	if err != nil {
+		return nil, nil, err
 	}
-}
-
-func setupRoutes(app *fiber.App, videoService video.Service) {
-	// Static files
-	app.Static("/", "./static")
-
-	// Create handlers
-	videoHandler := handlers.NewVideoHandler(videoService)
+	return repository.NewOffloadRepository(repo, backend, repository.OffloadConfig{
+		MinSize:   cfg.Storage.MinSize,
+		TierAfter: cfg.Storage.TierAfter,
+		Evict:     cfg.Storage.QuotaPolicy == config.QuotaEvict,
 
-	// API routes
-	app.Post("/api/transcribe", videoHandler.Transcribe)
-	app.Get("/api/transcribe/:id", videoHandler.GetTranscription)
-
-	// Health check
-	app.Get("/health", handlers.HealthCheck)
+		Compression: cfg.Storage.Compression,
+	}), db, nil
 }
 
-func startServer(app *fiber.App, cfg *config.Config) {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		<-c
-		log.Info().Msg("Gracefully shutting down...")
-		_ = app.Shutdown()
-	}()
-
-	if err := app.Listen(":" + cfg.ServerPort); err != nil {
-		log.Fatal().Err(err).Msg("Server error")
+// openDatabase connects to the database selected by DATABASE_DRIVER
+func openDatabase(cfg *config.Config) (repository.VideoRepository, database, error) {
+	if cfg.Database.Driver == config.DriverMemory {
+		repo := memory.NewRepository()
+		return repo, repo, nil
 	}
-}
-
-func initializeVideoService(cfg *config.Config) (video.Service, error) {
-	// Initialize repository
-	db, err := sqlite.NewDB(cfg.Database.Path)
-	if err != nil {
-		return nil, err
+	if cfg.Database.Driver == config.DriverPostgres {
+		db, err := postgres.NewDB(cfg.Database.URL, postgres.PoolConfig{
+			MaxOpenConns:    cfg.Database.MaxConnections,
+			MaxIdleConns:    cfg.Database.MaxIdleConnections,
+			ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		repo, err := postgres.NewRepository(db)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return repo, db, nil
 	}
 
-	repo, err := sqlite.NewRepository(db)
+	db, err := sqlite.NewDB(cfg.Database.Path, sqlite.PoolConfig{
+		MaxOpenConns:    cfg.Database.MaxConnections,
+		MaxIdleConns:    cfg.Database.MaxIdleConnections,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		BusyTimeout:     cfg.Database.BusyTimeout,
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	// Initialize script runner
-	scriptRunner, err := scripts.NewScriptRunner(scripts.Config{
-		PythonPath:  cfg.Video.PythonPath,
-		ScriptsPath: cfg.Video.ScriptsPath,
-		Timeout:     cfg.Video.ProcessTimeout,
-		TempDir:     cfg.TempDir,
+	repo, err := sqlite.NewRepository(db, sqlite.Config{
+		CompressTranscripts: cfg.Database.CompressTranscripts,
+		CompressMinSize:     cfg.Database.CompressMinSize,
 	})
 	if err != nil {
-		return nil, err
+		db.Close()
+		return nil, nil, err
 	}
-
-	// Initialize validator
-	validator := validation.NewValidator(cfg)
-
-	// Create and return video service
-	return video.NewService(repo, scriptRunner, validator, video.Config{
-		ProcessTimeout: cfg.Video.ProcessTimeout,
-		MaxDuration:    cfg.Video.MaxDuration,
-		DefaultModel:   cfg.Video.DefaultModel,
-	}), nil
+	return repo, db, nil
 }
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+	"yt-text/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireAdmin guards operator endpoints with a static bearer token. Browsers
+// can supply the token as the password of HTTP Basic auth. When no token is
+// configured the endpoints are disabled entirely.
+func RequireAdmin(token string) fiber.Handler {
+	const op = "Middleware.RequireAdmin"
+
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return errors.Forbidden(op, nil, "Admin API is disabled")
+		}
+
+		if !validAdminToken(c, token) {
+			c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="yt-text admin"`)
+			return errors.Unauthorized(op, nil, "Invalid or missing admin token")
+		}
+
+		return c.Next()
+	}
+}
+
+// adminKey is the c.Locals key marking requests made with the admin token
+const adminKey = "admin"
+
+// AdminToken marks requests carrying the admin token, so routes open to
+// clients can give the admin more rights. Other requests continue as they
+// are.
+func AdminToken(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token != "" && validAdminToken(c, token) {
+			c.Locals(adminKey, true)
+		}
+		return c.Next()
+	}
+}
+
+// IsAdmin reports whether AdminToken marked the request as the admin's
+func IsAdmin(c *fiber.Ctx) bool {
+	admin, _ := c.Locals(adminKey).(bool)
+	return admin
+}
+
+func validAdminToken(c *fiber.Ctx, token string) bool {
+	provided := adminToken(c)
+	return provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// adminToken extracts the token from a Bearer or Basic Authorization header,
+// or from X-Admin-Token
+func adminToken(c *fiber.Ctx) string {
+	auth := c.Get(fiber.HeaderAuthorization)
+	switch {
+	case strings.HasPrefix(auth, "Bearer "):
+		return strings.TrimPrefix(auth, "Bearer ")
+	case strings.HasPrefix(auth, "Basic "):
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic "))
+		if err != nil {
+			return ""
+		}
+		_, password, _ := strings.Cut(string(decoded), ":")
+		return password
+	}
+	return c.Get("X-Admin-Token")
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/url"
+	"strings"
+	"yt-text/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyQuery carries a caller's API key where headers can't be set, such
+// as a browser EventSource
+const APIKeyQuery = "api_key"
+
+// ClientConfig restricts who may start jobs and follow them
+type ClientConfig struct {
+	// APIKeys are accepted in X-API-Key or the api_key query parameter.
+	// Empty leaves the routes open.
+	APIKeys []string
+	// AllowedOrigins lists browser origins, e.g. https://example.com, that
+	// may call the routes besides the server's own. Empty or "*" allows any.
+	AllowedOrigins []string
+}
+
+// RequireClient guards routes that start GPU-heavy work or hold connections
+// open. CORS only stops browsers from reading responses, so requests from
+// other origins are rejected here before any work starts. Requests without
+// an Origin header come from non-browser clients and are left to the API
+// key check. Requests AdminToken marked as the admin's pass too.
+func RequireClient(cfg ClientConfig) fiber.Handler {
+	const op = "Middleware.RequireClient"
+
+	keys := make([][]byte, 0, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, []byte(key))
+		}
+	}
+
+	anyOrigin := len(cfg.AllowedOrigins) == 0
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		anyOrigin = anyOrigin || origin == "*"
+		origins[strings.ToLower(origin)] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		if IsAdmin(c) {
+			return c.Next()
+		}
+		if origin := c.Get(fiber.HeaderOrigin); origin != "" && !anyOrigin &&
+			!origins[strings.ToLower(origin)] && !sameOrigin(c, origin) {
+			return errors.Forbidden(op, nil, "Origin not allowed")
+		}
+
+		if len(keys) > 0 && !validKey(clientKey(c), keys) {
+			return errors.Unauthorized(op, nil, "Invalid or missing API key")
+		}
+
+		return c.Next()
+	}
+}
+
+// sameOrigin reports whether origin is the server itself, as for the web UI
+// it serves
+func sameOrigin(c *fiber.Ctx, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, c.Hostname())
+}
+
+// clientKey extracts the API key from X-API-Key or the api_key query
+// parameter
+func clientKey(c *fiber.Ctx) string {
+	if key := c.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	return c.Query(APIKeyQuery)
+}
+
+func validKey(provided string, keys [][]byte) bool {
+	for _, key := range keys {
+		if secretEqual(provided, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHeader carries a caller's API key
+const APIKeyHeader = "X-API-Key"
+
+// ExemptConfig lists callers that bypass rate limiting
+type ExemptConfig struct {
+	APIKeys        []string // Values of X-API-Key
+	CIDRs          []string // Client networks, e.g. 10.0.0.0/8
+	InternalHeader string   // Header name used by internal callers
+	InternalSecret string   // Value InternalHeader must carry
+	AdminToken     string   // Requests authenticated as admin are exempt too
+}
+
+// RateLimitExempt returns a predicate for limiter.Config.Next that reports
+// whether a request comes from a trusted caller: health checkers, internal
+// batch jobs or the admin UI.
+func RateLimitExempt(cfg ExemptConfig) (func(*fiber.Ctx) bool, error) {
+	networks := make([]*net.IPNet, 0, len(cfg.CIDRs))
+	for _, cidr := range cfg.CIDRs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exempt CIDR %q: %w", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+
+	keys := make([][]byte, 0, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, []byte(key))
+		}
+	}
+
+	return func(c *fiber.Ctx) bool {
+		if cfg.InternalHeader != "" && cfg.InternalSecret != "" &&
+			secretEqual(c.Get(cfg.InternalHeader), []byte(cfg.InternalSecret)) {
+			return true
+		}
+
+		if key := c.Get(APIKeyHeader); key != "" {
+			for _, k := range keys {
+				if secretEqual(key, k) {
+					return true
+				}
+			}
+		}
+
+		if cfg.AdminToken != "" && secretEqual(adminToken(c), []byte(cfg.AdminToken)) {
+			return true
+		}
+
+		if ip := net.ParseIP(c.IP()); ip != nil {
+			for _, network := range networks {
+				if network.Contains(ip) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}, nil
+}
+
+func secretEqual(provided string, secret []byte) bool {
+	return provided != "" && subtle.ConstantTimeCompare([]byte(provided), secret) == 1
+}
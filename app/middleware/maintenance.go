@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"yt-text/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Maintenance rejects write requests with 503 and a Retry-After header while
+// letting reads through, so completed transcripts stay available. Paths with
+// one of the exempt prefixes (e.g. the admin API) are always let through.
+func Maintenance(retryAfter time.Duration, exemptPrefixes ...string) fiber.Handler {
+	const op = "Middleware.Maintenance"
+	retryAfterSeconds := strconv.Itoa(int(retryAfter.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		if isReadMethod(c.Method()) {
+			return c.Next()
+		}
+
+		for _, prefix := range exemptPrefixes {
+			if strings.HasPrefix(c.Path(), prefix) {
+				return c.Next()
+			}
+		}
+
+		c.Set(fiber.HeaderRetryAfter, retryAfterSeconds)
+		return errors.Unavailable(op, nil, "Service is under maintenance, please try again later")
+	}
+}
+
+func isReadMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
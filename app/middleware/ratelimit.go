@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"yt-text/errors"
+	"yt-text/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// Headers describing a caller's rate limit budget
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset" // Seconds until the budget is renewed
+)
+
+// RateLimitHeaders lists the headers clients need to back off, for CORS to
+// expose to browsers
+var RateLimitHeaders = []string{
+	HeaderRateLimitLimit, HeaderRateLimitRemaining, HeaderRateLimitReset, fiber.HeaderRetryAfter,
+}
+
+// RateLimitConfig allows each caller Max requests per Window
+type RateLimitConfig struct {
+	Store  ratelimit.Store
+	Max    int
+	Window time.Duration
+
+	// APIKeys identify callers by key rather than by address. Keys that
+	// aren't listed are ignored, so made-up keys can't dodge the limit.
+	APIKeys []string
+
+	// Next skips the limit when it returns true, as for exempt callers
+	Next func(*fiber.Ctx) bool
+}
+
+// RateLimit counts requests per caller: by API key when the request carries
+// a known one, otherwise by client IP. Responses carry X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset, the seconds until the window
+// ends; requests over the limit are rejected with 429 and Retry-After. When
+// the store can't be reached requests are let through, so an outage of a
+// shared store doesn't take the API down with it.
+func RateLimit(cfg RateLimitConfig) fiber.Handler {
+	const op = "Middleware.RateLimit"
+
+	keys := make([][]byte, 0, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, []byte(key))
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		count, ends, err := cfg.Store.Hit(c.Context(), callerKey(c, keys), cfg.Window)
+		if err != nil {
+			log.Warn().Err(err).Str("path", c.Path()).Msg("Rate limit store unavailable, letting request through")
+			return c.Next()
+		}
+
+		resetIn := max(time.Until(ends), 0)
+		SetRateLimit(c, cfg.Max, cfg.Max-count, resetIn)
+
+		if count > cfg.Max {
+			err := errors.TooManyRequests(op, nil, "Rate limit exceeded")
+			err.RetryAfter = resetIn
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// SetRateLimit writes the X-RateLimit headers. Reset is rounded up to whole
+// seconds, so clients waiting it out don't come back early.
+func SetRateLimit(c *fiber.Ctx, limit, remaining int, reset time.Duration) {
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(limit))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(max(remaining, 0)))
+	c.Set(HeaderRateLimitReset, strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+}
+
+// callerKey names the caller a request is counted against. API keys are
+// hashed so they aren't written to a shared store.
+func callerKey(c *fiber.Ctx, keys [][]byte) string {
+	if key := clientKey(c); key != "" && validKey(key, keys) {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:])
+	}
+	return "ip:" + c.IP()
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"yt-text/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionCookie holds the session token of a signed-in user
+const SessionCookie = "session"
+
+// userIDKey is the c.Locals key holding the signed-in user's ID
+const userIDKey = "user_id"
+
+// Session signs a request in as the user of its session cookie. verify
+// returns the user a token was issued to; requests without a valid cookie
+// continue anonymously.
+func Session(verify func(token string) (string, bool)) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token := c.Cookies(SessionCookie); token != "" {
+			if userID, ok := verify(token); ok {
+				c.Locals(userIDKey, userID)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// UserID returns the signed-in user set by Session, "" for anonymous
+// requests
+func UserID(c *fiber.Ctx) string {
+	userID, _ := c.Locals(userIDKey).(string)
+	return userID
+}
+
+// RequireUser rejects anonymous requests when accounts are enabled. Without
+// accounts every request passes and is anonymous. Requests AdminToken marked
+// as the admin's pass too.
+func RequireUser(enabled bool) fiber.Handler {
+	const op = "Middleware.RequireUser"
+
+	return func(c *fiber.Ctx) error {
+		if enabled && UserID(c) == "" && !IsAdmin(c) {
+			return errors.Unauthorized(op, nil, "Sign in required")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"yt-text/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sharedVideoKey is the c.Locals key holding the video a share link grants
+// access to
+const sharedVideoKey = "shared_video_id"
+
+// SharedLink admits requests whose :token parameter is a valid share link.
+// verify returns the video a token was issued for; the handlers read it
+// with SharedVideoID in place of an :id parameter.
+func SharedLink(verify func(token string) (string, bool)) fiber.Handler {
+	const op = "Middleware.SharedLink"
+
+	return func(c *fiber.Ctx) error {
+		videoID, ok := verify(c.Params("token"))
+		if !ok {
+			return errors.Forbidden(op, nil, "Share link is invalid or has expired")
+		}
+		c.Locals(sharedVideoKey, videoID)
+		return c.Next()
+	}
+}
+
+// SharedVideoID returns the video set by SharedLink, "" outside shared
+// routes
+func SharedVideoID(c *fiber.Ctx) string {
+	videoID, _ := c.Locals(sharedVideoKey).(string)
+	return videoID
+}
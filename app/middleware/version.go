@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiVersionKey is the c.Locals key holding the API version of a route
+const apiVersionKey = "api_version"
+
+// APIVersion tags a request with the API version of the route it matched
+func APIVersion(version string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(apiVersionKey, version)
+		return c.Next()
+	}
+}
+
+// RequestAPIVersion returns the version set by APIVersion. Before a route
+// has matched, as when the rate limiter rejects a request, the version is
+// taken from an "/api/vN/" path prefix instead. It is "" for anything else.
+func RequestAPIVersion(c *fiber.Ctx) string {
+	if version, ok := c.Locals(apiVersionKey).(string); ok {
+		return version
+	}
+	rest, ok := strings.CutPrefix(c.Path(), "/api/")
+	if !ok {
+		return ""
+	}
+	version, _, _ := strings.Cut(rest, "/")
+	if len(version) < 2 || version[0] != 'v' || strings.Trim(version[1:], "0123456789") != "" {
+		return ""
+	}
+	return version
+}
+
+// Deprecated marks a legacy route with Deprecation and Sunset headers and a
+// Link to the same path under the successor prefix, so clients can migrate
+// before the route is removed. A zero sunset omits the Sunset header.
+func Deprecated(sunset time.Time, legacyPrefix, successorPrefix string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "true")
+		if !sunset.IsZero() {
+			c.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if path := c.Path(); strings.HasPrefix(path, legacyPrefix) {
+			successor := successorPrefix + strings.TrimPrefix(path, legacyPrefix)
+			c.Set(fiber.HeaderLink, "<"+successor+`>; rel="successor-version"`)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+// Answer is a reply to a question about a transcript, citing the passages
+// it was drawn from
+type Answer struct {
+	VideoID   string     `json:"video_id"`
+	Question  string     `json:"question"`
+	Answer    string     `json:"answer"`
+	Citations []Citation `json:"citations"`
+	Source    Source     `json:"source"` // Transcript the passages were taken from
+	Model     string     `json:"model"`
+}
+
+// Citation is a passage of the transcript an answer refers to as [Ref]
+type Citation struct {
+	Ref int `json:"ref"`
+	Segment
+}
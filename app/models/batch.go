@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+)
+
+// Statuses of batch items without a stored video
+const (
+	StatusPending  Status = "pending"  // Waiting to be submitted
+	StatusRejected Status = "rejected" // Submission failed, see the item's error
+	StatusDeleted  Status = "deleted"  // The video was deleted after submission
+)
+
+// Batch groups videos submitted together so their progress can be polled
+// as a whole
+type Batch struct {
+	ID        string      `json:"id"`
+	URL       string      `json:"url,omitempty"`   // Playlist or channel the items were expanded from
+	Title     string      `json:"title,omitempty"` // Title of that playlist or channel
+	Items     []BatchItem `json:"items"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// BatchItem is one URL of a batch
+type BatchItem struct {
+	URL     string `json:"url"`
+	VideoID string `json:"video_id,omitempty"` // Set once the URL was submitted
+	Error   string `json:"error,omitempty"`    // Why submission failed
+
+	// VideoStatus is the status of the item's video when the batch was
+	// loaded; empty if it hasn't been submitted or was since deleted
+	VideoStatus Status `json:"-"`
+}
+
+// Status reports where the item stands: pending or rejected before a video
+// exists, then the video's own status
+func (i BatchItem) Status() Status {
+	switch {
+	case i.Error != "":
+		return StatusRejected
+	case i.VideoID == "":
+		return StatusPending
+	case i.VideoStatus == "":
+		return StatusDeleted
+	default:
+		return i.VideoStatus
+	}
+}
+
+// Status is processing while any item is pending or processing, and
+// completed once every item has finished, successfully or not
+func (b *Batch) Status() Status {
+	for _, item := range b.Items {
+		if s := item.Status(); s == StatusPending || s == StatusProcessing {
+			return StatusProcessing
+		}
+	}
+	return StatusCompleted
+}
+
+// BatchResponse represents a batch in API responses
+type BatchResponse struct {
+	ID        string              `json:"id"`
+	URL       string              `json:"url,omitempty"`
+	Title     string              `json:"title,omitempty"`
+	Status    Status              `json:"status"`
+	Total     int                 `json:"total"`
+	Counts    map[Status]int      `json:"counts"` // Items per status
+	Items     []BatchItemResponse `json:"items"`
+	CreatedAt string              `json:"created_at"`
+}
+
+type BatchItemResponse struct {
+	URL     string `json:"url"`
+	VideoID string `json:"video_id,omitempty"`
+	Status  Status `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewBatchResponse creates a response from a batch
+func NewBatchResponse(b *Batch) *BatchResponse {
+	resp := &BatchResponse{
+		ID:        b.ID,
+		URL:       b.URL,
+		Title:     b.Title,
+		Status:    b.Status(),
+		Total:     len(b.Items),
+		Counts:    make(map[Status]int),
+		Items:     make([]BatchItemResponse, len(b.Items)),
+		CreatedAt: b.CreatedAt.Format(time.RFC3339),
+	}
+
+	for i, item := range b.Items {
+		status := item.Status()
+		resp.Counts[status]++
+		resp.Items[i] = BatchItemResponse{
+			URL:     item.URL,
+			VideoID: item.VideoID,
+			Status:  status,
+			Error:   item.Error,
+		}
+	}
+	return resp
+}
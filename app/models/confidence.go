@@ -0,0 +1,62 @@
+package models
+
+import "strings"
+
+// Whisper's own thresholds for a failed decode, under which it retries a
+// segment at a higher temperature; segments past either are flagged
+const (
+	LowConfidenceLogprob  = -1.0
+	LowConfidenceNoSpeech = 0.6
+)
+
+// ConfidenceRegion is a run of consecutive low-confidence segments
+type ConfidenceRegion struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// IsLowConfidence reports whether Whisper was unsure of the segment's text
+// or of there being speech in it at all. Segments without scores never
+// are.
+func (s Segment) IsLowConfidence() bool {
+	return s.AvgLogprob != nil && *s.AvgLogprob < LowConfidenceLogprob ||
+		s.NoSpeechProb != nil && *s.NoSpeechProb > LowConfidenceNoSpeech
+}
+
+// FlagLowConfidence returns a copy of segments with the low-confidence ones
+// flagged
+func FlagLowConfidence(segments []Segment) []Segment {
+	if segments == nil {
+		return nil
+	}
+
+	out := make([]Segment, len(segments))
+	for i, seg := range segments {
+		seg.LowConfidence = seg.IsLowConfidence()
+		out[i] = seg
+	}
+	return out
+}
+
+// LowConfidenceRegions merges consecutive low-confidence segments into
+// regions, in order
+func LowConfidenceRegions(segments []Segment) []ConfidenceRegion {
+	var regions []ConfidenceRegion
+	for i := 0; i < len(segments); i++ {
+		if !segments[i].IsLowConfidence() {
+			continue
+		}
+		start := i
+		var texts []string
+		for ; i < len(segments) && segments[i].IsLowConfidence(); i++ {
+			texts = append(texts, segments[i].Text)
+		}
+		regions = append(regions, ConfidenceRegion{
+			Start: segments[start].Start,
+			End:   segments[i-1].End,
+			Text:  strings.Join(texts, " "),
+		})
+	}
+	return regions
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// Enrichment is what was extracted from a transcript: the words it is
+// mostly about, the names it mentions and the links it reads out
+type Enrichment struct {
+	Keywords []string `json:"keywords"` // Lowercase, most frequent first
+	Entities []Entity `json:"entities"` // Most mentioned first
+	URLs     []string `json:"urls"`     // In order of first mention
+}
+
+// Entity is a name mentioned in a transcript, such as a person, place or
+// product
+type Entity struct {
+	Name     string `json:"name"`
+	Mentions int    `json:"mentions"`
+}
+
+// HasKeyword reports whether word is one of the keywords, in any case
+func (e *Enrichment) HasKeyword(word string) bool {
+	for _, k := range e.Keywords {
+		if strings.EqualFold(k, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasEntity reports whether name is one of the entities, in any case
+func (e *Enrichment) HasEntity(name string) bool {
+	for _, entity := range e.Entities {
+		if strings.EqualFold(entity.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a copy that shares nothing with e
+func (e *Enrichment) Clone() *Enrichment {
+	return &Enrichment{
+		Keywords: slices.Clone(e.Keywords),
+		Entities: slices.Clone(e.Entities),
+		URLs:     slices.Clone(e.URLs),
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+// Estimate is the result of a pre-flight check of a URL: what processing it
+// would take, without queueing a job. Fields that can't be known up front
+// are left out.
+type Estimate struct {
+	Title             string  `json:"title,omitempty"`
+	DurationSeconds   float64 `json:"duration_seconds,omitempty"`
+	Source            Source  `json:"source"`          // Expected transcript source
+	Model             string  `json:"model,omitempty"` // Whisper model, when Whisper is expected
+	ProcessingSeconds float64 `json:"processing_seconds,omitempty"`
+	QueueDepth        int     `json:"queue_depth"`
+	QueueWaitSeconds  float64 `json:"queue_wait_seconds"`
+	// CaptionsAvailable reports whether the video has official captions;
+	// null when that can't be checked without fetching them
+	CaptionsAvailable *bool `json:"captions_available"`
+}
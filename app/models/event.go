@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// JobEventType is a step in a transcription job's life
+type JobEventType string
+
+const (
+	JobQueued       JobEventType = "queued"
+	JobScheduled    JobEventType = "scheduled" // Waiting for a live stream or premiere to end
+	JobStarted      JobEventType = "started"
+	JobCaptionFetch JobEventType = "caption_fetch" // Fetching YouTube captions
+	JobWhisperStart JobEventType = "whisper_start" // Transcribing the audio with Whisper
+	JobCompleted    JobEventType = "completed"
+	JobFailed       JobEventType = "failed"
+	JobCancelled    JobEventType = "cancelled"
+	JobInterrupted  JobEventType = "interrupted" // Stopped by a shutdown, resumed on restart
+
+	// JobProgress is published when a job's stage, progress or queue
+	// position changes. It isn't kept in the job history.
+	JobProgress JobEventType = "progress"
+)
+
+// JobEvent records one state transition of a video's transcription jobs
+type JobEvent struct {
+	VideoID   string       `json:"-"`
+	Type      JobEventType `json:"event"`
+	Detail    string       `json:"detail,omitempty"` // Model, error code and the like
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// JobHistoryResponse lists a video's job events, oldest first
+type JobHistoryResponse struct {
+	VideoID string          `json:"video_id"`
+	Events  []JobEventEntry `json:"events"`
+}
+
+// JobEventEntry is a job event with the time since the video's first event
+type JobEventEntry struct {
+	JobEvent
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+func NewJobHistoryResponse(videoID string, events []JobEvent) JobHistoryResponse {
+	resp := JobHistoryResponse{VideoID: videoID, Events: make([]JobEventEntry, len(events))}
+	for i, event := range events {
+		resp.Events[i] = JobEventEntry{
+			JobEvent:       event,
+			ElapsedSeconds: event.CreatedAt.Sub(events[0].CreatedAt).Seconds(),
+		}
+	}
+	return resp
+}
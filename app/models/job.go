@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// QueuedJob is a transcription job waiting for or held by a worker, stored
+// so the queue can be rebuilt after a restart
+type QueuedJob struct {
+	VideoID   string
+	Source    string // Source preference the job was submitted with
+	Model     string // Whisper model override, if any
+	Whisper   WhisperOptions
+	Captions  CaptionOptions
+	Priority  bool
+	RequestID string
+	QueuedAt  time.Time
+
+	// ClaimedBy is the process running the job, empty while the job waits
+	// for one. A claim lapses unless its process renews it.
+	ClaimedBy string
+	ClaimedAt time.Time
+
+	// NotBefore holds a job back until then, zero to run it right away
+	NotBefore time.Time
+
+	// LookUp is set for jobs accepted before their video was looked up,
+	// which the job does first
+	LookUp bool
+}
+
+// Task is what Whisper does with the audio
+type Task string
+
+const (
+	TaskTranscribe Task = "transcribe" // Transcribe in the spoken language
+	TaskTranslate  Task = "translate"  // Translate the speech to English
+)
+
+// WhisperOptions tune a Whisper run. Zero values leave the script's
+// defaults in place.
+type WhisperOptions struct {
+	Language    string   `json:"language,omitempty"` // Spoken language code; detected when empty
+	Task        Task     `json:"task,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	BeamSize    int      `json:"beam_size,omitempty"`
+}
+
+// IsZero reports whether no option is set
+func (o WhisperOptions) IsZero() bool {
+	return o.Language == "" && o.Task == "" && o.Temperature == nil && o.BeamSize == 0
+}
+
+// CaptionOptions narrow which caption track a transcript may come from.
+// Zero values take any track, preferring human-made English captions.
+type CaptionOptions struct {
+	Languages  []string `json:"languages,omitempty"`   // Preferred languages, most wanted first; other languages are skipped
+	ManualOnly bool     `json:"manual_only,omitempty"` // Skip auto-generated captions
+}
+
+// IsZero reports whether no option is set
+func (o CaptionOptions) IsZero() bool {
+	return len(o.Languages) == 0 && !o.ManualOnly
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Passages joins consecutive segments into passages spanning at least
+// duration, so each one carries enough context to be searched or answered
+// from on its own
+func Passages(segments []Segment, duration time.Duration) []Segment {
+	var result []Segment
+	var text []string
+	var current Segment
+	for i, seg := range segments {
+		if len(text) == 0 {
+			current.Start = seg.Start
+		}
+		text = append(text, strings.TrimSpace(seg.Text))
+		current.End = seg.End
+
+		if current.End-current.Start >= duration.Seconds() || i == len(segments)-1 {
+			current.Text = strings.Join(text, " ")
+			result = append(result, current)
+			text = text[:0]
+		}
+	}
+	return result
+}
@@ -0,0 +1,32 @@
+package models
+
+// Stage is where a job stands within processing
+type Stage string
+
+const (
+	StageScheduled    Stage = "scheduled"    // Waiting for a live stream or premiere to end
+	StageQueued       Stage = "queued"       // Waiting for a worker
+	StageValidating   Stage = "validating"   // Looking the video up before it runs
+	StageRunning      Stage = "running"      // A worker took the job but hasn't reported a stage yet
+	StageDownloading  Stage = "downloading"  // Downloading audio for Whisper
+	StageTranscribing Stage = "transcribing" // Whisper is transcribing the audio
+	StageDone         Stage = "done"         // Completed or failed
+)
+
+// ProgressUpdate reports the state of a transcription to clients following
+// it as it runs
+type ProgressUpdate struct {
+	ID            string    `json:"id"`
+	Status        Status    `json:"status"`
+	Stage         Stage     `json:"stage,omitempty"`
+	Progress      float64   `json:"progress"`                 // Fraction from 0 to 1
+	QueuePosition int       `json:"queue_position,omitempty"` // 1-based, while queued
+	Segments      int       `json:"segments,omitempty"`       // Partial transcript segments available, while transcribing
+	Error         string    `json:"error,omitempty"`
+	ErrorCode     ErrorCode `json:"error_code,omitempty"`
+}
+
+// Done reports whether no further updates will follow
+func (p *ProgressUpdate) Done() bool {
+	return p.Status != StatusProcessing
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Revision is one version of a video's primary transcript. The transcript
+// as it was transcribed is recorded as a revision when it is first
+// corrected, and every correction adds the next one.
+type Revision struct {
+	VideoID   string    `json:"video_id"`
+	Number    int       `json:"revision"`
+	Source    Source    `json:"source"`
+	Text      string    `json:"text,omitempty"`     // Left out of listings
+	Segments  []Segment `json:"segments,omitempty"` // Left out of listings
+	Note      string    `json:"note,omitempty"`
+	UserID    string    `json:"user_id,omitempty"` // Who made the correction, when signed in
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RevisionList is the history of a video's transcript, oldest first
+type RevisionList struct {
+	VideoID string `json:"video_id"`
+
+	// Current is the revision the transcript is at; 0 when it is as
+	// transcribed and was never corrected since
+	Current   int        `json:"current"`
+	Revisions []Revision `json:"revisions"`
+}
+
+// Change is a run of words a revision kept, deleted or inserted
+type Change struct {
+	Op   string `json:"op"` // "equal", "delete" or "insert"
+	Text string `json:"text"`
+}
+
+// Change operations
+const (
+	ChangeEqual  = "equal"
+	ChangeDelete = "delete"
+	ChangeInsert = "insert"
+)
+
+// RevisionDiff is a revision with the word changes made to an earlier one
+type RevisionDiff struct {
+	Revision *Revision `json:"revision"`
+	Against  int       `json:"against"` // 0 when there is no earlier revision
+	Inserted int       `json:"inserted_words"`
+	Deleted  int       `json:"deleted_words"`
+	Changes  []Change  `json:"changes"`
+}
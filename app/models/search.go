@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Embedding is the vector of one passage of a transcript, as produced by
+// Model
+type Embedding struct {
+	VideoID string
+	Source  Source // Transcript the passage was taken from
+	Model   string
+	Passage int // Position of the passage in the transcript, from 0
+	Segment
+	Vector    []float32
+	CreatedAt time.Time
+}
+
+// SearchResult is a passage matching a search, with the video it is from
+type SearchResult struct {
+	VideoID string  `json:"video_id"`
+	Title   string  `json:"title,omitempty"`
+	URL     string  `json:"url"`
+	Score   float64 `json:"score"` // Cosine similarity to the query, up to 1
+	Segment
+}
+
+// SearchResponse lists the passages closest in meaning to a query
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Model   string         `json:"model"` // Embedding model the passages were compared with
+	Results []SearchResult `json:"results"`
+}
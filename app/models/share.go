@@ -0,0 +1,9 @@
+package models
+
+// ShareResponse is a link giving read-only access to a transcript until it
+// expires
+type ShareResponse struct {
+	VideoID   string `json:"video_id"`
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// SummaryStyle is the shape of a summary
+type SummaryStyle string
+
+const (
+	StyleAbstract SummaryStyle = "abstract" // A paragraph of the transcript's key sentences
+	StyleBullets  SummaryStyle = "bullets"  // One key point per line
+	StyleChapters SummaryStyle = "chapters" // A short abstract under each chapter's title
+)
+
+// Summary is a condensed version of a video's transcript. A video has one
+// per style and model.
+type Summary struct {
+	VideoID   string       `json:"video_id"`
+	Text      string       `json:"summary"`
+	Style     SummaryStyle `json:"style"`
+	Source    Source       `json:"source"` // Transcript the summary was made from
+	Model     string       `json:"model"`  // Summarizer that produced it
+	CreatedAt time.Time    `json:"created_at"`
+}
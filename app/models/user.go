@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// User is an account signed in with an OAuth provider. Videos are shared
+// between users; each user's list holds the ones they submitted.
+type User struct {
+	ID        string    `json:"id"`
+	Provider  string    `json:"provider"` // OAuth provider the user signs in with
+	Subject   string    `json:"-"`        // The user's ID at the provider
+	Email     string    `json:"email,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	LastLogin time.Time `json:"last_login"`
+}
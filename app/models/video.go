@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -12,15 +13,193 @@ const (
 	StatusFailed     Status = "failed"
 )
 
+// Source identifies where a transcript came from
+type Source string
+
+const (
+	SourceWhisper  Source = "whisper"
+	SourceCaptions Source = "youtube_captions"
+)
+
+// Platform is the site a video is hosted on
+type Platform string
+
+const (
+	PlatformYouTube    Platform = "youtube"
+	PlatformVimeo      Platform = "vimeo"
+	PlatformTwitch     Platform = "twitch"
+	PlatformSoundCloud Platform = "soundcloud"
+	PlatformOther      Platform = "other"  // Any other site yt-dlp supports
+	PlatformDirect     Platform = "direct" // A direct link to an audio file
+)
+
+// ErrorCode classifies why a transcription failed
+type ErrorCode string
+
+const (
+	ErrorUnknown            ErrorCode = "unknown"
+	ErrorVideoPrivate       ErrorCode = "video_private"
+	ErrorVideoUnavailable   ErrorCode = "video_unavailable"
+	ErrorGeoBlocked         ErrorCode = "geo_blocked"
+	ErrorAgeRestricted      ErrorCode = "age_restricted"
+	ErrorLiveOnly           ErrorCode = "live_only"
+	ErrorTooLong            ErrorCode = "too_long"
+	ErrorNoSpeech           ErrorCode = "no_speech"
+	ErrorOutOfMemory        ErrorCode = "out_of_memory"
+	ErrorNetwork            ErrorCode = "network_error"
+	ErrorTimeout            ErrorCode = "timeout"
+	ErrorServiceUnavailable ErrorCode = "service_unavailable"
+	ErrorQueueFull          ErrorCode = "queue_full"
+	ErrorNoCaptions         ErrorCode = "no_captions"
+	ErrorCancelled          ErrorCode = "cancelled"
+	ErrorRejected           ErrorCode = "rejected" // Turned away when the job looked the video up
+)
+
+// Retryable reports whether submitting the same video again may succeed
+// without anything changing on the video's side
+func (c ErrorCode) Retryable() bool {
+	switch c {
+	case ErrorOutOfMemory, ErrorNetwork, ErrorTimeout, ErrorServiceUnavailable, ErrorUnknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// Segment is one timed span of a transcript, with times in seconds
+type Segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+
+	// Whisper's confidence in the segment: the average log probability of
+	// its tokens, and the probability that it holds no speech at all.
+	// Captions and corrected text have neither.
+	AvgLogprob   *float64 `json:"avg_logprob,omitempty"`
+	NoSpeechProb *float64 `json:"no_speech_prob,omitempty"`
+
+	LowConfidence bool `json:"low_confidence,omitempty"` // Filled in for responses
+}
+
+// Chapter is a titled section of a transcript, with times in seconds
+type Chapter struct {
+	Title string  `json:"title"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text,omitempty"` // Filled in from a transcript's segments for responses
+}
+
 type Video struct {
-	ID            string    `json:"id"`
-	URL           string    `json:"url"`
-	Title         string    `json:"title"`
-	Transcription string    `json:"transcription"`
-	Status        Status    `json:"status"`
-	Error         string    `json:"error,omitempty"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID                     string      `json:"id"`
+	URL                    string      `json:"url"`
+	Title                  string      `json:"title"`
+	Language               string      `json:"language,omitempty"` // Audio language hint, used to pick a model
+	Platform               Platform    `json:"platform,omitempty"`
+	MediaID                string      `json:"media_id,omitempty"` // The video's ID on its platform
+	Uploader               string      `json:"uploader,omitempty"` // Channel or account the video was published by
+	Transcription          string      `json:"transcription"`
+	Source                 Source      `json:"source,omitempty"`
+	Segments               []Segment   `json:"-"` // Timing of the primary transcript, when the source provided it
+	SecondaryTranscription string      `json:"-"` // Transcript from the other source, when both were produced
+	SecondarySource        Source      `json:"secondary_source,omitempty"`
+	SecondarySegments      []Segment   `json:"-"` // Timing of the secondary transcript
+	Chapters               []Chapter   `json:"-"` // Sections of the video, from its own chapters or detected topics
+	Enrichment             *Enrichment `json:"-"` // Keywords, names and links found in the primary transcript
+	TranscriptKey          string      `json:"-"` // Storage key of the primary transcript when it is kept outside the database
+	SecondaryTranscriptKey string      `json:"-"` // Storage key of the secondary transcript
+	Status                 Status      `json:"status"`
+	Pinned                 bool        `json:"pinned"`               // Pinned videos are never removed by retention cleanup
+	ExpiresAt              *time.Time  `json:"expires_at,omitempty"` // When cleanup removes the video, instead of after the retention window
+	Error                  string      `json:"error,omitempty"`
+	ErrorCode              ErrorCode   `json:"error_code,omitempty"`
+	FailureLog             string      `json:"-"`                          // Raw error and tail of backend output from the last failed run
+	CaptionWER             *float64    `json:"caption_wer,omitempty"`      // Word error rate of captions against Whisper, when both were run
+	CaptionLanguage        string      `json:"caption_language,omitempty"` // Language of the caption track a transcript came from
+	CaptionKind            string      `json:"caption_kind,omitempty"`     // "manual" or "auto" for that track
+	RetryAt                *time.Time  `json:"retry_at,omitempty"`         // When a job waiting for a live stream or premiere to end runs next
+	CreatedAt              time.Time   `json:"created_at"`
+	UpdatedAt              time.Time   `json:"updated_at"`
+
+	// Model is the Whisper model of the transcript from Whisper, if any
+	Model string `json:"model,omitempty"`
+
+	// Revision is the correction the primary transcript is at; 0 while it
+	// is as transcribed
+	Revision int `json:"revision,omitempty"`
+
+	// CaptionComparison is how the captions compared with Whisper on the
+	// last run that made both
+	CaptionComparison *CaptionComparison `json:"-"`
+
+	// Timings is how long the stages of the last run took
+	Timings *StageTimings `json:"-"`
+
+	// Version counts the saves of the video. A save only succeeds while it
+	// matches the stored row, so a copy read before another save can't
+	// overwrite it.
+	Version int64 `json:"-"`
+}
+
+// TranscriptionFrom returns the transcript produced by the given source
+func (v *Video) TranscriptionFrom(source Source) (string, bool) {
+	switch {
+	case source == "":
+		return "", false
+	case source == v.Source && v.Transcription != "":
+		return v.Transcription, true
+	case source == v.SecondarySource && v.SecondaryTranscription != "":
+		return v.SecondaryTranscription, true
+	default:
+		return "", false
+	}
+}
+
+// SegmentsFrom returns the timing of the transcript produced by the given
+// source. It reports false when no transcript from that source is stored;
+// transcripts stored without timing yield no segments.
+func (v *Video) SegmentsFrom(source Source) ([]Segment, bool) {
+	if _, ok := v.TranscriptionFrom(source); !ok {
+		return nil, false
+	}
+	if source == v.Source {
+		return v.Segments, true
+	}
+	return v.SecondarySegments, true
+}
+
+// ChaptersFrom returns the video's chapters with the text of the transcript
+// from the given source. Segments belong to the chapter they start in.
+func (v *Video) ChaptersFrom(source Source) []Chapter {
+	if len(v.Chapters) == 0 {
+		return nil
+	}
+	segments, _ := v.SegmentsFrom(source)
+
+	chapters := make([]Chapter, len(v.Chapters))
+	for i, ch := range v.Chapters {
+		first, last := i == 0, i == len(v.Chapters)-1
+		var text []string
+		for _, seg := range segments {
+			if (seg.Start >= ch.Start || first) && (seg.Start < ch.End || last) {
+				text = append(text, seg.Text)
+			}
+		}
+		ch.Text = strings.Join(text, " ")
+		chapters[i] = ch
+	}
+	return chapters
+}
+
+// Sources lists the sources a transcript is stored for, primary first
+func (v *Video) Sources() []Source {
+	var sources []Source
+	if _, ok := v.TranscriptionFrom(v.Source); ok {
+		sources = append(sources, v.Source)
+	}
+	if _, ok := v.TranscriptionFrom(v.SecondarySource); ok {
+		sources = append(sources, v.SecondarySource)
+	}
+	return sources
 }
 
 // Status check methods
@@ -28,36 +207,222 @@ func (v *Video) IsProcessing() bool { return v.Status == StatusProcessing }
 func (v *Video) IsCompleted() bool  { return v.Status == StatusCompleted }
 func (v *Video) IsFailed() bool     { return v.Status == StatusFailed }
 
-// IsStale checks if the job has been stuck in processing for too long
+// ExpiredAt reports whether retention cleanup at now removes the video
+// when it keeps videos updated since cutoff. A video's own expiry takes
+// the place of the cutoff; a zero cutoff keeps videos without one.
+func (v *Video) ExpiredAt(cutoff, now time.Time) bool {
+	if v.ExpiresAt != nil {
+		return v.ExpiresAt.Before(now)
+	}
+	return v.UpdatedAt.Before(cutoff)
+}
+
+// IsStale checks if the job has been stuck in processing for too long. A
+// job scheduled for later is only stale once it was due that long ago.
 func (v *Video) IsStale(timeout time.Duration) bool {
 	if v.Status != StatusProcessing {
 		return false
 	}
-	return time.Since(v.UpdatedAt) > timeout
+	since := v.UpdatedAt
+	if v.RetryAt != nil && v.RetryAt.After(since) {
+		since = *v.RetryAt
+	}
+	return time.Since(since) > timeout
+}
+
+// StageTimings is how long each stage of a video's last run took, in
+// seconds. Stages the run didn't go through are left out; runs that fell
+// back from captions to Whisper count both.
+type StageTimings struct {
+	LookupSeconds         float64 `json:"lookup_seconds,omitempty"`          // Checking the video before the run
+	CaptionFetchSeconds   float64 `json:"caption_fetch_seconds,omitempty"`   // Fetching YouTube captions
+	DownloadSeconds       float64 `json:"download_seconds,omitempty"`        // Downloading audio for Whisper
+	TranscriptionSeconds  float64 `json:"transcription_seconds,omitempty"`   // Running Whisper
+	PostProcessingSeconds float64 `json:"post_processing_seconds,omitempty"` // Storing the transcript and finding chapters
+	TotalSeconds          float64 `json:"total_seconds"`
 }
 
 // VideoResponse represents the API response
 type VideoResponse struct {
-	ID            string `json:"id"`
-	URL           string `json:"url"`
-	Status        Status `json:"status"`
-	Transcription string `json:"transcription,omitempty"`
-	Title         string `json:"title,omitempty"`
-	Error         string `json:"error,omitempty"`
-	CreatedAt     string `json:"created_at"`
-	UpdatedAt     string `json:"updated_at"`
+	ID              string      `json:"id"`
+	URL             string      `json:"url"`
+	Status          Status      `json:"status"`
+	Transcription   string      `json:"transcription,omitempty"`
+	Source          Source      `json:"source,omitempty"`
+	Sources         []Source    `json:"sources,omitempty"`  // Every source a transcript is available from
+	Segments        []Segment   `json:"segments,omitempty"` // Timed segments of the transcript, when requested
+	Chapters        []Chapter   `json:"chapters,omitempty"`
+	Enrichment      *Enrichment `json:"enrichment,omitempty"`
+	Title           string      `json:"title,omitempty"`
+	Language        string      `json:"language,omitempty"`
+	Platform        Platform    `json:"platform,omitempty"`
+	MediaID         string      `json:"media_id,omitempty"`
+	Uploader        string      `json:"uploader,omitempty"`
+	Error           string      `json:"error,omitempty"`
+	ErrorCode       ErrorCode   `json:"error_code,omitempty"`
+	Retryable       *bool       `json:"retryable,omitempty"`
+	Pinned          bool        `json:"pinned"`
+	ExpiresAt       *time.Time  `json:"expires_at,omitempty"`
+	CaptionWER      *float64    `json:"caption_wer,omitempty"`
+	CaptionLanguage string      `json:"caption_language,omitempty"`
+	CaptionKind     string      `json:"caption_kind,omitempty"`
+	RetryAt         *time.Time  `json:"retry_at,omitempty"`
+	CreatedAt       string      `json:"created_at"`
+	UpdatedAt       string      `json:"updated_at"`
+
+	Model    string `json:"model,omitempty"`
+	Revision int    `json:"revision,omitempty"` // Of the primary transcript, once corrected
+
+	CaptionComparison *CaptionComparison `json:"caption_comparison,omitempty"`
+
+	// LowConfidence are the stretches of the transcript Whisper was unsure
+	// of; left out of listings
+	LowConfidence []ConfidenceRegion `json:"low_confidence,omitempty"`
+
+	// Timings is left out of listings
+	Timings *StageTimings `json:"timings,omitempty"`
 }
 
 // NewVideoResponse creates a response from a video model
 func NewVideoResponse(v *Video) *VideoResponse {
-	return &VideoResponse{
-		ID:            v.ID,
-		URL:           v.URL,
-		Status:        v.Status,
-		Transcription: v.Transcription,
-		Title:         v.Title,
-		Error:         v.Error,
-		CreatedAt:     v.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:     v.UpdatedAt.Format(time.RFC3339),
+	resp := &VideoResponse{
+		ID:              v.ID,
+		URL:             v.URL,
+		Status:          v.Status,
+		Transcription:   v.Transcription,
+		Source:          v.Source,
+		Sources:         v.Sources(),
+		Chapters:        v.ChaptersFrom(v.Source),
+		Enrichment:      v.Enrichment,
+		Title:           v.Title,
+		Language:        v.Language,
+		Platform:        v.Platform,
+		MediaID:         v.MediaID,
+		Uploader:        v.Uploader,
+		Error:           v.Error,
+		ErrorCode:       v.ErrorCode,
+		Pinned:          v.Pinned,
+		ExpiresAt:       v.ExpiresAt,
+		CaptionWER:      v.CaptionWER,
+		CaptionLanguage: v.CaptionLanguage,
+		CaptionKind:     v.CaptionKind,
+		RetryAt:         v.RetryAt,
+		CreatedAt:       v.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       v.UpdatedAt.Format(time.RFC3339),
+		Model:           v.Model,
+		Revision:        v.Revision,
+		Timings:         v.Timings,
+
+		CaptionComparison: v.CaptionComparison,
+		LowConfidence:     LowConfidenceRegions(v.Segments),
+	}
+
+	if v.IsFailed() && v.ErrorCode != "" {
+		retryable := v.ErrorCode.Retryable()
+		resp.Retryable = &retryable
+	}
+
+	return resp
+}
+
+// NewVideoResponseFrom creates a response carrying the transcript from a
+// specific source. It reports false when no transcript from that source is
+// stored.
+func NewVideoResponseFrom(v *Video, source Source) (*VideoResponse, bool) {
+	text, ok := v.TranscriptionFrom(source)
+	if !ok {
+		return nil, false
+	}
+
+	resp := NewVideoResponse(v)
+	resp.Transcription = text
+	resp.Source = source
+	resp.Chapters = v.ChaptersFrom(source)
+	segments, _ := v.SegmentsFrom(source)
+	resp.LowConfidence = LowConfidenceRegions(segments)
+	if source != v.Source {
+		// Both belong to the primary transcript
+		resp.Enrichment = nil
+		resp.Revision = 0
+	}
+	return resp, true
+}
+
+// VideoListResponse is one page of a video listing
+type VideoListResponse struct {
+	Items      []*VideoResponse `json:"items"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	Total      int              `json:"total"`
+	TotalPages int              `json:"total_pages"`
+}
+
+// NewVideoListResponse creates a listing response. Transcripts are left out
+// to keep pages small; clients fetch them per video.
+func NewVideoListResponse(videos []*Video, page, pageSize, total int) *VideoListResponse {
+	items := make([]*VideoResponse, len(videos))
+	for i, v := range videos {
+		items[i] = NewVideoResponse(v)
+		items[i].Transcription = ""
+		items[i].Chapters = nil
+		items[i].Timings = nil
+		items[i].LowConfidence = nil
+	}
+
+	return &VideoListResponse{
+		Items:      items,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: (total + pageSize - 1) / pageSize,
 	}
 }
+
+// VideoStats are totals over every stored video
+type VideoStats struct {
+	ByStatus map[Status]int `json:"by_status"`
+	BySource map[Source]int `json:"by_source"` // By the source of the primary transcript
+	ByModel  []ModelStats   `json:"by_model"`
+
+	// TranscriptBytes is the size of the transcripts stored in the
+	// database, as stored (compressed or not). It is reported with the
+	// other storage figures.
+	TranscriptBytes int64 `json:"-"`
+}
+
+// ModelStats are totals over the videos transcribed with one Whisper model
+type ModelStats struct {
+	Model  string `json:"model"`
+	Videos int    `json:"videos"`
+
+	// AvgProcessingSeconds is the mean run time of its completed videos
+	// with recorded timings, or 0 when there are none
+	AvgProcessingSeconds float64 `json:"avg_processing_seconds"`
+}
+
+// CaptionComparison scores a video's captions against a Whisper transcript
+// of it and records which of the two became the primary transcript
+type CaptionComparison struct {
+	WER        float64 `json:"wer"`        // Word error rate of the captions, taking Whisper as the reference
+	Similarity float64 `json:"similarity"` // 1 - WER, at least 0
+	Threshold  float64 `json:"threshold"`  // WER at or below which captions count as good
+	GoodEnough bool    `json:"good_enough"`
+
+	CaptionKind  string `json:"caption_kind,omitempty"` // "manual" or "auto"
+	CaptionWords int    `json:"caption_words"`
+	WhisperWords int    `json:"whisper_words"`
+
+	// Policy is the rule that picked the primary transcript, and Picked
+	// the source it picked
+	Policy string `json:"policy"`
+	Picked Source `json:"picked"`
+}
+
+// CaptionQuality aggregates caption word error rates over videos where both
+// captions and Whisper were run
+type CaptionQuality struct {
+	Compared   int     `json:"compared"`
+	MeanWER    float64 `json:"mean_wer"`
+	GoodEnough int     `json:"good_enough"` // Videos with WER at or below the threshold
+	Threshold  float64 `json:"threshold"`
+}
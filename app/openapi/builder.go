@@ -0,0 +1,389 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route describes one operation. Request and response shapes are given as
+// values of the Go types the handler uses; only their types matter.
+type Route struct {
+	Method      string // HTTP method, e.g. http.MethodGet
+	Path        string // Fiber-style path; ":id" segments become path parameters
+	OperationID string
+	Summary     string
+	Description string
+	Tag         string
+	Security    string // Name of the security scheme guarding the route, if any
+
+	Query    any // Struct whose `query` tagged fields are query parameters
+	Body     any // JSON request body
+	Response any // Data of a successful JSON response, wrapped in the envelope
+
+	// ContentType describes a successful response that isn't JSON, such as
+	// text/plain, instead of Response
+	ContentType string
+
+	Status int   // Success status; 200 when unset
+	Errors []int // Error statuses the route is known to answer with
+}
+
+// Builder accumulates routes into a Document, collecting the named Go types
+// it meets into shared component schemas
+type Builder struct {
+	doc      *Document
+	envelope func(data *Schema) *Schema
+	errorRef *Schema
+	names    map[reflect.Type]string
+}
+
+// NewBuilder starts a document. envelope wraps the schema of a successful
+// response's data in the API's response envelope; errorBody is a value of
+// the type error responses are written as.
+func NewBuilder(info Info, envelope func(data *Schema) *Schema, errorBody any) *Builder {
+	b := &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+			Components: Components{
+				Schemas:         make(map[string]*Schema),
+				SecuritySchemes: make(map[string]*SecurityScheme),
+			},
+		},
+		envelope: envelope,
+		names:    make(map[reflect.Type]string),
+	}
+	b.errorRef = b.Schema(errorBody)
+	return b
+}
+
+// Server adds a base URL the paths are relative to
+func (b *Builder) Server(url, description string) {
+	b.doc.Servers = append(b.doc.Servers, Server{URL: url, Description: description})
+}
+
+// Tag describes a group of operations
+func (b *Builder) Tag(name, description string) {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+}
+
+// SecurityScheme registers a scheme routes can refer to by name
+func (b *Builder) SecurityScheme(name string, scheme *SecurityScheme) {
+	b.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// Add documents a route
+func (b *Builder) Add(r Route) {
+	op := &Operation{
+		OperationID: r.OperationID,
+		Summary:     r.Summary,
+		Description: r.Description,
+		Responses:   make(map[string]*Response),
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+	if r.Security != "" {
+		op.Security = []map[string][]string{{r.Security: {}}}
+	}
+
+	path, params := pathParameters(r.Path)
+	op.Parameters = append(params, b.queryParameters(r.Query)...)
+
+	if r.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: b.Schema(r.Body)}},
+		}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	switch {
+	case r.Response != nil:
+		success.Content = map[string]*MediaType{"application/json": {Schema: b.envelope(b.Schema(r.Response))}}
+	case r.ContentType != "":
+		schema := &Schema{Type: "string"}
+		if !strings.HasPrefix(r.ContentType, "text/") {
+			schema.Format = "binary"
+		}
+		success.Content = map[string]*MediaType{r.ContentType: {Schema: schema}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	for _, code := range r.Errors {
+		op.Responses[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]*MediaType{"application/json": {Schema: b.errorRef}},
+		}
+	}
+
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+	switch r.Method {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodDelete:
+		item.Delete = op
+	case http.MethodPatch:
+		item.Patch = op
+	default:
+		panic("openapi: unsupported method " + r.Method)
+	}
+}
+
+// Document returns the document built so far
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// pathParameters turns a Fiber path into an OpenAPI one, returning the
+// parameters of its ":name" segments
+func pathParameters(path string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// queryParameters lists the fields of a struct that carry a `query` tag,
+// including those of embedded structs
+func (b *Builder) queryParameters(query any) []Parameter {
+	if query == nil {
+		return nil
+	}
+	var params []Parameter
+	for _, f := range fields(reflect.TypeOf(query)) {
+		name, _, _ := strings.Cut(f.Tag.Get("query"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := b.schemaOf(f.Type)
+		required := applyValidation(schema, f.Tag.Get("validate"))
+		params = append(params, Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
+
+// Schema returns the schema of v's type. Named struct types are added to the
+// components and referred to.
+func (b *Builder) Schema(v any) *Schema {
+	return b.schemaOf(reflect.TypeOf(v))
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+func (b *Builder) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Nanoseconds"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := b.schemaOf(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: new(float64)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return b.ref(t)
+	default:
+		// Interfaces and anything else hold any JSON value
+		return &Schema{}
+	}
+}
+
+// ref returns a reference to a named struct type's component, adding it on
+// first use. Names are capitalized, and types from different packages
+// sharing a name are told apart by their package.
+func (b *Builder) ref(t reflect.Type) *Schema {
+	if name, ok := b.names[t]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	for other := range b.names {
+		if b.names[other] == name {
+			pkg := t.PkgPath()
+			pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+			break
+		}
+	}
+
+	// Registered before the fields are walked, so recursive types end
+	b.names[t] = name
+	b.doc.Components.Schemas[name] = &Schema{}
+	*b.doc.Components.Schemas[name] = *b.structSchema(t)
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema describes a struct's JSON object, as encoding/json writes it
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, f := range fields(t) {
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := b.schemaOf(f.Type)
+		if applyValidation(prop, f.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = prop
+	}
+	return schema
+}
+
+// fields lists the exported fields of a struct, flattening embedded structs
+// without a JSON name as encoding/json does
+func fields(t reflect.Type) []reflect.StructField {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var result []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && name == "" {
+			result = append(result, fields(f.Type)...)
+			continue
+		}
+		if f.IsExported() {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// applyValidation carries the rules of a `validate` tag that OpenAPI can
+// express over to a schema, reporting whether the value is required
+func applyValidation(schema *Schema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			switch {
+			case schema.Type == "string" && key == "max":
+				schema.MaxLength = &n
+			case schema.Type == "integer" || schema.Type == "number":
+				bound := float64(n)
+				if key == "min" {
+					schema.Minimum = &bound
+				} else {
+					schema.Maximum = &bound
+				}
+			}
+		}
+	}
+	return required
+}
+
+// Routes lists the documented operations as "METHOD path", with paths in
+// the Fiber form the routes were added in
+func (d *Document) Routes() []string {
+	var result []string
+	for path, item := range d.Paths {
+		fiberPath := fiberPath(path)
+		for method, op := range map[string]*Operation{
+			http.MethodGet:    item.Get,
+			http.MethodPut:    item.Put,
+			http.MethodPost:   item.Post,
+			http.MethodDelete: item.Delete,
+			http.MethodPatch:  item.Patch,
+		} {
+			if op != nil {
+				result = append(result, fmt.Sprintf("%s %s", method, fiberPath))
+			}
+		}
+	}
+	return result
+}
+
+func fiberPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + segment[1:len(segment)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
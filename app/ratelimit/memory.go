@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory keeps counts in the process. Each replica counts on its own, so a
+// caller spread over n replicas gets n times the limit.
+type Memory struct {
+	mu      sync.Mutex
+	windows map[string]window
+	swept   time.Time
+}
+
+type window struct {
+	count int
+	ends  time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{windows: make(map[string]window)}
+}
+
+func (m *Memory) Hit(_ context.Context, key string, length time.Duration) (int, time.Time, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Forget callers whose window has ended, at most once per window length
+	// so the sweep stays cheap
+	if now.Sub(m.swept) >= length {
+		for k, w := range m.windows {
+			if !now.Before(w.ends) {
+				delete(m.windows, k)
+			}
+		}
+		m.swept = now
+	}
+
+	w, ok := m.windows[key]
+	if !ok || !now.Before(w.ends) {
+		w = window{ends: now.Add(length)}
+	}
+	w.count++
+	m.windows[key] = w
+	return w.count, w.ends, nil
+}
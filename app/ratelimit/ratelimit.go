@@ -0,0 +1,42 @@
+// Package ratelimit counts requests per caller in fixed windows. Counts are
+// kept in memory, or in Redis so that replicas behind a load balancer share
+// them.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Stores selectable with RATE_LIMIT_STORE
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+// Store counts hits per key. A key's window starts with its first hit and
+// lasts the given length; the count starts over in the next one.
+type Store interface {
+	// Hit counts a hit on key, returning the hits in the current window
+	// including this one and when the window ends
+	Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error)
+}
+
+type Config struct {
+	Store    string
+	RedisURL string        // e.g. redis://:password@localhost:6379/0
+	Timeout  time.Duration // Bounds each Redis round trip
+}
+
+// New returns the store selected by cfg
+func New(cfg Config) (Store, error) {
+	switch cfg.Store {
+	case StoreMemory, "":
+		return NewMemory(), nil
+	case StoreRedis:
+		return NewRedis(cfg.RedisURL, cfg.Timeout)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+}
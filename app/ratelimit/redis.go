@@ -0,0 +1,255 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// redisKeyPrefix keeps the counters apart from other data in the server
+	redisKeyPrefix = "yt-text:ratelimit:"
+
+	// redisIdleConns is how many connections are kept open between hits
+	redisIdleConns = 8
+)
+
+// hitScript counts a hit and starts the key's expiry on the first hit of a
+// window, returning the count and the milliseconds left. Running it as one
+// script keeps concurrent replicas from racing between the two steps. A key
+// left without an expiry, as by an interrupted write, is given one.
+const hitScript = `local n = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if n == 1 or ttl < 0 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[1])
+  ttl = tonumber(ARGV[1])
+end
+return {n, ttl}`
+
+// Redis keeps counts in a Redis server shared by every replica. It speaks
+// just enough of the protocol to run hitScript, over a small pool of
+// connections.
+type Redis struct {
+	addr     string
+	tls      bool
+	username string
+	password string
+	db       int
+	timeout  time.Duration
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// redisError is an error reply from the server. The connection stays usable.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// NewRedis parses a redis:// or rediss:// (TLS) URL. The path selects the
+// database, e.g. redis://:secret@localhost:6379/2.
+func NewRedis(rawURL string, timeout time.Duration) (*Redis, error) {
+	if rawURL == "" {
+		return nil, fmt.Errorf("redis url is required for the redis rate limit store")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid redis url scheme %q, expected redis or rediss", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid redis url: missing host")
+	}
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+
+	r := &Redis{
+		addr:    u.Host,
+		tls:     u.Scheme == "rediss",
+		timeout: timeout,
+		idle:    make(chan *redisConn, redisIdleConns),
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil || r.db < 0 {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+	return r, nil
+}
+
+func (r *Redis) Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	reply, err := r.do(ctx, "EVAL", hitScript, "1", redisKeyPrefix+key, strconv.FormatInt(window.Milliseconds(), 10))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	values, ok := reply.([]any)
+	if !ok || len(values) != 2 {
+		return 0, time.Time{}, fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+	count, ok1 := values[0].(int64)
+	ttl, ok2 := values[1].(int64)
+	if !ok1 || !ok2 {
+		return 0, time.Time{}, fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+	return int(count), time.Now().Add(time.Duration(ttl) * time.Millisecond), nil
+}
+
+// do sends a command and reads its reply. A connection is only reused after
+// a complete exchange, since anything else leaves it mid-reply.
+func (r *Redis) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(ctx, r.timeout, args...)
+	if _, ok := err.(redisError); err != nil && !ok {
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case r.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+// conn takes an idle connection or dials a new one
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: r.timeout}
+	var raw net.Conn
+	var err error
+	if r.tls {
+		raw, err = (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		raw, err = dialer.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	conn := &redisConn{Conn: raw, r: bufio.NewReader(raw)}
+
+	if r.password != "" {
+		args := []string{"AUTH", r.password}
+		if r.username != "" {
+			args = []string{"AUTH", r.username, r.password}
+		}
+		if _, err := conn.do(ctx, r.timeout, args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := conn.do(ctx, r.timeout, "SELECT", strconv.Itoa(r.db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *redisConn) do(ctx context.Context, timeout time.Duration, args ...string) (any, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c, b.String()); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	return c.read()
+}
+
+// read parses one RESP2 reply: simple strings, errors, integers, bulk
+// strings and arrays of those
+func (c *redisConn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid integer %q", line[1:])
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]any, n)
+		for i := range values {
+			// An error inside an array leaves the rest of the array
+			// unread, so it isn't returned as a plain redisError
+			if values[i], err = c.read(); err != nil {
+				return nil, fmt.Errorf("redis: %w", err)
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
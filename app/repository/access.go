@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+// AccessLog collects when videos were last read, so a store can record
+// reads in batches instead of writing on every one. The zero value is ready
+// to use.
+type AccessLog struct {
+	mu      sync.Mutex
+	pending map[string]time.Time // by video ID
+}
+
+// Record notes that a video was read now
+func (l *AccessLog) Record(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pending == nil {
+		l.pending = make(map[string]time.Time)
+	}
+	l.pending[id] = time.Now()
+}
+
+// Take returns the reads recorded since the last call and forgets them
+func (l *AccessLog) Take() map[string]time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pending := l.pending
+	l.pending = nil
+	return pending
+}
+
+// Restore puts back reads that could not be written, unless the video was
+// read again since
+func (l *AccessLog) Restore(reads map[string]time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pending == nil {
+		l.pending = make(map[string]time.Time, len(reads))
+	}
+	for id, at := range reads {
+		if _, ok := l.pending[id]; !ok {
+			l.pending[id] = at
+		}
+	}
+}
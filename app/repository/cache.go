@@ -0,0 +1,389 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"yt-text/models"
+)
+
+// CachedRepository wraps a VideoRepository with a bounded in-process LRU of
+// recently read or written videos, so status polling and popular transcript
+// reads don't hit the database every time. Entries are dropped on Save and
+// reloaded on the next read, since Save doesn't write every column. Pinned
+// videos stay cached until they change. Saves by other processes sharing
+// the database can't drop entries, so for them entries expire after a TTL
+// and videos still being processed aren't cached at all.
+// Callers always receive copies and may modify them freely. Transcripts
+// streamed on their own, such as shared links to popular ones, are kept in
+// a separate LRU so reading them doesn't mean loading the whole video.
+type CachedRepository struct {
+	VideoRepository
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element // by video ID
+	byURL    map[string]string        // URL -> video ID
+	order    *list.List               // of *cacheEntry, front is most recently used
+	maxItems int
+	maxBytes int
+	bytes    int
+	ttl      time.Duration // 0 keeps entries until they're dropped
+
+	// A read from the wrapped repository may return a row that a Save
+	// replaced before the read could cache it. Invalidate records the
+	// epoch it ran at for each video, and reads only cache a video not
+	// invalidated since they started. The record is only needed while
+	// reads are in flight.
+	epoch       uint64
+	invalidated map[string]uint64 // video ID -> epoch of its last invalidation
+	reading     int
+
+	texts *textCache // nil when transcripts aren't cached on their own
+}
+
+// cacheEntry is a cached video and when it's read again
+type cacheEntry struct {
+	video   *models.Video
+	expires time.Time // zero without a TTL
+}
+
+// SharedCacheTTL is how long entries are kept when other processes write to
+// the same database
+const SharedCacheTTL = 30 * time.Second
+
+// NewCachedRepository caches up to maxItems videos whose transcripts total at
+// most maxBytes, and streamed transcripts totalling at most textMaxBytes.
+// Non-positive limits disable the caches. A positive ttl is for databases
+// other processes write to: entries are read again once they are that old,
+// and only finished videos are cached.
+func NewCachedRepository(inner VideoRepository, maxItems, maxBytes, textMaxBytes int, ttl time.Duration) VideoRepository {
+	if maxItems <= 0 && textMaxBytes <= 0 {
+		return inner
+	}
+
+	r := &CachedRepository{
+		VideoRepository: inner,
+		entries:         make(map[string]*list.Element),
+		byURL:           make(map[string]string),
+		order:           list.New(),
+		invalidated:     make(map[string]uint64),
+		maxItems:        max(maxItems, 0),
+		maxBytes:        maxBytes,
+		ttl:             max(ttl, 0),
+	}
+	if textMaxBytes > 0 {
+		r.texts = newTextCache(textMaxBytes, r.ttl)
+	}
+	return r
+}
+
+func (r *CachedRepository) Save(ctx context.Context, video *models.Video) error {
+	defer r.Invalidate(video.ID)
+	return r.VideoRepository.Save(ctx, video)
+}
+
+func (r *CachedRepository) SaveCorrection(ctx context.Context, video *models.Video, revisions []*models.Revision) error {
+	defer r.Invalidate(video.ID)
+	return r.VideoRepository.SaveCorrection(ctx, video, revisions)
+}
+
+func (r *CachedRepository) Delete(ctx context.Context, id string) error {
+	defer r.Invalidate(id)
+	return r.VideoRepository.Delete(ctx, id)
+}
+
+func (r *CachedRepository) SetPinned(ctx context.Context, id string, pinned bool) error {
+	defer r.Invalidate(id)
+	return r.VideoRepository.SetPinned(ctx, id, pinned)
+}
+
+func (r *CachedRepository) SetExpiry(ctx context.Context, id string, expiresAt *time.Time) error {
+	defer r.Invalidate(id)
+	return r.VideoRepository.SetExpiry(ctx, id, expiresAt)
+}
+
+// CleanupExpiredTranscriptions drops the deleted videos from the cache too
+func (r *CachedRepository) CleanupExpiredTranscriptions(ctx context.Context, cutoff, now time.Time, limit int) (int64, error) {
+	n, err := r.VideoRepository.CleanupExpiredTranscriptions(ctx, cutoff, now, limit)
+	if n == 0 {
+		return n, err
+	}
+	if r.texts != nil {
+		r.texts.clear()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for elem := r.order.Front(); elem != nil; {
+		next := elem.Next()
+		video := elem.Value.(*cacheEntry).video
+		if !video.Pinned && video.Status != models.StatusProcessing && video.ExpiredAt(cutoff, now) {
+			r.remove(elem)
+		}
+		elem = next
+	}
+	return n, err
+}
+
+func (r *CachedRepository) Find(ctx context.Context, id string) (*models.Video, error) {
+	if video, ok := r.get(id); ok {
+		return video, nil
+	}
+
+	started := r.startRead()
+	video, err := r.VideoRepository.Find(ctx, id)
+	r.finishRead(video, started)
+	if err != nil {
+		return nil, err
+	}
+	return copyVideo(video), nil
+}
+
+func (r *CachedRepository) FindByURL(ctx context.Context, url string) (*models.Video, error) {
+	r.mu.Lock()
+	id, ok := r.byURL[url]
+	r.mu.Unlock()
+	if ok {
+		if video, ok := r.get(id); ok {
+			return video, nil
+		}
+	}
+
+	started := r.startRead()
+	video, err := r.VideoRepository.FindByURL(ctx, url)
+	r.finishRead(video, started)
+	if err != nil {
+		return nil, err
+	}
+	return copyVideo(video), nil
+}
+
+func (r *CachedRepository) FindByMedia(ctx context.Context, platform models.Platform, mediaID string) (*models.Video, error) {
+	started := r.startRead()
+	video, err := r.VideoRepository.FindByMedia(ctx, platform, mediaID)
+	r.finishRead(video, started)
+	if err != nil {
+		return nil, err
+	}
+	return copyVideo(video), nil
+}
+
+// TranscriptionReader serves cached transcripts from memory and streams the
+// rest from the underlying repository, caching them as they are read
+func (r *CachedRepository) TranscriptionReader(ctx context.Context, id string, source models.Source) (io.Reader, error) {
+	if text, ok := r.cachedText(id, source); ok {
+		return strings.NewReader(text), nil
+	}
+
+	text, err := r.VideoRepository.TranscriptionReader(ctx, id, source)
+	if err != nil || r.texts == nil {
+		return text, err
+	}
+	return r.texts.reader(id, source, text), nil
+}
+
+// EncodedTranscriptionReader serves cached transcripts as plain text and
+// passes the rest through to the wrapped repository. Only plain streams are
+// cached; clients accepting compressed ones are already cheap to serve.
+func (r *CachedRepository) EncodedTranscriptionReader(ctx context.Context, id string, source models.Source, accepted []string) (io.Reader, string, error) {
+	encoded, ok := r.VideoRepository.(EncodedTranscriptReader)
+	if !ok {
+		text, err := r.TranscriptionReader(ctx, id, source)
+		return text, "", err
+	}
+
+	if text, ok := r.cachedText(id, source); ok {
+		return strings.NewReader(text), "", nil
+	}
+
+	text, encoding, err := encoded.EncodedTranscriptionReader(ctx, id, source, accepted)
+	if err != nil || encoding != "" || r.texts == nil {
+		return text, encoding, err
+	}
+	return r.texts.reader(id, source, text), "", nil
+}
+
+// cachedText returns a transcript from a cached video or the transcript
+// cache
+func (r *CachedRepository) cachedText(id string, source models.Source) (string, bool) {
+	if video, ok := r.get(id); ok {
+		from := source
+		if from == "" {
+			from = video.Source
+		}
+		if text, ok := video.TranscriptionFrom(from); ok {
+			return text, true
+		}
+	}
+	if r.texts != nil {
+		return r.texts.get(id, source)
+	}
+	return "", false
+}
+
+// StorageUsage passes through to the wrapped repository's backend
+func (r *CachedRepository) StorageUsage() (used, limit int64, ok bool) {
+	if meter, ok := r.VideoRepository.(StorageMeter); ok {
+		return meter.StorageUsage()
+	}
+	return 0, 0, false
+}
+
+// StorageFull passes through to the wrapped repository's backend
+func (r *CachedRepository) StorageFull() bool {
+	meter, ok := r.VideoRepository.(StorageMeter)
+	return ok && meter.StorageFull()
+}
+
+// Invalidate drops a video and its transcripts from the caches
+func (r *CachedRepository) Invalidate(id string) {
+	if r.texts != nil {
+		r.texts.invalidate(id)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if elem, ok := r.entries[id]; ok {
+		r.remove(elem)
+	}
+	if r.reading > 0 {
+		r.epoch++
+		r.invalidated[id] = r.epoch
+	}
+}
+
+// startRead is called before reading a video from the wrapped repository,
+// and returns what finishRead needs to tell whether it is still current
+func (r *CachedRepository) startRead() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reading++
+	return r.epoch
+}
+
+// finishRead caches a video read since started unless it was invalidated
+// in the meantime. video is nil when the read failed.
+func (r *CachedRepository) finishRead(video *models.Video, started uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reading--
+	if video != nil && r.invalidated[video.ID] <= started {
+		r.put(video)
+	}
+	if r.reading == 0 {
+		clear(r.invalidated)
+	}
+}
+
+func (r *CachedRepository) get(id string) (*models.Video, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elem, ok := r.entries[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		r.remove(elem)
+		return nil, false
+	}
+	r.order.MoveToFront(elem)
+	return copyVideo(entry.video), true
+}
+
+// put caches a copy of video. Callers must hold r.mu.
+func (r *CachedRepository) put(video *models.Video) {
+	cached := copyVideo(video)
+	size := videoSize(cached)
+
+	if elem, ok := r.entries[cached.ID]; ok {
+		r.remove(elem)
+	}
+	if r.maxItems == 0 || (r.maxBytes > 0 && size > r.maxBytes) {
+		return
+	}
+	entry := &cacheEntry{video: cached}
+	if r.ttl > 0 {
+		// Another process may be running the job, and only this one's
+		// saves drop entries
+		if !cached.IsCompleted() && !cached.IsFailed() {
+			return
+		}
+		entry.expires = time.Now().Add(r.ttl)
+	}
+
+	r.entries[cached.ID] = r.order.PushFront(entry)
+	r.byURL[cached.URL] = cached.ID
+	r.bytes += size
+
+	// Pinned videos are never evicted, so they can take the cache past its
+	// limits
+	for elem := r.order.Back(); elem != nil && r.full(); {
+		prev := elem.Prev()
+		if !elem.Value.(*cacheEntry).video.Pinned {
+			r.remove(elem)
+		}
+		elem = prev
+	}
+}
+
+// full reports whether the cache is over its limits. Callers must hold r.mu.
+func (r *CachedRepository) full() bool {
+	return r.order.Len() > r.maxItems || (r.maxBytes > 0 && r.bytes > r.maxBytes)
+}
+
+// remove drops an entry. Callers must hold r.mu.
+func (r *CachedRepository) remove(elem *list.Element) {
+	video := r.order.Remove(elem).(*cacheEntry).video
+	delete(r.entries, video.ID)
+	if r.byURL[video.URL] == video.ID {
+		delete(r.byURL, video.URL)
+	}
+	r.bytes -= videoSize(video)
+}
+
+func copyVideo(video *models.Video) *models.Video {
+	c := *video
+	if video.CaptionWER != nil {
+		wer := *video.CaptionWER
+		c.CaptionWER = &wer
+	}
+	if video.Segments != nil {
+		c.Segments = append([]models.Segment(nil), video.Segments...)
+	}
+	if video.SecondarySegments != nil {
+		c.SecondarySegments = append([]models.Segment(nil), video.SecondarySegments...)
+	}
+	if video.Chapters != nil {
+		c.Chapters = append([]models.Chapter(nil), video.Chapters...)
+	}
+	if video.Enrichment != nil {
+		c.Enrichment = video.Enrichment.Clone()
+	}
+	if video.CaptionComparison != nil {
+		comparison := *video.CaptionComparison
+		c.CaptionComparison = &comparison
+	}
+	return &c
+}
+
+// videoSize approximates the memory held by a cached video
+func videoSize(video *models.Video) int {
+	size := len(video.Transcription) + len(video.SecondaryTranscription) + len(video.FailureLog)
+	for _, segments := range [][]models.Segment{video.Segments, video.SecondarySegments} {
+		for _, seg := range segments {
+			size += len(seg.Text) + 16 // two float64 timestamps
+		}
+	}
+	for _, ch := range video.Chapters {
+		size += len(ch.Title) + 16
+	}
+	return size
+}
@@ -0,0 +1,141 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"yt-text/models"
+	"yt-text/repository"
+	"yt-text/repository/memory"
+)
+
+// pausingRepository holds a Find that has read its video until released, so
+// a test can write the video in between
+type pausingRepository struct {
+	repository.VideoRepository
+
+	read    chan struct{} // receives once a paused Find has read the video
+	release chan struct{}
+}
+
+func (r *pausingRepository) Find(ctx context.Context, id string) (*models.Video, error) {
+	video, err := r.VideoRepository.Find(ctx, id)
+	if r.read != nil {
+		r.read <- struct{}{}
+		<-r.release
+	}
+	return video, err
+}
+
+func TestCacheSkipsReadsRacingSave(t *testing.T) {
+	ctx := context.Background()
+	inner := &pausingRepository{VideoRepository: memory.NewRepository()}
+	cache := repository.NewCachedRepository(inner, 10, 0, 0, 0)
+
+	now := time.Now()
+	video := &models.Video{ID: "racy", URL: "https://example.com/racy", Status: models.StatusProcessing, CreatedAt: now, UpdatedAt: now}
+	if err := cache.Save(ctx, video); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// A poller reads the video while it's processing, and the job finishes
+	// before the poller's read is cached
+	inner.read, inner.release = make(chan struct{}), make(chan struct{})
+	polled := make(chan *models.Video)
+	go func() {
+		v, err := cache.Find(ctx, "racy")
+		if err != nil {
+			t.Errorf("Find: %v", err)
+		}
+		polled <- v
+	}()
+	<-inner.read
+	inner.read = nil
+
+	video.Status = models.StatusCompleted
+	video.Transcription = "done"
+	if err := cache.Save(ctx, video); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	close(inner.release)
+	if v := <-polled; v != nil && v.Status != models.StatusProcessing {
+		t.Fatalf("racing Find = %s, want the processing row it read", v.Status)
+	}
+
+	got, err := cache.Find(ctx, "racy")
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if got.Status != models.StatusCompleted {
+		t.Errorf("Find after the race = %s, want completed", got.Status)
+	}
+}
+
+func TestCacheKeepsPinnedVideos(t *testing.T) {
+	ctx := context.Background()
+	inner := memory.NewRepository()
+	cache := repository.NewCachedRepository(inner, 1, 0, 0, 0)
+
+	now := time.Now()
+	for _, id := range []string{"pinned", "other"} {
+		video := &models.Video{ID: id, URL: "https://example.com/" + id, Status: models.StatusCompleted, CreatedAt: now, UpdatedAt: now}
+		if err := cache.Save(ctx, video); err != nil {
+			t.Fatalf("Save(%s): %v", id, err)
+		}
+	}
+	if err := cache.SetPinned(ctx, "pinned", true); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+	for _, id := range []string{"pinned", "other"} {
+		if _, err := cache.Find(ctx, id); err != nil {
+			t.Fatalf("Find(%s): %v", id, err)
+		}
+	}
+
+	// Changed behind the cache's back, so only a cached copy has the old
+	// title
+	for _, id := range []string{"pinned", "other"} {
+		video, err := inner.Find(ctx, id)
+		if err != nil {
+			t.Fatalf("Find(%s): %v", id, err)
+		}
+		video.Title = "changed"
+		if err := inner.Save(ctx, video); err != nil {
+			t.Fatalf("Save(%s): %v", id, err)
+		}
+	}
+
+	if video, _ := cache.Find(ctx, "pinned"); video.Title == "changed" {
+		t.Error("pinned video was evicted for a newer one")
+	}
+}
+
+func TestSharedCacheSkipsUnfinishedVideos(t *testing.T) {
+	ctx := context.Background()
+	inner := memory.NewRepository()
+	cache := repository.NewCachedRepository(inner, 10, 0, 0, time.Minute)
+
+	now := time.Now()
+	video := &models.Video{ID: "shared", URL: "https://example.com/shared", Status: models.StatusProcessing, CreatedAt: now, UpdatedAt: now}
+	if err := cache.Save(ctx, video); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := cache.Find(ctx, "shared"); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	// Another process finishes the job, which this cache never hears about
+	video.Status = models.StatusCompleted
+	video.Transcription = "done"
+	if err := inner.Save(ctx, video); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := cache.Find(ctx, "shared")
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if got.Status != models.StatusCompleted {
+		t.Errorf("Find = %s, want completed", got.Status)
+	}
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encodings transcript objects can be compressed with. They double as the
+// HTTP content codings, so a stored object can be sent to clients as it is.
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// Valid UTF-8 text can start with neither magic number (0x8B and 0xB5 are
+// continuation bytes), so objects are self-describing: compressed and plain
+// ones live side by side, and changing the setting never breaks old reads.
+var (
+	gzipMagic = []byte{0x1F, 0x8B}
+	zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+)
+
+// zstdEncoder is safe for concurrent EncodeAll calls
+var zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+
+// compress encodes data for storage. Any encoding other than gzip or zstd
+// stores it as it is.
+func compress(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case EncodingZstd:
+		return zstdEncoder.EncodeAll(data, make([]byte, 0, len(data)/4)), nil
+	case EncodingGzip:
+		var b bytes.Buffer
+		w := gzip.NewWriter(&b)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	default:
+		return data, nil
+	}
+}
+
+// encodingOf returns the encoding a stored object starts with, "" for plain
+// text
+func encodingOf(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, zstdMagic):
+		return EncodingZstd
+	case bytes.HasPrefix(head, gzipMagic):
+		return EncodingGzip
+	default:
+		return ""
+	}
+}
+
+// storedBody is an object's body with the reader that decodes it; closing
+// it closes the object too
+type storedBody struct {
+	io.Reader
+	close func() error
+}
+
+func (b *storedBody) Close() error {
+	return b.close()
+}
+
+// sniff returns body unread, along with the encoding it was stored with
+func sniff(body io.ReadCloser) (io.ReadCloser, string) {
+	buffered := bufio.NewReader(body)
+	// A short or failed peek is plain text, or an error the caller reads
+	head, _ := buffered.Peek(len(zstdMagic))
+	return &storedBody{Reader: buffered, close: body.Close}, encodingOf(head)
+}
+
+// decompress returns a reader of body's text, whatever it was stored with
+func decompress(body io.ReadCloser) (io.ReadCloser, error) {
+	body, encoding := sniff(body)
+
+	switch encoding {
+	case EncodingZstd:
+		dec, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("failed to decompress transcript: %w", err)
+		}
+		return &storedBody{Reader: dec, close: func() error {
+			dec.Close()
+			return body.Close()
+		}}, nil
+	case EncodingGzip:
+		dec, err := gzip.NewReader(body)
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("failed to decompress transcript: %w", err)
+		}
+		return &storedBody{Reader: dec, close: body.Close}, nil
+	default:
+		return body, nil
+	}
+}
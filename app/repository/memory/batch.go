@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// CreateBatch stores a batch and its items
+func (r *Repository) CreateBatch(ctx context.Context, batch *models.Batch) error {
+	const op = "MemoryRepository.CreateBatch"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.batches[batch.ID]; ok {
+		return errors.Internal(op, nil, "Failed to save batch")
+	}
+	r.batches[batch.ID] = copyBatch(batch)
+	return nil
+}
+
+// UpdateBatchItem records the outcome of submitting one item
+func (r *Repository) UpdateBatchItem(ctx context.Context, batchID string, position int, item models.BatchItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if batch, ok := r.batches[batchID]; ok && position >= 0 && position < len(batch.Items) {
+		batch.Items[position].VideoID = item.VideoID
+		batch.Items[position].Error = item.Error
+	}
+	return nil
+}
+
+// FindBatch loads a batch with its items and their videos' current status
+func (r *Repository) FindBatch(ctx context.Context, id string) (*models.Batch, error) {
+	const op = "MemoryRepository.FindBatch"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.batches[id]
+	if !ok {
+		return nil, errors.NotFound(op, nil, "Batch not found")
+	}
+
+	batch := copyBatch(stored)
+	for i, item := range batch.Items {
+		if video, ok := r.videos[item.VideoID]; ok {
+			batch.Items[i].VideoStatus = video.Status
+		}
+	}
+	return batch, nil
+}
+
+// copyBatch returns a copy of batch without its items' video statuses
+func copyBatch(batch *models.Batch) *models.Batch {
+	c := *batch
+	c.Items = make([]models.BatchItem, len(batch.Items))
+	for i, item := range batch.Items {
+		c.Items[i] = models.BatchItem{URL: item.URL, VideoID: item.VideoID, Error: item.Error}
+	}
+	return &c
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"yt-text/models"
+)
+
+// SaveEmbeddings replaces a video's embeddings from model
+func (r *Repository) SaveEmbeddings(ctx context.Context, videoID, model string, embeddings []models.Embedding) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var kept []models.Embedding
+	for _, e := range r.embeddings[videoID] {
+		if e.Model != model {
+			kept = append(kept, e)
+		}
+	}
+	for _, e := range embeddings {
+		e.VideoID, e.Model = videoID, model
+		e.Vector = append([]float32(nil), e.Vector...)
+		kept = append(kept, e)
+	}
+	r.embeddings[videoID] = kept
+	return nil
+}
+
+// ScanEmbeddings calls fn with copies taken under the lock, so fn may use
+// the repository
+func (r *Repository) ScanEmbeddings(ctx context.Context, model string, fn func(models.Embedding) error) error {
+	r.mu.RLock()
+	var embeddings []models.Embedding
+	for _, list := range r.embeddings {
+		for _, e := range list {
+			if e.Model == model {
+				embeddings = append(embeddings, e)
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, e := range embeddings {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) ListUnembedded(ctx context.Context, model string, limit int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []*models.Video
+	for _, v := range r.videos {
+		if !v.IsCompleted() || len(v.Segments) == 0 || r.embedded(v, model) {
+			continue
+		}
+		videos = append(videos, v)
+	}
+	sort.Slice(videos, func(i, j int) bool { return videos[i].UpdatedAt.After(videos[j].UpdatedAt) })
+	if len(videos) > limit {
+		videos = videos[:limit]
+	}
+
+	ids := make([]string, len(videos))
+	for i, v := range videos {
+		ids[i] = v.ID
+	}
+	return ids, nil
+}
+
+// embedded reports whether model embedded the video since it last changed.
+// Callers must hold r.mu.
+func (r *Repository) embedded(v *models.Video, model string) bool {
+	for _, e := range r.embeddings[v.ID] {
+		if e.Model == model && !e.CreatedAt.Before(v.UpdatedAt) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"context"
+	"yt-text/models"
+)
+
+// AddJobEvent appends an entry to a video's job history
+func (r *Repository) AddJobEvent(ctx context.Context, event models.JobEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[event.VideoID] = append(r.events[event.VideoID], event)
+	return nil
+}
+
+// ListJobEvents returns a video's job history, oldest first
+func (r *Repository) ListJobEvents(ctx context.Context, videoID string) ([]models.JobEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.JobEvent{}, r.events[videoID]...), nil
+}
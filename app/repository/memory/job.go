@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"yt-text/models"
+)
+
+// SaveJob stores a job, or updates it when the video already has one.
+// Claims are only changed by the claim methods.
+func (r *Repository) SaveJob(ctx context.Context, job models.QueuedJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.jobs[job.VideoID]; ok {
+		job.QueuedAt = existing.QueuedAt
+		job.ClaimedBy, job.ClaimedAt = existing.ClaimedBy, existing.ClaimedAt
+	}
+	r.jobs[job.VideoID] = copyJob(job)
+	return nil
+}
+
+func (r *Repository) DeleteJob(ctx context.Context, videoID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.jobs, videoID)
+	return nil
+}
+
+// ListJobs returns stored jobs in the order they should be resumed:
+// prioritized jobs first, then oldest first
+func (r *Repository) ListJobs(ctx context.Context) ([]models.QueuedJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := r.queuedJobs()
+	for i := range jobs {
+		jobs[i] = unclaimed(jobs[i])
+	}
+	return jobs, nil
+}
+
+// queuedJobs returns copies of the stored jobs in the order they run
+func (r *Repository) queuedJobs() []models.QueuedJob {
+	var jobs []models.QueuedJob
+	for _, job := range r.jobs {
+		jobs = append(jobs, copyJob(job))
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Priority != jobs[j].Priority {
+			return jobs[i].Priority
+		}
+		return jobs[i].QueuedAt.Before(jobs[j].QueuedAt)
+	})
+	return jobs
+}
+
+// ClaimJob hands the next waiting job to owner, along with jobs whose claim
+// has not been renewed since expiredBefore. Jobs scheduled for later are
+// left until then. It returns nil when no job is waiting.
+func (r *Repository) ClaimJob(ctx context.Context, owner string, expiredBefore time.Time) (*models.QueuedJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, job := range r.queuedJobs() {
+		if job.ClaimedBy != "" && !job.ClaimedAt.Before(expiredBefore) {
+			continue
+		}
+		if !job.NotBefore.IsZero() && job.NotBefore.After(now) {
+			continue
+		}
+
+		stored := r.jobs[job.VideoID]
+		stored.ClaimedBy, stored.ClaimedAt = owner, now
+		r.jobs[job.VideoID] = stored
+
+		job = unclaimed(job)
+		job.ClaimedBy, job.ClaimedAt = owner, now
+		return &job, nil
+	}
+	return nil, nil
+}
+
+// RenewJobClaims extends the claims held by owner
+func (r *Repository) RenewJobClaims(ctx context.Context, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, job := range r.jobs {
+		if job.ClaimedBy == owner {
+			job.ClaimedAt = now
+			r.jobs[id] = job
+		}
+	}
+	return nil
+}
+
+// ReleaseJobClaims gives up the claims held by owner
+func (r *Repository) ReleaseJobClaims(ctx context.Context, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, job := range r.jobs {
+		if job.ClaimedBy == owner {
+			job.ClaimedBy, job.ClaimedAt = "", time.Time{}
+			r.jobs[id] = job
+		}
+	}
+	return nil
+}
+
+// unclaimed returns a job as the SQL stores read it back, without its
+// claim or schedule
+func unclaimed(job models.QueuedJob) models.QueuedJob {
+	job.ClaimedBy, job.ClaimedAt, job.NotBefore = "", time.Time{}, time.Time{}
+	return job
+}
+
+// copyJob returns a copy of job sharing nothing with it
+func copyJob(job models.QueuedJob) models.QueuedJob {
+	if job.Whisper.Temperature != nil {
+		temperature := *job.Whisper.Temperature
+		job.Whisper.Temperature = &temperature
+	}
+	if job.Captions.Languages != nil {
+		job.Captions.Languages = append([]string(nil), job.Captions.Languages...)
+	}
+	return job
+}
@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"testing"
+	"yt-text/repository"
+	"yt-text/repository/repotest"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.VideoRepository {
+		repo := NewRepository()
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	})
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// SaveCorrection saves a video and new revisions of its transcript under
+// one lock, checking every revision number before storing anything
+func (r *Repository) SaveCorrection(ctx context.Context, video *models.Video, revisions []*models.Revision) error {
+	const op = "MemoryRepository.SaveCorrection"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, revision := range revisions {
+		for _, rev := range r.revisions[revision.VideoID] {
+			if rev.Number == revision.Number {
+				return errors.Conflict(op, nil, "Transcript was corrected by another request")
+			}
+		}
+	}
+	if err := r.save(op, video); err != nil {
+		return err
+	}
+
+	for _, revision := range revisions {
+		stored := *revision
+		stored.Segments = append([]models.Segment(nil), revision.Segments...)
+		list := append(r.revisions[revision.VideoID], stored)
+		sort.Slice(list, func(i, j int) bool { return list[i].Number < list[j].Number })
+		r.revisions[revision.VideoID] = list
+	}
+	return nil
+}
+
+func (r *Repository) ListRevisions(ctx context.Context, videoID string) ([]models.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revisions := make([]models.Revision, len(r.revisions[videoID]))
+	for i, rev := range r.revisions[videoID] {
+		rev.Text = ""
+		rev.Segments = nil
+		revisions[i] = rev
+	}
+	return revisions, nil
+}
+
+func (r *Repository) FindRevision(ctx context.Context, videoID string, number int) (*models.Revision, error) {
+	const op = "MemoryRepository.FindRevision"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rev := range r.revisions[videoID] {
+		if rev.Number == number {
+			rev.Segments = append([]models.Segment(nil), rev.Segments...)
+			return &rev, nil
+		}
+	}
+	return nil, errors.NotFound(op, nil, "Revision not found")
+}
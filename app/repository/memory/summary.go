@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// SaveSummary stores a video's summary, replacing any earlier one of the
+// same style and model
+func (r *Repository) SaveSummary(ctx context.Context, summary *models.Summary) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	summaries := r.summaries[summary.VideoID]
+	for i, s := range summaries {
+		if s.Style == summary.Style && s.Model == summary.Model {
+			summaries[i] = *summary
+			return nil
+		}
+	}
+	r.summaries[summary.VideoID] = append(summaries, *summary)
+	return nil
+}
+
+func (r *Repository) FindSummary(ctx context.Context, videoID string, style models.SummaryStyle, model string) (*models.Summary, error) {
+	const op = "MemoryRepository.FindSummary"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *models.Summary
+	for _, s := range r.summaries[videoID] {
+		if s.Style != style || (model != "" && s.Model != model) {
+			continue
+		}
+		if found == nil || s.CreatedAt.After(found.CreatedAt) {
+			found = &s
+		}
+	}
+	if found == nil {
+		return nil, errors.NotFound(op, nil, "Summary not found")
+	}
+	return found, nil
+}
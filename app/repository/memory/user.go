@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// SaveUser stores a user by provider and subject, keeping the ID of one
+// that signed in before
+func (r *Repository) SaveUser(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Provider == user.Provider && existing.Subject == user.Subject {
+			user.ID = existing.ID
+			user.CreatedAt = existing.CreatedAt
+			break
+		}
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *Repository) FindUser(ctx context.Context, id string) (*models.User, error) {
+	const op = "MemoryRepository.FindUser"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.NotFound(op, nil, "User not found")
+	}
+	return &user, nil
+}
+
+// AddUserVideo adds a video to a user's list, or moves it to the top
+func (r *Repository) AddUserVideo(ctx context.Context, userID, videoID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.userVideos[userID] == nil {
+		r.userVideos[userID] = make(map[string]time.Time)
+	}
+	r.userVideos[userID][videoID] = at
+	return nil
+}
+
+func (r *Repository) HasUserVideo(ctx context.Context, userID, videoID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.userVideos[userID][videoID]
+	return ok, nil
+}
+
+func (r *Repository) RemoveUserVideo(ctx context.Context, userID, videoID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.userVideos[userID], videoID)
+	for _, videos := range r.userVideos {
+		if _, ok := videos[videoID]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,553 @@
+// Package memory keeps videos in process memory. Nothing survives a
+// restart, so it suits demos and tests rather than deployments.
+package memory
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/repository"
+)
+
+// Repository implements repository.VideoRepository with maps guarded by one
+// lock. It behaves like the SQL stores: Save checks the video's version and
+// never changes its pin, expiry or creation time, reads record when a video
+// was last read, and deleting a video deletes its job, history, summaries,
+// embeddings and revisions. Callers always get copies.
+type Repository struct {
+	mu           sync.RWMutex
+	videos       map[string]*models.Video
+	jobs         map[string]models.QueuedJob // by video ID
+	events       map[string][]models.JobEvent
+	summaries    map[string][]models.Summary // by video ID
+	batches      map[string]*models.Batch
+	lastAccessed map[string]time.Time
+
+	users      map[string]models.User
+	userVideos map[string]map[string]time.Time // user ID -> video ID -> when submitted
+
+	embeddings map[string][]models.Embedding // by video ID
+	revisions  map[string][]models.Revision  // by video ID, in order
+}
+
+func NewRepository() *Repository {
+	return &Repository{
+		videos:       make(map[string]*models.Video),
+		jobs:         make(map[string]models.QueuedJob),
+		events:       make(map[string][]models.JobEvent),
+		summaries:    make(map[string][]models.Summary),
+		batches:      make(map[string]*models.Batch),
+		lastAccessed: make(map[string]time.Time),
+		users:        make(map[string]models.User),
+		userVideos:   make(map[string]map[string]time.Time),
+		embeddings:   make(map[string][]models.Embedding),
+		revisions:    make(map[string][]models.Revision),
+	}
+}
+
+// PingContext always succeeds, so the repository can stand in for a
+// database connection in health checks
+func (r *Repository) PingContext(ctx context.Context) error {
+	return nil
+}
+
+// Close drops every stored video
+func (r *Repository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clear(r.videos)
+	clear(r.jobs)
+	clear(r.events)
+	clear(r.summaries)
+	clear(r.batches)
+	clear(r.lastAccessed)
+	clear(r.users)
+	clear(r.userVideos)
+	clear(r.embeddings)
+	clear(r.revisions)
+	return nil
+}
+
+func (r *Repository) Save(ctx context.Context, video *models.Video) error {
+	const op = "MemoryRepository.Save"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.save(op, video)
+}
+
+// save stores a copy of video as its next version, under the write lock
+func (r *Repository) save(op string, video *models.Video) error {
+	stored := copyVideo(video)
+	stored.Version++
+	if existing, ok := r.videos[video.ID]; ok {
+		if existing.Version != video.Version {
+			return errors.Conflict(op, repository.ErrConflict, "Video was changed by another request")
+		}
+		stored.URL = existing.URL
+		stored.Pinned = existing.Pinned
+		stored.ExpiresAt = existing.ExpiresAt
+		stored.CreatedAt = existing.CreatedAt
+	}
+	r.videos[video.ID] = stored
+	video.Version++
+	return nil
+}
+
+func (r *Repository) Find(ctx context.Context, id string) (*models.Video, error) {
+	const op = "MemoryRepository.Find"
+
+	return r.findWhere(op, func(v *models.Video) bool { return v.ID == id })
+}
+
+func (r *Repository) FindByURL(ctx context.Context, url string) (*models.Video, error) {
+	const op = "MemoryRepository.FindByURL"
+
+	return r.findWhere(op, func(v *models.Video) bool { return v.URL == url })
+}
+
+// FindByMedia prefers a completed video, then the most recently updated
+func (r *Repository) FindByMedia(ctx context.Context, platform models.Platform, mediaID string) (*models.Video, error) {
+	const op = "MemoryRepository.FindByMedia"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var best *models.Video
+	for _, v := range r.videos {
+		if v.Platform != platform || v.MediaID != mediaID {
+			continue
+		}
+		if best == nil || betterMatch(v, best) {
+			best = v
+		}
+	}
+	if best == nil {
+		return nil, errors.NotFound(op, nil, "Video not found")
+	}
+
+	r.lastAccessed[best.ID] = time.Now()
+	return copyVideo(best), nil
+}
+
+func betterMatch(v, than *models.Video) bool {
+	vDone, thanDone := v.Status == models.StatusCompleted, than.Status == models.StatusCompleted
+	if vDone != thanDone {
+		return vDone
+	}
+	return v.UpdatedAt.After(than.UpdatedAt)
+}
+
+// findWhere returns the first video matching and records the read
+func (r *Repository) findWhere(op string, match func(*models.Video) bool) (*models.Video, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range r.videos {
+		if match(v) {
+			r.lastAccessed[v.ID] = time.Now()
+			return copyVideo(v), nil
+		}
+	}
+	return nil, errors.NotFound(op, nil, "Video not found")
+}
+
+// Delete removes a video along with its job, history and summary. Batch
+// items keep the video's ID and report an empty status.
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	const op = "MemoryRepository.Delete"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.videos[id]; !ok {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	r.delete(id)
+	return nil
+}
+
+func (r *Repository) delete(id string) {
+	delete(r.videos, id)
+	delete(r.jobs, id)
+	delete(r.events, id)
+	delete(r.summaries, id)
+	delete(r.embeddings, id)
+	delete(r.revisions, id)
+	delete(r.lastAccessed, id)
+	for _, videos := range r.userVideos {
+		delete(videos, id)
+	}
+}
+
+// TranscriptionReader returns a stored transcript; an empty source selects
+// the primary one
+func (r *Repository) TranscriptionReader(ctx context.Context, id string, source models.Source) (io.Reader, error) {
+	const op = "MemoryRepository.TranscriptionReader"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, ok := r.videos[id]
+	if !ok {
+		return nil, errors.NotFound(op, nil, "Video not found")
+	}
+	switch source {
+	case "", v.Source:
+		return strings.NewReader(v.Transcription), nil
+	case v.SecondarySource:
+		return strings.NewReader(v.SecondaryTranscription), nil
+	default:
+		return nil, errors.NotFound(op, nil, "No transcript stored from source "+string(source))
+	}
+}
+
+// FindRecentByStatus returns the most recently updated videos with a status
+func (r *Repository) FindRecentByStatus(ctx context.Context, status models.Status, limit int) ([]*models.Video, error) {
+	videos := r.selectVideos(func(v *models.Video) bool { return v.Status == status }, func(a, b *models.Video) bool {
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+	return page(videos, 0, limit), nil
+}
+
+// List returns a page of videos matching filter, newest first, along with
+// the total number of matching videos
+func (r *Repository) List(ctx context.Context, filter repository.VideoFilter) ([]*models.Video, int, error) {
+	// Both run under the read lock; a user's videos sort by when they
+	// submitted them
+	submitted := func(v *models.Video) time.Time { return v.CreatedAt }
+	if filter.UserID != "" {
+		submitted = func(v *models.Video) time.Time { return r.userVideos[filter.UserID][v.ID] }
+	}
+	videos := r.selectVideos(func(v *models.Video) bool {
+		if filter.UserID != "" {
+			if _, ok := r.userVideos[filter.UserID][v.ID]; !ok {
+				return false
+			}
+		}
+		if filter.Keyword != "" && (v.Enrichment == nil || !v.Enrichment.HasKeyword(filter.Keyword)) {
+			return false
+		}
+		if filter.Entity != "" && (v.Enrichment == nil || !v.Enrichment.HasEntity(filter.Entity)) {
+			return false
+		}
+		return (filter.Status == "" || v.Status == filter.Status) && (filter.Language == "" || v.Language == filter.Language)
+	}, func(a, b *models.Video) bool {
+		if at, bt := submitted(a), submitted(b); !at.Equal(bt) {
+			return at.After(bt)
+		}
+		return a.ID < b.ID
+	})
+	return page(videos, filter.Offset, filter.Limit), len(videos), nil
+}
+
+// selectVideos returns copies of the videos matching, in the order less
+// gives
+func (r *Repository) selectVideos(match func(*models.Video) bool, less func(a, b *models.Video) bool) []*models.Video {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []*models.Video
+	for _, v := range r.videos {
+		if match(v) {
+			videos = append(videos, copyVideo(v))
+		}
+	}
+	sort.Slice(videos, func(i, j int) bool { return less(videos[i], videos[j]) })
+	return videos
+}
+
+// page slices out up to limit videos from offset
+func page(videos []*models.Video, offset, limit int) []*models.Video {
+	if offset >= len(videos) {
+		return []*models.Video{}
+	}
+	videos = videos[offset:]
+	if limit < len(videos) {
+		videos = videos[:max(limit, 0)]
+	}
+	return videos
+}
+
+// DatabaseSize estimates the bytes held, counting the text of each video
+func (r *Repository) DatabaseSize(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var size int64
+	for _, v := range r.videos {
+		size += int64(len(v.ID) + len(v.URL) + len(v.Title) + len(v.Transcription) + len(v.SecondaryTranscription) + len(v.FailureLog))
+		for _, segments := range [][]models.Segment{v.Segments, v.SecondarySegments} {
+			for _, seg := range segments {
+				size += int64(len(seg.Text)) + 16 // two float64 timestamps
+			}
+		}
+	}
+	return size, nil
+}
+
+func (r *Repository) SetPinned(ctx context.Context, id string, pinned bool) error {
+	const op = "MemoryRepository.SetPinned"
+
+	return r.update(op, id, func(v *models.Video) { v.Pinned = pinned })
+}
+
+func (r *Repository) SetExpiry(ctx context.Context, id string, expiresAt *time.Time) error {
+	const op = "MemoryRepository.SetExpiry"
+
+	return r.update(op, id, func(v *models.Video) { v.ExpiresAt = copyTime(expiresAt) })
+}
+
+// update changes a stored video in place
+func (r *Repository) update(op, id string, change func(*models.Video)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.videos[id]
+	if !ok {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	change(v)
+	return nil
+}
+
+// CleanupExpiredTranscriptions deletes up to limit finished, unpinned
+// videos past their own expiry at now, or without one and last updated
+// before cutoff, the longest expired first
+func (r *Repository) CleanupExpiredTranscriptions(ctx context.Context, cutoff, now time.Time, limit int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expired := r.expired(cutoff, now)
+	sort.Slice(expired, func(i, j int) bool { return expiry(expired[i]).Before(expiry(expired[j])) })
+	if limit >= 0 && limit < len(expired) {
+		expired = expired[:limit]
+	}
+	for _, v := range expired {
+		r.delete(v.ID)
+	}
+	return int64(len(expired)), nil
+}
+
+// CountExpiredTranscriptions returns how many videos
+// CleanupExpiredTranscriptions would remove without a limit
+func (r *Repository) CountExpiredTranscriptions(ctx context.Context, cutoff, now time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int64(len(r.expired(cutoff, now))), nil
+}
+
+// expired returns the videos cleanup may remove. Pinned videos and jobs
+// still in progress are never cleaned up.
+func (r *Repository) expired(cutoff, now time.Time) []*models.Video {
+	var videos []*models.Video
+	for _, v := range r.videos {
+		if !v.Pinned && v.Status != models.StatusProcessing && v.ExpiredAt(cutoff, now) {
+			videos = append(videos, v)
+		}
+	}
+	return videos
+}
+
+func expiry(v *models.Video) time.Time {
+	if v.ExpiresAt != nil {
+		return *v.ExpiresAt
+	}
+	return v.UpdatedAt
+}
+
+// CaptionQuality aggregates caption word error rates
+func (r *Repository) CaptionQuality(ctx context.Context, threshold float64) (*models.CaptionQuality, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	quality := &models.CaptionQuality{Threshold: threshold}
+	var sum float64
+	for _, v := range r.videos {
+		if wer := v.CaptionWER; wer != nil {
+			quality.Compared++
+			sum += *wer
+			if *wer <= threshold {
+				quality.GoodEnough++
+			}
+		}
+	}
+	if quality.Compared > 0 {
+		quality.MeanWER = sum / float64(quality.Compared)
+	}
+	return quality, nil
+}
+
+// VideoStats totals the stored videos by status, source and Whisper model
+func (r *Repository) VideoStats(ctx context.Context) (*models.VideoStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := &models.VideoStats{
+		ByStatus: make(map[models.Status]int),
+		BySource: make(map[models.Source]int),
+		ByModel:  []models.ModelStats{},
+	}
+	type modelTotals struct {
+		videos, timed int
+		seconds       float64
+	}
+	byModel := make(map[string]*modelTotals)
+	for _, v := range r.videos {
+		stats.ByStatus[v.Status]++
+		if v.Source != "" {
+			stats.BySource[v.Source]++
+		}
+		stats.TranscriptBytes += int64(len(v.Transcription) + len(v.SecondaryTranscription))
+
+		if v.Model == "" {
+			continue
+		}
+		totals, ok := byModel[v.Model]
+		if !ok {
+			totals = &modelTotals{}
+			byModel[v.Model] = totals
+		}
+		totals.videos++
+		if v.Status == models.StatusCompleted && v.Timings != nil {
+			totals.timed++
+			totals.seconds += v.Timings.TotalSeconds
+		}
+	}
+
+	for model, totals := range byModel {
+		entry := models.ModelStats{Model: model, Videos: totals.videos}
+		if totals.timed > 0 {
+			entry.AvgProcessingSeconds = totals.seconds / float64(totals.timed)
+		}
+		stats.ByModel = append(stats.ByModel, entry)
+	}
+	sort.Slice(stats.ByModel, func(i, j int) bool { return stats.ByModel[i].Model < stats.ByModel[j].Model })
+	return stats, nil
+}
+
+// FindColdTranscripts returns completed videos not read since accessedBefore
+// that hold an inline transcript of at least minSize bytes
+func (r *Repository) FindColdTranscripts(ctx context.Context, accessedBefore time.Time, minSize, limit int) ([]*models.Video, error) {
+	videos := r.byLastRead(func(v *models.Video, lastRead time.Time) bool {
+		return v.Status == models.StatusCompleted && lastRead.Before(accessedBefore) &&
+			(v.TranscriptKey == "" && len(v.Transcription) >= minSize ||
+				v.SecondaryTranscriptKey == "" && len(v.SecondaryTranscription) >= minSize)
+	})
+	return page(videos, 0, limit), nil
+}
+
+// FindStoredTranscripts returns unpinned videos with a transcript kept in
+// the storage backend, least recently read first
+func (r *Repository) FindStoredTranscripts(ctx context.Context, limit int) ([]*models.Video, error) {
+	videos := r.byLastRead(func(v *models.Video, _ time.Time) bool {
+		return !v.Pinned && (v.TranscriptKey != "" || v.SecondaryTranscriptKey != "")
+	})
+	return page(videos, 0, limit), nil
+}
+
+// byLastRead returns the videos matching, least recently read first.
+// Videos never read count from their last update.
+func (r *Repository) byLastRead(match func(v *models.Video, lastRead time.Time) bool) []*models.Video {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lastRead := func(v *models.Video) time.Time {
+		if t, ok := r.lastAccessed[v.ID]; ok {
+			return t
+		}
+		return v.UpdatedAt
+	}
+
+	var videos []*models.Video
+	for _, v := range r.videos {
+		if match(v, lastRead(v)) {
+			videos = append(videos, v)
+		}
+	}
+	sort.Slice(videos, func(i, j int) bool { return lastRead(videos[i]).Before(lastRead(videos[j])) })
+	for i, v := range videos {
+		videos[i] = copyVideo(v)
+	}
+	return videos
+}
+
+// TranscriptKeys returns the keys of every transcript kept in the storage
+// backend
+func (r *Repository) TranscriptKeys(ctx context.Context) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var keys []string
+	for _, v := range r.videos {
+		for _, key := range []string{v.TranscriptKey, v.SecondaryTranscriptKey} {
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// SetTranscriptKeys records where a video's transcripts were moved and
+// clears the inline copies
+func (r *Repository) SetTranscriptKeys(ctx context.Context, id, primaryKey, secondaryKey string) error {
+	const op = "MemoryRepository.SetTranscriptKeys"
+
+	return r.update(op, id, func(v *models.Video) {
+		if primaryKey != "" {
+			v.Transcription, v.TranscriptKey = "", primaryKey
+		}
+		if secondaryKey != "" {
+			v.SecondaryTranscription, v.SecondaryTranscriptKey = "", secondaryKey
+		}
+	})
+}
+
+// copyVideo returns a copy sharing nothing with video
+func copyVideo(video *models.Video) *models.Video {
+	c := *video
+	if video.CaptionWER != nil {
+		wer := *video.CaptionWER
+		c.CaptionWER = &wer
+	}
+	c.ExpiresAt = copyTime(video.ExpiresAt)
+	c.RetryAt = copyTime(video.RetryAt)
+	if video.Timings != nil {
+		timings := *video.Timings
+		c.Timings = &timings
+	}
+	if video.Segments != nil {
+		c.Segments = append([]models.Segment(nil), video.Segments...)
+	}
+	if video.SecondarySegments != nil {
+		c.SecondarySegments = append([]models.Segment(nil), video.SecondarySegments...)
+	}
+	if video.Chapters != nil {
+		c.Chapters = append([]models.Chapter(nil), video.Chapters...)
+	}
+	if video.Enrichment != nil {
+		c.Enrichment = video.Enrichment.Clone()
+	}
+	if video.CaptionComparison != nil {
+		comparison := *video.CaptionComparison
+		c.CaptionComparison = &comparison
+	}
+	return &c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
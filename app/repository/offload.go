@@ -0,0 +1,542 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/storage"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// tierInterval is how often cold transcripts are looked for
+	tierInterval = time.Hour
+
+	// tierBatchSize bounds the videos moved per pass
+	tierBatchSize = 100
+
+	// evictBatchSize is how many videos are looked up at a time when making
+	// room in a full backend
+	evictBatchSize = 20
+
+	// orphanGrace spares recent objects from the reaper, since an object is
+	// written before the row referring to it is saved
+	orphanGrace = time.Hour
+
+	// transcriptPrefix is where transcriptKey puts every transcript
+	transcriptPrefix = "transcripts"
+)
+
+// StorageMeter is implemented by repositories keeping transcripts in a
+// backend that counts its bytes
+type StorageMeter interface {
+	StorageUsage() (used, limit int64, ok bool)
+	StorageFull() bool
+}
+
+// EncodedTranscriptReader is implemented by repositories that can hand out
+// a transcript still compressed, sparing a decompression for clients that
+// accept the encoding it was stored with
+type EncodedTranscriptReader interface {
+	// EncodedTranscriptionReader streams the transcript as stored when its
+	// encoding is one of accepted and returns the encoding; otherwise it
+	// streams plain text and returns ""
+	EncodedTranscriptionReader(ctx context.Context, id string, source models.Source, accepted []string) (io.Reader, string, error)
+}
+
+// OffloadConfig decides which transcripts leave the database
+type OffloadConfig struct {
+	MinSize int // Transcripts smaller than this many bytes always stay inline
+
+	// TierAfter keeps new transcripts in the database and moves them to the
+	// backend once they haven't been read for this long. Zero moves them
+	// when they are saved.
+	TierAfter time.Duration
+
+	// Evict makes room in a backend at its size limit by deleting the least
+	// recently read videos, as retention cleanup would once they expire.
+	// Otherwise transcripts that don't fit stay in the database.
+	Evict bool
+
+	// Compression is EncodingGzip or EncodingZstd to compress transcripts
+	// before they are written; anything else writes plain text
+	Compression string
+}
+
+// OffloadRepository wraps a VideoRepository so large transcripts are kept in
+// a storage backend instead of the database. The row keeps the object's key;
+// reads put the transcript back, so callers see complete videos. List leaves
+// offloaded transcripts empty, since listings never include transcripts.
+type OffloadRepository struct {
+	VideoRepository
+
+	backend storage.Backend
+	config  OffloadConfig
+}
+
+// NewOffloadRepository offloads large transcripts to backend. A nil backend
+// keeps everything in the database.
+func NewOffloadRepository(inner VideoRepository, backend storage.Backend, cfg OffloadConfig) VideoRepository {
+	if backend == nil {
+		return inner
+	}
+	return &OffloadRepository{VideoRepository: inner, backend: backend, config: cfg}
+}
+
+// transcriptKey names the object holding a video's transcript from source.
+// Keys depend on the source rather than the primary/secondary slot, so a
+// transcript moving between slots keeps its object.
+func transcriptKey(id string, source models.Source) string {
+	if source == "" {
+		source = "transcript"
+	}
+	return fmt.Sprintf("%s/%s/%s.txt", transcriptPrefix, id, source)
+}
+
+func (r *OffloadRepository) Save(ctx context.Context, video *models.Video) error {
+	const op = "OffloadRepository.Save"
+
+	stored, err := r.offloadVideo(ctx, video)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to store transcript")
+	}
+	if err := r.VideoRepository.Save(ctx, stored); err != nil {
+		return err
+	}
+	video.Version = stored.Version
+	return nil
+}
+
+// SaveCorrection offloads the corrected transcript as Save does. Revisions
+// are always kept in the database.
+func (r *OffloadRepository) SaveCorrection(ctx context.Context, video *models.Video, revisions []*models.Revision) error {
+	const op = "OffloadRepository.SaveCorrection"
+
+	stored, err := r.offloadVideo(ctx, video)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to store transcript")
+	}
+	if err := r.VideoRepository.SaveCorrection(ctx, stored, revisions); err != nil {
+		return err
+	}
+	video.Version = stored.Version
+	return nil
+}
+
+// offloadVideo returns the copy of video to save, its large transcripts
+// replaced by their keys in the backend
+func (r *OffloadRepository) offloadVideo(ctx context.Context, video *models.Video) (*models.Video, error) {
+	stored := *video
+	var err error
+	stored.Transcription, stored.TranscriptKey, err = r.offload(ctx, video, video.Source, video.Transcription)
+	if err != nil {
+		return nil, err
+	}
+	stored.SecondaryTranscription, stored.SecondaryTranscriptKey, err = r.offload(ctx, video, video.SecondarySource, video.SecondaryTranscription)
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// offload writes a large transcript to the backend and returns what the row
+// should hold instead. With tiering, only transcripts already moved out stay
+// out; the rest wait until they go cold.
+func (r *OffloadRepository) offload(ctx context.Context, video *models.Video, source models.Source, text string) (string, string, error) {
+	if text == "" || len(text) < r.config.MinSize {
+		return text, "", nil
+	}
+
+	key := transcriptKey(video.ID, source)
+	if r.config.TierAfter > 0 && key != video.TranscriptKey && key != video.SecondaryTranscriptKey {
+		return text, "", nil
+	}
+
+	data, err := compress(r.config.Compression, []byte(text))
+	if err != nil {
+		return text, "", err
+	}
+	stored, err := r.put(ctx, key, data, video.ID)
+	if err != nil || !stored {
+		return text, "", err
+	}
+	return "", key, nil
+}
+
+// put writes an object, first making room for it when the backend is full
+// and eviction is on. It reports false when the object doesn't fit; the
+// video with ID keep is never evicted for it.
+func (r *OffloadRepository) put(ctx context.Context, key string, data []byte, keep string) (bool, error) {
+	err := r.backend.Put(ctx, key, data)
+	if stderrors.Is(err, storage.ErrQuotaExceeded) && r.config.Evict {
+		if err := r.evict(ctx, int64(len(data)), keep); err != nil {
+			log.Error().Err(err).Msg("Failed to make room in transcript storage")
+		}
+		err = r.backend.Put(ctx, key, data)
+	}
+	if stderrors.Is(err, storage.ErrQuotaExceeded) {
+		log.Warn().Err(err).Str("video_id", keep).Msg("Transcript storage is full, keeping the transcript in the database")
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// evict deletes the least recently read unpinned videos with stored
+// transcripts until need more bytes fit under the backend's limit
+func (r *OffloadRepository) evict(ctx context.Context, need int64, keep string) error {
+	meter, ok := r.backend.(storage.Metered)
+	if !ok {
+		return nil
+	}
+
+	for {
+		videos, err := r.VideoRepository.FindStoredTranscripts(ctx, evictBatchSize)
+		if err != nil {
+			return err
+		}
+
+		evicted := 0
+		for _, video := range videos {
+			if used, limit := meter.Usage(); limit <= 0 || used+need <= limit {
+				return nil
+			}
+			if video.ID == keep {
+				continue
+			}
+			if err := r.Delete(ctx, video.ID); err != nil {
+				return err
+			}
+			log.Info().Str("video_id", video.ID).Msg("Deleted least recently read video to make room in transcript storage")
+			evicted++
+		}
+		if evicted == 0 {
+			return nil
+		}
+	}
+}
+
+// StorageUsage returns the bytes the backend holds and its limit, 0 when
+// unlimited. It reports false for backends that don't keep count.
+func (r *OffloadRepository) StorageUsage() (used, limit int64, ok bool) {
+	meter, ok := r.backend.(storage.Metered)
+	if !ok {
+		return 0, 0, false
+	}
+	used, limit = meter.Usage()
+	return used, limit, true
+}
+
+// StorageFull reports whether the backend is at its limit with nothing
+// evicted to make room, so new transcripts would stay in the database
+func (r *OffloadRepository) StorageFull() bool {
+	used, limit, ok := r.StorageUsage()
+	return ok && !r.config.Evict && limit > 0 && used >= limit
+}
+
+// RunTiering moves cold transcripts to the backend until ctx is done. It
+// returns immediately when transcripts are offloaded on save.
+func (r *OffloadRepository) RunTiering(ctx context.Context) {
+	if r.config.TierAfter <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(tierInterval)
+	defer ticker.Stop()
+
+	for {
+		moved, err := r.TierColdTranscripts(ctx, time.Now().Add(-r.config.TierAfter))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to tier cold transcripts")
+		} else if moved > 0 {
+			log.Info().Int("videos", moved).Msg("Moved cold transcripts to storage")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// TierColdTranscripts moves the inline transcripts of videos not read since
+// accessedBefore to the backend and returns how many videos were moved. An
+// object is only referenced once the backend reports it complete.
+func (r *OffloadRepository) TierColdTranscripts(ctx context.Context, accessedBefore time.Time) (int, error) {
+	videos, err := r.VideoRepository.FindColdTranscripts(ctx, accessedBefore, r.config.MinSize, tierBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, video := range videos {
+		primaryKey, err := r.tier(ctx, video.ID, video.Source, video.Transcription, video.TranscriptKey)
+		if err != nil {
+			return moved, err
+		}
+		secondaryKey, err := r.tier(ctx, video.ID, video.SecondarySource, video.SecondaryTranscription, video.SecondaryTranscriptKey)
+		if err != nil {
+			return moved, err
+		}
+
+		if primaryKey == "" && secondaryKey == "" {
+			continue // Nothing fit in the backend
+		}
+		if err := r.VideoRepository.SetTranscriptKeys(ctx, video.ID, primaryKey, secondaryKey); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// tier uploads one inline transcript and returns its key, or "" when it
+// stays where it is
+func (r *OffloadRepository) tier(ctx context.Context, id string, source models.Source, text, currentKey string) (string, error) {
+	if currentKey != "" || text == "" || len(text) < r.config.MinSize {
+		return "", nil
+	}
+
+	key := transcriptKey(id, source)
+	data, err := compress(r.config.Compression, []byte(text))
+	if err != nil {
+		return "", err
+	}
+	stored, err := r.put(ctx, key, data, id)
+	if err != nil || !stored {
+		return "", err
+	}
+
+	info, err := r.backend.Stat(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if info.Size != int64(len(data)) {
+		return "", fmt.Errorf("stored %s has %d bytes, want %d", key, info.Size, len(data))
+	}
+	return key, nil
+}
+
+// RunReaper deletes orphaned transcript objects every interval until ctx is
+// done. A dry run only logs how many there are.
+func (r *OffloadRepository) RunReaper(ctx context.Context, interval time.Duration, dryRun bool) {
+	if _, ok := r.backend.(storage.Lister); !ok {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		reaped, err := r.ReapOrphans(ctx, dryRun)
+		switch {
+		case err != nil:
+			log.Error().Err(err).Msg("Failed to reap orphaned transcripts")
+		case dryRun:
+			log.Info().Int("objects", reaped).Msg("Dry run: would delete orphaned transcripts")
+		case reaped > 0:
+			log.Info().Int("objects", reaped).Msg("Deleted orphaned transcripts")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReapOrphans deletes the transcript objects no video refers to, left behind
+// when a video's row was removed but its objects weren't, and returns how
+// many there were. A dry run deletes nothing. Videos referring to objects
+// that are gone are logged; their transcripts can't be recovered. Backends
+// that can't list their objects are left alone.
+func (r *OffloadRepository) ReapOrphans(ctx context.Context, dryRun bool) (int, error) {
+	lister, ok := r.backend.(storage.Lister)
+	if !ok {
+		return 0, nil
+	}
+
+	// Objects written after the keys are read may belong to rows saved
+	// since; the grace period keeps them
+	keys, err := r.VideoRepository.TranscriptKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	found := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		found[key] = false
+	}
+	writtenBefore := time.Now().Add(-orphanGrace)
+
+	reaped := 0
+	err = lister.List(ctx, transcriptPrefix, func(object storage.ObjectInfo) error {
+		if _, ok := found[object.Key]; ok {
+			found[object.Key] = true
+			return nil
+		}
+		if object.ModTime.After(writtenBefore) {
+			return nil
+		}
+		if !dryRun {
+			if err := r.backend.Delete(ctx, object.Key); err != nil {
+				return err
+			}
+		}
+		reaped++
+		return nil
+	})
+	if err != nil {
+		return reaped, err
+	}
+
+	missing := 0
+	for _, ok := range found {
+		if !ok {
+			missing++
+		}
+	}
+	if missing > 0 {
+		log.Warn().Int("objects", missing).Msg("Videos refer to transcript objects that are missing")
+	}
+	return reaped, nil
+}
+
+func (r *OffloadRepository) Find(ctx context.Context, id string) (*models.Video, error) {
+	video, err := r.VideoRepository.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.load(ctx, video)
+}
+
+func (r *OffloadRepository) FindByURL(ctx context.Context, url string) (*models.Video, error) {
+	video, err := r.VideoRepository.FindByURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return r.load(ctx, video)
+}
+
+func (r *OffloadRepository) FindByMedia(ctx context.Context, platform models.Platform, mediaID string) (*models.Video, error) {
+	video, err := r.VideoRepository.FindByMedia(ctx, platform, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return r.load(ctx, video)
+}
+
+func (r *OffloadRepository) FindRecentByStatus(ctx context.Context, status models.Status, limit int) ([]*models.Video, error) {
+	videos, err := r.VideoRepository.FindRecentByStatus(ctx, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, video := range videos {
+		if _, err := r.load(ctx, video); err != nil {
+			return nil, err
+		}
+	}
+	return videos, nil
+}
+
+// load reads a video's offloaded transcripts back from the backend
+func (r *OffloadRepository) load(ctx context.Context, video *models.Video) (*models.Video, error) {
+	const op = "OffloadRepository.load"
+
+	var err error
+	if video.TranscriptKey != "" {
+		if video.Transcription, err = r.read(ctx, video.TranscriptKey); err != nil {
+			return nil, errors.Internal(op, err, "Failed to read transcript")
+		}
+	}
+	if video.SecondaryTranscriptKey != "" {
+		if video.SecondaryTranscription, err = r.read(ctx, video.SecondaryTranscriptKey); err != nil {
+			return nil, errors.Internal(op, err, "Failed to read transcript")
+		}
+	}
+	return video, nil
+}
+
+func (r *OffloadRepository) read(ctx context.Context, key string) (string, error) {
+	body, err := r.backend.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	body, err = decompress(body)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	var b strings.Builder
+	if _, err := io.Copy(&b, body); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// TranscriptionReader streams offloaded transcripts straight from the
+// backend, decompressing them on the way
+func (r *OffloadRepository) TranscriptionReader(ctx context.Context, id string, source models.Source) (io.Reader, error) {
+	text, _, err := r.EncodedTranscriptionReader(ctx, id, source, nil)
+	return text, err
+}
+
+func (r *OffloadRepository) EncodedTranscriptionReader(ctx context.Context, id string, source models.Source, accepted []string) (io.Reader, string, error) {
+	const op = "OffloadRepository.EncodedTranscriptionReader"
+
+	video, err := r.VideoRepository.Find(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := video.TranscriptKey
+	switch source {
+	case "", video.Source:
+	case video.SecondarySource:
+		key = video.SecondaryTranscriptKey
+	}
+	if key == "" {
+		text, err := r.VideoRepository.TranscriptionReader(ctx, id, source)
+		return text, "", err
+	}
+
+	body, err := r.backend.Get(ctx, key)
+	if err != nil {
+		return nil, "", errors.Internal(op, err, "Failed to read transcript")
+	}
+	body, encoding := sniff(body)
+	if encoding != "" && slices.Contains(accepted, encoding) {
+		return body, encoding, nil
+	}
+	if body, err = decompress(body); err != nil {
+		return nil, "", errors.Internal(op, err, "Failed to read transcript")
+	}
+	return body, "", nil
+}
+
+// Delete removes the video's objects once its row is gone. Objects that
+// can't be removed are left behind rather than failing the delete.
+func (r *OffloadRepository) Delete(ctx context.Context, id string) error {
+	video, err := r.VideoRepository.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.VideoRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	for _, key := range []string{video.TranscriptKey, video.SecondaryTranscriptKey} {
+		if key != "" {
+			_ = r.backend.Delete(ctx, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"yt-text/repository"
+
+	_ "github.com/lib/pq" // Registers the "postgres" driver
+)
+
+// driverName is the database/sql driver used to connect, registered by
+// github.com/lib/pq
+const driverName = "postgres"
+
+type DB struct {
+	*sql.DB
+	statements *statements
+
+	// Read times waiting for the next flush
+	accessed  repository.AccessLog
+	stopFlush context.CancelFunc
+	flushDone chan struct{}
+}
+
+type statements struct {
+	insert   *sql.Stmt
+	get      *sql.Stmt
+	getByURL *sql.Stmt
+}
+
+// PoolConfig bounds the connection pool shared by the app's goroutines.
+// Zero values keep database/sql's defaults.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+func NewDB(url string, pool PoolConfig) (*DB, error) {
+	db, err := sql.Open(driverName, url)
+	if err != nil {
+		return nil, err
+	}
+
+	// Configure the pool
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// Bring the schema up to date
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Prepare statements
+	stmts, err := prepareStatements(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	flushCtx, stopFlush := context.WithCancel(context.Background())
+	d := &DB{
+		DB:         db,
+		statements: stmts,
+		stopFlush:  stopFlush,
+		flushDone:  make(chan struct{}),
+	}
+	go d.runAccessFlush(flushCtx)
+	return d, nil
+}
+
+func prepareStatements(db *sql.DB) (*statements, error) {
+	insert, err := db.Prepare(insertQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	get, err := db.Prepare(getQuery)
+	if err != nil {
+		insert.Close()
+		return nil, err
+	}
+
+	getByURL, err := db.Prepare(getByURLQuery)
+	if err != nil {
+		insert.Close()
+		get.Close()
+		return nil, err
+	}
+
+	return &statements{
+		insert:   insert,
+		get:      get,
+		getByURL: getByURL,
+	}, nil
+}
+
+// Close writes the pending read times and closes the connections
+func (db *DB) Close() error {
+	db.stopFlush()
+	<-db.flushDone
+
+	if db.statements != nil {
+		db.statements.insert.Close()
+		db.statements.get.Close()
+		db.statements.getByURL.Close()
+	}
+	return db.DB.Close()
+}
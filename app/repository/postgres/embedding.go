@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/repository"
+)
+
+// SaveEmbeddings replaces a video's embeddings from model
+func (r *Repository) SaveEmbeddings(ctx context.Context, videoID, model string, embeddings []models.Embedding) error {
+	const op = "PostgresRepository.SaveEmbeddings"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save embeddings")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, deleteEmbeddingsQuery, videoID, model); err != nil {
+		return errors.Internal(op, err, "Failed to save embeddings")
+	}
+
+	stmt, err := tx.PrepareContext(ctx, insertEmbeddingQuery)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save embeddings")
+	}
+	defer stmt.Close()
+
+	for _, e := range embeddings {
+		_, err := stmt.ExecContext(ctx, videoID, model, e.Passage, e.Source,
+			e.Start, e.End, e.Text, repository.EncodeVector(e.Vector), e.CreatedAt)
+		if err != nil {
+			return errors.Internal(op, err, "Failed to save embedding")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Internal(op, err, "Failed to save embeddings")
+	}
+	return nil
+}
+
+// ScanEmbeddings reads embeddings one row at a time, so searching doesn't
+// hold every vector in memory at once
+func (r *Repository) ScanEmbeddings(ctx context.Context, model string, fn func(models.Embedding) error) error {
+	const op = "PostgresRepository.ScanEmbeddings"
+
+	rows, err := r.db.QueryContext(ctx, scanEmbeddingsQuery, model)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to query embeddings")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.Embedding
+		var source string
+		var vector []byte
+		err := rows.Scan(&e.VideoID, &e.Model, &e.Passage, &source, &e.Start, &e.End, &e.Text, &vector, &e.CreatedAt)
+		if err != nil {
+			return errors.Internal(op, err, "Failed to scan embedding")
+		}
+		e.Source = models.Source(source)
+		if e.Vector, err = repository.DecodeVector(vector); err != nil {
+			return errors.Internal(op, err, "Failed to decode embedding")
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Internal(op, err, "Failed to query embeddings")
+	}
+	return nil
+}
+
+// ListUnembedded returns the completed transcripts model has no current
+// embeddings of, most recently changed first
+func (r *Repository) ListUnembedded(ctx context.Context, model string, limit int) ([]string, error) {
+	const op = "PostgresRepository.ListUnembedded"
+
+	rows, err := r.db.QueryContext(ctx, listUnembeddedQuery, model, limit)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos to embed")
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Internal(op, err, "Failed to scan video ID")
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos to embed")
+	}
+	return ids, nil
+}
@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrationLockID is the advisory lock held while migrating, so replicas
+// starting together apply each migration once
+const migrationLockID = 0x79742d74657874 // "yt-text"
+
+// migrations are applied in order and recorded in schema_migrations. Append
+// new ones; never change one that has already shipped.
+var migrations = []string{
+	`CREATE TABLE videos (
+        id TEXT PRIMARY KEY,
+        url TEXT UNIQUE NOT NULL,
+        title TEXT NOT NULL DEFAULT '',
+        language TEXT NOT NULL DEFAULT '',
+        status TEXT NOT NULL,
+        pinned BOOLEAN NOT NULL DEFAULT FALSE,
+        transcription TEXT NOT NULL DEFAULT '',
+        source TEXT NOT NULL DEFAULT '',
+        segments TEXT NOT NULL DEFAULT '',
+        secondary_transcription TEXT NOT NULL DEFAULT '',
+        secondary_source TEXT NOT NULL DEFAULT '',
+        secondary_segments TEXT NOT NULL DEFAULT '',
+        error TEXT NOT NULL DEFAULT '',
+        error_code TEXT NOT NULL DEFAULT '',
+        failure_log TEXT NOT NULL DEFAULT '',
+        caption_wer DOUBLE PRECISION,
+        created_at TIMESTAMPTZ NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL
+    );
+    CREATE INDEX idx_videos_status ON videos(status);
+    CREATE INDEX idx_videos_created_at ON videos(created_at DESC, id);
+
+    CREATE TABLE batches (
+        id TEXT PRIMARY KEY,
+        url TEXT NOT NULL DEFAULT '',
+        title TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMPTZ NOT NULL
+    );
+    CREATE TABLE batch_items (
+        batch_id TEXT NOT NULL REFERENCES batches(id) ON DELETE CASCADE,
+        position INTEGER NOT NULL,
+        url TEXT NOT NULL,
+        video_id TEXT NOT NULL DEFAULT '',
+        error TEXT NOT NULL DEFAULT '',
+        PRIMARY KEY (batch_id, position)
+    )`,
+	`ALTER TABLE videos
+        ADD COLUMN transcript_key TEXT NOT NULL DEFAULT '',
+        ADD COLUMN secondary_transcript_key TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE videos ADD COLUMN last_accessed TIMESTAMPTZ`,
+	`CREATE TABLE jobs (
+        video_id TEXT PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+        source TEXT NOT NULL DEFAULT '',
+        priority BOOLEAN NOT NULL DEFAULT FALSE,
+        request_id TEXT NOT NULL DEFAULT '',
+        queued_at TIMESTAMPTZ NOT NULL
+    )`,
+	`ALTER TABLE jobs ADD COLUMN model TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE jobs ADD COLUMN whisper_options TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE summaries (
+        video_id TEXT PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+        summary TEXT NOT NULL,
+        source TEXT NOT NULL DEFAULT '',
+        model TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMPTZ NOT NULL
+    )`,
+	`ALTER TABLE videos ADD COLUMN chapters TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE videos
+        ADD COLUMN platform TEXT NOT NULL DEFAULT '',
+        ADD COLUMN media_id TEXT NOT NULL DEFAULT '',
+        ADD COLUMN uploader TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE jobs
+        ADD COLUMN claimed_by TEXT NOT NULL DEFAULT '',
+        ADD COLUMN claimed_at TIMESTAMPTZ`,
+	`ALTER TABLE videos ADD COLUMN expires_at TIMESTAMPTZ`,
+	`CREATE TABLE job_events (
+        id BIGSERIAL PRIMARY KEY,
+        video_id TEXT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+        event TEXT NOT NULL,
+        detail TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMPTZ NOT NULL
+    );
+    CREATE INDEX idx_job_events_video_id ON job_events(video_id, id)`,
+	`CREATE INDEX idx_videos_media ON videos(platform, media_id)`,
+	`ALTER TABLE videos ADD COLUMN retry_at TIMESTAMPTZ;
+    ALTER TABLE jobs ADD COLUMN not_before TIMESTAMPTZ`,
+	`ALTER TABLE videos ADD COLUMN caption_language TEXT NOT NULL DEFAULT '';
+    ALTER TABLE videos ADD COLUMN caption_kind TEXT NOT NULL DEFAULT '';
+    ALTER TABLE jobs ADD COLUMN caption_options TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE jobs ADD COLUMN look_up BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE videos ADD COLUMN timings TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE videos ADD COLUMN model TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE videos ADD COLUMN version BIGINT NOT NULL DEFAULT 0`,
+	`CREATE TABLE users (
+        id TEXT PRIMARY KEY,
+        provider TEXT NOT NULL,
+        subject TEXT NOT NULL,
+        email TEXT NOT NULL DEFAULT '',
+        name TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMPTZ NOT NULL,
+        last_login TIMESTAMPTZ NOT NULL,
+        UNIQUE (provider, subject)
+    );
+    CREATE TABLE user_videos (
+        user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        video_id TEXT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+        created_at TIMESTAMPTZ NOT NULL,
+        PRIMARY KEY (user_id, video_id)
+    );
+    CREATE INDEX idx_user_videos_created_at ON user_videos(user_id, created_at DESC);
+    CREATE INDEX idx_user_videos_video_id ON user_videos(video_id)`,
+	`ALTER TABLE summaries ADD COLUMN style TEXT NOT NULL DEFAULT 'abstract';
+    ALTER TABLE summaries DROP CONSTRAINT summaries_pkey;
+    ALTER TABLE summaries ADD PRIMARY KEY (video_id, style, model)`,
+	`CREATE TABLE embeddings (
+        video_id TEXT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+        model TEXT NOT NULL,
+        passage INTEGER NOT NULL,
+        source TEXT NOT NULL DEFAULT '',
+        start_time DOUBLE PRECISION NOT NULL,
+        end_time DOUBLE PRECISION NOT NULL,
+        text TEXT NOT NULL,
+        vector BYTEA NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL,
+        PRIMARY KEY (video_id, model, passage)
+    );
+    CREATE INDEX idx_embeddings_model ON embeddings(model)`,
+	`ALTER TABLE videos ADD COLUMN enrichment TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE videos ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+    CREATE TABLE revisions (
+        video_id TEXT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+        revision INTEGER NOT NULL,
+        source TEXT NOT NULL DEFAULT '',
+        text TEXT NOT NULL,
+        segments TEXT NOT NULL DEFAULT '',
+        note TEXT NOT NULL DEFAULT '',
+        user_id TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMPTZ NOT NULL,
+        PRIMARY KEY (video_id, revision)
+    )`,
+	`ALTER TABLE videos ADD COLUMN caption_comparison TEXT NOT NULL DEFAULT ''`,
+}
+
+// migrate applies pending migrations in one transaction
+func migrate(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to lock schema: %w", err)
+	}
+
+	_, err = tx.Exec(`
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+        )
+    `)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		if _, err := tx.Exec(migrations[version-1]); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
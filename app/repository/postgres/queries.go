@@ -0,0 +1,387 @@
+package postgres
+
+const (
+	videoColumns = `
+        id, url, title, language, platform, media_id, uploader, status, pinned, expires_at, transcription, source,
+        segments,
+        secondary_transcription, secondary_source, secondary_segments, chapters,
+        transcript_key, secondary_transcript_key,
+        error, error_code, failure_log, caption_wer, caption_language, caption_kind, retry_at, timings, model,
+        enrichment, revision, caption_comparison, created_at, updated_at, version
+    `
+
+	// Like the SQLite store, an upsert never changes pinned or expires_at,
+	// and only updates a row still at the version before the saved one
+	insertQuery = `
+        INSERT INTO videos (` + videoColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+            $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34)
+        ON CONFLICT (id) DO UPDATE SET
+            title = excluded.title,
+            language = excluded.language,
+            platform = excluded.platform,
+            media_id = excluded.media_id,
+            uploader = excluded.uploader,
+            status = excluded.status,
+            transcription = excluded.transcription,
+            source = excluded.source,
+            segments = excluded.segments,
+            secondary_transcription = excluded.secondary_transcription,
+            secondary_source = excluded.secondary_source,
+            secondary_segments = excluded.secondary_segments,
+            chapters = excluded.chapters,
+            transcript_key = excluded.transcript_key,
+            secondary_transcript_key = excluded.secondary_transcript_key,
+            error = excluded.error,
+            error_code = excluded.error_code,
+            failure_log = excluded.failure_log,
+            caption_wer = excluded.caption_wer,
+            caption_language = excluded.caption_language,
+            caption_kind = excluded.caption_kind,
+            retry_at = excluded.retry_at,
+            timings = excluded.timings,
+            model = excluded.model,
+            enrichment = excluded.enrichment,
+            revision = excluded.revision,
+            caption_comparison = excluded.caption_comparison,
+            updated_at = excluded.updated_at,
+            version = excluded.version
+        WHERE videos.version = excluded.version - 1
+    `
+
+	getQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos WHERE id = $1
+    `
+
+	getByURLQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos WHERE url = $1
+    `
+
+	getByMediaQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos WHERE platform = $1 AND media_id = $2
+        ORDER BY status = 'completed' DESC, updated_at DESC
+        LIMIT 1
+    `
+
+	captionQualityQuery = `
+        SELECT COUNT(*), COALESCE(AVG(caption_wer), 0),
+            COUNT(*) FILTER (WHERE caption_wer <= $1)
+        FROM videos WHERE caption_wer IS NOT NULL
+    `
+
+	statusCountsQuery = `
+        SELECT status, COUNT(*) FROM videos GROUP BY status
+    `
+
+	sourceCountsQuery = `
+        SELECT source, COUNT(*) FROM videos WHERE source != '' GROUP BY source
+    `
+
+	modelStatsQuery = `
+        SELECT model, COUNT(*),
+            COALESCE(AVG((timings::jsonb->>'total_seconds')::float8)
+                FILTER (WHERE status = 'completed' AND timings != ''), 0)
+        FROM videos WHERE model != ''
+        GROUP BY model ORDER BY model
+    `
+
+	transcriptBytesQuery = `
+        SELECT COALESCE(SUM(octet_length(transcription) + octet_length(secondary_transcription)), 0)
+        FROM videos
+    `
+
+	transcriptSourcesQuery = `
+        SELECT source, secondary_source FROM videos WHERE id = $1
+    `
+
+	primaryChunkQuery = `
+        SELECT substr(transcription, $1, $2) FROM videos WHERE id = $3
+    `
+
+	secondaryChunkQuery = `
+        SELECT substr(secondary_transcription, $1, $2) FROM videos WHERE id = $3
+    `
+
+	recentByStatusQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos WHERE status = $1
+        ORDER BY updated_at DESC LIMIT $2
+    `
+
+	// Empty filter values match every row. Keywords are stored lowercase;
+	// entity names match in any case.
+	listFilter = `
+        WHERE ($1::text = '' OR status = $1) AND ($2::text = '' OR language = $2)
+            AND ($3::text = '' OR id IN (SELECT video_id FROM user_videos WHERE user_id = $3))
+            AND ($4::text = '' OR NULLIF(enrichment, '')::jsonb -> 'keywords' ? lower($4))
+            AND ($5::text = '' OR EXISTS (
+                SELECT 1 FROM jsonb_array_elements(NULLIF(enrichment, '')::jsonb -> 'entities') entity
+                WHERE lower(entity ->> 'name') = lower($5)))
+    `
+
+	listQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos ` + listFilter + `
+        ORDER BY created_at DESC, id LIMIT $6 OFFSET $7
+    `
+
+	// A user's videos are listed by when they last submitted them
+	userListQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos ` + listFilter + `
+        ORDER BY (SELECT created_at FROM user_videos WHERE user_id = $3 AND video_id = videos.id) DESC, id
+        LIMIT $6 OFFSET $7
+    `
+
+	countQuery = `
+        SELECT COUNT(*) FROM videos ` + listFilter
+
+	// Claims are only changed by the claim queries below
+	saveJobQuery = `
+        INSERT INTO jobs (video_id, source, model, whisper_options, priority, request_id, queued_at,
+            claimed_by, claimed_at, not_before, caption_options, look_up)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        ON CONFLICT(video_id) DO UPDATE SET
+            source = excluded.source,
+            model = excluded.model,
+            whisper_options = excluded.whisper_options,
+            priority = excluded.priority,
+            request_id = excluded.request_id,
+            not_before = excluded.not_before,
+            caption_options = excluded.caption_options,
+            look_up = excluded.look_up
+    `
+
+	deleteJobQuery = `
+        DELETE FROM jobs WHERE video_id = $1
+    `
+
+	listJobsQuery = `
+        SELECT video_id, source, model, whisper_options, caption_options, priority, request_id, queued_at, look_up
+        FROM jobs ORDER BY priority DESC, queued_at
+    `
+
+	// SKIP LOCKED lets concurrent workers claim different jobs instead of
+	// queueing behind each other's row locks
+	claimJobQuery = `
+        UPDATE jobs SET claimed_by = $1, claimed_at = $2
+        WHERE video_id = (
+            SELECT video_id FROM jobs
+            WHERE (claimed_by = '' OR claimed_at < $3) AND (not_before IS NULL OR not_before <= $2)
+            ORDER BY priority DESC, queued_at LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING video_id, source, model, whisper_options, caption_options, priority, request_id, queued_at, look_up
+    `
+
+	renewJobClaimsQuery = `
+        UPDATE jobs SET claimed_at = $1 WHERE claimed_by = $2
+    `
+
+	releaseJobClaimsQuery = `
+        UPDATE jobs SET claimed_by = '', claimed_at = NULL WHERE claimed_by = $1
+    `
+
+	insertJobEventQuery = `
+        INSERT INTO job_events (video_id, event, detail, created_at)
+        VALUES ($1, $2, $3, $4)
+    `
+
+	listJobEventsQuery = `
+        SELECT video_id, event, detail, created_at
+        FROM job_events WHERE video_id = $1
+        ORDER BY id
+    `
+
+	// One summary is kept per style and model
+	saveSummaryQuery = `
+        INSERT INTO summaries (video_id, style, model, summary, source, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT(video_id, style, model) DO UPDATE SET
+            summary = excluded.summary,
+            source = excluded.source,
+            created_at = excluded.created_at
+    `
+
+	// An empty model picks the newest summary of the style
+	getSummaryQuery = `
+        SELECT video_id, style, model, summary, source, created_at
+        FROM summaries WHERE video_id = $1 AND style = $2 AND ($3 = '' OR model = $3)
+        ORDER BY created_at DESC LIMIT 1
+    `
+
+	// Signing in again refreshes the profile and keeps the ID
+	saveUserQuery = `
+        INSERT INTO users (id, provider, subject, email, name, created_at, last_login)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT(provider, subject) DO UPDATE SET
+            email = excluded.email,
+            name = excluded.name,
+            last_login = excluded.last_login
+        RETURNING id, created_at
+    `
+
+	getUserQuery = `
+        SELECT id, provider, subject, email, name, created_at, last_login
+        FROM users WHERE id = $1
+    `
+
+	addUserVideoQuery = `
+        INSERT INTO user_videos (user_id, video_id, created_at) VALUES ($1, $2, $3)
+        ON CONFLICT(user_id, video_id) DO UPDATE SET created_at = excluded.created_at
+    `
+
+	hasUserVideoQuery = `
+        SELECT EXISTS (SELECT 1 FROM user_videos WHERE user_id = $1 AND video_id = $2)
+    `
+
+	removeUserVideoQuery = `
+        DELETE FROM user_videos WHERE user_id = $1 AND video_id = $2
+    `
+
+	sharedUserVideoQuery = `
+        SELECT EXISTS (SELECT 1 FROM user_videos WHERE video_id = $1)
+    `
+
+	deleteEmbeddingsQuery = `
+        DELETE FROM embeddings WHERE video_id = $1 AND model = $2
+    `
+
+	insertEmbeddingQuery = `
+        INSERT INTO embeddings (video_id, model, passage, source, start_time, end_time, text, vector, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+
+	scanEmbeddingsQuery = `
+        SELECT video_id, model, passage, source, start_time, end_time, text, vector, created_at
+        FROM embeddings WHERE model = $1
+    `
+
+	// Transcripts without timing aren't split into passages, so they are
+	// never embedded
+	listUnembeddedQuery = `
+        SELECT id FROM videos
+        WHERE status = 'completed' AND segments != ''
+        AND NOT EXISTS (
+            SELECT 1 FROM embeddings
+            WHERE video_id = videos.id AND model = $1 AND created_at >= videos.updated_at
+        )
+        ORDER BY updated_at DESC
+        LIMIT $2
+    `
+
+	insertBatchQuery = `
+        INSERT INTO batches (id, url, title, created_at) VALUES ($1, $2, $3, $4)
+    `
+
+	insertBatchItemQuery = `
+        INSERT INTO batch_items (batch_id, position, url, video_id, error)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+
+	updateBatchItemQuery = `
+        UPDATE batch_items SET video_id = $1, error = $2
+        WHERE batch_id = $3 AND position = $4
+    `
+
+	getBatchQuery = `
+        SELECT id, url, title, created_at FROM batches WHERE id = $1
+    `
+
+	// Items whose video was deleted come back with an empty status
+	batchItemsQuery = `
+        SELECT i.url, i.video_id, i.error, COALESCE(v.status, '')
+        FROM batch_items i LEFT JOIN videos v ON v.id = i.video_id
+        WHERE i.batch_id = $1
+        ORDER BY i.position
+    `
+
+	databaseSizeQuery = `
+        SELECT pg_database_size(current_database())
+    `
+
+	deleteQuery = `
+        DELETE FROM videos WHERE id = $1
+    `
+
+	touchQuery = `
+        UPDATE videos SET last_accessed = $1 WHERE id = $2
+    `
+
+	// Videos never read since tiering was enabled count from their last update
+	coldTranscriptsQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos
+        WHERE status = 'completed' AND COALESCE(last_accessed, updated_at) < $1
+            AND ((transcript_key = '' AND octet_length(transcription) >= $2)
+                OR (secondary_transcript_key = '' AND octet_length(secondary_transcription) >= $2))
+        ORDER BY COALESCE(last_accessed, updated_at) LIMIT $3
+    `
+
+	storedTranscriptsQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos
+        WHERE NOT pinned AND (transcript_key != '' OR secondary_transcript_key != '')
+        ORDER BY COALESCE(last_accessed, updated_at) LIMIT $1
+    `
+
+	transcriptKeysQuery = `
+        SELECT transcript_key, secondary_transcript_key
+        FROM videos
+        WHERE transcript_key != '' OR secondary_transcript_key != ''
+    `
+
+	setTranscriptKeysQuery = `
+        UPDATE videos SET
+            transcription = CASE WHEN $1 = '' THEN transcription ELSE '' END,
+            transcript_key = CASE WHEN $1 = '' THEN transcript_key ELSE $1 END,
+            secondary_transcription = CASE WHEN $2 = '' THEN secondary_transcription ELSE '' END,
+            secondary_transcript_key = CASE WHEN $2 = '' THEN secondary_transcript_key ELSE $2 END
+        WHERE id = $3
+    `
+
+	setPinnedQuery = `
+        UPDATE videos SET pinned = $1 WHERE id = $2
+    `
+
+	setExpiryQuery = `
+        UPDATE videos SET expires_at = $1 WHERE id = $2
+    `
+
+	// Pinned videos and jobs still in progress are never cleaned up. The
+	// longest expired go first.
+	cleanupExpiredQuery = `
+        DELETE FROM videos WHERE id IN (
+            SELECT id FROM videos
+            WHERE NOT pinned AND status <> 'processing'
+                AND (expires_at < $1 OR (expires_at IS NULL AND updated_at < $2))
+            ORDER BY COALESCE(expires_at, updated_at)
+            LIMIT $3
+        )
+    `
+
+	countExpiredQuery = `
+        SELECT COUNT(*) FROM videos
+        WHERE NOT pinned AND status <> 'processing'
+            AND (expires_at < $1 OR (expires_at IS NULL AND updated_at < $2))
+    `
+
+	insertRevisionQuery = `
+        INSERT INTO revisions (video_id, revision, source, text, segments, note, user_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (video_id, revision) DO NOTHING
+    `
+
+	listRevisionsQuery = `
+        SELECT video_id, revision, source, note, user_id, created_at
+        FROM revisions WHERE video_id = $1 ORDER BY revision
+    `
+
+	getRevisionQuery = `
+        SELECT video_id, revision, source, text, segments, note, user_id, created_at
+        FROM revisions WHERE video_id = $1 AND revision = $2
+    `
+)
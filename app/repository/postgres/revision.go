@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/repository"
+)
+
+// SaveCorrection saves a video and new revisions of its transcript in one
+// transaction
+func (r *Repository) SaveCorrection(ctx context.Context, video *models.Video, revisions []*models.Revision) error {
+	const op = "PostgresRepository.SaveCorrection"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save correction")
+	}
+	defer tx.Rollback()
+
+	res, err := r.save(ctx, tx.StmtContext(ctx, r.db.statements.insert), video)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.Conflict(op, repository.ErrConflict, "Video was changed by another request")
+	}
+
+	for _, revision := range revisions {
+		segments, err := encodeSegments(revision.Segments)
+		if err != nil {
+			return errors.Internal(op, err, "Failed to save revision")
+		}
+		res, err := tx.ExecContext(ctx, insertRevisionQuery,
+			revision.VideoID, revision.Number, string(revision.Source), revision.Text, segments,
+			revision.Note, revision.UserID, revision.CreatedAt)
+		if err != nil {
+			return errors.Internal(op, err, "Failed to save revision")
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return errors.Conflict(op, nil, "Transcript was corrected by another request")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Internal(op, err, "Failed to save correction")
+	}
+	video.Version++
+	return nil
+}
+
+func (r *Repository) ListRevisions(ctx context.Context, videoID string) ([]models.Revision, error) {
+	const op = "PostgresRepository.ListRevisions"
+
+	rows, err := r.db.QueryContext(ctx, listRevisionsQuery, videoID)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query revisions")
+	}
+	defer rows.Close()
+
+	revisions := []models.Revision{}
+	for rows.Next() {
+		var rev models.Revision
+		var source string
+		if err := rows.Scan(&rev.VideoID, &rev.Number, &source, &rev.Note, &rev.UserID, &rev.CreatedAt); err != nil {
+			return nil, errors.Internal(op, err, "Failed to scan revision")
+		}
+		rev.Source = models.Source(source)
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query revisions")
+	}
+	return revisions, nil
+}
+
+func (r *Repository) FindRevision(ctx context.Context, videoID string, number int) (*models.Revision, error) {
+	const op = "PostgresRepository.FindRevision"
+
+	rev := &models.Revision{}
+	var source, segments string
+	err := r.db.QueryRowContext(ctx, getRevisionQuery, videoID, number).Scan(
+		&rev.VideoID, &rev.Number, &source, &rev.Text, &segments, &rev.Note, &rev.UserID, &rev.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "Revision not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query revision")
+	}
+
+	rev.Source = models.Source(source)
+	if rev.Segments, err = decodeSegments(segments); err != nil {
+		return nil, errors.Internal(op, err, "Failed to read revision")
+	}
+	return rev, nil
+}
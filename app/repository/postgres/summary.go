@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// SaveSummary stores a video's summary, replacing any earlier one of the
+// same style and model
+func (r *Repository) SaveSummary(ctx context.Context, summary *models.Summary) error {
+	const op = "PostgresRepository.SaveSummary"
+
+	_, err := r.db.ExecContext(ctx, saveSummaryQuery, summary.VideoID, summary.Style, summary.Model, summary.Text, summary.Source, summary.CreatedAt)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save summary")
+	}
+	return nil
+}
+
+func (r *Repository) FindSummary(ctx context.Context, videoID string, style models.SummaryStyle, model string) (*models.Summary, error) {
+	const op = "PostgresRepository.FindSummary"
+
+	summary := &models.Summary{}
+	var styleName, source string
+	err := r.db.QueryRowContext(ctx, getSummaryQuery, videoID, style, model).Scan(&summary.VideoID, &styleName, &summary.Model, &summary.Text, &source, &summary.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "Summary not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query summary")
+	}
+	summary.Style = models.SummaryStyle(styleName)
+	summary.Source = models.Source(source)
+
+	return summary, nil
+}
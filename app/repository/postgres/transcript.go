@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// transcriptChunkSize is the number of characters read per query when
+// streaming a transcript
+const transcriptChunkSize = 64 * 1024
+
+// TranscriptionReader returns a reader that pulls a stored transcript out of
+// the database in chunks, so large transcripts never have to be held in
+// memory as a whole. An empty source selects the primary transcript.
+func (r *Repository) TranscriptionReader(ctx context.Context, id string, source models.Source) (io.Reader, error) {
+	const op = "PostgresRepository.TranscriptionReader"
+
+	var primary, secondary string
+	err := r.db.QueryRowContext(ctx, transcriptSourcesQuery, id).Scan(&primary, &secondary)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "Video not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query video")
+	}
+
+	query := primaryChunkQuery
+	switch source {
+	case "", models.Source(primary):
+	case models.Source(secondary):
+		query = secondaryChunkQuery
+	default:
+		return nil, errors.NotFound(op, nil, "No transcript stored from source "+string(source))
+	}
+
+	return &transcriptReader{ctx: ctx, db: r.db, query: query, id: id}, nil
+}
+
+// transcriptReader reads a transcript column with substr, one chunk at a time
+type transcriptReader struct {
+	ctx    context.Context
+	db     *DB
+	query  string
+	id     string
+	offset int // 1-based character offset of the next chunk
+	buf    []byte
+	done   bool
+}
+
+func (t *transcriptReader) Read(p []byte) (int, error) {
+	if len(t.buf) == 0 {
+		if t.done {
+			return 0, io.EOF
+		}
+		if err := t.fill(); err != nil {
+			return 0, err
+		}
+		if len(t.buf) == 0 {
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(p, t.buf)
+	t.buf = t.buf[n:]
+	return n, nil
+}
+
+func (t *transcriptReader) fill() error {
+	if t.offset == 0 {
+		t.offset = 1
+	}
+
+	var chunk string
+	if err := t.db.QueryRowContext(t.ctx, t.query, t.offset, transcriptChunkSize, t.id).Scan(&chunk); err != nil {
+		return err
+	}
+
+	t.offset += transcriptChunkSize
+	t.done = len([]rune(chunk)) < transcriptChunkSize
+	t.buf = []byte(chunk)
+	return nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// SaveUser stores a user by provider and subject, keeping the ID of one
+// that signed in before
+func (r *Repository) SaveUser(ctx context.Context, user *models.User) error {
+	const op = "PostgresRepository.SaveUser"
+
+	err := r.db.QueryRowContext(ctx, saveUserQuery,
+		user.ID, user.Provider, user.Subject, user.Email, user.Name, user.CreatedAt, user.LastLogin,
+	).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save user")
+	}
+	return nil
+}
+
+func (r *Repository) FindUser(ctx context.Context, id string) (*models.User, error) {
+	const op = "PostgresRepository.FindUser"
+
+	user := &models.User{}
+	err := r.db.QueryRowContext(ctx, getUserQuery, id).Scan(
+		&user.ID, &user.Provider, &user.Subject, &user.Email, &user.Name, &user.CreatedAt, &user.LastLogin)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "User not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query user")
+	}
+	return user, nil
+}
+
+// AddUserVideo adds a video to a user's list, or moves it to the top
+func (r *Repository) AddUserVideo(ctx context.Context, userID, videoID string, at time.Time) error {
+	const op = "PostgresRepository.AddUserVideo"
+
+	if _, err := r.db.ExecContext(ctx, addUserVideoQuery, userID, videoID, at); err != nil {
+		return errors.Internal(op, err, "Failed to record the user's video")
+	}
+	return nil
+}
+
+func (r *Repository) HasUserVideo(ctx context.Context, userID, videoID string) (bool, error) {
+	const op = "PostgresRepository.HasUserVideo"
+
+	var ok bool
+	if err := r.db.QueryRowContext(ctx, hasUserVideoQuery, userID, videoID).Scan(&ok); err != nil {
+		return false, errors.Internal(op, err, "Failed to query the user's videos")
+	}
+	return ok, nil
+}
+
+func (r *Repository) RemoveUserVideo(ctx context.Context, userID, videoID string) (bool, error) {
+	const op = "PostgresRepository.RemoveUserVideo"
+
+	if _, err := r.db.ExecContext(ctx, removeUserVideoQuery, userID, videoID); err != nil {
+		return false, errors.Internal(op, err, "Failed to remove the user's video")
+	}
+	var shared bool
+	if err := r.db.QueryRowContext(ctx, sharedUserVideoQuery, videoID).Scan(&shared); err != nil {
+		return false, errors.Internal(op, err, "Failed to query the user's videos")
+	}
+	return shared, nil
+}
@@ -0,0 +1,644 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/repository"
+)
+
+// Repository stores videos in PostgreSQL. Postgres compresses large values
+// itself (TOAST), so transcripts are stored as plain text.
+type Repository struct {
+	db *DB
+}
+
+func NewRepository(db *DB) (*Repository, error) {
+	return &Repository{db: db}, nil
+}
+
+func (r *Repository) Save(ctx context.Context, video *models.Video) error {
+	const op = "PostgresRepository.Save"
+
+	res, err := r.save(ctx, r.db.statements.insert, video)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.Conflict(op, repository.ErrConflict, "Video was changed by another request")
+	}
+	video.Version++
+	return nil
+}
+
+// save inserts or updates a video as its next version with insert, the
+// prepared statement or its copy in a transaction. Nothing is changed when
+// the stored row is at another version.
+func (r *Repository) save(ctx context.Context, insert *sql.Stmt, video *models.Video) (sql.Result, error) {
+	segments, err := encodeSegments(video.Segments)
+	if err != nil {
+		return nil, err
+	}
+	secondarySegments, err := encodeSegments(video.SecondarySegments)
+	if err != nil {
+		return nil, err
+	}
+	chapters, err := encodeChapters(video.Chapters)
+	if err != nil {
+		return nil, err
+	}
+	timings, err := encodeTimings(video.Timings)
+	if err != nil {
+		return nil, err
+	}
+	enrichment, err := encodeEnrichment(video.Enrichment)
+	if err != nil {
+		return nil, err
+	}
+	comparison, err := encodeComparison(video.CaptionComparison)
+	if err != nil {
+		return nil, err
+	}
+
+	return insert.ExecContext(ctx,
+		video.ID,
+		video.URL,
+		video.Title,
+		video.Language,
+		string(video.Platform),
+		video.MediaID,
+		video.Uploader,
+		string(video.Status),
+		video.Pinned,
+		video.ExpiresAt,
+		video.Transcription,
+		string(video.Source),
+		segments,
+		video.SecondaryTranscription,
+		string(video.SecondarySource),
+		secondarySegments,
+		chapters,
+		video.TranscriptKey,
+		video.SecondaryTranscriptKey,
+		video.Error,
+		string(video.ErrorCode),
+		video.FailureLog,
+		video.CaptionWER,
+		video.CaptionLanguage,
+		video.CaptionKind,
+		video.RetryAt,
+		timings,
+		video.Model,
+		enrichment,
+		video.Revision,
+		comparison,
+		video.CreatedAt,
+		video.UpdatedAt,
+		video.Version+1,
+	)
+}
+
+func (r *Repository) Find(ctx context.Context, id string) (*models.Video, error) {
+	const op = "PostgresRepository.Find"
+
+	video, err := scanVideo(r.db.statements.get.QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "Video not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query video")
+	}
+
+	r.db.touch(video.ID)
+	return video, nil
+}
+
+func (r *Repository) FindByURL(ctx context.Context, url string) (*models.Video, error) {
+	const op = "PostgresRepository.FindByURL"
+
+	video, err := scanVideo(r.db.statements.getByURL.QueryRowContext(ctx, url))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "Video not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query video")
+	}
+
+	r.db.touch(video.ID)
+	return video, nil
+}
+
+func (r *Repository) FindByMedia(ctx context.Context, platform models.Platform, mediaID string) (*models.Video, error) {
+	const op = "PostgresRepository.FindByMedia"
+
+	video, err := scanVideo(r.db.QueryRowContext(ctx, getByMediaQuery, platform, mediaID))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "Video not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query video")
+	}
+
+	r.db.touch(video.ID)
+	return video, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanVideo reads a row selected with videoColumns
+func scanVideo(row rowScanner) (*models.Video, error) {
+	video := &models.Video{}
+	var platform, status, source, segments, secondarySource, secondarySegments, chapters, timings, enrichment, comparison, errorCode string
+	var captionWER sql.NullFloat64
+	var expiresAt, retryAt sql.NullTime
+
+	err := row.Scan(
+		&video.ID,
+		&video.URL,
+		&video.Title,
+		&video.Language,
+		&platform,
+		&video.MediaID,
+		&video.Uploader,
+		&status,
+		&video.Pinned,
+		&expiresAt,
+		&video.Transcription,
+		&source,
+		&segments,
+		&video.SecondaryTranscription,
+		&secondarySource,
+		&secondarySegments,
+		&chapters,
+		&video.TranscriptKey,
+		&video.SecondaryTranscriptKey,
+		&video.Error,
+		&errorCode,
+		&video.FailureLog,
+		&captionWER,
+		&video.CaptionLanguage,
+		&video.CaptionKind,
+		&retryAt,
+		&timings,
+		&video.Model,
+		&enrichment,
+		&video.Revision,
+		&comparison,
+		&video.CreatedAt,
+		&video.UpdatedAt,
+		&video.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if video.Segments, err = decodeSegments(segments); err != nil {
+		return nil, err
+	}
+	if video.SecondarySegments, err = decodeSegments(secondarySegments); err != nil {
+		return nil, err
+	}
+	if video.Chapters, err = decodeChapters(chapters); err != nil {
+		return nil, err
+	}
+	if video.Timings, err = decodeTimings(timings); err != nil {
+		return nil, err
+	}
+	if video.Enrichment, err = decodeEnrichment(enrichment); err != nil {
+		return nil, err
+	}
+	if video.CaptionComparison, err = decodeComparison(comparison); err != nil {
+		return nil, err
+	}
+
+	video.Platform = models.Platform(platform)
+	video.Status = models.Status(status)
+	video.Source = models.Source(source)
+	video.SecondarySource = models.Source(secondarySource)
+	video.ErrorCode = models.ErrorCode(errorCode)
+	if captionWER.Valid {
+		video.CaptionWER = &captionWER.Float64
+	}
+	if expiresAt.Valid {
+		video.ExpiresAt = &expiresAt.Time
+	}
+	if retryAt.Valid {
+		video.RetryAt = &retryAt.Time
+	}
+	return video, nil
+}
+
+// queryVideos runs a query selecting videoColumns
+func (r *Repository) queryVideos(ctx context.Context, query string, args ...interface{}) ([]*models.Video, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*models.Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	return videos, rows.Err()
+}
+
+// FindRecentByStatus returns the most recently updated videos with a status
+func (r *Repository) FindRecentByStatus(ctx context.Context, status models.Status, limit int) ([]*models.Video, error) {
+	const op = "PostgresRepository.FindRecentByStatus"
+
+	videos, err := r.queryVideos(ctx, recentByStatusQuery, string(status), limit)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos")
+	}
+	return videos, nil
+}
+
+// List returns a page of videos matching filter, newest first, along with
+// the total number of matching videos
+func (r *Repository) List(ctx context.Context, filter repository.VideoFilter) ([]*models.Video, int, error) {
+	const op = "PostgresRepository.List"
+
+	args := []interface{}{string(filter.Status), filter.Language, filter.UserID, filter.Keyword, filter.Entity}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, errors.Internal(op, err, "Failed to count videos")
+	}
+
+	query := listQuery
+	if filter.UserID != "" {
+		query = userListQuery
+	}
+	videos, err := r.queryVideos(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, errors.Internal(op, err, "Failed to query videos")
+	}
+	if videos == nil {
+		videos = []*models.Video{}
+	}
+
+	return videos, total, nil
+}
+
+// DatabaseSize returns the size of the database in bytes
+func (r *Repository) DatabaseSize(ctx context.Context) (int64, error) {
+	const op = "PostgresRepository.DatabaseSize"
+
+	var size int64
+	if err := r.db.QueryRowContext(ctx, databaseSizeQuery).Scan(&size); err != nil {
+		return 0, errors.Internal(op, err, "Failed to query database size")
+	}
+	return size, nil
+}
+
+// Delete removes a video and its transcripts
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	const op = "PostgresRepository.Delete"
+
+	res, err := r.db.ExecContext(ctx, deleteQuery, id)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to delete video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	return nil
+}
+
+// SetPinned pins or unpins a video. Save never changes the flag of an
+// existing row, so a worker saving a stale copy can't undo a pin.
+func (r *Repository) SetPinned(ctx context.Context, id string, pinned bool) error {
+	const op = "PostgresRepository.SetPinned"
+
+	res, err := r.db.ExecContext(ctx, setPinnedQuery, pinned, id)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to update video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	return nil
+}
+
+// SetExpiry sets when cleanup removes a video; nil leaves it to the
+// retention window. Like the pin, Save never changes it.
+func (r *Repository) SetExpiry(ctx context.Context, id string, expiresAt *time.Time) error {
+	const op = "PostgresRepository.SetExpiry"
+
+	res, err := r.db.ExecContext(ctx, setExpiryQuery, expiresAt, id)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to update video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	return nil
+}
+
+// CleanupExpiredTranscriptions deletes up to limit finished, unpinned
+// videos past their own expiry at now, or without one and last updated
+// before cutoff, and returns how many were removed. A zero cutoff only
+// removes videos past their own expiry.
+func (r *Repository) CleanupExpiredTranscriptions(ctx context.Context, cutoff, now time.Time, limit int) (int64, error) {
+	const op = "PostgresRepository.CleanupExpiredTranscriptions"
+
+	res, err := r.db.ExecContext(ctx, cleanupExpiredQuery, now, cutoff, limit)
+	if err != nil {
+		return 0, errors.Internal(op, err, "Failed to delete expired videos")
+	}
+	return res.RowsAffected()
+}
+
+// CountExpiredTranscriptions returns how many videos
+// CleanupExpiredTranscriptions would remove without a limit
+func (r *Repository) CountExpiredTranscriptions(ctx context.Context, cutoff, now time.Time) (int64, error) {
+	const op = "PostgresRepository.CountExpiredTranscriptions"
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, countExpiredQuery, now, cutoff).Scan(&count); err != nil {
+		return 0, errors.Internal(op, err, "Failed to count expired videos")
+	}
+	return count, nil
+}
+
+// FindColdTranscripts returns completed videos not read since accessedBefore
+// that hold an inline transcript of at least minSize bytes
+func (r *Repository) FindColdTranscripts(ctx context.Context, accessedBefore time.Time, minSize, limit int) ([]*models.Video, error) {
+	const op = "PostgresRepository.FindColdTranscripts"
+
+	videos, err := r.queryVideos(ctx, coldTranscriptsQuery, accessedBefore, minSize, limit)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos")
+	}
+	return videos, nil
+}
+
+func (r *Repository) FindStoredTranscripts(ctx context.Context, limit int) ([]*models.Video, error) {
+	const op = "PostgresRepository.FindStoredTranscripts"
+
+	videos, err := r.queryVideos(ctx, storedTranscriptsQuery, limit)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos")
+	}
+	return videos, nil
+}
+
+// TranscriptKeys returns the keys of every transcript kept in the storage
+// backend. It runs rarely, so it isn't kept as a prepared statement.
+func (r *Repository) TranscriptKeys(ctx context.Context) ([]string, error) {
+	const op = "PostgresRepository.TranscriptKeys"
+
+	var keys []string
+	err := r.scanRows(ctx, transcriptKeysQuery, func(rows *sql.Rows) error {
+		var primary, secondary string
+		if err := rows.Scan(&primary, &secondary); err != nil {
+			return err
+		}
+		for _, key := range []string{primary, secondary} {
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query transcript keys")
+	}
+	return keys, nil
+}
+
+// SetTranscriptKeys records where a video's transcripts were moved and
+// clears the inline copies
+func (r *Repository) SetTranscriptKeys(ctx context.Context, id, primaryKey, secondaryKey string) error {
+	const op = "PostgresRepository.SetTranscriptKeys"
+
+	res, err := r.db.ExecContext(ctx, setTranscriptKeysQuery, primaryKey, secondaryKey, id)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to update video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	return nil
+}
+
+// CaptionQuality aggregates caption word error rates
+func (r *Repository) CaptionQuality(ctx context.Context, threshold float64) (*models.CaptionQuality, error) {
+	const op = "PostgresRepository.CaptionQuality"
+
+	quality := &models.CaptionQuality{Threshold: threshold}
+	err := r.db.QueryRowContext(ctx, captionQualityQuery, threshold).
+		Scan(&quality.Compared, &quality.MeanWER, &quality.GoodEnough)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query caption quality")
+	}
+
+	return quality, nil
+}
+
+// VideoStats totals the stored videos by status, source and Whisper model.
+// Like CaptionQuality, it runs rarely, so its queries aren't prepared.
+func (r *Repository) VideoStats(ctx context.Context) (*models.VideoStats, error) {
+	const op = "PostgresRepository.VideoStats"
+
+	stats := &models.VideoStats{
+		ByStatus: make(map[models.Status]int),
+		BySource: make(map[models.Source]int),
+		ByModel:  []models.ModelStats{},
+	}
+	err := r.scanRows(ctx, statusCountsQuery, func(rows *sql.Rows) error {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return err
+		}
+		stats.ByStatus[models.Status(status)] = count
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to count videos by status")
+	}
+
+	err = r.scanRows(ctx, sourceCountsQuery, func(rows *sql.Rows) error {
+		var source string
+		var count int
+		if err := rows.Scan(&source, &count); err != nil {
+			return err
+		}
+		stats.BySource[models.Source(source)] = count
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to count videos by source")
+	}
+
+	err = r.scanRows(ctx, modelStatsQuery, func(rows *sql.Rows) error {
+		var model models.ModelStats
+		if err := rows.Scan(&model.Model, &model.Videos, &model.AvgProcessingSeconds); err != nil {
+			return err
+		}
+		stats.ByModel = append(stats.ByModel, model)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to count videos by model")
+	}
+
+	if err := r.db.QueryRowContext(ctx, transcriptBytesQuery).Scan(&stats.TranscriptBytes); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query transcript size")
+	}
+	return stats, nil
+}
+
+// scanRows runs a query and calls scan for each row
+func (r *Repository) scanRows(ctx context.Context, query string, scan func(*sql.Rows) error) error {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// encodeSegments stores segment timing as JSON
+func encodeSegments(segments []models.Segment) (string, error) {
+	if len(segments) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(segments)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeSegments(text string) ([]models.Segment, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	var segments []models.Segment
+	if err := json.Unmarshal([]byte(text), &segments); err != nil {
+		return nil, fmt.Errorf("failed to decode segments: %w", err)
+	}
+	return segments, nil
+}
+
+// encodeChapters stores chapters as JSON
+func encodeChapters(chapters []models.Chapter) (string, error) {
+	if len(chapters) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(chapters)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeChapters(text string) ([]models.Chapter, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	var chapters []models.Chapter
+	if err := json.Unmarshal([]byte(text), &chapters); err != nil {
+		return nil, fmt.Errorf("failed to decode chapters: %w", err)
+	}
+	return chapters, nil
+}
+
+// encodeTimings stores a video's stage timings as JSON
+func encodeTimings(timings *models.StageTimings) (string, error) {
+	if timings == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(timings)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeTimings(text string) (*models.StageTimings, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	timings := &models.StageTimings{}
+	if err := json.Unmarshal([]byte(text), timings); err != nil {
+		return nil, fmt.Errorf("failed to decode timings: %w", err)
+	}
+	return timings, nil
+}
+
+// encodeEnrichment stores what was extracted from a transcript as JSON,
+// which the list filters query
+func encodeEnrichment(enrichment *models.Enrichment) (string, error) {
+	if enrichment == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(enrichment)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeEnrichment(text string) (*models.Enrichment, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	enrichment := &models.Enrichment{}
+	if err := json.Unmarshal([]byte(text), enrichment); err != nil {
+		return nil, fmt.Errorf("failed to decode enrichment: %w", err)
+	}
+	return enrichment, nil
+}
+
+func encodeComparison(comparison *models.CaptionComparison) (string, error) {
+	if comparison == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(comparison)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeComparison(text string) (*models.CaptionComparison, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	comparison := &models.CaptionComparison{}
+	if err := json.Unmarshal([]byte(text), comparison); err != nil {
+		return nil, fmt.Errorf("failed to decode caption comparison: %w", err)
+	}
+	return comparison, nil
+}
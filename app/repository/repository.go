@@ -2,11 +2,123 @@ package repository
 
 import (
 	"context"
+	stderrors "errors"
+	"io"
+	"time"
 	"yt-text/models"
 )
 
+// VideoFilter selects videos to list. Empty fields match every video.
+type VideoFilter struct {
+	Status   models.Status
+	Language string
+	Limit    int
+	Offset   int
+
+	// UserID lists only the videos a user submitted, most recently
+	// submitted first
+	UserID string
+
+	// Keyword and Entity match videos whose enrichment lists them, in any
+	// case
+	Keyword string
+	Entity  string
+}
+
+// ErrConflict is wrapped by the error of a Save that lost to another: the
+// video was saved again since the copy being saved was read
+var ErrConflict = stderrors.New("video was changed since it was read")
+
 type VideoRepository interface {
-    Save(ctx context.Context, video *models.Video) error
-    Find(ctx context.Context, id string) (*models.Video, error)
-    FindByURL(ctx context.Context, url string) (*models.Video, error)
+	// Save stores a new video, or updates one whose Version still matches
+	// the stored row and advances it. Otherwise it fails with ErrConflict
+	// and the caller should read the video again.
+	Save(ctx context.Context, video *models.Video) error
+	Find(ctx context.Context, id string) (*models.Video, error)
+	FindByURL(ctx context.Context, url string) (*models.Video, error)
+	// FindByMedia finds a video by its platform and ID there, whatever URL
+	// it was submitted with. Of several matches, a completed video is
+	// preferred, then the most recently updated.
+	FindByMedia(ctx context.Context, platform models.Platform, mediaID string) (*models.Video, error)
+	Delete(ctx context.Context, id string) error
+	TranscriptionReader(ctx context.Context, id string, source models.Source) (io.Reader, error)
+	FindRecentByStatus(ctx context.Context, status models.Status, limit int) ([]*models.Video, error)
+	List(ctx context.Context, filter VideoFilter) ([]*models.Video, int, error)
+	DatabaseSize(ctx context.Context) (int64, error)
+	SetPinned(ctx context.Context, id string, pinned bool) error
+	SetExpiry(ctx context.Context, id string, expiresAt *time.Time) error
+	CleanupExpiredTranscriptions(ctx context.Context, cutoff, now time.Time, limit int) (int64, error)
+	CountExpiredTranscriptions(ctx context.Context, cutoff, now time.Time) (int64, error)
+	CaptionQuality(ctx context.Context, threshold float64) (*models.CaptionQuality, error)
+	VideoStats(ctx context.Context) (*models.VideoStats, error)
+
+	// FindColdTranscripts returns completed videos not read since
+	// accessedBefore that hold an inline transcript of at least minSize bytes
+	FindColdTranscripts(ctx context.Context, accessedBefore time.Time, minSize, limit int) ([]*models.Video, error)
+	// FindStoredTranscripts returns unpinned videos with a transcript kept
+	// in the storage backend, least recently read first
+	FindStoredTranscripts(ctx context.Context, limit int) ([]*models.Video, error)
+	// TranscriptKeys returns the keys of every transcript kept in the
+	// storage backend
+	TranscriptKeys(ctx context.Context) ([]string, error)
+	// SetTranscriptKeys records where a video's transcripts were moved and
+	// clears the inline copies. An empty key leaves that transcript alone.
+	SetTranscriptKeys(ctx context.Context, id, primaryKey, secondaryKey string) error
+
+	// Jobs that haven't finished, kept so the queue survives a restart
+	SaveJob(ctx context.Context, job models.QueuedJob) error
+	DeleteJob(ctx context.Context, videoID string) error
+	ListJobs(ctx context.Context) ([]models.QueuedJob, error)
+
+	// ClaimJob and RenewJobClaims share stored jobs between processes; a
+	// claim not renewed since expiredBefore can be taken over. Released
+	// claims can be taken right away.
+	ClaimJob(ctx context.Context, owner string, expiredBefore time.Time) (*models.QueuedJob, error)
+	RenewJobClaims(ctx context.Context, owner string) error
+	ReleaseJobClaims(ctx context.Context, owner string) error
+
+	// Job history, kept until the video is deleted
+	AddJobEvent(ctx context.Context, event models.JobEvent) error
+	ListJobEvents(ctx context.Context, videoID string) ([]models.JobEvent, error)
+
+	// Summaries, at most one per video, style and model. FindSummary
+	// returns the newest summary of the style when model is empty.
+	SaveSummary(ctx context.Context, summary *models.Summary) error
+	FindSummary(ctx context.Context, videoID string, style models.SummaryStyle, model string) (*models.Summary, error)
+
+	// Accounts. SaveUser creates a user signing in for the first time, or
+	// refreshes the profile of a returning one, and fills in the stored ID
+	// and creation time either way.
+	SaveUser(ctx context.Context, user *models.User) error
+	FindUser(ctx context.Context, id string) (*models.User, error)
+	// AddUserVideo records that a user submitted a video. Submitting it
+	// again moves it to the top of their list.
+	AddUserVideo(ctx context.Context, userID, videoID string, at time.Time) error
+	// HasUserVideo reports whether a user submitted a video
+	HasUserVideo(ctx context.Context, userID, videoID string) (bool, error)
+	// RemoveUserVideo takes a video off a user's list and reports whether
+	// other users still have it on theirs
+	RemoveUserVideo(ctx context.Context, userID, videoID string) (bool, error)
+
+	// Revisions of primary transcripts, deleted with their video.
+	// SaveCorrection saves a corrected video as Save does together with its
+	// new revisions, in one transaction: nothing is stored when the video
+	// fails with ErrConflict or already has a revision of one of their
+	// numbers. ListRevisions leaves out their text and segments.
+	SaveCorrection(ctx context.Context, video *models.Video, revisions []*models.Revision) error
+	ListRevisions(ctx context.Context, videoID string) ([]models.Revision, error)
+	FindRevision(ctx context.Context, videoID string, number int) (*models.Revision, error)
+
+	// Passage embeddings for semantic search, deleted with their video.
+	// SaveEmbeddings replaces a video's embeddings from the same model.
+	// ScanEmbeddings calls fn with every embedding from model until fn
+	// returns an error. ListUnembedded returns up to limit completed, timed
+	// transcripts that model hasn't embedded since they last changed.
+	SaveEmbeddings(ctx context.Context, videoID, model string, embeddings []models.Embedding) error
+	ScanEmbeddings(ctx context.Context, model string, fn func(models.Embedding) error) error
+	ListUnembedded(ctx context.Context, model string, limit int) ([]string, error)
+
+	CreateBatch(ctx context.Context, batch *models.Batch) error
+	UpdateBatchItem(ctx context.Context, batchID string, position int, item models.BatchItem) error
+	FindBatch(ctx context.Context, id string) (*models.Batch, error)
 }
@@ -0,0 +1,415 @@
+// Package repotest checks that a repository.VideoRepository behaves the way
+// the services rely on, so every backend can be held to the same contract.
+package repotest
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+	"yt-text/models"
+	"yt-text/repository"
+)
+
+// Run runs the conformance suite. open returns an empty repository for each
+// test and arranges for it to be closed.
+func Run(t *testing.T, open func(t *testing.T) repository.VideoRepository) {
+	t.Run("Save", func(t *testing.T) { testSave(t, open(t)) })
+	t.Run("List", func(t *testing.T) { testList(t, open(t)) })
+	t.Run("Jobs", func(t *testing.T) { testJobs(t, open(t)) })
+	t.Run("Cleanup", func(t *testing.T) { testCleanup(t, open(t)) })
+	t.Run("Corrections", func(t *testing.T) { testCorrections(t, open(t)) })
+}
+
+// newVideo returns a video ready to be saved for the first time
+func newVideo(id string, status models.Status, at time.Time) *models.Video {
+	return &models.Video{
+		ID:        id,
+		URL:       "https://www.youtube.com/watch?v=" + id,
+		Title:     "Video " + id,
+		Status:    status,
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+}
+
+func save(t *testing.T, repo repository.VideoRepository, videos ...*models.Video) {
+	t.Helper()
+	for _, video := range videos {
+		if err := repo.Save(context.Background(), video); err != nil {
+			t.Fatalf("Save(%s): %v", video.ID, err)
+		}
+	}
+}
+
+func find(t *testing.T, repo repository.VideoRepository, id string) *models.Video {
+	t.Helper()
+	video, err := repo.Find(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Find(%s): %v", id, err)
+	}
+	return video
+}
+
+func ids(videos []*models.Video) []string {
+	ids := make([]string, len(videos))
+	for i, video := range videos {
+		ids[i] = video.ID
+	}
+	return ids
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testSave(t *testing.T, repo repository.VideoRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	video := newVideo("save", models.StatusProcessing, now)
+	save(t, repo, video)
+	if video.Version != 1 {
+		t.Errorf("Version after first save = %d, want 1", video.Version)
+	}
+
+	first, second := find(t, repo, "save"), find(t, repo, "save")
+	if first.Version != 1 {
+		t.Errorf("stored Version = %d, want 1", first.Version)
+	}
+	first.Status = models.StatusCompleted
+	first.Transcription = "hello world"
+	save(t, repo, first)
+	if first.Version != 2 {
+		t.Errorf("Version after update = %d, want 2", first.Version)
+	}
+
+	// The second copy was read before the first was saved
+	second.Status = models.StatusFailed
+	err := repo.Save(ctx, second)
+	if !stderrors.Is(err, repository.ErrConflict) {
+		t.Fatalf("Save of a stale copy = %v, want ErrConflict", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("Version after a conflict = %d, want it left at 1", second.Version)
+	}
+	stored := find(t, repo, "save")
+	if stored.Status != models.StatusCompleted || stored.Transcription != "hello world" || stored.Version != 2 {
+		t.Errorf("stored video = %s %q v%d, want the first save's", stored.Status, stored.Transcription, stored.Version)
+	}
+
+	// Pins and expiry are only changed by their own methods
+	expiresAt := now.Add(time.Hour)
+	if err := repo.SetPinned(ctx, "save", true); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+	if err := repo.SetExpiry(ctx, "save", &expiresAt); err != nil {
+		t.Fatalf("SetExpiry: %v", err)
+	}
+	stored.Title = "Renamed"
+	stored.Pinned, stored.ExpiresAt = false, nil
+	save(t, repo, stored)
+	stored = find(t, repo, "save")
+	if stored.Title != "Renamed" || !stored.Pinned || stored.ExpiresAt == nil {
+		t.Errorf("after save: title %q, pinned %v, expires %v; want the new title with the pin and expiry kept",
+			stored.Title, stored.Pinned, stored.ExpiresAt)
+	}
+
+	if _, err := repo.Find(ctx, "missing"); err == nil {
+		t.Error("Find of a missing video succeeded")
+	}
+}
+
+func testList(t *testing.T, repo repository.VideoRepository) {
+	ctx := context.Background()
+	start := time.Now().UTC().Add(-time.Hour)
+
+	a := newVideo("a", models.StatusCompleted, start)
+	a.Language = "en"
+	a.Enrichment = &models.Enrichment{Keywords: []string{"go"}, Entities: []models.Entity{{Name: "Gopher", Mentions: 2}}}
+	b := newVideo("b", models.StatusCompleted, start.Add(time.Minute))
+	b.Language = "de"
+	c := newVideo("c", models.StatusFailed, start.Add(2*time.Minute))
+	c.Language = "en"
+	d := newVideo("d", models.StatusCompleted, start.Add(3*time.Minute))
+	d.Language = "en"
+	save(t, repo, a, b, c, d)
+
+	user := &models.User{ID: "user-1", Provider: "github", Subject: "1", CreatedAt: start, LastLogin: start}
+	if err := repo.SaveUser(ctx, user); err != nil {
+		t.Fatalf("SaveUser: %v", err)
+	}
+	// b is submitted again after a, so it moves to the top of the list
+	for _, submit := range []struct {
+		id string
+		at time.Time
+	}{{"b", start.Add(5 * time.Minute)}, {"a", start.Add(10 * time.Minute)}, {"b", start.Add(15 * time.Minute)}} {
+		if err := repo.AddUserVideo(ctx, user.ID, submit.id, submit.at); err != nil {
+			t.Fatalf("AddUserVideo(%s): %v", submit.id, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter repository.VideoFilter
+		want   []string
+		total  int
+	}{
+		{"all, newest first", repository.VideoFilter{Limit: 10}, []string{"d", "c", "b", "a"}, 4},
+		{"status", repository.VideoFilter{Status: models.StatusCompleted, Limit: 10}, []string{"d", "b", "a"}, 3},
+		{"status and language", repository.VideoFilter{Status: models.StatusCompleted, Language: "en", Limit: 10}, []string{"d", "a"}, 2},
+		{"page", repository.VideoFilter{Limit: 2, Offset: 1}, []string{"c", "b"}, 4},
+		{"past the end", repository.VideoFilter{Limit: 2, Offset: 4}, []string{}, 4},
+		{"user, last submitted first", repository.VideoFilter{UserID: user.ID, Limit: 10}, []string{"b", "a"}, 2},
+		{"unknown user", repository.VideoFilter{UserID: "nobody", Limit: 10}, []string{}, 0},
+		{"keyword in any case", repository.VideoFilter{Keyword: "Go", Limit: 10}, []string{"a"}, 1},
+		{"entity in any case", repository.VideoFilter{Entity: "gopher", Limit: 10}, []string{"a"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			videos, total, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if got := ids(videos); !equal(got, tt.want) || total != tt.total {
+				t.Errorf("List = %v (total %d), want %v (total %d)", got, total, tt.want, tt.total)
+			}
+		})
+	}
+}
+
+func testJobs(t *testing.T, repo repository.VideoRepository) {
+	ctx := context.Background()
+	start := time.Now().UTC().Add(-time.Hour)
+
+	for _, id := range []string{"first", "second", "urgent", "later"} {
+		save(t, repo, newVideo(id, models.StatusProcessing, start))
+	}
+	jobs := []models.QueuedJob{
+		{VideoID: "first", Source: "auto", QueuedAt: start},
+		{VideoID: "second", Source: "auto", QueuedAt: start.Add(time.Second)},
+		{VideoID: "urgent", Source: "auto", Priority: true, QueuedAt: start.Add(2 * time.Second)},
+	}
+	for _, job := range jobs {
+		if err := repo.SaveJob(ctx, job); err != nil {
+			t.Fatalf("SaveJob(%s): %v", job.VideoID, err)
+		}
+	}
+
+	// Saving a job again updates it without moving it back in the queue
+	update := jobs[0]
+	update.Model = "small"
+	update.QueuedAt = start.Add(time.Minute)
+	if err := repo.SaveJob(ctx, update); err != nil {
+		t.Fatalf("SaveJob(first) again: %v", err)
+	}
+
+	stored, err := repo.ListJobs(ctx)
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	order := make([]string, len(stored))
+	for i, job := range stored {
+		order[i] = job.VideoID
+	}
+	if want := []string{"urgent", "first", "second"}; !equal(order, want) {
+		t.Fatalf("ListJobs order = %v, want %v", order, want)
+	}
+	if stored[1].Model != "small" {
+		t.Errorf("updated job's model = %q, want small", stored[1].Model)
+	}
+
+	// Jobs scheduled for later aren't claimed yet
+	later := models.QueuedJob{VideoID: "later", Source: "auto", QueuedAt: start.Add(3 * time.Second), NotBefore: time.Now().Add(time.Hour)}
+	if err := repo.SaveJob(ctx, later); err != nil {
+		t.Fatalf("SaveJob(later): %v", err)
+	}
+
+	claim := func(owner string, expiredBefore time.Time) string {
+		t.Helper()
+		job, err := repo.ClaimJob(ctx, owner, expiredBefore)
+		if err != nil {
+			t.Fatalf("ClaimJob(%s): %v", owner, err)
+		}
+		if job == nil {
+			return ""
+		}
+		if job.ClaimedBy != owner {
+			t.Errorf("claimed job's owner = %q, want %q", job.ClaimedBy, owner)
+		}
+		return job.VideoID
+	}
+	lapsed := time.Now().Add(-time.Minute)
+	for _, want := range []struct{ owner, id string }{
+		{"a", "urgent"}, {"b", "first"}, {"a", "second"}, {"b", ""},
+	} {
+		if got := claim(want.owner, lapsed); got != want.id {
+			t.Errorf("ClaimJob(%s) = %q, want %q", want.owner, got, want.id)
+		}
+	}
+
+	if err := repo.ReleaseJobClaims(ctx, "b"); err != nil {
+		t.Fatalf("ReleaseJobClaims: %v", err)
+	}
+	if got := claim("c", lapsed); got != "first" {
+		t.Errorf("ClaimJob after a release = %q, want first", got)
+	}
+	// Claims not renewed since expiredBefore can be taken over
+	if err := repo.RenewJobClaims(ctx, "a"); err != nil {
+		t.Fatalf("RenewJobClaims: %v", err)
+	}
+	if got := claim("d", time.Now().Add(time.Minute)); got != "urgent" {
+		t.Errorf("ClaimJob of lapsed claims = %q, want urgent", got)
+	}
+
+	// Deleting a job, or its video, removes it from the queue
+	if err := repo.DeleteJob(ctx, "urgent"); err != nil {
+		t.Fatalf("DeleteJob: %v", err)
+	}
+	if err := repo.Delete(ctx, "second"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	stored, err = repo.ListJobs(ctx)
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	order = order[:0]
+	for _, job := range stored {
+		order = append(order, job.VideoID)
+		if job.ClaimedBy != "" {
+			t.Errorf("ListJobs reports %s claimed by %q", job.VideoID, job.ClaimedBy)
+		}
+	}
+	if want := []string{"first", "later"}; !equal(order, want) {
+		t.Errorf("ListJobs after deletes = %v, want %v", order, want)
+	}
+}
+
+func testCleanup(t *testing.T, repo repository.VideoRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	cutoff := now.Add(-24 * time.Hour)
+	old := now.Add(-48 * time.Hour)
+
+	save(t, repo,
+		newVideo("old", models.StatusCompleted, old),
+		newVideo("old-failed", models.StatusFailed, old.Add(time.Minute)),
+		newVideo("fresh", models.StatusCompleted, now),
+		newVideo("pinned", models.StatusCompleted, old),
+		newVideo("processing", models.StatusProcessing, old),
+		newVideo("kept-longer", models.StatusCompleted, old),
+		newVideo("expired-early", models.StatusCompleted, now),
+	)
+	if err := repo.SetPinned(ctx, "pinned", true); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+	for id, expiresAt := range map[string]time.Time{
+		"kept-longer":   now.Add(time.Hour),
+		"expired-early": now.Add(-time.Hour),
+	} {
+		if err := repo.SetExpiry(ctx, id, &expiresAt); err != nil {
+			t.Fatalf("SetExpiry(%s): %v", id, err)
+		}
+	}
+
+	count, err := repo.CountExpiredTranscriptions(ctx, cutoff, now)
+	if err != nil {
+		t.Fatalf("CountExpiredTranscriptions: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("CountExpiredTranscriptions = %d, want 3", count)
+	}
+
+	// The longest expired go first
+	deleted, err := repo.CleanupExpiredTranscriptions(ctx, cutoff, now, 2)
+	if err != nil {
+		t.Fatalf("CleanupExpiredTranscriptions: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("CleanupExpiredTranscriptions deleted %d, want 2", deleted)
+	}
+	for _, id := range []string{"old", "old-failed"} {
+		if _, err := repo.Find(ctx, id); err == nil {
+			t.Errorf("%s survived cleanup", id)
+		}
+	}
+	find(t, repo, "expired-early")
+
+	deleted, err = repo.CleanupExpiredTranscriptions(ctx, cutoff, now, 10)
+	if err != nil {
+		t.Fatalf("CleanupExpiredTranscriptions: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("second cleanup deleted %d, want 1", deleted)
+	}
+	for _, id := range []string{"fresh", "pinned", "processing", "kept-longer"} {
+		find(t, repo, id)
+	}
+	if count, err := repo.CountExpiredTranscriptions(ctx, cutoff, now); err != nil || count != 0 {
+		t.Errorf("CountExpiredTranscriptions after cleanup = %d, %v; want 0", count, err)
+	}
+}
+
+func testCorrections(t *testing.T, repo repository.VideoRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	video := newVideo("corrected", models.StatusCompleted, now)
+	video.Transcription = "helo world"
+	save(t, repo, video)
+
+	stale := find(t, repo, "corrected")
+	video.Transcription, video.Revision = "hello world", 2
+	revisions := []*models.Revision{
+		{VideoID: "corrected", Number: 1, Text: "helo world", CreatedAt: now},
+		{VideoID: "corrected", Number: 2, Text: "hello world", CreatedAt: now},
+	}
+	if err := repo.SaveCorrection(ctx, video, revisions); err != nil {
+		t.Fatalf("SaveCorrection: %v", err)
+	}
+	if video.Version != 2 {
+		t.Errorf("Version after SaveCorrection = %d, want 2", video.Version)
+	}
+
+	// A correction of the stale copy loses on the video's version and
+	// stores no revision
+	stale.Transcription, stale.Revision = "hello, world", 3
+	err := repo.SaveCorrection(ctx, stale, []*models.Revision{{VideoID: "corrected", Number: 3, Text: "hello, world", CreatedAt: now}})
+	if !stderrors.Is(err, repository.ErrConflict) {
+		t.Fatalf("SaveCorrection of a stale copy = %v, want ErrConflict", err)
+	}
+
+	// One whose revision number is taken leaves the video alone
+	current := find(t, repo, "corrected")
+	current.Transcription, current.Revision = "Hello world", 2
+	if err := repo.SaveCorrection(ctx, current, []*models.Revision{{VideoID: "corrected", Number: 2, Text: "Hello world", CreatedAt: now}}); err == nil {
+		t.Fatal("SaveCorrection with a taken revision number succeeded")
+	}
+
+	stored := find(t, repo, "corrected")
+	if stored.Transcription != "hello world" || stored.Revision != 2 || stored.Version != 2 {
+		t.Errorf("stored video = %q at revision %d v%d, want the first correction's", stored.Transcription, stored.Revision, stored.Version)
+	}
+	listed, err := repo.ListRevisions(ctx, "corrected")
+	if err != nil {
+		t.Fatalf("ListRevisions: %v", err)
+	}
+	if len(listed) != 2 || listed[0].Number != 1 || listed[1].Number != 2 {
+		t.Fatalf("ListRevisions = %+v, want revisions 1 and 2", listed)
+	}
+	revision, err := repo.FindRevision(ctx, "corrected", 2)
+	if err != nil {
+		t.Fatalf("FindRevision: %v", err)
+	}
+	if revision.Text != "hello world" {
+		t.Errorf("revision 2 text = %q, want hello world", revision.Text)
+	}
+}
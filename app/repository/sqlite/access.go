@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// accessFlushInterval is how often the times videos were read are written.
+// Tiering judges them over hours, so they needn't cost a write each.
+const accessFlushInterval = 10 * time.Second
+
+// touch records that a video was read, for tiering cold transcripts. It is
+// written with the next flush.
+func (db *DB) touch(id string) {
+	db.accessed.Record(id)
+}
+
+// flushAccesses writes the read times collected since the last flush in one
+// transaction. A failure only makes videos look colder until the next one.
+func (db *DB) flushAccesses(ctx context.Context) error {
+	reads := db.accessed.Take()
+	if len(reads) == 0 {
+		return nil
+	}
+
+	if err := db.writeAccesses(ctx, reads); err != nil {
+		db.accessed.Restore(reads)
+		return err
+	}
+	return nil
+}
+
+func (db *DB) writeAccesses(ctx context.Context, reads map[string]time.Time) error {
+	tx, err := db.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, touchQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for id, at := range reads {
+		if _, err := stmt.ExecContext(ctx, at, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// runAccessFlush flushes read times every accessFlushInterval until ctx is
+// done, then once more so none are lost on shutdown
+func (db *DB) runAccessFlush(ctx context.Context) {
+	defer close(db.flushDone)
+
+	ticker := time.NewTicker(accessFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := db.flushAccesses(flushCtx); err != nil {
+				log.Error().Err(err).Msg("Failed to record video reads")
+			}
+			return
+		case <-ticker.C:
+		}
+
+		if err := db.flushAccesses(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to record video reads")
+		}
+	}
+}
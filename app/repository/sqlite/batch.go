@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// CreateBatch stores a batch and its items in one transaction
+func (r *Repository) CreateBatch(ctx context.Context, batch *models.Batch) error {
+	const op = "SQLiteRepository.CreateBatch"
+
+	tx, err := r.db.writer.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save batch")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, insertBatchQuery, batch.ID, batch.URL, batch.Title, batch.CreatedAt); err != nil {
+		return errors.Internal(op, err, "Failed to save batch")
+	}
+
+	stmt, err := tx.PrepareContext(ctx, insertBatchItemQuery)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save batch")
+	}
+	defer stmt.Close()
+
+	for i, item := range batch.Items {
+		if _, err := stmt.ExecContext(ctx, batch.ID, i, item.URL, item.VideoID, item.Error); err != nil {
+			return errors.Internal(op, err, "Failed to save batch item")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Internal(op, err, "Failed to save batch")
+	}
+	return nil
+}
+
+// UpdateBatchItem records the outcome of submitting one item
+func (r *Repository) UpdateBatchItem(ctx context.Context, batchID string, position int, item models.BatchItem) error {
+	const op = "SQLiteRepository.UpdateBatchItem"
+
+	_, err := r.db.writer.ExecContext(ctx, updateBatchItemQuery, item.VideoID, item.Error, batchID, position)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to update batch item")
+	}
+	return nil
+}
+
+// FindBatch loads a batch with its items and their videos' current status
+func (r *Repository) FindBatch(ctx context.Context, id string) (*models.Batch, error) {
+	const op = "SQLiteRepository.FindBatch"
+
+	batch := &models.Batch{}
+	err := r.db.QueryRowContext(ctx, getBatchQuery, id).Scan(&batch.ID, &batch.URL, &batch.Title, &batch.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "Batch not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query batch")
+	}
+
+	rows, err := r.db.QueryContext(ctx, batchItemsQuery, id)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query batch items")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.BatchItem
+		var status string
+		if err := rows.Scan(&item.URL, &item.VideoID, &item.Error, &status); err != nil {
+			return nil, errors.Internal(op, err, "Failed to read batch item")
+		}
+		item.VideoStatus = models.Status(status)
+		batch.Items = append(batch.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query batch items")
+	}
+
+	return batch, nil
+}
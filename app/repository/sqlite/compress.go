@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdMagic starts every zstd frame. Valid UTF-8 text can't begin with it
+// (0xB5 is a continuation byte), so stored values are self-describing and
+// compressed and plain rows can live side by side.
+var zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+
+// Encoders and decoders are safe for concurrent EncodeAll/DecodeAll calls
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// textCodec transparently compresses large transcript columns
+type textCodec struct {
+	enabled bool
+	minSize int // texts shorter than this are stored as plain text
+}
+
+// encode returns the value to store for text: a zstd-compressed BLOB when
+// compression is enabled and the text is large enough, otherwise the text
+func (c textCodec) encode(text string) interface{} {
+	if !c.enabled || len(text) < c.minSize {
+		return text
+	}
+	return zstdEncoder.EncodeAll([]byte(text), nil)
+}
+
+// decode reverses encode. It handles compressed rows even when compression
+// has since been disabled.
+func (c textCodec) decode(raw []byte) (string, error) {
+	if !isCompressed(raw) {
+		return string(raw), nil
+	}
+
+	text, err := zstdDecoder.DecodeAll(raw, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress transcript: %w", err)
+	}
+	return string(text), nil
+}
+
+// reader streams a stored value without decompressing it all at once
+func (c textCodec) reader(raw []byte) (io.Reader, error) {
+	if !isCompressed(raw) {
+		return bytes.NewReader(raw), nil
+	}
+
+	dec, err := zstd.NewReader(bytes.NewReader(raw), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress transcript: %w", err)
+	}
+	return dec.IOReadCloser(), nil
+}
+
+func isCompressed(raw []byte) bool {
+	return bytes.HasPrefix(raw, zstdMagic)
+}
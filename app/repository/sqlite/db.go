@@ -1,14 +1,29 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+	"yt-text/repository"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
+// DB holds two pools on one database file. SQLite lets a single connection
+// write at a time, so writes share one connection and queue in database/sql
+// instead of failing with "database is locked"; reads use the embedded pool
+// and, with the WAL, never wait for a writer.
 type DB struct {
 	*sql.DB
+	writer     *sql.DB
 	statements *statements
+
+	// Read times waiting for the next flush
+	accessed  repository.AccessLog
+	stopFlush context.CancelFunc
+	flushDone chan struct{}
 }
 
 type statements struct {
@@ -18,75 +33,143 @@ type statements struct {
 	update   *sql.Stmt
 }
 
-func NewDB(path string) (*DB, error) {
-	db, err := sql.Open("sqlite3", path)
-	if err != nil {
-		return nil, err
+// PoolConfig bounds the pool of read connections. Zero values keep
+// database/sql's defaults.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// BusyTimeout is how long a connection waits for another process's
+	// lock, such as a cleanup run from the command line, before failing
+	BusyTimeout time.Duration
+}
+
+// DefaultBusyTimeout is used when PoolConfig.BusyTimeout is zero
+const DefaultBusyTimeout = 5 * time.Second
+
+func NewDB(path string, pool PoolConfig) (*DB, error) {
+	writer := open(path, pool.BusyTimeout, true)
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
+	if pool.ConnMaxLifetime > 0 {
+		writer.SetConnMaxLifetime(pool.ConnMaxLifetime)
 	}
 
 	// Configure database
-	if err := setupDB(db); err != nil {
-		db.Close()
+	if err := setupDB(writer); err != nil {
+		writer.Close()
 		return nil, err
 	}
 
+	db := open(path, pool.BusyTimeout, false)
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+
 	// Prepare statements
-	stmts, err := prepareStatements(db)
+	stmts, err := prepareStatements(db, writer)
 	if err != nil {
 		db.Close()
+		writer.Close()
 		return nil, err
 	}
 
-	return &DB{
+	flushCtx, stopFlush := context.WithCancel(context.Background())
+	d := &DB{
 		DB:         db,
+		writer:     writer,
 		statements: stmts,
-	}, nil
+		stopFlush:  stopFlush,
+		flushDone:  make(chan struct{}),
+	}
+	go d.runAccessFlush(flushCtx)
+	return d, nil
 }
 
-func setupDB(db *sql.DB) error {
-	// Set pragmas for better performance
-	pragmas := []string{
-		"PRAGMA journal_mode = WAL",
-		"PRAGMA synchronous = NORMAL",
-		"PRAGMA foreign_keys = ON",
-		"PRAGMA temp_store = MEMORY",
+// open returns a pool of connections to the database at path. Writer
+// connections begin transactions with the write lock already taken, so a
+// transaction never fails to upgrade its lock halfway through.
+func open(path string, busyTimeout time.Duration, writer bool) *sql.DB {
+	if busyTimeout <= 0 {
+		busyTimeout = DefaultBusyTimeout
 	}
+	dsn := fmt.Sprintf("%s?_busy_timeout=%d", path, busyTimeout.Milliseconds())
+	if writer {
+		dsn += "&_txlock=immediate"
+	}
+	return sql.OpenDB(connector{dsn: dsn})
+}
 
-	for _, pragma := range pragmas {
-		if _, err := db.Exec(pragma); err != nil {
-			return err
+// connector opens connections with the pragmas that only last as long as
+// the connection
+type connector struct {
+	dsn string
+}
+
+var sqliteDriver = &sqlite3.SQLiteDriver{
+	ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+		pragmas := []string{
+			"PRAGMA synchronous = NORMAL",
+			"PRAGMA foreign_keys = ON",
+			"PRAGMA temp_store = MEMORY",
+		}
+		for _, pragma := range pragmas {
+			if _, err := conn.Exec(pragma, nil); err != nil {
+				return err
+			}
 		}
+		return nil
+	},
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return sqliteDriver.Open(c.dsn)
+}
+
+func (c connector) Driver() driver.Driver {
+	return sqliteDriver
+}
+
+func setupDB(db *sql.DB) error {
+	if err := setPragmas(db); err != nil {
+		return err
 	}
 
-	// Create tables
-	if err := createTables(db); err != nil {
+	// Bring the schema up to date
+	if _, _, err := migrate(db, -1); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func createTables(db *sql.DB) error {
-	_, err := db.Exec(`
-        CREATE TABLE IF NOT EXISTS videos (
-            id TEXT PRIMARY KEY,
-            url TEXT UNIQUE NOT NULL,
-            title TEXT,
-            status TEXT NOT NULL,
-            transcription TEXT,
-            error TEXT,
-            created_at DATETIME NOT NULL,
-            updated_at DATETIME NOT NULL
-        );
-        CREATE INDEX IF NOT EXISTS idx_videos_url ON videos(url);
-        CREATE INDEX IF NOT EXISTS idx_videos_status ON videos(status);
-    `)
-	return err
+// setPragmas sets the pragmas stored in the database file. auto_vacuum only
+// takes effect on databases without tables yet; Maintain can convert older
+// ones.
+func setPragmas(db *sql.DB) error {
+	pragmas := []string{
+		"PRAGMA auto_vacuum = INCREMENTAL",
+		"PRAGMA journal_mode = WAL",
+	}
+
+	for _, pragma := range pragmas {
+		if _, err := db.Exec(pragma); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func prepareStatements(db *sql.DB) (*statements, error) {
+func prepareStatements(db, writer *sql.DB) (*statements, error) {
 	// Prepare all statements
-	insert, err := db.Prepare(insertQuery)
+	insert, err := writer.Prepare(insertQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +187,7 @@ func prepareStatements(db *sql.DB) (*statements, error) {
 		return nil, err
 	}
 
-	update, err := db.Prepare(updateQuery)
+	update, err := writer.Prepare(updateQuery)
 	if err != nil {
 		insert.Close()
 		get.Close()
@@ -120,12 +203,17 @@ func prepareStatements(db *sql.DB) (*statements, error) {
 	}, nil
 }
 
+// Close writes the pending read times and closes the connections
 func (db *DB) Close() error {
+	db.stopFlush()
+	<-db.flushDone
+
 	if db.statements != nil {
 		db.statements.insert.Close()
 		db.statements.get.Close()
 		db.statements.getByURL.Close()
 		db.statements.update.Close()
 	}
+	db.writer.Close()
 	return db.DB.Close()
 }
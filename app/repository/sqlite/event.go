@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"context"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// AddJobEvent appends an entry to a video's job history
+func (r *Repository) AddJobEvent(ctx context.Context, event models.JobEvent) error {
+	const op = "SQLiteRepository.AddJobEvent"
+
+	_, err := r.db.writer.ExecContext(ctx, insertJobEventQuery, event.VideoID, event.Type, event.Detail, event.CreatedAt)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save job event")
+	}
+	return nil
+}
+
+// ListJobEvents returns a video's job history, oldest first
+func (r *Repository) ListJobEvents(ctx context.Context, videoID string) ([]models.JobEvent, error) {
+	const op = "SQLiteRepository.ListJobEvents"
+
+	rows, err := r.db.QueryContext(ctx, listJobEventsQuery, videoID)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query job events")
+	}
+	defer rows.Close()
+
+	events := []models.JobEvent{}
+	for rows.Next() {
+		var event models.JobEvent
+		var eventType string
+		if err := rows.Scan(&event.VideoID, &eventType, &event.Detail, &event.CreatedAt); err != nil {
+			return nil, errors.Internal(op, err, "Failed to read job event")
+		}
+		event.Type = models.JobEventType(eventType)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query job events")
+	}
+	return events, nil
+}
@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// SaveJob stores a job, or updates it when the video already has one
+func (r *Repository) SaveJob(ctx context.Context, job models.QueuedJob) error {
+	const op = "SQLiteRepository.SaveJob"
+
+	options, err := encodeWhisperOptions(job.Whisper)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to encode job options")
+	}
+	captions, err := encodeCaptionOptions(job.Captions)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to encode job options")
+	}
+
+	var claimedAt, notBefore interface{}
+	if !job.ClaimedAt.IsZero() {
+		claimedAt = job.ClaimedAt
+	}
+	if !job.NotBefore.IsZero() {
+		notBefore = job.NotBefore
+	}
+
+	_, err = r.db.writer.ExecContext(ctx, saveJobQuery, job.VideoID, job.Source, job.Model, options, job.Priority, job.RequestID, job.QueuedAt,
+		job.ClaimedBy, claimedAt, notBefore, captions, job.LookUp)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save job")
+	}
+	return nil
+}
+
+func (r *Repository) DeleteJob(ctx context.Context, videoID string) error {
+	const op = "SQLiteRepository.DeleteJob"
+
+	if _, err := r.db.writer.ExecContext(ctx, deleteJobQuery, videoID); err != nil {
+		return errors.Internal(op, err, "Failed to delete job")
+	}
+	return nil
+}
+
+// ListJobs returns stored jobs in the order they should be resumed:
+// prioritized jobs first, then oldest first
+func (r *Repository) ListJobs(ctx context.Context) ([]models.QueuedJob, error) {
+	const op = "SQLiteRepository.ListJobs"
+
+	rows, err := r.db.QueryContext(ctx, listJobsQuery)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query jobs")
+	}
+	defer rows.Close()
+
+	var jobs []models.QueuedJob
+	for rows.Next() {
+		var job models.QueuedJob
+		var options, captions string
+		if err := rows.Scan(&job.VideoID, &job.Source, &job.Model, &options, &captions, &job.Priority, &job.RequestID, &job.QueuedAt, &job.LookUp); err != nil {
+			return nil, errors.Internal(op, err, "Failed to read job")
+		}
+		if job.Whisper, err = decodeWhisperOptions(options); err != nil {
+			return nil, errors.Internal(op, err, "Failed to read job")
+		}
+		if job.Captions, err = decodeCaptionOptions(captions); err != nil {
+			return nil, errors.Internal(op, err, "Failed to read job")
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query jobs")
+	}
+
+	return jobs, nil
+}
+
+// ClaimJob hands the next waiting job to owner, along with jobs whose claim
+// has not been renewed since expiredBefore. Jobs scheduled for later are
+// left until then. It returns nil when no job is waiting.
+func (r *Repository) ClaimJob(ctx context.Context, owner string, expiredBefore time.Time) (*models.QueuedJob, error) {
+	const op = "SQLiteRepository.ClaimJob"
+
+	job := models.QueuedJob{ClaimedBy: owner, ClaimedAt: time.Now()}
+	var options, captions string
+	err := r.db.writer.QueryRowContext(ctx, claimJobQuery, owner, job.ClaimedAt, expiredBefore, job.ClaimedAt).
+		Scan(&job.VideoID, &job.Source, &job.Model, &options, &captions, &job.Priority, &job.RequestID, &job.QueuedAt, &job.LookUp)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to claim job")
+	}
+	if job.Whisper, err = decodeWhisperOptions(options); err != nil {
+		return nil, errors.Internal(op, err, "Failed to read job")
+	}
+	if job.Captions, err = decodeCaptionOptions(captions); err != nil {
+		return nil, errors.Internal(op, err, "Failed to read job")
+	}
+	return &job, nil
+}
+
+// RenewJobClaims extends the claims held by owner
+func (r *Repository) RenewJobClaims(ctx context.Context, owner string) error {
+	const op = "SQLiteRepository.RenewJobClaims"
+
+	if _, err := r.db.writer.ExecContext(ctx, renewJobClaimsQuery, time.Now(), owner); err != nil {
+		return errors.Internal(op, err, "Failed to renew job claims")
+	}
+	return nil
+}
+
+// ReleaseJobClaims gives up the claims held by owner
+func (r *Repository) ReleaseJobClaims(ctx context.Context, owner string) error {
+	const op = "SQLiteRepository.ReleaseJobClaims"
+
+	if _, err := r.db.writer.ExecContext(ctx, releaseJobClaimsQuery, owner); err != nil {
+		return errors.Internal(op, err, "Failed to release job claims")
+	}
+	return nil
+}
+
+// encodeWhisperOptions stores a job's Whisper options as JSON
+func encodeWhisperOptions(options models.WhisperOptions) (string, error) {
+	if options.IsZero() {
+		return "", nil
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeWhisperOptions(text string) (models.WhisperOptions, error) {
+	var options models.WhisperOptions
+	if text == "" {
+		return options, nil
+	}
+
+	if err := json.Unmarshal([]byte(text), &options); err != nil {
+		return options, fmt.Errorf("failed to decode whisper options: %w", err)
+	}
+	return options, nil
+}
+
+// encodeCaptionOptions stores a job's caption options as JSON
+func encodeCaptionOptions(options models.CaptionOptions) (string, error) {
+	if options.IsZero() {
+		return "", nil
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeCaptionOptions(text string) (models.CaptionOptions, error) {
+	var options models.CaptionOptions
+	if text == "" {
+		return options, nil
+	}
+
+	if err := json.Unmarshal([]byte(text), &options); err != nil {
+		return options, fmt.Errorf("failed to decode caption options: %w", err)
+	}
+	return options, nil
+}
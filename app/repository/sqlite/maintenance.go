@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// autoVacuumIncremental is the auto_vacuum mode that lets
+// PRAGMA incremental_vacuum return free pages to the file system
+const autoVacuumIncremental = 2
+
+// MaintenanceResult reports what a maintenance pass did
+type MaintenanceResult struct {
+	// CheckpointedFrames is how many WAL frames were copied into the
+	// database before the WAL was truncated
+	CheckpointedFrames int `json:"checkpointed_frames"`
+
+	// FreedPages is how many free pages were returned to the file system
+	FreedPages int64 `json:"freed_pages"`
+
+	// Vacuumed reports a full VACUUM, which also switches databases created
+	// before incremental vacuuming to it
+	Vacuumed bool `json:"vacuumed"`
+
+	SizeBefore int64   `json:"size_before"`
+	SizeAfter  int64   `json:"size_after"`
+	Seconds    float64 `json:"seconds"`
+}
+
+// Maintain checkpoints and truncates the WAL, refreshes the query planner's
+// statistics and returns free pages to the file system. Databases created
+// before incremental vacuuming was turned on only shrink with full, which
+// rewrites the whole file and blocks writers while it runs.
+func (db *DB) Maintain(ctx context.Context, full bool) (*MaintenanceResult, error) {
+	started := time.Now()
+	result := &MaintenanceResult{}
+
+	var err error
+	if result.SizeBefore, err = db.size(ctx); err != nil {
+		return nil, err
+	}
+
+	// Pragmas that change the file's mode apply to the connection running
+	// the VACUUM, so the pass keeps to the writer's
+	conn, err := db.writer.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var busy, frames int
+	if err := conn.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &frames, &result.CheckpointedFrames); err != nil {
+		return nil, fmt.Errorf("failed to checkpoint: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "ANALYZE"); err != nil {
+		return nil, fmt.Errorf("failed to analyze: %w", err)
+	}
+
+	var mode int
+	if err := conn.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&mode); err != nil {
+		return nil, err
+	}
+	var freeBefore, freeAfter int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA freelist_count").Scan(&freeBefore); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case full:
+		if mode != autoVacuumIncremental {
+			if _, err := conn.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := conn.ExecContext(ctx, "VACUUM"); err != nil {
+			return nil, fmt.Errorf("failed to vacuum: %w", err)
+		}
+		result.Vacuumed = true
+	case mode == autoVacuumIncremental:
+		// Each step of the pragma frees one page, so its rows are read to
+		// the end
+		rows, err := conn.QueryContext(ctx, "PRAGMA incremental_vacuum")
+		if err != nil {
+			return nil, fmt.Errorf("failed to vacuum: %w", err)
+		}
+		for rows.Next() {
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to vacuum: %w", err)
+		}
+	}
+
+	if err := conn.QueryRowContext(ctx, "PRAGMA freelist_count").Scan(&freeAfter); err != nil {
+		return nil, err
+	}
+	result.FreedPages = freeBefore - freeAfter
+	if result.SizeAfter, err = db.size(ctx); err != nil {
+		return nil, err
+	}
+	result.Seconds = time.Since(started).Round(time.Millisecond).Seconds()
+	return result, nil
+}
+
+// size returns the bytes the database's pages take up
+func (db *DB) size(ctx context.Context) (int64, error) {
+	var size int64
+	err := db.QueryRowContext(ctx, databaseSizeQuery).Scan(&size)
+	return size, err
+}
+
+// RunMaintenance maintains the database every interval until ctx is done,
+// starting one interval from now
+func (db *DB) RunMaintenance(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result, err := db.Maintain(ctx, false)
+		if err != nil {
+			log.Error().Err(err).Msg("Database maintenance failed")
+			continue
+		}
+		log.Info().
+			Int("checkpointed_frames", result.CheckpointedFrames).
+			Int64("freed_pages", result.FreedPages).
+			Int64("bytes", result.SizeAfter).
+			Msg("Maintained database")
+	}
+}
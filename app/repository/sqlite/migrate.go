@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// migrationFiles hold the schema's numbered migrations, each a pair of
+// NNNN_name.up.sql and NNNN_name.down.sql applied in one transaction.
+// Add new ones; never change one that has already shipped.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationName = regexp.MustCompile(`^(\d+)_\w+\.(up|down)\.sql$`)
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations reads the embedded migrations, checking that they are
+// numbered from 1 without gaps and can all be reverted
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(migrationFiles, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: entry.Name()}
+			byVersion[version] = m
+		}
+		if match[2] == "up" {
+			m.up, m.name = string(body), entry.Name()
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	migrations := make([]migration, len(byVersion))
+	for version := 1; version <= len(byVersion); version++ {
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("migration %d is missing", version)
+		}
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", m.name)
+		}
+		migrations[version-1] = *m
+	}
+	return migrations, nil
+}
+
+// Migrate moves the schema of the database at path to version target, or
+// to the latest version when target is negative, reverting migrations to
+// go down. It returns the versions before and after.
+func Migrate(path string, target int, busyTimeout time.Duration) (from, to int, err error) {
+	db := open(path, busyTimeout, true)
+	defer db.Close()
+
+	if err := setPragmas(db); err != nil {
+		return 0, 0, err
+	}
+	return migrate(db, target)
+}
+
+// migrate applies or reverts migrations until the schema is at version
+// target, or the latest version when target is negative
+func migrate(db *sql.DB, target int) (from, to int, err error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, 0, err
+	}
+	if target < 0 {
+		target = len(migrations)
+	}
+	if target > len(migrations) {
+		return 0, 0, fmt.Errorf("no migration %d, the latest is %d", target, len(migrations))
+	}
+
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS schema_version (
+            version INTEGER PRIMARY KEY,
+            applied_at DATETIME NOT NULL
+        )
+    `)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&current); err != nil {
+		return 0, 0, err
+	}
+	if current > len(migrations) {
+		return current, current, fmt.Errorf("database schema is at version %d, newer than this build knows (%d)", current, len(migrations))
+	}
+	if current == 0 {
+		if err := adoptLegacySchema(db); err != nil {
+			return 0, 0, fmt.Errorf("failed to upgrade database tables: %w", err)
+		}
+	}
+
+	from = current
+	for current < target {
+		m := migrations[current]
+		if err := step(db, m.up, `INSERT INTO schema_version (version, applied_at) VALUES (?, ?)`, m.version, time.Now()); err != nil {
+			return from, current, fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+		}
+		current++
+	}
+	for current > target {
+		m := migrations[current-1]
+		if err := step(db, m.down, `DELETE FROM schema_version WHERE version = ?`, m.version); err != nil {
+			return from, current, fmt.Errorf("failed to revert migration %s: %w", m.name, err)
+		}
+		current--
+	}
+	return from, current, nil
+}
+
+// step runs a migration and records it in one transaction
+func step(db *sql.DB, script, record string, args ...interface{}) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// legacyColumns were added to tables by hand before migrations were
+// numbered. Databases from then may lack any of them.
+var legacyColumns = []struct {
+	table      string
+	name       string
+	definition string
+}{
+	{"videos", "failure_log", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "error_code", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "source", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "caption_wer", "REAL"},
+	{"videos", "secondary_transcription", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "secondary_source", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "language", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "pinned", "INTEGER NOT NULL DEFAULT 0"},
+	{"videos", "segments", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "secondary_segments", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "chapters", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "transcript_key", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "secondary_transcript_key", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "last_accessed", "DATETIME"},
+	{"videos", "platform", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "media_id", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "uploader", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "expires_at", "DATETIME"},
+	{"videos", "retry_at", "DATETIME"},
+	{"videos", "caption_language", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "caption_kind", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "timings", "TEXT NOT NULL DEFAULT ''"},
+	{"videos", "model", "TEXT NOT NULL DEFAULT ''"},
+	{"jobs", "model", "TEXT NOT NULL DEFAULT ''"},
+	{"jobs", "whisper_options", "TEXT NOT NULL DEFAULT ''"},
+	{"jobs", "claimed_by", "TEXT NOT NULL DEFAULT ''"},
+	{"jobs", "claimed_at", "DATETIME"},
+	{"jobs", "not_before", "DATETIME"},
+	{"jobs", "caption_options", "TEXT NOT NULL DEFAULT ''"},
+	{"jobs", "look_up", "INTEGER NOT NULL DEFAULT 0"},
+	{"batches", "url", "TEXT NOT NULL DEFAULT ''"},
+	{"batches", "title", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// adoptLegacySchema brings the tables of a database from before numbered
+// migrations up to the first migration, which then creates whatever
+// tables are still missing. It does nothing to a new database.
+func adoptLegacySchema(db *sql.DB) error {
+	for _, c := range legacyColumns {
+		if err := addColumnIfMissing(db, c.table, c.name, c.definition); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", c.table, c.name, err)
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column to a table that exists without it
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	var tables, columns int
+	err := db.QueryRow(
+		"SELECT (SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?), (SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?)",
+		table, table, column,
+	).Scan(&tables, &columns)
+	if err != nil {
+		return err
+	}
+	if tables == 0 || columns > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS batch_items;
+DROP TABLE IF EXISTS batches;
+DROP TABLE IF EXISTS job_events;
+DROP TABLE IF EXISTS summaries;
+DROP TABLE IF EXISTS jobs;
+DROP TABLE IF EXISTS videos;
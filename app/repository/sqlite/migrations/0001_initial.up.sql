@@ -0,0 +1,85 @@
+-- The schema as of the first numbered migration. Tables are created only
+-- if missing, so databases from before numbered migrations can adopt it.
+CREATE TABLE IF NOT EXISTS videos (
+    id TEXT PRIMARY KEY,
+    url TEXT UNIQUE NOT NULL,
+    title TEXT,
+    language TEXT NOT NULL DEFAULT '',
+    platform TEXT NOT NULL DEFAULT '',
+    media_id TEXT NOT NULL DEFAULT '',
+    uploader TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    pinned INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME,
+    transcription TEXT,
+    source TEXT NOT NULL DEFAULT '',
+    segments TEXT NOT NULL DEFAULT '',
+    secondary_transcription TEXT NOT NULL DEFAULT '',
+    secondary_source TEXT NOT NULL DEFAULT '',
+    secondary_segments TEXT NOT NULL DEFAULT '',
+    chapters TEXT NOT NULL DEFAULT '',
+    transcript_key TEXT NOT NULL DEFAULT '',
+    secondary_transcript_key TEXT NOT NULL DEFAULT '',
+    error TEXT,
+    error_code TEXT NOT NULL DEFAULT '',
+    failure_log TEXT NOT NULL DEFAULT '',
+    caption_wer REAL,
+    caption_language TEXT NOT NULL DEFAULT '',
+    caption_kind TEXT NOT NULL DEFAULT '',
+    retry_at DATETIME,
+    timings TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    last_accessed DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_videos_url ON videos(url);
+CREATE INDEX IF NOT EXISTS idx_videos_status ON videos(status);
+CREATE INDEX IF NOT EXISTS idx_videos_media ON videos(platform, media_id);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    video_id TEXT PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    source TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    whisper_options TEXT NOT NULL DEFAULT '',
+    caption_options TEXT NOT NULL DEFAULT '',
+    priority INTEGER NOT NULL DEFAULT 0,
+    request_id TEXT NOT NULL DEFAULT '',
+    queued_at DATETIME NOT NULL,
+    claimed_by TEXT NOT NULL DEFAULT '',
+    claimed_at DATETIME,
+    not_before DATETIME,
+    look_up INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS summaries (
+    video_id TEXT PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS job_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    video_id TEXT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_job_events_video_id ON job_events(video_id, id);
+
+CREATE TABLE IF NOT EXISTS batches (
+    id TEXT PRIMARY KEY,
+    url TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS batch_items (
+    batch_id TEXT NOT NULL REFERENCES batches(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    video_id TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (batch_id, position)
+);
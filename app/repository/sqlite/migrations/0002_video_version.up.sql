@@ -0,0 +1 @@
+ALTER TABLE videos ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_videos;
+DROP TABLE IF EXISTS users;
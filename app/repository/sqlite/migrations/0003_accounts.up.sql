@@ -0,0 +1,19 @@
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    last_login DATETIME NOT NULL,
+    UNIQUE (provider, subject)
+);
+
+CREATE TABLE user_videos (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    video_id TEXT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, video_id)
+);
+CREATE INDEX idx_user_videos_created_at ON user_videos(user_id, created_at DESC);
+CREATE INDEX idx_user_videos_video_id ON user_videos(video_id);
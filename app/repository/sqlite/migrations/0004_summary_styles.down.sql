@@ -0,0 +1,13 @@
+CREATE TABLE summaries_old (
+    video_id TEXT PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+-- Keep each video's newest abstract, the only style there was before
+INSERT OR REPLACE INTO summaries_old (video_id, summary, source, model, created_at)
+SELECT video_id, summary, source, model, created_at FROM summaries
+WHERE style = 'abstract' ORDER BY created_at;
+DROP TABLE summaries;
+ALTER TABLE summaries_old RENAME TO summaries;
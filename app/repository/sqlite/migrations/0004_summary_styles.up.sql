@@ -0,0 +1,13 @@
+CREATE TABLE summaries_new (
+    video_id TEXT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    style TEXT NOT NULL DEFAULT 'abstract',
+    model TEXT NOT NULL DEFAULT '',
+    summary TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (video_id, style, model)
+);
+INSERT INTO summaries_new (video_id, style, model, summary, source, created_at)
+SELECT video_id, 'abstract', model, summary, source, created_at FROM summaries;
+DROP TABLE summaries;
+ALTER TABLE summaries_new RENAME TO summaries;
@@ -0,0 +1,13 @@
+CREATE TABLE embeddings (
+    video_id TEXT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    model TEXT NOT NULL,
+    passage INTEGER NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    start_time REAL NOT NULL,
+    end_time REAL NOT NULL,
+    text TEXT NOT NULL,
+    vector BLOB NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (video_id, model, passage)
+);
+CREATE INDEX idx_embeddings_model ON embeddings(model);
@@ -0,0 +1 @@
+ALTER TABLE videos ADD COLUMN enrichment TEXT NOT NULL DEFAULT '';
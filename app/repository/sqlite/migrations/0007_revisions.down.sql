@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS revisions;
+ALTER TABLE videos DROP COLUMN revision;
@@ -0,0 +1,13 @@
+ALTER TABLE videos ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE revisions (
+    video_id TEXT NOT NULL REFERENCES videos(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    text BLOB NOT NULL,
+    segments BLOB NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    user_id TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (video_id, revision)
+);
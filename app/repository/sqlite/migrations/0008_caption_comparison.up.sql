@@ -0,0 +1 @@
+ALTER TABLE videos ADD COLUMN caption_comparison TEXT NOT NULL DEFAULT '';
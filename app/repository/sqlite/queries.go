@@ -1,38 +1,418 @@
 package sqlite
 
 const (
+	videoColumns = `
+        id, url, title, language, platform, media_id, uploader, status, pinned, expires_at, transcription, source,
+        segments,
+        secondary_transcription, secondary_source, secondary_segments, chapters,
+        transcript_key, secondary_transcript_key,
+        error, error_code, failure_log, caption_wer, caption_language, caption_kind, retry_at, timings, model,
+        enrichment, revision, caption_comparison, created_at, updated_at, version
+    `
+
+	// Updates only a row still at the version before the saved one
 	insertQuery = `
-        INSERT INTO videos (
-            id, url, title, status, transcription,
-            error, created_at, updated_at
-        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        INSERT INTO videos (` + videoColumns + `)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         ON CONFLICT(id) DO UPDATE SET
             title = excluded.title,
+            language = excluded.language,
+            platform = excluded.platform,
+            media_id = excluded.media_id,
+            uploader = excluded.uploader,
             status = excluded.status,
             transcription = excluded.transcription,
+            source = excluded.source,
+            segments = excluded.segments,
+            secondary_transcription = excluded.secondary_transcription,
+            secondary_source = excluded.secondary_source,
+            secondary_segments = excluded.secondary_segments,
+            chapters = excluded.chapters,
+            transcript_key = excluded.transcript_key,
+            secondary_transcript_key = excluded.secondary_transcript_key,
             error = excluded.error,
-            updated_at = excluded.updated_at
+            error_code = excluded.error_code,
+            failure_log = excluded.failure_log,
+            caption_wer = excluded.caption_wer,
+            caption_language = excluded.caption_language,
+            caption_kind = excluded.caption_kind,
+            retry_at = excluded.retry_at,
+            timings = excluded.timings,
+            model = excluded.model,
+            enrichment = excluded.enrichment,
+            revision = excluded.revision,
+            caption_comparison = excluded.caption_comparison,
+            updated_at = excluded.updated_at,
+            version = excluded.version
+        WHERE videos.version = excluded.version - 1
     `
 
 	getQuery = `
-        SELECT id, url, title, status, transcription,
-               error, created_at, updated_at
+        SELECT ` + videoColumns + `
         FROM videos WHERE id = ?
     `
 
 	getByURLQuery = `
-        SELECT id, url, title, status, transcription,
-               error, created_at, updated_at
+        SELECT ` + videoColumns + `
         FROM videos WHERE url = ?
     `
 
+	getByMediaQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos WHERE platform = ? AND media_id = ?
+        ORDER BY status = 'completed' DESC, updated_at DESC
+        LIMIT 1
+    `
+
 	updateQuery = `
         UPDATE videos SET
             title = ?,
+            language = ?,
+            platform = ?,
+            media_id = ?,
+            uploader = ?,
             status = ?,
             transcription = ?,
+            source = ?,
+            segments = ?,
+            secondary_transcription = ?,
+            secondary_source = ?,
+            secondary_segments = ?,
+            chapters = ?,
+            transcript_key = ?,
+            secondary_transcript_key = ?,
             error = ?,
+            error_code = ?,
+            failure_log = ?,
+            caption_wer = ?,
             updated_at = ?
         WHERE id = ?
     `
+
+	captionQualityQuery = `
+        SELECT COUNT(*), COALESCE(AVG(caption_wer), 0),
+            COALESCE(SUM(CASE WHEN caption_wer <= ? THEN 1 ELSE 0 END), 0)
+        FROM videos WHERE caption_wer IS NOT NULL
+    `
+
+	statusCountsQuery = `
+        SELECT status, COUNT(*) FROM videos GROUP BY status
+    `
+
+	sourceCountsQuery = `
+        SELECT source, COUNT(*) FROM videos WHERE source != '' GROUP BY source
+    `
+
+	modelStatsQuery = `
+        SELECT model, COUNT(*),
+            COALESCE(AVG(CASE WHEN status = 'completed' AND timings != ''
+                THEN json_extract(timings, '$.total_seconds') END), 0)
+        FROM videos WHERE model != ''
+        GROUP BY model ORDER BY model
+    `
+
+	transcriptBytesQuery = `
+        SELECT COALESCE(SUM(COALESCE(length(transcription), 0) + length(secondary_transcription)), 0)
+        FROM videos
+    `
+
+	transcriptSourcesQuery = `
+        SELECT source, secondary_source,
+            typeof(transcription) = 'blob', typeof(secondary_transcription) = 'blob'
+        FROM videos WHERE id = ?
+    `
+
+	primaryChunkQuery = `
+        SELECT COALESCE(substr(transcription, ?, ?), '') FROM videos WHERE id = ?
+    `
+
+	secondaryChunkQuery = `
+        SELECT substr(secondary_transcription, ?, ?) FROM videos WHERE id = ?
+    `
+
+	primaryTranscriptQuery = `
+        SELECT transcription FROM videos WHERE id = ?
+    `
+
+	secondaryTranscriptQuery = `
+        SELECT secondary_transcription FROM videos WHERE id = ?
+    `
+
+	recentByStatusQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos WHERE status = ?
+        ORDER BY updated_at DESC LIMIT ?
+    `
+
+	// Empty filter values match every row. Keywords are stored lowercase;
+	// entity names match in any case.
+	listFilter = `
+        WHERE (? = '' OR status = ?) AND (? = '' OR language = ?)
+            AND (? = '' OR id IN (SELECT video_id FROM user_videos WHERE user_id = ?))
+            AND (? = '' OR EXISTS (SELECT 1 FROM json_each(NULLIF(enrichment, ''), '$.keywords')
+                WHERE value = lower(?)))
+            AND (? = '' OR EXISTS (SELECT 1 FROM json_each(NULLIF(enrichment, ''), '$.entities')
+                WHERE lower(json_extract(value, '$.name')) = lower(?)))
+    `
+
+	listQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos ` + listFilter + `
+        ORDER BY created_at DESC, id LIMIT ? OFFSET ?
+    `
+
+	// A user's videos are listed by when they last submitted them
+	userListQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos ` + listFilter + `
+        ORDER BY (SELECT created_at FROM user_videos WHERE user_id = ? AND video_id = videos.id) DESC, id
+        LIMIT ? OFFSET ?
+    `
+
+	countQuery = `
+        SELECT COUNT(*) FROM videos ` + listFilter
+
+	// Claims are only changed by the claim queries below
+	saveJobQuery = `
+        INSERT INTO jobs (video_id, source, model, whisper_options, priority, request_id, queued_at,
+            claimed_by, claimed_at, not_before, caption_options, look_up)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT(video_id) DO UPDATE SET
+            source = excluded.source,
+            model = excluded.model,
+            whisper_options = excluded.whisper_options,
+            priority = excluded.priority,
+            request_id = excluded.request_id,
+            not_before = excluded.not_before,
+            caption_options = excluded.caption_options,
+            look_up = excluded.look_up
+    `
+
+	deleteJobQuery = `
+        DELETE FROM jobs WHERE video_id = ?
+    `
+
+	listJobsQuery = `
+        SELECT video_id, source, model, whisper_options, caption_options, priority, request_id, queued_at, look_up
+        FROM jobs ORDER BY priority DESC, queued_at
+    `
+
+	// A single statement, so two processes can't claim the same job
+	claimJobQuery = `
+        UPDATE jobs SET claimed_by = ?, claimed_at = ?
+        WHERE video_id = (
+            SELECT video_id FROM jobs
+            WHERE (claimed_by = '' OR claimed_at < ?) AND (not_before IS NULL OR not_before <= ?)
+            ORDER BY priority DESC, queued_at LIMIT 1
+        )
+        RETURNING video_id, source, model, whisper_options, caption_options, priority, request_id, queued_at, look_up
+    `
+
+	renewJobClaimsQuery = `
+        UPDATE jobs SET claimed_at = ? WHERE claimed_by = ?
+    `
+
+	releaseJobClaimsQuery = `
+        UPDATE jobs SET claimed_by = '', claimed_at = NULL WHERE claimed_by = ?
+    `
+
+	insertJobEventQuery = `
+        INSERT INTO job_events (video_id, event, detail, created_at)
+        VALUES (?, ?, ?, ?)
+    `
+
+	listJobEventsQuery = `
+        SELECT video_id, event, detail, created_at
+        FROM job_events WHERE video_id = ?
+        ORDER BY id
+    `
+
+	// One summary is kept per style and model
+	saveSummaryQuery = `
+        INSERT INTO summaries (video_id, style, model, summary, source, created_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT(video_id, style, model) DO UPDATE SET
+            summary = excluded.summary,
+            source = excluded.source,
+            created_at = excluded.created_at
+    `
+
+	// An empty model picks the newest summary of the style
+	getSummaryQuery = `
+        SELECT video_id, style, model, summary, source, created_at
+        FROM summaries WHERE video_id = ? AND style = ? AND (? = '' OR model = ?)
+        ORDER BY created_at DESC LIMIT 1
+    `
+
+	// Signing in again refreshes the profile and keeps the ID
+	saveUserQuery = `
+        INSERT INTO users (id, provider, subject, email, name, created_at, last_login)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT(provider, subject) DO UPDATE SET
+            email = excluded.email,
+            name = excluded.name,
+            last_login = excluded.last_login
+        RETURNING id, created_at
+    `
+
+	getUserQuery = `
+        SELECT id, provider, subject, email, name, created_at, last_login
+        FROM users WHERE id = ?
+    `
+
+	addUserVideoQuery = `
+        INSERT INTO user_videos (user_id, video_id, created_at) VALUES (?, ?, ?)
+        ON CONFLICT(user_id, video_id) DO UPDATE SET created_at = excluded.created_at
+    `
+
+	hasUserVideoQuery = `
+        SELECT EXISTS (SELECT 1 FROM user_videos WHERE user_id = ? AND video_id = ?)
+    `
+
+	removeUserVideoQuery = `
+        DELETE FROM user_videos WHERE user_id = ? AND video_id = ?
+    `
+
+	sharedUserVideoQuery = `
+        SELECT EXISTS (SELECT 1 FROM user_videos WHERE video_id = ?)
+    `
+
+	deleteEmbeddingsQuery = `
+        DELETE FROM embeddings WHERE video_id = ? AND model = ?
+    `
+
+	insertEmbeddingQuery = `
+        INSERT INTO embeddings (video_id, model, passage, source, start_time, end_time, text, vector, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `
+
+	scanEmbeddingsQuery = `
+        SELECT video_id, model, passage, source, start_time, end_time, text, vector, created_at
+        FROM embeddings WHERE model = ?
+    `
+
+	// Transcripts without timing aren't split into passages, so they are
+	// never embedded
+	listUnembeddedQuery = `
+        SELECT id FROM videos
+        WHERE status = 'completed' AND segments != ''
+        AND NOT EXISTS (
+            SELECT 1 FROM embeddings
+            WHERE video_id = videos.id AND model = ? AND created_at >= videos.updated_at
+        )
+        ORDER BY updated_at DESC
+        LIMIT ?
+    `
+
+	insertBatchQuery = `
+        INSERT INTO batches (id, url, title, created_at) VALUES (?, ?, ?, ?)
+    `
+
+	insertBatchItemQuery = `
+        INSERT INTO batch_items (batch_id, position, url, video_id, error)
+        VALUES (?, ?, ?, ?, ?)
+    `
+
+	updateBatchItemQuery = `
+        UPDATE batch_items SET video_id = ?, error = ?
+        WHERE batch_id = ? AND position = ?
+    `
+
+	getBatchQuery = `
+        SELECT id, url, title, created_at FROM batches WHERE id = ?
+    `
+
+	// Items whose video was deleted come back with an empty status
+	batchItemsQuery = `
+        SELECT i.url, i.video_id, i.error, COALESCE(v.status, '')
+        FROM batch_items i LEFT JOIN videos v ON v.id = i.video_id
+        WHERE i.batch_id = ?
+        ORDER BY i.position
+    `
+
+	databaseSizeQuery = `
+        SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()
+    `
+
+	deleteQuery = `
+        DELETE FROM videos WHERE id = ?
+    `
+
+	touchQuery = `
+        UPDATE videos SET last_accessed = ? WHERE id = ?
+    `
+
+	// Videos never read since tiering was enabled count from their last update
+	coldTranscriptsQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos
+        WHERE status = 'completed' AND COALESCE(last_accessed, updated_at) < ?1
+            AND ((transcript_key = '' AND length(transcription) >= ?2)
+                OR (secondary_transcript_key = '' AND length(secondary_transcription) >= ?2))
+        ORDER BY COALESCE(last_accessed, updated_at) LIMIT ?3
+    `
+
+	storedTranscriptsQuery = `
+        SELECT ` + videoColumns + `
+        FROM videos
+        WHERE pinned = 0 AND (transcript_key != '' OR secondary_transcript_key != '')
+        ORDER BY COALESCE(last_accessed, updated_at) LIMIT ?
+    `
+
+	transcriptKeysQuery = `
+        SELECT transcript_key, secondary_transcript_key
+        FROM videos
+        WHERE transcript_key != '' OR secondary_transcript_key != ''
+    `
+
+	setTranscriptKeysQuery = `
+        UPDATE videos SET
+            transcription = CASE WHEN ?1 = '' THEN transcription ELSE '' END,
+            transcript_key = CASE WHEN ?1 = '' THEN transcript_key ELSE ?1 END,
+            secondary_transcription = CASE WHEN ?2 = '' THEN secondary_transcription ELSE '' END,
+            secondary_transcript_key = CASE WHEN ?2 = '' THEN secondary_transcript_key ELSE ?2 END
+        WHERE id = ?3
+    `
+
+	setPinnedQuery = `
+        UPDATE videos SET pinned = ? WHERE id = ?
+    `
+
+	setExpiryQuery = `
+        UPDATE videos SET expires_at = ? WHERE id = ?
+    `
+
+	// Pinned videos and jobs still in progress are never cleaned up. The
+	// longest expired go first.
+	cleanupExpiredQuery = `
+        DELETE FROM videos WHERE id IN (
+            SELECT id FROM videos
+            WHERE pinned = 0 AND status != 'processing'
+                AND (expires_at < ? OR (expires_at IS NULL AND updated_at < ?))
+            ORDER BY COALESCE(expires_at, updated_at)
+            LIMIT ?
+        )
+    `
+
+	countExpiredQuery = `
+        SELECT COUNT(*) FROM videos
+        WHERE pinned = 0 AND status != 'processing'
+            AND (expires_at < ? OR (expires_at IS NULL AND updated_at < ?))
+    `
+
+	insertRevisionQuery = `
+        INSERT INTO revisions (video_id, revision, source, text, segments, note, user_id, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (video_id, revision) DO NOTHING
+    `
+
+	listRevisionsQuery = `
+        SELECT video_id, revision, source, note, user_id, created_at
+        FROM revisions WHERE video_id = ? ORDER BY revision
+    `
+
+	getRevisionQuery = `
+        SELECT video_id, revision, source, text, segments, note, user_id, created_at
+        FROM revisions WHERE video_id = ? AND revision = ?
+    `
 )
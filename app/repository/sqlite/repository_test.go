@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"yt-text/repository"
+	"yt-text/repository/repotest"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.VideoRepository {
+		db, err := NewDB(filepath.Join(t.TempDir(), "test.db"), PoolConfig{})
+		if err != nil {
+			t.Fatalf("NewDB: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		repo, err := NewRepository(db, Config{CompressTranscripts: true, CompressMinSize: 1})
+		if err != nil {
+			t.Fatalf("NewRepository: %v", err)
+		}
+		return repo
+	})
+}
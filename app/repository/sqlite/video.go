@@ -3,67 +3,122 @@ package sqlite
 import (
 	"context"
 	"database/sql"
-	"strings"
+	"encoding/json"
+	"fmt"
 	"time"
 	"yt-text/errors"
 	"yt-text/models"
+	"yt-text/repository"
 )
 
 type Repository struct {
-	db *DB
+	db    *DB
+	codec textCodec
 }
 
-func NewRepository(db *DB) (*Repository, error) {
-	return &Repository{db: db}, nil
+// Config tunes how the repository stores videos
+type Config struct {
+	CompressTranscripts bool // zstd-compress large transcript columns
+	CompressMinSize     int  // Transcripts smaller than this many bytes stay plain text
 }
 
+func NewRepository(db *DB, cfg Config) (*Repository, error) {
+	return &Repository{
+		db: db,
+		codec: textCodec{
+			enabled: cfg.CompressTranscripts,
+			minSize: cfg.CompressMinSize,
+		},
+	}, nil
+}
+
+// Save stores a video. Writes queue for the one writer connection, and a
+// lock held by another process is waited out for the busy timeout.
 func (r *Repository) Save(ctx context.Context, video *models.Video) error {
 	const op = "SQLiteRepository.Save"
 
-	for i := 0; i < 3; i++ { // Simple retry logic
-		err := r.save(ctx, video)
-		if err == nil {
-			return nil
-		}
-		if !isLockError(err) {
-			return errors.Internal(op, err, "Failed to save video")
-		}
-		time.Sleep(time.Second * time.Duration(i+1))
+	res, err := r.save(ctx, r.db.statements.insert, video)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to save video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.Conflict(op, repository.ErrConflict, "Video was changed by another request")
 	}
-	return errors.Internal(op, nil, "Failed after retries")
+	video.Version++
+	return nil
 }
 
-func (r *Repository) save(ctx context.Context, video *models.Video) error {
-	_, err := r.db.statements.insert.ExecContext(ctx,
+// save inserts or updates a video as its next version with insert, the
+// prepared statement or its copy in a transaction. Nothing is changed when
+// the stored row is at another version.
+func (r *Repository) save(ctx context.Context, insert *sql.Stmt, video *models.Video) (sql.Result, error) {
+	segments, err := r.encodeSegments(video.Segments)
+	if err != nil {
+		return nil, err
+	}
+	secondarySegments, err := r.encodeSegments(video.SecondarySegments)
+	if err != nil {
+		return nil, err
+	}
+	chapters, err := encodeChapters(video.Chapters)
+	if err != nil {
+		return nil, err
+	}
+	timings, err := encodeTimings(video.Timings)
+	if err != nil {
+		return nil, err
+	}
+	enrichment, err := encodeEnrichment(video.Enrichment)
+	if err != nil {
+		return nil, err
+	}
+	comparison, err := encodeComparison(video.CaptionComparison)
+	if err != nil {
+		return nil, err
+	}
+
+	return insert.ExecContext(ctx,
 		video.ID,
 		video.URL,
 		video.Title,
+		video.Language,
+		string(video.Platform),
+		video.MediaID,
+		video.Uploader,
 		string(video.Status),
-		video.Transcription,
+		video.Pinned,
+		video.ExpiresAt,
+		r.codec.encode(video.Transcription),
+		string(video.Source),
+		segments,
+		r.codec.encode(video.SecondaryTranscription),
+		string(video.SecondarySource),
+		secondarySegments,
+		chapters,
+		video.TranscriptKey,
+		video.SecondaryTranscriptKey,
 		video.Error,
+		string(video.ErrorCode),
+		video.FailureLog,
+		video.CaptionWER,
+		video.CaptionLanguage,
+		video.CaptionKind,
+		video.RetryAt,
+		timings,
+		video.Model,
+		enrichment,
+		video.Revision,
+		comparison,
 		video.CreatedAt,
 		video.UpdatedAt,
+		video.Version+1,
 	)
-	return err
 }
 
 func (r *Repository) Find(ctx context.Context, id string) (*models.Video, error) {
 	const op = "SQLiteRepository.Find"
 
-	video := &models.Video{}
-	var status string
-
-	err := r.db.statements.get.QueryRowContext(ctx, id).Scan(
-		&video.ID,
-		&video.URL,
-		&video.Title,
-		&status,
-		&video.Transcription,
-		&video.Error,
-		&video.CreatedAt,
-		&video.UpdatedAt,
-	)
-
+	video, err := r.scanVideo(r.db.statements.get.QueryRowContext(ctx, id))
 	if err == sql.ErrNoRows {
 		return nil, errors.NotFound(op, nil, "Video not found")
 	}
@@ -71,39 +126,579 @@ func (r *Repository) Find(ctx context.Context, id string) (*models.Video, error)
 		return nil, errors.Internal(op, err, "Failed to query video")
 	}
 
-	video.Status = models.Status(status)
+	r.db.touch(video.ID)
 	return video, nil
 }
 
 func (r *Repository) FindByURL(ctx context.Context, url string) (*models.Video, error) {
 	const op = "SQLiteRepository.FindByURL"
 
+	video, err := r.scanVideo(r.db.statements.getByURL.QueryRowContext(ctx, url))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "Video not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query video")
+	}
+
+	r.db.touch(video.ID)
+	return video, nil
+}
+
+func (r *Repository) FindByMedia(ctx context.Context, platform models.Platform, mediaID string) (*models.Video, error) {
+	const op = "SQLiteRepository.FindByMedia"
+
+	video, err := r.scanVideo(r.db.QueryRowContext(ctx, getByMediaQuery, platform, mediaID))
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFound(op, nil, "Video not found")
+	}
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query video")
+	}
+
+	r.db.touch(video.ID)
+	return video, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanVideo reads a row selected with videoColumns, decompressing
+// transcripts as needed
+func (r *Repository) scanVideo(row rowScanner) (*models.Video, error) {
 	video := &models.Video{}
-	var status string
+	var platform, status, source, secondarySource, chapters, timings, enrichment, comparison, errorCode string
+	var transcription, segments, secondaryTranscription, secondarySegments []byte
+	var captionWER sql.NullFloat64
+	var expiresAt, retryAt sql.NullTime
 
-	err := r.db.statements.getByURL.QueryRowContext(ctx, url).Scan(
+	err := row.Scan(
 		&video.ID,
 		&video.URL,
 		&video.Title,
+		&video.Language,
+		&platform,
+		&video.MediaID,
+		&video.Uploader,
 		&status,
-		&video.Transcription,
+		&video.Pinned,
+		&expiresAt,
+		&transcription,
+		&source,
+		&segments,
+		&secondaryTranscription,
+		&secondarySource,
+		&secondarySegments,
+		&chapters,
+		&video.TranscriptKey,
+		&video.SecondaryTranscriptKey,
 		&video.Error,
+		&errorCode,
+		&video.FailureLog,
+		&captionWER,
+		&video.CaptionLanguage,
+		&video.CaptionKind,
+		&retryAt,
+		&timings,
+		&video.Model,
+		&enrichment,
+		&video.Revision,
+		&comparison,
 		&video.CreatedAt,
 		&video.UpdatedAt,
+		&video.Version,
 	)
+	if err != nil {
+		return nil, err
+	}
 
-	if err == sql.ErrNoRows {
-		return nil, errors.NotFound(op, nil, "Video not found")
+	if video.Transcription, err = r.codec.decode(transcription); err != nil {
+		return nil, err
 	}
-	if err != nil {
-		return nil, errors.Internal(op, err, "Failed to query video")
+	if video.SecondaryTranscription, err = r.codec.decode(secondaryTranscription); err != nil {
+		return nil, err
+	}
+	if video.Segments, err = r.decodeSegments(segments); err != nil {
+		return nil, err
+	}
+	if video.SecondarySegments, err = r.decodeSegments(secondarySegments); err != nil {
+		return nil, err
+	}
+	if video.Chapters, err = decodeChapters(chapters); err != nil {
+		return nil, err
+	}
+	if video.Timings, err = decodeTimings(timings); err != nil {
+		return nil, err
+	}
+	if video.Enrichment, err = decodeEnrichment(enrichment); err != nil {
+		return nil, err
+	}
+	if video.CaptionComparison, err = decodeComparison(comparison); err != nil {
+		return nil, err
 	}
 
+	video.Platform = models.Platform(platform)
 	video.Status = models.Status(status)
+	video.Source = models.Source(source)
+	video.SecondarySource = models.Source(secondarySource)
+	video.ErrorCode = models.ErrorCode(errorCode)
+	if captionWER.Valid {
+		video.CaptionWER = &captionWER.Float64
+	}
+	if expiresAt.Valid {
+		video.ExpiresAt = &expiresAt.Time
+	}
+	if retryAt.Valid {
+		video.RetryAt = &retryAt.Time
+	}
 	return video, nil
 }
 
-func isLockError(err error) bool {
-	return strings.Contains(err.Error(), "database is locked") ||
-		strings.Contains(err.Error(), "busy")
+// FindRecentByStatus returns the most recently updated videos with a status
+func (r *Repository) FindRecentByStatus(ctx context.Context, status models.Status, limit int) ([]*models.Video, error) {
+	const op = "SQLiteRepository.FindRecentByStatus"
+
+	rows, err := r.db.QueryContext(ctx, recentByStatusQuery, string(status), limit)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos")
+	}
+	defer rows.Close()
+
+	var videos []*models.Video
+	for rows.Next() {
+		video, err := r.scanVideo(rows)
+		if err != nil {
+			return nil, errors.Internal(op, err, "Failed to read video")
+		}
+		videos = append(videos, video)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos")
+	}
+
+	return videos, nil
+}
+
+// List returns a page of videos matching filter, newest first, along with
+// the total number of matching videos
+func (r *Repository) List(ctx context.Context, filter repository.VideoFilter) ([]*models.Video, int, error) {
+	const op = "SQLiteRepository.List"
+
+	status := string(filter.Status)
+	args := []interface{}{status, status, filter.Language, filter.Language, filter.UserID, filter.UserID,
+		filter.Keyword, filter.Keyword, filter.Entity, filter.Entity}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, errors.Internal(op, err, "Failed to count videos")
+	}
+
+	query := listQuery
+	if filter.UserID != "" {
+		query = userListQuery
+		args = append(args, filter.UserID)
+	}
+	rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, errors.Internal(op, err, "Failed to query videos")
+	}
+	defer rows.Close()
+
+	videos := make([]*models.Video, 0, filter.Limit)
+	for rows.Next() {
+		video, err := r.scanVideo(rows)
+		if err != nil {
+			return nil, 0, errors.Internal(op, err, "Failed to read video")
+		}
+		videos = append(videos, video)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.Internal(op, err, "Failed to query videos")
+	}
+
+	return videos, total, nil
+}
+
+// DatabaseSize returns the size of the main database file in bytes
+func (r *Repository) DatabaseSize(ctx context.Context) (int64, error) {
+	const op = "SQLiteRepository.DatabaseSize"
+
+	var size int64
+	if err := r.db.QueryRowContext(ctx, databaseSizeQuery).Scan(&size); err != nil {
+		return 0, errors.Internal(op, err, "Failed to query database size")
+	}
+	return size, nil
+}
+
+// Delete removes a video and its transcripts
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	const op = "SQLiteRepository.Delete"
+
+	res, err := r.db.writer.ExecContext(ctx, deleteQuery, id)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to delete video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	return nil
+}
+
+// SetPinned pins or unpins a video. Save never changes the flag of an
+// existing row, so a worker saving a stale copy can't undo a pin.
+func (r *Repository) SetPinned(ctx context.Context, id string, pinned bool) error {
+	const op = "SQLiteRepository.SetPinned"
+
+	res, err := r.db.writer.ExecContext(ctx, setPinnedQuery, pinned, id)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to update video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	return nil
+}
+
+// SetExpiry sets when cleanup removes a video; nil leaves it to the
+// retention window. Like the pin, Save never changes it.
+func (r *Repository) SetExpiry(ctx context.Context, id string, expiresAt *time.Time) error {
+	const op = "SQLiteRepository.SetExpiry"
+
+	res, err := r.db.writer.ExecContext(ctx, setExpiryQuery, expiresAt, id)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to update video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	return nil
+}
+
+// CleanupExpiredTranscriptions deletes up to limit finished, unpinned
+// videos past their own expiry at now, or without one and last updated
+// before cutoff, and returns how many were removed. A zero cutoff only
+// removes videos past their own expiry.
+func (r *Repository) CleanupExpiredTranscriptions(ctx context.Context, cutoff, now time.Time, limit int) (int64, error) {
+	const op = "SQLiteRepository.CleanupExpiredTranscriptions"
+
+	res, err := r.db.writer.ExecContext(ctx, cleanupExpiredQuery, now, cutoff, limit)
+	if err != nil {
+		return 0, errors.Internal(op, err, "Failed to delete expired videos")
+	}
+	return res.RowsAffected()
+}
+
+// CountExpiredTranscriptions returns how many videos
+// CleanupExpiredTranscriptions would remove without a limit
+func (r *Repository) CountExpiredTranscriptions(ctx context.Context, cutoff, now time.Time) (int64, error) {
+	const op = "SQLiteRepository.CountExpiredTranscriptions"
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, countExpiredQuery, now, cutoff).Scan(&count); err != nil {
+		return 0, errors.Internal(op, err, "Failed to count expired videos")
+	}
+	return count, nil
+}
+
+// FindColdTranscripts returns completed videos not read since accessedBefore
+// that hold an inline transcript of at least minSize bytes
+func (r *Repository) FindColdTranscripts(ctx context.Context, accessedBefore time.Time, minSize, limit int) ([]*models.Video, error) {
+	const op = "SQLiteRepository.FindColdTranscripts"
+
+	rows, err := r.db.QueryContext(ctx, coldTranscriptsQuery, accessedBefore, minSize, limit)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos")
+	}
+	defer rows.Close()
+
+	var videos []*models.Video
+	for rows.Next() {
+		video, err := r.scanVideo(rows)
+		if err != nil {
+			return nil, errors.Internal(op, err, "Failed to read video")
+		}
+		videos = append(videos, video)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos")
+	}
+
+	return videos, nil
+}
+
+func (r *Repository) FindStoredTranscripts(ctx context.Context, limit int) ([]*models.Video, error) {
+	const op = "SQLiteRepository.FindStoredTranscripts"
+
+	rows, err := r.db.QueryContext(ctx, storedTranscriptsQuery, limit)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos")
+	}
+	defer rows.Close()
+
+	var videos []*models.Video
+	for rows.Next() {
+		video, err := r.scanVideo(rows)
+		if err != nil {
+			return nil, errors.Internal(op, err, "Failed to read video")
+		}
+		videos = append(videos, video)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query videos")
+	}
+
+	return videos, nil
+}
+
+// TranscriptKeys returns the keys of every transcript kept in the storage
+// backend. It runs rarely, so it isn't kept as a prepared statement.
+func (r *Repository) TranscriptKeys(ctx context.Context) ([]string, error) {
+	const op = "SQLiteRepository.TranscriptKeys"
+
+	var keys []string
+	err := r.scanRows(ctx, transcriptKeysQuery, func(rows *sql.Rows) error {
+		var primary, secondary string
+		if err := rows.Scan(&primary, &secondary); err != nil {
+			return err
+		}
+		for _, key := range []string{primary, secondary} {
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query transcript keys")
+	}
+	return keys, nil
+}
+
+// SetTranscriptKeys records where a video's transcripts were moved and
+// clears the inline copies
+func (r *Repository) SetTranscriptKeys(ctx context.Context, id, primaryKey, secondaryKey string) error {
+	const op = "SQLiteRepository.SetTranscriptKeys"
+
+	res, err := r.db.writer.ExecContext(ctx, setTranscriptKeysQuery, primaryKey, secondaryKey, id)
+	if err != nil {
+		return errors.Internal(op, err, "Failed to update video")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.NotFound(op, nil, "Video not found")
+	}
+	return nil
+}
+
+// CaptionQuality aggregates caption word error rates. It runs rarely, so it
+// isn't kept as a prepared statement.
+func (r *Repository) CaptionQuality(ctx context.Context, threshold float64) (*models.CaptionQuality, error) {
+	const op = "SQLiteRepository.CaptionQuality"
+
+	quality := &models.CaptionQuality{Threshold: threshold}
+	err := r.db.QueryRowContext(ctx, captionQualityQuery, threshold).
+		Scan(&quality.Compared, &quality.MeanWER, &quality.GoodEnough)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to query caption quality")
+	}
+
+	return quality, nil
+}
+
+// VideoStats totals the stored videos by status, source and Whisper model.
+// Like CaptionQuality, it runs rarely, so its queries aren't prepared.
+func (r *Repository) VideoStats(ctx context.Context) (*models.VideoStats, error) {
+	const op = "SQLiteRepository.VideoStats"
+
+	stats := &models.VideoStats{
+		ByStatus: make(map[models.Status]int),
+		BySource: make(map[models.Source]int),
+		ByModel:  []models.ModelStats{},
+	}
+	err := r.scanRows(ctx, statusCountsQuery, func(rows *sql.Rows) error {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return err
+		}
+		stats.ByStatus[models.Status(status)] = count
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to count videos by status")
+	}
+
+	err = r.scanRows(ctx, sourceCountsQuery, func(rows *sql.Rows) error {
+		var source string
+		var count int
+		if err := rows.Scan(&source, &count); err != nil {
+			return err
+		}
+		stats.BySource[models.Source(source)] = count
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to count videos by source")
+	}
+
+	err = r.scanRows(ctx, modelStatsQuery, func(rows *sql.Rows) error {
+		var model models.ModelStats
+		if err := rows.Scan(&model.Model, &model.Videos, &model.AvgProcessingSeconds); err != nil {
+			return err
+		}
+		stats.ByModel = append(stats.ByModel, model)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to count videos by model")
+	}
+
+	if err := r.db.QueryRowContext(ctx, transcriptBytesQuery).Scan(&stats.TranscriptBytes); err != nil {
+		return nil, errors.Internal(op, err, "Failed to query transcript size")
+	}
+	return stats, nil
+}
+
+// scanRows runs a query and calls scan for each row
+func (r *Repository) scanRows(ctx context.Context, query string, scan func(*sql.Rows) error) error {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// encodeSegments stores segment timing as JSON, compressed like transcripts
+func (r *Repository) encodeSegments(segments []models.Segment) (interface{}, error) {
+	if len(segments) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(segments)
+	if err != nil {
+		return nil, err
+	}
+	return r.codec.encode(string(data)), nil
+}
+
+func (r *Repository) decodeSegments(raw []byte) ([]models.Segment, error) {
+	text, err := r.codec.decode(raw)
+	if err != nil || text == "" {
+		return nil, err
+	}
+
+	var segments []models.Segment
+	if err := json.Unmarshal([]byte(text), &segments); err != nil {
+		return nil, fmt.Errorf("failed to decode segments: %w", err)
+	}
+	return segments, nil
+}
+
+// encodeChapters stores chapters as JSON. They are small, so unlike
+// segments they are never compressed.
+func encodeChapters(chapters []models.Chapter) (string, error) {
+	if len(chapters) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(chapters)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeChapters(text string) ([]models.Chapter, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	var chapters []models.Chapter
+	if err := json.Unmarshal([]byte(text), &chapters); err != nil {
+		return nil, fmt.Errorf("failed to decode chapters: %w", err)
+	}
+	return chapters, nil
+}
+
+// encodeTimings stores a video's stage timings as JSON
+func encodeTimings(timings *models.StageTimings) (string, error) {
+	if timings == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(timings)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeTimings(text string) (*models.StageTimings, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	timings := &models.StageTimings{}
+	if err := json.Unmarshal([]byte(text), timings); err != nil {
+		return nil, fmt.Errorf("failed to decode timings: %w", err)
+	}
+	return timings, nil
+}
+
+// encodeEnrichment stores what was extracted from a transcript as JSON,
+// which the list filters query
+func encodeEnrichment(enrichment *models.Enrichment) (string, error) {
+	if enrichment == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(enrichment)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeEnrichment(text string) (*models.Enrichment, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	enrichment := &models.Enrichment{}
+	if err := json.Unmarshal([]byte(text), enrichment); err != nil {
+		return nil, fmt.Errorf("failed to decode enrichment: %w", err)
+	}
+	return enrichment, nil
+}
+
+func encodeComparison(comparison *models.CaptionComparison) (string, error) {
+	if comparison == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(comparison)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeComparison(text string) (*models.CaptionComparison, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	comparison := &models.CaptionComparison{}
+	if err := json.Unmarshal([]byte(text), comparison); err != nil {
+		return nil, fmt.Errorf("failed to decode caption comparison: %w", err)
+	}
+	return comparison, nil
 }
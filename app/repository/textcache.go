@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"container/list"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"yt-text/models"
+)
+
+// textCache is a byte-bounded LRU of transcript texts by video and source.
+// It fills from the streams it serves, so a transcript is cached once a
+// client has read it to the end.
+type textCache struct {
+	mu       sync.Mutex
+	entries  map[textKey]*list.Element
+	sources  map[string][]models.Source // video ID -> cached sources
+	order    *list.List                 // front is most recently used
+	maxBytes int
+	bytes    int
+	ttl      time.Duration // 0 keeps entries until they're dropped
+
+	// generation changes on every invalidation, so a stream that started
+	// before one doesn't cache the old text after it
+	generation uint64
+}
+
+type textKey struct {
+	id     string
+	source models.Source
+}
+
+type textEntry struct {
+	key     textKey
+	text    string
+	expires time.Time // zero without a TTL
+}
+
+// maxTextShare bounds one transcript to this fraction of the cache, so a
+// single long one can't flush all the others
+const maxTextShare = 8
+
+func newTextCache(maxBytes int, ttl time.Duration) *textCache {
+	return &textCache{
+		ttl:      ttl,
+		entries:  make(map[textKey]*list.Element),
+		sources:  make(map[string][]models.Source),
+		order:    list.New(),
+		maxBytes: maxBytes,
+	}
+}
+
+func (c *textCache) get(id string, source models.Source) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[textKey{id, source}]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*textEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(elem)
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return entry.text, true
+}
+
+// reader returns text, copying it into the cache as it is read. Only a
+// transcript read to the end, with no invalidation since, is kept.
+func (c *textCache) reader(id string, source models.Source, text io.Reader) io.Reader {
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	return &cachingReader{
+		Reader:     text,
+		cache:      c,
+		key:        textKey{id, source},
+		generation: generation,
+	}
+}
+
+func (c *textCache) put(key textKey, text string, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	entry := &textEntry{key: key, text: text}
+	if c.ttl > 0 {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.sources[key.id] = append(c.sources[key.id], key.source)
+	c.bytes += len(text)
+
+	for c.bytes > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+// invalidate drops a video's transcripts
+func (c *textCache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, source := range c.sources[id] {
+		if elem, ok := c.entries[textKey{id, source}]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+// clear drops every transcript, for changes that don't say which videos
+// they touched
+func (c *textCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = make(map[textKey]*list.Element)
+	c.sources = make(map[string][]models.Source)
+	c.order.Init()
+	c.bytes = 0
+}
+
+// remove drops an entry. Callers must hold c.mu.
+func (c *textCache) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*textEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= len(entry.text)
+
+	sources := c.sources[entry.key.id]
+	for i, source := range sources {
+		if source == entry.key.source {
+			sources = append(sources[:i], sources[i+1:]...)
+			break
+		}
+	}
+	if len(sources) == 0 {
+		delete(c.sources, entry.key.id)
+	} else {
+		c.sources[entry.key.id] = sources
+	}
+}
+
+// cachingReader copies what is read from a transcript stream and caches it
+// at EOF. It stops copying once the text is too large to be cached.
+type cachingReader struct {
+	io.Reader
+
+	cache      *textCache
+	key        textKey
+	generation uint64
+	text       strings.Builder
+	tooLarge   bool
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if !r.tooLarge {
+		if r.text.Len()+n > r.cache.maxBytes/maxTextShare {
+			r.tooLarge = true
+			r.text = strings.Builder{}
+		} else {
+			r.text.Write(p[:n])
+		}
+	}
+	if err == io.EOF && !r.tooLarge {
+		r.cache.put(r.key, r.text.String(), r.generation)
+		r.tooLarge = true // Cache it once
+	}
+	return n, err
+}
+
+// Close closes the stream when it has to be closed
+func (r *cachingReader) Close() error {
+	if closer, ok := r.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
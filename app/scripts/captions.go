@@ -0,0 +1,32 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+)
+
+// FetchCaptions extracts an existing subtitle track with yt-dlp. It needs no
+// API key and doesn't download any media. Languages and manualOnly narrow
+// the choice of track like youtube.SelectTrack.
+func (r *ScriptRunner) FetchCaptions(ctx context.Context, url string, languages []string, manualOnly bool) (CaptionsResult, error) {
+	const op = "ScriptRunner.FetchCaptions"
+	var result CaptionsResult
+
+	var flags []string
+	if manualOnly {
+		flags = append(flags, "manual_only")
+	}
+	output, _, err := r.runScript(ctx, "captions.py", map[string]string{
+		"url":       url,
+		"languages": strings.Join(languages, ","),
+	}, flags)
+	if err != nil {
+		return result, newScriptError(op, err, "caption fetch failed")
+	}
+
+	if err := unmarshalResult(output, &result); err != nil {
+		return result, newScriptError(op, err, "failed to parse captions result")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,37 @@
+package scripts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// Check verifies that the scripts are in place and that the interpreter can
+// load yt-dlp, which every video script needs. It starts Python, so it
+// takes about as long as a script's startup.
+func (r *ScriptRunner) Check(ctx context.Context) error {
+	if err := validateConfig(r.config); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, r.config.PythonPath, "-c", "import yt_dlp")
+	cmd.Dir = r.config.ScriptsPath
+	cmd.Env = buildEnvironment(r.config.Environment)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if msg := bytes.TrimSpace(output); len(msg) > 0 {
+			return fmt.Errorf("yt-dlp is not available: %s", lastLine(msg))
+		}
+		return fmt.Errorf("yt-dlp is not available: %w", err)
+	}
+	return nil
+}
+
+// lastLine returns the last line of output, where Python puts the error of
+// a traceback
+func lastLine(output []byte) []byte {
+	if i := bytes.LastIndexByte(output, '\n'); i >= 0 {
+		return output[i+1:]
+	}
+	return output
+}
@@ -1,11 +1,15 @@
 package scripts
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type ScriptError struct {
 	Op      string
 	Err     error
 	Message string
+	Output  string // Tail of the script's stderr/stdout, for failure diagnostics
 }
 
 func (e *ScriptError) Error() string {
@@ -26,3 +30,15 @@ func newScriptError(op string, err error, message string) *ScriptError {
 		Message: message,
 	}
 }
+
+// OutputTail returns the captured script output carried by err, if any
+func OutputTail(err error) string {
+	var scriptErr *ScriptError
+	for errors.As(err, &scriptErr) {
+		if scriptErr.Output != "" {
+			return scriptErr.Output
+		}
+		err = scriptErr.Err
+	}
+	return ""
+}
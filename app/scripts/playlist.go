@@ -0,0 +1,34 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// ExpandPlaylist lists the videos of a playlist or channel, up to limit,
+// without downloading any media
+func (r *ScriptRunner) ExpandPlaylist(ctx context.Context, url string, limit int) (PlaylistResult, error) {
+	const op = "ScriptRunner.ExpandPlaylist"
+	var result PlaylistResult
+
+	output, tail, err := r.runScript(ctx, "playlist.py", map[string]string{
+		"url":   url,
+		"limit": strconv.Itoa(limit),
+	}, nil)
+	if err != nil {
+		return result, newScriptError(op, err, "playlist expansion failed")
+	}
+
+	if err := unmarshalResult(output, &result); err != nil {
+		return result, newScriptError(op, err, "failed to parse playlist result")
+	}
+
+	if result.Error != "" {
+		scriptErr := newScriptError(op, errors.New(result.Error), "playlist expansion failed")
+		scriptErr.Output = tail
+		return result, scriptErr
+	}
+
+	return result, nil
+}
@@ -0,0 +1,35 @@
+package scripts
+
+import "context"
+
+// Progress is a stage update reported by a script while it runs. Progress
+// is the fraction of the stage completed, from 0 to 1. Updates carrying a
+// Segment report a piece of transcript instead and leave the rest unset.
+type Progress struct {
+	Stage    string   `json:"stage"`
+	Progress float64  `json:"progress"`
+	Segment  *Segment `json:"segment,omitempty"`
+}
+
+// ProgressFunc receives progress updates from a running script
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose scripts report progress to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func progressFromContext(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
+
+// ReportProgress sends an update to the context's progress function, for
+// stages that run outside a script
+func ReportProgress(ctx context.Context, p Progress) {
+	if fn := progressFromContext(ctx); fn != nil {
+		fn(p)
+	}
+}
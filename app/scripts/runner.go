@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"yt-text/logger"
 
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
 type ScriptRunner struct {
@@ -30,7 +33,7 @@ func validateConfig(cfg Config) error {
 	}
 
 	// Verify required scripts exist
-	requiredScripts := []string{"validate.py", "api.py"}
+	requiredScripts := []string{"validate.py", "api.py", "captions.py", "playlist.py", "summarize.py"}
 	for _, script := range requiredScripts {
 		scriptPath := filepath.Join(cfg.ScriptsPath, script)
 		if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
@@ -45,13 +48,24 @@ func (r *ScriptRunner) runScript(
 	scriptName string,
 	args map[string]string,
 	flags []string,
-) ([]byte, error) {
+) ([]byte, string, error) {
+	return r.runScriptWithInput(ctx, scriptName, args, flags, nil)
+}
+
+// runScriptWithInput runs a script with input as its stdin, for data too
+// large to pass as an argument
+func (r *ScriptRunner) runScriptWithInput(
+	ctx context.Context,
+	scriptName string,
+	args map[string]string,
+	flags []string,
+	input io.Reader,
+) ([]byte, string, error) {
 	const op = "ScriptRunner.runScript"
 	scriptPath := filepath.Join(r.config.ScriptsPath, scriptName)
-	logger := zerolog.Ctx(ctx)
+	logger := scriptLogger(ctx, scriptName)
 
 	logger.Debug().
-		Str("script", scriptName).
 		Interface("args", args).
 		Interface("flags", flags).
 		Msg("Executing script")
@@ -59,14 +73,22 @@ func (r *ScriptRunner) runScript(
 	cmdArgs := buildCommandArgs(scriptPath, args, flags)
 	cmd := exec.CommandContext(ctx, r.config.PythonPath, cmdArgs...)
 	cmd.Dir = r.config.ScriptsPath
-	cmd.Env = buildEnvironment(r.config.Environment)
+	cmd.Stdin = input
+	cmd.Env = append(buildEnvironment(r.config.Environment), correlationEnvironment(ctx)...)
 
-	output, err := r.executeCommand(cmd, logger)
+	output, tail, err := r.executeCommand(cmd, &logger, progressFromContext(ctx))
 	if err != nil {
-		return nil, newScriptError(op, err, "script execution failed")
+		// Make timeouts and cancellation distinguishable from the script
+		// itself being killed
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%w (%v)", ctxErr, err)
+		}
+		scriptErr := newScriptError(op, err, "script execution failed")
+		scriptErr.Output = tail
+		return nil, tail, scriptErr
 	}
 
-	return output, nil
+	return output, tail, nil
 }
 
 func buildCommandArgs(scriptPath string, args map[string]string, flags []string) []string {
@@ -93,18 +115,55 @@ func buildEnvironment(additionalEnv []string) []string {
 	return env
 }
 
-func (r *ScriptRunner) executeCommand(cmd *exec.Cmd, logger *zerolog.Logger) ([]byte, error) {
-	var stdout, stderr bytes.Buffer
+// correlationEnvironment exposes the request and job IDs to the script so its
+// logs and errors can be matched with the Go side
+func correlationEnvironment(ctx context.Context) []string {
+	var env []string
+	if id := logger.RequestID(ctx); id != "" {
+		env = append(env, "YT_TEXT_REQUEST_ID="+id)
+	}
+	if id := logger.JobID(ctx); id != "" {
+		env = append(env, "YT_TEXT_JOB_ID="+id)
+	}
+	return env
+}
+
+// scriptLogger returns the logger script output is re-emitted through,
+// tagged with the script name and correlation IDs
+func scriptLogger(ctx context.Context, scriptName string) zerolog.Logger {
+	base := zerolog.Ctx(ctx)
+	if base.GetLevel() == zerolog.Disabled {
+		base = &log.Logger
+	}
+
+	return base.With().
+		Str("script", scriptName).
+		Str("request_id", logger.RequestID(ctx)).
+		Str("job_id", logger.JobID(ctx)).
+		Logger()
+}
+
+// executeCommand runs cmd and returns its stdout along with the tail of its
+// output for diagnostics
+func (r *ScriptRunner) executeCommand(cmd *exec.Cmd, logger *zerolog.Logger, onProgress ProgressFunc) ([]byte, string, error) {
+	var stdout bytes.Buffer
+	stderr := newStderrLogger(*logger, onProgress)
 	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	cmd.Stderr = stderr
 
-	if err := cmd.Run(); err != nil {
-		stderrOutput := stderr.String()
+	err := cmd.Run()
+	stderr.Flush()
+	if err != nil {
+		summary := stderr.Summary()
 		logger.Error().
 			Err(err).
-			Str("stderr", stderrOutput).
+			Str("summary", summary).
 			Msg("Script execution failed")
-		return nil, fmt.Errorf("%v (stderr: %s)", err, stderrOutput)
+		tail := outputTail(stderr.Tail(), stdout.Bytes())
+		if summary == "" {
+			return nil, tail, err
+		}
+		return nil, tail, fmt.Errorf("%v: %s", err, summary)
 	}
 
 	output := stdout.Bytes()
@@ -113,10 +172,19 @@ func (r *ScriptRunner) executeCommand(cmd *exec.Cmd, logger *zerolog.Logger) ([]
 			Err(err).
 			Str("output", string(output)).
 			Msg("Invalid JSON output")
-		return nil, err
+		return nil, outputTail(stderr.Tail(), output), err
 	}
 
-	return output, nil
+	return output, stderr.Tail(), nil
+}
+
+// outputTail combines the stderr tail with the end of stdout, capped at
+// maxOutputTail overall
+func outputTail(stderrTail string, stdout []byte) string {
+	if len(stdout) == 0 {
+		return stderrTail
+	}
+	return string(appendTail([]byte(stderrTail), "--- stdout ---\n"+string(stdout)))
 }
 
 func unmarshalResult(data []byte, v interface{}) error {
@@ -0,0 +1,187 @@
+package scripts
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// stderrLogger is an io.Writer for a script's stderr. Each complete line is
+// decoded as a JSON log record written by python/scripts/logs.py and
+// re-emitted through the Go logger; anything else (tracebacks from crashes,
+// native library noise) is logged as raw output. Progress and segment lines
+// are passed to onProgress instead of being logged.
+type stderrLogger struct {
+	mu         sync.Mutex
+	logger     zerolog.Logger
+	onProgress ProgressFunc
+	partial    []byte
+
+	lastError string // most recent error-level message
+	lastLine  string // most recent non-empty line of any kind
+	tail      []byte // last maxOutputTail bytes of raw output
+}
+
+// maxOutputTail caps how much script output is kept for failure diagnostics
+const maxOutputTail = 16 * 1024
+
+// Fields owned by the record itself rather than passed through as context.
+// The correlation IDs are dropped because the Go logger already carries them.
+var reservedLogFields = map[string]bool{
+	"time":       true,
+	"level":      true,
+	"logger":     true,
+	"message":    true,
+	"request_id": true,
+	"job_id":     true,
+}
+
+func newStderrLogger(logger zerolog.Logger, onProgress ProgressFunc) *stderrLogger {
+	return &stderrLogger{logger: logger, onProgress: onProgress}
+}
+
+func (w *stderrLogger) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.handleLine(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush handles any trailing output not terminated by a newline
+func (w *stderrLogger) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.handleLine(string(w.partial))
+		w.partial = nil
+	}
+}
+
+// Tail returns the most recent raw stderr lines, capped at maxOutputTail
+func (w *stderrLogger) Tail() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return string(w.tail)
+}
+
+// Summary returns a short description of why the script failed, preferring
+// the last error it logged over raw output
+func (w *stderrLogger) Summary() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.lastError != "" {
+		return w.lastError
+	}
+	return w.lastLine
+}
+
+func (w *stderrLogger) handleLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	var record map[string]interface{}
+	isRecord := strings.HasPrefix(line, "{") && json.Unmarshal([]byte(line), &record) == nil
+	if isRecord && record["event"] == "progress" {
+		w.handleProgress(record)
+		return
+	}
+	if isRecord && record["event"] == "segment" {
+		w.handleSegment(record)
+		return
+	}
+
+	w.lastLine = line
+	w.tail = appendTail(w.tail, line+"\n")
+
+	if !isRecord {
+		w.logger.Warn().Str("output", line).Msg("Script stderr")
+		return
+	}
+
+	level := parseScriptLevel(record["level"])
+	message, _ := record["message"].(string)
+	if level >= zerolog.ErrorLevel && message != "" {
+		w.lastError = message
+	}
+
+	event := w.logger.WithLevel(level)
+	if name, ok := record["logger"].(string); ok {
+		event = event.Str("script_logger", name)
+	}
+	for key, value := range record {
+		if !reservedLogFields[key] {
+			event = event.Interface(key, value)
+		}
+	}
+	event.Msg(message)
+}
+
+// handleProgress forwards a progress line written by logs.report_progress
+func (w *stderrLogger) handleProgress(record map[string]interface{}) {
+	if w.onProgress == nil {
+		return
+	}
+
+	stage, _ := record["stage"].(string)
+	progress, _ := record["progress"].(float64)
+	w.onProgress(Progress{Stage: stage, Progress: progress})
+}
+
+// handleSegment forwards a transcript segment written by
+// logs.report_segment
+func (w *stderrLogger) handleSegment(record map[string]interface{}) {
+	if w.onProgress == nil {
+		return
+	}
+
+	var segment Segment
+	segment.Start, _ = record["start"].(float64)
+	segment.End, _ = record["end"].(float64)
+	segment.Text, _ = record["text"].(string)
+	w.onProgress(Progress{Segment: &segment})
+}
+
+func parseScriptLevel(v interface{}) zerolog.Level {
+	name, _ := v.(string)
+	switch strings.ToLower(name) {
+	case "debug":
+		return zerolog.DebugLevel
+	case "warning", "warn":
+		return zerolog.WarnLevel
+	case "error", "critical", "fatal":
+		return zerolog.ErrorLevel
+	default:
+		return zerolog.InfoLevel
+	}
+}
+
+// appendTail appends s to tail, dropping whole lines from the front once the
+// result exceeds maxOutputTail
+func appendTail(tail []byte, s string) []byte {
+	tail = append(tail, s...)
+	if len(tail) <= maxOutputTail {
+		return tail
+	}
+
+	tail = tail[len(tail)-maxOutputTail:]
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	return append([]byte(nil), tail...)
+}
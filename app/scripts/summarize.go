@@ -0,0 +1,36 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Summarize condenses a transcript to at most maxSentences sentences, as a
+// paragraph or, with style "bullets", one per line. The transcript is passed
+// on stdin.
+func (r *ScriptRunner) Summarize(ctx context.Context, text, style string, maxSentences int) (SummaryResult, error) {
+	const op = "ScriptRunner.Summarize"
+	var result SummaryResult
+
+	output, tail, err := r.runScriptWithInput(ctx, "summarize.py", map[string]string{
+		"max_sentences": strconv.Itoa(maxSentences),
+		"style":         style,
+	}, nil, strings.NewReader(text))
+	if err != nil {
+		return result, newScriptError(op, err, "summarization failed")
+	}
+
+	if err := unmarshalResult(output, &result); err != nil {
+		return result, newScriptError(op, err, "failed to parse summary result")
+	}
+
+	if result.Error != "" {
+		scriptErr := newScriptError(op, errors.New(result.Error), "summarization failed")
+		scriptErr.Output = tail
+		return result, scriptErr
+	}
+
+	return result, nil
+}
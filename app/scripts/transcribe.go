@@ -2,6 +2,7 @@ package scripts
 
 import (
 	"context"
+	"errors"
 
 	"github.com/rs/zerolog"
 )
@@ -13,7 +14,6 @@ func (r *ScriptRunner) Transcribe(
 	enableConstraints bool,
 ) (TranscriptionResult, error) {
 	const op = "ScriptRunner.Transcribe"
-	var result TranscriptionResult
 
 	logger := zerolog.Ctx(ctx)
 	logger.Debug().
@@ -23,14 +23,50 @@ func (r *ScriptRunner) Transcribe(
 
 	args := buildTranscribeArgs(url, opts)
 	flags := buildTranscribeFlags(enableConstraints)
+	return r.transcribe(ctx, op, args, flags)
+}
 
-	output, err := r.runScript(ctx, "api.py", args, flags)
+// TranscribeFile transcribes an audio file that was already downloaded,
+// skipping yt-dlp
+func (r *ScriptRunner) TranscribeFile(
+	ctx context.Context,
+	path string,
+	opts map[string]string,
+) (TranscriptionResult, error) {
+	const op = "ScriptRunner.TranscribeFile"
+
+	zerolog.Ctx(ctx).Debug().
+		Str("path", path).
+		Interface("opts", opts).
+		Msg("Starting file transcription")
+
+	args := map[string]string{"file": path}
+	for k, v := range opts {
+		args[k] = v
+	}
+	return r.transcribe(ctx, op, args, nil)
+}
+
+// transcribe runs api.py and checks its result
+func (r *ScriptRunner) transcribe(ctx context.Context, op string, args map[string]string, flags []string) (TranscriptionResult, error) {
+	var result TranscriptionResult
+
+	output, tail, err := r.runScript(ctx, "api.py", args, flags)
 	if err != nil {
 		return result, newScriptError(op, err, "transcription failed")
 	}
 
 	if err := unmarshalResult(output, &result); err != nil {
-		return result, newScriptError(op, err, "failed to parse transcription result")
+		scriptErr := newScriptError(op, err, "failed to parse transcription result")
+		scriptErr.Output = outputTail(tail, output)
+		return result, scriptErr
+	}
+
+	// api.py reports per-URL failures in the result rather than its exit code
+	if result.Error != "" {
+		scriptErr := newScriptError(op, errors.New(result.Error), "transcription failed")
+		scriptErr.Output = tail
+		return result, scriptErr
 	}
 
 	return result, nil
@@ -12,6 +12,10 @@ type Config struct {
 	TempDir     string        // Temporary directory for downloads
 	Environment []string      // Additional environment variables
 	Model       string        // Default Whisper model to use
+
+	// ValidateTimeout bounds validation on its own, which only reads
+	// metadata and should take seconds rather than a transcription's minutes
+	ValidateTimeout time.Duration
 }
 
 // GetDefaultModel returns the default model from the configuration or a fallback value.
@@ -25,19 +29,79 @@ func (cfg *Config) GetDefaultModel() string {
 
 // VideoInfo represents the validation result from the Python validation script
 type VideoInfo struct {
-	Valid    bool    `json:"valid"`           // Whether the video is valid and can be processed
-	Duration float64 `json:"duration"`        // Duration of the video in seconds
-	Format   string  `json:"format"`          // Format of the video
-	Error    string  `json:"error,omitempty"` // Error message if validation failed
-	URL      string  `json:"url"`             // Original URL that was validated
+	Valid      bool    `json:"valid"`           // Whether the video is valid and can be processed
+	Duration   float64 `json:"duration"`        // Duration of the video in seconds, 0 if unknown
+	FileSize   int64   `json:"filesize"`        // Approximate size of the audio download in bytes, 0 if unknown
+	Format     string  `json:"format"`          // Format of the video
+	Language   string  `json:"language"`        // Audio language reported by the site, if known
+	Uploader   string  `json:"uploader"`        // Channel or account that published the video, if known
+	LiveStatus string  `json:"live_status"`     // yt-dlp's live status, e.g. "is_live" or "was_live"; empty if not reported
+	Error      string  `json:"error,omitempty"` // Error message if validation failed
+	URL        string  `json:"url"`             // Original URL that was validated
 }
 
 // TranscriptionResult represents the transcription output from the Python API script
 type TranscriptionResult struct {
-	Text      string  `json:"text"`            // The transcribed text
-	ModelName string  `json:"model_name"`      // Name of the Whisper model used
-	Duration  float64 `json:"duration"`        // Time taken to transcribe in seconds
-	Error     string  `json:"error,omitempty"` // Error message if transcription failed
-	Title     *string `json:"title,omitempty"` // Title of the video if available
-	URL       *string `json:"url,omitempty"`   // Original URL that was transcribed
+	Text      string    `json:"text"`            // The transcribed text
+	Segments  []Segment `json:"segments"`        // Timed segments the text was joined from
+	ModelName string    `json:"model_name"`      // Name of the Whisper model used
+	Duration  float64   `json:"duration"`        // Time taken to transcribe in seconds
+	Error     string    `json:"error,omitempty"` // Error message if transcription failed
+	Title     *string   `json:"title,omitempty"` // Title of the video if available
+	Chapters  []Chapter `json:"chapters"`        // Chapters set by the uploader, if any
+	URL       *string   `json:"url,omitempty"`   // Original URL that was transcribed
+}
+
+// Segment is one timed span of a transcript or caption track, with times in
+// seconds
+type Segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+
+	// Set by the transcription script only: the segment's average token
+	// log probability and its probability of holding no speech
+	AvgLogprob   *float64 `json:"avg_logprob,omitempty"`
+	NoSpeechProb *float64 `json:"no_speech_prob,omitempty"`
+}
+
+// Chapter is one of the chapters an uploader marked in a video, with times
+// in seconds
+type Chapter struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Title string  `json:"title"`
+}
+
+// CaptionsResult represents the output of the Python captions script
+type CaptionsResult struct {
+	Segments []Segment `json:"segments"`        // Caption lines in order
+	Language string    `json:"language"`        // Language code of the chosen track
+	Kind     string    `json:"kind"`            // "manual" or "auto"
+	Title    *string   `json:"title,omitempty"` // Title of the video if available
+	Chapters []Chapter `json:"chapters"`        // Chapters set by the uploader, if any
+	Error    string    `json:"error,omitempty"` // Why no captions were returned
+}
+
+// PlaylistEntry is one video of an expanded playlist
+type PlaylistEntry struct {
+	URL   string  `json:"url"`
+	Title *string `json:"title,omitempty"`
+}
+
+// PlaylistResult represents the output of the Python playlist script
+type PlaylistResult struct {
+	Videos []PlaylistEntry `json:"videos"`          // Videos in playlist order
+	Title  *string         `json:"title,omitempty"` // Title of the playlist or channel
+	Error  string          `json:"error,omitempty"` // Why the URL couldn't be expanded
+}
+
+// SummaryModel is the model_name the summarization script reports
+const SummaryModel = "extractive"
+
+// SummaryResult represents the output of the Python summarization script
+type SummaryResult struct {
+	Summary   string `json:"summary"`         // The summary text
+	ModelName string `json:"model_name"`      // Name of the summarizer used
+	Error     string `json:"error,omitempty"` // Error message if summarization failed
 }
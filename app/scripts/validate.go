@@ -8,7 +8,13 @@ func (r *ScriptRunner) Validate(ctx context.Context, url string) (VideoInfo, err
 	const op = "ScriptRunner.Validate"
 	var result VideoInfo
 
-	output, err := r.runScript(ctx, "validate.py", map[string]string{
+	if r.config.ValidateTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.ValidateTimeout)
+		defer cancel()
+	}
+
+	output, _, err := r.runScript(ctx, "validate.py", map[string]string{
 		"url": url,
 	}, nil)
 	if err != nil {
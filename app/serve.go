@@ -0,0 +1,532 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"yt-text/config"
+	"yt-text/events"
+	"yt-text/handlers"
+	"yt-text/logger"
+	"yt-text/middleware"
+	"yt-text/openapi"
+	"yt-text/ratelimit"
+	"yt-text/repository"
+	"yt-text/repository/sqlite"
+	"yt-text/services/video"
+	"yt-text/signing"
+	"yt-text/static"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/etag"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/gofiber/fiber/v2/middleware/timeout"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// runServe starts the HTTP server along with the transcription workers and
+// background maintenance. --mode=api leaves out the workers and
+// --mode=worker the server, so each can be scaled on its own against a
+// shared database.
+func runServe(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	mode := flags.String("mode", cfg.Mode, "all, api (server only) or worker (queue consumers only)")
+	flags.Parse(args)
+
+	switch *mode {
+	case config.ModeAll, config.ModeAPI, config.ModeWorker:
+	default:
+		return fmt.Errorf("unknown mode %q", *mode)
+	}
+	if cfg.Database.Driver == config.DriverMemory && *mode != config.ModeAll {
+		return fmt.Errorf("the memory driver can't be shared between processes, so it needs --mode=%s", config.ModeAll)
+	}
+
+	// Initialize logger
+	appLogger, err := logger.NewLogger(cfg.LogDir)
+	if err != nil {
+		return err
+	}
+	log.Logger = appLogger.Logger // Set global logger
+
+	a, err := newApplication(cfg, video.Mode(*mode))
+	if err != nil {
+		return err
+	}
+	defer a.db.Close()
+	videoService, summaryService, askService := a.videos, a.summaries, a.questions
+
+	if *mode == config.ModeWorker {
+		return runWorker(cfg, a)
+	}
+
+	// Initialize Fiber app
+	app := fiber.New(fiber.Config{
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		ErrorHandler: handlers.ErrorHandler,
+		// Optional additional configurations
+		DisableStartupMessage: !cfg.Debug,
+		StrictRouting:         true,
+		CaseSensitive:         true,
+		AppName:               "yt-text " + cfg.Version,
+	})
+
+	// Settings that can change without a restart, on SIGHUP or from the
+	// admin API
+	reloader := newReloader(cfg, a)
+
+	// Setup middleware
+	setupMiddleware(app, cfg, appLogger, reloader)
+
+	// SQLite files are maintained by the server; Postgres looks after itself
+	sqliteDB, _ := a.db.(*sqlite.DB)
+	var maintain handlers.MaintainFunc
+	if sqliteDB != nil {
+		maintain = sqliteDB.Maintain
+	}
+
+	// Sign-in with OAuth providers. The session cookie is read ahead of
+	// every route, so submissions are recorded for the signed-in user.
+	accountHandler := handlers.NewAccountHandler(a.accounts, strings.HasPrefix(cfg.Accounts.BaseURL, "https://"))
+	if cfg.Accounts.Enabled() {
+		app.Use(middleware.Session(a.accounts.VerifySession))
+		app.Get("/auth/:provider/login", accountHandler.Login)
+		app.Get("/auth/:provider/callback", accountHandler.Callback)
+		app.Post("/auth/logout", accountHandler.Logout)
+	}
+
+	// Share links are signed with their own secret, so rotating it revokes
+	// every link without signing users out
+	var shareLinks *signing.Signer
+	if cfg.Sharing.Enabled() {
+		shareLinks = signing.New(cfg.Sharing.Secret)
+	}
+	shareHandler := handlers.NewShareHandler(videoService, shareLinks, cfg.Sharing.DefaultTTL, cfg.Sharing.MaxTTL)
+
+	// Setup routes
+	hub := events.NewHub(a.events, videoService.Progress)
+	routes := apiRoutes{
+		video:        handlers.NewVideoHandler(videoService, hub),
+		admin:        handlers.NewAdminHandler(videoService),
+		stats:        handlers.NewStatsHandler(videoService),
+		summary:      handlers.NewSummaryHandler(summaryService),
+		ask:          handlers.NewAskHandler(askService),
+		search:       handlers.NewSearchHandler(a.search),
+		config:       handlers.NewConfigHandler(reloader.Reload),
+		database:     handlers.NewDatabaseHandler(maintain),
+		account:      accountHandler,
+		share:        shareHandler,
+		requireAdmin: middleware.RequireAdmin(cfg.Admin.Token),
+		markAdmin:    middleware.AdminToken(cfg.Admin.Token),
+		requireClient: middleware.RequireClient(middleware.ClientConfig{
+			APIKeys:        cfg.API.Keys,
+			AllowedOrigins: cfg.API.AllowedOrigins,
+		}),
+		requireUser: middleware.RequireUser(cfg.Accounts.Enabled()),
+		sharedLink:  middleware.SharedLink(shareHandler.VerifyLink),
+	}
+
+	// Versioned API, plus the unprefixed v1 routes kept for existing clients
+	routes.register(app.Group("/api/v2"), middleware.APIVersion("v2"))
+	routes.register(app.Group("/api/v1"), middleware.Deprecated(cfg.API.V1Sunset, "/api/v1", "/api/v2"))
+	routes.register(app.Group("/api"), middleware.Deprecated(cfg.API.V1Sunset, "/api", "/api/v2"))
+
+	// API description, checked against the routes mounted above
+	spec := handlers.APISpec(cfg.Version)
+	checkAPISpec(app, spec)
+	docsHandler := handlers.NewDocsHandler(spec)
+	app.Get("/api/openapi.json", docsHandler.Spec)
+	app.Get("/api/docs", docsHandler.UI)
+
+	// Operator dashboard
+	dashboardHandler := handlers.NewDashboardHandler(videoService)
+	app.Get("/admin", routes.requireAdmin, dashboardHandler.Dashboard)
+
+	// Health checks: liveness only tells the process is up, readiness
+	// whether its dependencies are usable
+	healthHandler := handlers.NewHealthHandler(readinessChecks(cfg, a))
+	app.Get("/health", handlers.HealthCheck)
+	app.Get("/health/live", handlers.HealthCheck)
+	app.Get("/health/ready", healthHandler.Ready)
+
+	// Static files, embedded unless STATIC_DIR overrides them
+	frontend := staticFiles(cfg)
+	app.Use("/static", filesystem.New(filesystem.Config{Root: frontend}))
+	app.Use("/", filesystem.New(filesystem.Config{Root: frontend}))
+
+	// Delete expired transcripts and move cold ones to storage in the
+	// background
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	go videoService.RunCleanup(cleanupCtx)
+	if *mode == config.ModeAll {
+		// Run stored jobs too, including those left by an earlier run
+		go videoService.ClaimJobs(cleanupCtx)
+	}
+	if tiered, ok := a.repo.(*repository.OffloadRepository); ok {
+		go tiered.RunTiering(cleanupCtx)
+		go tiered.RunReaper(cleanupCtx, cfg.Video.CleanupInterval, cfg.Video.CleanupDryRun)
+	}
+	if sqliteDB != nil && cfg.Database.MaintenanceInterval > 0 {
+		go sqliteDB.RunMaintenance(cleanupCtx, cfg.Database.MaintenanceInterval)
+	}
+
+	// Embed transcripts for semantic search as they complete
+	go a.search.RunIndexer(cleanupCtx)
+
+	go reloadOnHangup(cleanupCtx, reloader)
+
+	// Graceful shutdown setup
+	shutdownChan := make(chan os.Signal, 1)
+	signal.Notify(shutdownChan, os.Interrupt, syscall.SIGTERM)
+	shutdownDone := make(chan struct{})
+
+	go func() {
+		defer close(shutdownDone)
+		<-shutdownChan
+		log.Info().Msg("Shutting down server...")
+		stopCleanup()
+
+		// Create shutdown context with timeout
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			log.Error().Err(err).Msg("Server shutdown error")
+		}
+
+		drainJobs(videoService, cfg.DrainTimeout)
+
+		// Close any other resources
+		if err := a.db.Close(); err != nil {
+			log.Error().Err(err).Msg("Database shutdown error")
+		}
+	}()
+
+	// Start server
+	serverAddr := ":" + cfg.ServerPort
+	if cfg.Debug {
+		log.Info().Str("addr", "http://localhost"+serverAddr).Msg("Server starting")
+	}
+
+	if err := app.Listen(serverAddr); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	// Listen returns as soon as shutdown starts; wait for the jobs
+	<-shutdownDone
+	return nil
+}
+
+// drainJobs gives running transcriptions up to timeout to finish before
+// interrupting them
+func drainJobs(videoService video.Service, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Info().Dur("timeout", timeout).Msg("Waiting for running transcriptions")
+	if interrupted := videoService.Drain(ctx); interrupted > 0 {
+		log.Warn().Int("jobs", interrupted).Msg("Interrupted transcriptions, they will resume after a restart")
+	}
+}
+
+// runWorker runs jobs claimed from the shared repository until interrupted,
+// without serving HTTP. Cleanup is left to the API processes.
+func runWorker(cfg *config.Config, a *application) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Info().Msg("Worker started")
+	a.videos.ClaimJobs(ctx)
+	log.Info().Msg("Shutting down worker...")
+	drainJobs(a.videos, cfg.DrainTimeout)
+	return nil
+}
+
+// apiRoutes holds the handlers mounted under each API version
+type apiRoutes struct {
+	video        *handlers.VideoHandler
+	admin        *handlers.AdminHandler
+	stats        *handlers.StatsHandler
+	summary      *handlers.SummaryHandler
+	ask          *handlers.AskHandler
+	search       *handlers.SearchHandler
+	config       *handlers.ConfigHandler
+	database     *handlers.DatabaseHandler
+	account      *handlers.AccountHandler
+	share        *handlers.ShareHandler
+	requireAdmin fiber.Handler
+	// markAdmin lets the admin use routes scoped to the caller's videos on
+	// any video
+	markAdmin fiber.Handler
+
+	// requireClient guards routes that start jobs or hold a connection
+	// open while one runs
+	requireClient fiber.Handler
+
+	// requireUser guards routes scoped to the signed-in user when accounts
+	// are enabled
+	requireUser fiber.Handler
+
+	// sharedLink admits the holders of a share link to its transcript
+	sharedLink fiber.Handler
+}
+
+// register mounts the API on r, running version ahead of every handler.
+// version is attached per route rather than with r.Use, because a Use on
+// /api would also match /api/v2.
+func (h apiRoutes) register(r fiber.Router, version fiber.Handler) {
+	r.Post("/transcribe", version, h.requireClient, h.video.Transcribe)
+	r.Post("/transcribe/batch", version, h.requireClient, h.video.TranscribeBatch)
+	r.Post("/transcribe/estimate", version, h.requireClient, h.video.Estimate)
+	r.Get("/transcribe/batch/:id", version, h.video.GetBatch)
+	r.Get("/transcriptions", version, h.requireUser, h.video.ListTranscriptions)
+	r.Get("/export", version, h.requireClient, h.requireUser, h.video.Export)
+	r.Get("/transcribe/:id", version, h.requireUser, h.video.GetTranscription)
+	r.Get("/transcribe/:id/text", version, h.requireUser, h.video.GetTranscriptionText)
+	r.Get("/transcribe/:id/events", version, h.requireClient, h.video.Events)
+	r.Get("/transcribe/:id/history", version, h.requireUser, h.video.GetJobHistory)
+	r.Post("/transcribe/:id/retry", version, h.requireClient, h.video.RetryTranscription)
+	r.Post("/transcribe/:id/share", version, h.requireClient, h.requireUser, h.share.Share)
+	r.Get("/shared/:token", version, h.sharedLink, h.video.GetTranscription)
+	r.Get("/shared/:token/text", version, h.sharedLink, h.video.GetTranscriptionText)
+	r.Delete("/transcribe/:id", version, h.requireClient, h.requireUser, h.video.DeleteTranscription)
+	r.Patch("/transcribe/:id", version, h.markAdmin, h.requireClient, h.requireUser, h.video.UpdateTranscription)
+	r.Patch("/transcribe/:id/transcript", version, h.requireClient, h.requireUser, h.video.CorrectTranscript)
+	r.Get("/transcribe/:id/revisions", version, h.requireUser, h.video.ListRevisions)
+	r.Get("/transcribe/:id/revisions/:revision", version, h.requireUser, h.video.GetRevision)
+	r.Post("/summarize", version, h.requireClient, h.summary.Summarize)
+	r.Get("/summary/:id", version, h.summary.GetSummary)
+	r.Post("/transcribe/:id/ask", version, h.requireClient, h.ask.Ask)
+	r.Get("/search/semantic", version, h.requireClient, h.search.Semantic)
+
+	// Admin routes
+	r.Get("/jobs/:id/logs", version, h.requireAdmin, h.admin.JobLogs)
+	r.Get("/admin/queue", version, h.requireAdmin, h.admin.QueueStatus)
+	r.Post("/admin/queue/pause", version, h.requireAdmin, h.admin.PauseQueue)
+	r.Post("/admin/queue/resume", version, h.requireAdmin, h.admin.ResumeQueue)
+	r.Get("/admin/jobs", version, h.requireAdmin, h.admin.ListJobs)
+	r.Delete("/admin/jobs/:id", version, h.requireAdmin, h.admin.CancelJob)
+	r.Post("/admin/jobs/:id/priority", version, h.requireAdmin, h.admin.PrioritizeJob)
+	r.Delete("/admin/jobs/:id/priority", version, h.requireAdmin, h.admin.DeprioritizeJob)
+	r.Post("/admin/config/reload", version, h.requireAdmin, h.config.Reload)
+	r.Post("/admin/database/maintenance", version, h.requireAdmin, h.database.Maintain)
+
+	// Stats
+	r.Get("/stats", version, h.stats.Stats)
+
+	// Accounts
+	r.Get("/me", version, h.requireUser, h.account.Me)
+}
+
+// checkAPISpec warns about /api/v2 routes missing from the API description
+// and documented routes that aren't mounted, so the two don't drift apart
+func checkAPISpec(app *fiber.App, spec *openapi.Document) {
+	documented := make(map[string]bool)
+	for _, route := range spec.Routes() {
+		documented[route] = true
+	}
+
+	for _, route := range app.GetRoutes(true) {
+		path, ok := strings.CutPrefix(route.Path, "/api/v2")
+		if !ok || route.Method == fiber.MethodHead {
+			continue
+		}
+		key := route.Method + " " + path
+		if !documented[key] {
+			log.Warn().Str("route", key).Msg("Route missing from the API description")
+		}
+		delete(documented, key)
+	}
+	for route := range documented {
+		log.Warn().Str("route", route).Msg("Documented route is not mounted")
+	}
+}
+
+// staticFiles returns the frontend embedded in the binary, or STATIC_DIR
+// when set
+func staticFiles(cfg *config.Config) http.FileSystem {
+	if cfg.StaticDir != "" {
+		return http.Dir(cfg.StaticDir)
+	}
+	return http.FS(static.Files)
+}
+
+func setupMiddleware(app *fiber.App, cfg *config.Config, logger *logger.Logger, reloader *config.Reloader) {
+	if cfg.Middleware.EnableRecover {
+		app.Use(recover.New(recover.Config{
+			EnableStackTrace: cfg.Debug,
+		}))
+	}
+
+	if cfg.Middleware.EnableRequestID {
+		app.Use(requestid.New(requestid.Config{
+			Header: "X-Request-ID",
+			Generator: func() string {
+				return uuid.New().String()
+			},
+		}))
+	}
+
+	if cfg.Middleware.EnableLogger {
+		app.Use(logger.Middleware())
+	}
+
+	if cfg.Middleware.EnableTimeout {
+		app.Use(timeout.New(func(c *fiber.Ctx) error {
+			// timeout only logs the errors its handler returns, which would
+			// leave an empty 200, so they are written out here
+			if err := c.Next(); err != nil {
+				return handlers.ErrorHandler(c, err)
+			}
+			return nil
+		}, cfg.RequestTimeout))
+	}
+
+	if cfg.Middleware.EnableCORS {
+		// Browser clients can only back off if they can read the rate limit
+		// headers
+		exposed := slices.Clone(cfg.CORS.ExposedHeaders)
+		for _, header := range middleware.RateLimitHeaders {
+			if !slices.ContainsFunc(exposed, func(h string) bool { return strings.EqualFold(h, header) }) {
+				exposed = append(exposed, header)
+			}
+		}
+
+		app.Use(cors.New(cors.Config{
+			AllowOrigins:     strings.Join(cfg.CORS.AllowedOrigins, ","),
+			AllowMethods:     strings.Join(cfg.CORS.AllowedMethods, ","),
+			AllowHeaders:     strings.Join(cfg.CORS.AllowedHeaders, ","),
+			ExposeHeaders:    strings.Join(exposed, ","),
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		}))
+	}
+
+	if cfg.Middleware.EnableRateLimit && cfg.RateLimit.Enabled {
+		exempt, err := middleware.RateLimitExempt(middleware.ExemptConfig{
+			APIKeys:        cfg.RateLimit.ExemptAPIKeys,
+			CIDRs:          cfg.RateLimit.ExemptCIDRs,
+			InternalHeader: cfg.RateLimit.InternalHeader,
+			InternalSecret: cfg.RateLimit.InternalSecret,
+			AdminToken:     cfg.Admin.Token,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid rate limit exemptions")
+		}
+
+		store, err := ratelimit.New(ratelimit.Config{
+			Store:    cfg.RateLimit.Store,
+			RedisURL: cfg.RateLimit.RedisURL,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid rate limit store")
+		}
+
+		newLimiter := func(max int) fiber.Handler {
+			return middleware.RateLimit(middleware.RateLimitConfig{
+				Store:   store,
+				Max:     max,
+				Window:  time.Minute,
+				APIKeys: cfg.API.Keys,
+				Next:    exempt,
+			})
+		}
+
+		// A reload swaps in a limiter with the new limit. Counts are kept in
+		// the store, so callers don't start over.
+		var rateLimit atomic.Pointer[fiber.Handler]
+		current := newLimiter(cfg.RateLimit.RequestsPerMinute)
+		rateLimit.Store(&current)
+		reloader.OnReload(func(cfg *config.Config) {
+			next := newLimiter(cfg.RateLimit.RequestsPerMinute)
+			rateLimit.Store(&next)
+		})
+		app.Use(func(c *fiber.Ctx) error {
+			return (*rateLimit.Load())(c)
+		})
+	}
+
+	if cfg.Maintenance.Enabled {
+		app.Use(middleware.Maintenance(cfg.Maintenance.RetryAfter,
+			"/api/admin", "/api/jobs",
+			"/api/v1/admin", "/api/v1/jobs",
+			"/api/v2/admin", "/api/v2/jobs"))
+	}
+
+	if cfg.Middleware.EnableCompress {
+		app.Use(compress.New(compress.Config{
+			Level: compress.LevelDefault,
+			// Compressors buffer output, which would hold back SSE events
+			Next: isEventStream,
+		}))
+	}
+
+	if cfg.Middleware.EnableETag {
+		// Hashing the body would wait for a stream to end
+		app.Use(etag.New(etag.Config{Next: isEventStream}))
+	}
+
+	if cfg.Middleware.EnableDebugMode && cfg.Debug {
+		app.Use(func(c *fiber.Ctx) error {
+			c.Set("X-Debug-Mode", "true")
+			return c.Next()
+		})
+	}
+}
+
+// isEventStream reports whether a request is for a Server-Sent Events stream
+func isEventStream(c *fiber.Ctx) bool {
+	return strings.HasSuffix(c.Path(), "/events")
+}
+
+// newReloader passes reloaded settings on to the services holding them
+func newReloader(cfg *config.Config, a *application) *config.Reloader {
+	reloader := config.NewReloader(cfg)
+	reloader.OnReload(func(cfg *config.Config) {
+		a.validator.SetConfig(cfg)
+		a.videos.UpdateSettings(video.Settings{
+			DefaultModel: cfg.Video.DefaultModel,
+			ModelRoutes:  cfg.Video.ModelRoutes,
+			Retention:    cfg.Video.Retention,
+		})
+	})
+	return reloader
+}
+
+// reloadOnHangup reloads the configuration on each SIGHUP until ctx is
+// cancelled
+func reloadOnHangup(ctx context.Context, reloader *config.Reloader) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		}
+
+		changed, err := reloader.Reload()
+		if err != nil {
+			log.Error().Err(err).Msg("Config reload failed, keeping the current settings")
+			continue
+		}
+		log.Info().Strs("changed", changed).Msg("Config reloaded")
+	}
+}
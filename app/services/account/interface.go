@@ -0,0 +1,45 @@
+package account
+
+import (
+	"context"
+	"time"
+	"yt-text/models"
+)
+
+// Service signs users in with OAuth providers and keeps them signed in
+// with session tokens. Videos stay shared between users; an account only
+// decides whose list a submission shows up in.
+type Service interface {
+	// Providers lists the names of the configured providers
+	Providers() []string
+
+	// LoginURL returns the provider page a user signing in is sent to.
+	// state comes back to the callback unchanged.
+	LoginURL(provider, state string) (string, error)
+
+	// SignIn completes a login with the code the provider sent back to the
+	// callback. A user signing in for the first time is created.
+	SignIn(ctx context.Context, provider, code string) (*models.User, error)
+
+	// GetUser retrieves a user by ID
+	GetUser(ctx context.Context, id string) (*models.User, error)
+
+	// NewSession returns a token keeping a user signed in until it expires
+	NewSession(userID string) (token string, expires time.Time)
+
+	// VerifySession returns the user a token was issued to, or false when
+	// it was tampered with or has expired
+	VerifySession(token string) (userID string, ok bool)
+}
+
+type Config struct {
+	// BaseURL is where the server is reached from browsers, e.g.
+	// https://example.com. Providers redirect back to it.
+	BaseURL string
+
+	// SessionSecret signs session tokens; changing it signs everyone out
+	SessionSecret string
+	SessionTTL    time.Duration
+
+	Providers []*Provider
+}
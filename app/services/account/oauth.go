@@ -0,0 +1,184 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider is an OAuth 2.0 identity provider using the authorization code
+// flow
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+
+	authURL    string
+	tokenURL   string
+	profileURL string
+	scopes     []string
+
+	// parseProfile reads the user's ID, email and name from the response
+	// of profileURL
+	parseProfile func(body []byte) (Profile, error)
+}
+
+// Profile is what a provider tells about a signed-in user
+type Profile struct {
+	Subject string // Stable ID of the user at the provider
+	Email   string
+	Name    string
+}
+
+// providerTimeout bounds each request to a provider
+const providerTimeout = 10 * time.Second
+
+var providerClient = &http.Client{Timeout: providerTimeout}
+
+// Google signs users in with their Google account
+func Google(clientID, clientSecret string) *Provider {
+	return &Provider{
+		Name:         "google",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		authURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		tokenURL:     "https://oauth2.googleapis.com/token",
+		profileURL:   "https://openidconnect.googleapis.com/v1/userinfo",
+		scopes:       []string{"openid", "email", "profile"},
+		parseProfile: func(body []byte) (Profile, error) {
+			var info struct {
+				Sub   string `json:"sub"`
+				Email string `json:"email"`
+				Name  string `json:"name"`
+			}
+			if err := json.Unmarshal(body, &info); err != nil {
+				return Profile{}, err
+			}
+			return Profile{Subject: info.Sub, Email: info.Email, Name: info.Name}, nil
+		},
+	}
+}
+
+// GitHub signs users in with their GitHub account. The email is only
+// known when the user made it public.
+func GitHub(clientID, clientSecret string) *Provider {
+	return &Provider{
+		Name:         "github",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		authURL:      "https://github.com/login/oauth/authorize",
+		tokenURL:     "https://github.com/login/oauth/access_token",
+		profileURL:   "https://api.github.com/user",
+		scopes:       []string{"read:user"},
+		parseProfile: func(body []byte) (Profile, error) {
+			var info struct {
+				ID    int64  `json:"id"`
+				Login string `json:"login"`
+				Name  string `json:"name"`
+				Email string `json:"email"`
+			}
+			if err := json.Unmarshal(body, &info); err != nil {
+				return Profile{}, err
+			}
+			name := info.Name
+			if name == "" {
+				name = info.Login
+			}
+			return Profile{Subject: strconv.FormatInt(info.ID, 10), Email: info.Email, Name: name}, nil
+		},
+	}
+}
+
+// authCodeURL returns the provider page asking the user to sign in
+func (p *Provider) authCodeURL(redirectURL, state string) string {
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.ClientID},
+		"redirect_uri":  {redirectURL},
+		"scope":         {strings.Join(p.scopes, " ")},
+		"state":         {state},
+	}
+	return p.authURL + "?" + query.Encode()
+}
+
+// exchange trades the code from the callback for an access token
+func (p *Provider) exchange(ctx context.Context, code, redirectURL string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	body, err := send(req)
+	if err != nil {
+		return "", fmt.Errorf("%s token exchange failed: %w", p.Name, err)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("%s token exchange failed: %w", p.Name, err)
+	}
+	// GitHub reports a bad code with a 200 and an error field
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("%s token exchange failed: %s", p.Name, token.Error)
+	}
+	return token.AccessToken, nil
+}
+
+// profile fetches the signed-in user with an access token
+func (p *Provider) profile(ctx context.Context, accessToken string) (Profile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.profileURL, nil)
+	if err != nil {
+		return Profile{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	body, err := send(req)
+	if err != nil {
+		return Profile{}, fmt.Errorf("%s profile request failed: %w", p.Name, err)
+	}
+	profile, err := p.parseProfile(body)
+	if err != nil {
+		return Profile{}, fmt.Errorf("%s profile request failed: %w", p.Name, err)
+	}
+	if profile.Subject == "" {
+		return Profile{}, fmt.Errorf("%s profile has no user ID", p.Name)
+	}
+	return profile, nil
+}
+
+// send runs a request and returns the body of a successful response
+func send(req *http.Request) ([]byte, error) {
+	resp, err := providerClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return body, nil
+}
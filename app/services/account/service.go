@@ -0,0 +1,120 @@
+package account
+
+import (
+	"context"
+	"strings"
+	"time"
+	"yt-text/errors"
+	"yt-text/logger"
+	"yt-text/models"
+	"yt-text/repository"
+	"yt-text/signing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+type service struct {
+	repo      repository.VideoRepository
+	providers map[string]*Provider
+	names     []string
+	sessions  *signing.Signer
+	config    Config
+	logger    zerolog.Logger
+}
+
+func NewService(repo repository.VideoRepository, config Config) Service {
+	s := &service{
+		repo:      repo,
+		providers: make(map[string]*Provider, len(config.Providers)),
+		sessions:  signing.New(config.SessionSecret),
+		config:    config,
+		logger:    zerolog.New(zerolog.NewConsoleWriter()),
+	}
+	for _, p := range config.Providers {
+		s.providers[p.Name] = p
+		s.names = append(s.names, p.Name)
+	}
+	return s
+}
+
+func (s *service) Providers() []string {
+	return s.names
+}
+
+// redirectURL is the callback the provider sends the user back to
+func (s *service) redirectURL(provider string) string {
+	return strings.TrimSuffix(s.config.BaseURL, "/") + "/auth/" + provider + "/callback"
+}
+
+func (s *service) provider(op, name string) (*Provider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, errors.NotFound(op, nil, "Unknown login provider")
+	}
+	return p, nil
+}
+
+func (s *service) LoginURL(provider, state string) (string, error) {
+	const op = "AccountService.LoginURL"
+
+	p, err := s.provider(op, provider)
+	if err != nil {
+		return "", err
+	}
+	return p.authCodeURL(s.redirectURL(provider), state), nil
+}
+
+func (s *service) SignIn(ctx context.Context, provider, code string) (*models.User, error) {
+	const op = "AccountService.SignIn"
+
+	p, err := s.provider(op, provider)
+	if err != nil {
+		return nil, err
+	}
+	if code == "" {
+		return nil, errors.InvalidInput(op, nil, "Login was cancelled or failed")
+	}
+
+	logger := s.logger.With().
+		Str("operation", op).
+		Str("provider", provider).
+		Str("request_id", logger.RequestID(ctx)).
+		Logger()
+
+	token, err := p.exchange(ctx, code, s.redirectURL(provider))
+	if err != nil {
+		logger.Warn().Err(err).Msg("Login failed")
+		return nil, errors.Unauthorized(op, err, "Login failed")
+	}
+	profile, err := p.profile(ctx, token)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Login failed")
+		return nil, errors.Unavailable(op, err, "Could not read the account from the login provider")
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:        uuid.New().String(),
+		Provider:  provider,
+		Subject:   profile.Subject,
+		Email:     profile.Email,
+		Name:      profile.Name,
+		CreatedAt: now,
+		LastLogin: now,
+	}
+	if err := s.repo.SaveUser(ctx, user); err != nil {
+		return nil, err
+	}
+	logger.Info().Str("user_id", user.ID).Msg("User signed in")
+	return user, nil
+}
+
+func (s *service) GetUser(ctx context.Context, id string) (*models.User, error) {
+	const op = "AccountService.GetUser"
+
+	if id == "" {
+		return nil, errors.InvalidInput(op, nil, "ID is required")
+	}
+	return s.repo.FindUser(ctx, id)
+}
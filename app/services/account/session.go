@@ -0,0 +1,17 @@
+package account
+
+import "time"
+
+// Session tokens name the user they were issued to. They are stateless:
+// signing out only deletes the cookie, and a token stays valid until it
+// expires.
+
+func (s *service) NewSession(userID string) (string, time.Time) {
+	expires := time.Now().Add(s.config.SessionTTL)
+	return s.sessions.Sign(userID, expires), expires
+}
+
+func (s *service) VerifySession(token string) (string, bool) {
+	userID, _, ok := s.sessions.Verify(token)
+	return userID, ok
+}
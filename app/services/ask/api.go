@@ -0,0 +1,49 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"yt-text/llm"
+	"yt-text/models"
+)
+
+// apiProvider answers with a remote language model
+type apiProvider struct {
+	chat *llm.Client
+}
+
+// NewAPIProvider answers with the chat completions endpoint of cfg
+func NewAPIProvider(cfg llm.Config) Provider {
+	return &apiProvider{chat: llm.New(cfg)}
+}
+
+func (p *apiProvider) Name() string { return "api" }
+
+const instructions = "You answer questions about a video using only the numbered passages of its transcript " +
+	"that follow. Cite every passage you use by its number in square brackets, like [2]. " +
+	"If the passages don't answer the question, say so. Reply in the language of the question, " +
+	"without any preamble."
+
+func (p *apiProvider) Answer(ctx context.Context, question string, passages []models.Segment) (*Result, error) {
+	var prompt strings.Builder
+	for i, passage := range passages {
+		fmt.Fprintf(&prompt, "[%d] %s\n\n", i+1, passage.Text)
+	}
+	prompt.WriteString("Question: " + question)
+
+	reply, err := p.chat.Complete(ctx, []llm.Message{
+		{Role: "system", Content: instructions},
+		{Role: "user", Content: prompt.String()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Text:             reply.Text,
+		Model:            reply.Model,
+		PromptTokens:     reply.PromptTokens,
+		CompletionTokens: reply.CompletionTokens,
+		Cost:             reply.Cost,
+	}, nil
+}
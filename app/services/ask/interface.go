@@ -0,0 +1,22 @@
+package ask
+
+import (
+	"context"
+	"time"
+	"yt-text/models"
+)
+
+type Service interface {
+	// Ask answers a question about a completed transcription from the
+	// passages that match it best, citing them with their timestamps
+	Ask(ctx context.Context, videoID, question string) (*models.Answer, error)
+}
+
+type Config struct {
+	// Passages is how many of the best-matching passages the answer is
+	// drawn from
+	Passages int `json:"passages"`
+
+	// PassageDuration is how much of the video a passage spans, at least
+	PassageDuration time.Duration `json:"passage_duration"`
+}
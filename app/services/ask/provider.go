@@ -0,0 +1,42 @@
+package ask
+
+import (
+	"context"
+	"yt-text/models"
+)
+
+// Provider answers questions from transcript passages
+type Provider interface {
+	// Name identifies the provider in logs
+	Name() string
+
+	// Answer replies to question from passages, given best match first.
+	// The reply cites passages as [n], counting from 1.
+	Answer(ctx context.Context, question string, passages []models.Segment) (*Result, error)
+}
+
+// Result is an answer produced by a provider
+type Result struct {
+	Text  string
+	Model string
+
+	// Token usage and its estimated cost in US dollars, when the provider
+	// reports them
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// localProvider answers with the best-matching passage as it is, for
+// servers without a language model
+type localProvider struct{}
+
+func NewLocalProvider() Provider {
+	return localProvider{}
+}
+
+func (localProvider) Name() string { return "local" }
+
+func (localProvider) Answer(ctx context.Context, question string, passages []models.Segment) (*Result, error) {
+	return &Result{Text: passages[0].Text + " [1]", Model: "passage"}, nil
+}
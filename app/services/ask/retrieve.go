@@ -0,0 +1,83 @@
+package ask
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"yt-text/models"
+)
+
+// BM25 parameters, at their usual values
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// terms splits text into lowercase words and numbers
+func terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// rank returns up to n passages matching question, best first, scored with
+// Okapi BM25. Passages sharing no term with the question are left out.
+func rank(passages []models.Segment, question string, n int) []models.Segment {
+	docs := make([]map[string]int, len(passages))
+	lengths := make([]int, len(passages))
+	docFreq := make(map[string]int)
+	total := 0
+	for i, passage := range passages {
+		docs[i] = make(map[string]int)
+		for _, term := range terms(passage.Text) {
+			if docs[i][term] == 0 {
+				docFreq[term]++
+			}
+			docs[i][term]++
+			lengths[i]++
+		}
+		total += lengths[i]
+	}
+	if total == 0 {
+		return nil
+	}
+	avgLength := float64(total) / float64(len(passages))
+
+	// Repeating a word in the question doesn't make it count more
+	query := make(map[string]bool)
+	for _, term := range terms(question) {
+		query[term] = true
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	var matches []scored
+	for i, doc := range docs {
+		score := 0.0
+		for term := range query {
+			tf := float64(doc[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(docFreq[term])
+			idf := math.Log(1 + (float64(len(passages))-df+0.5)/(df+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/avgLength))
+		}
+		if score > 0 {
+			matches = append(matches, scored{i, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].score > matches[b].score })
+	if len(matches) > n {
+		matches = matches[:n]
+	}
+	result := make([]models.Segment, len(matches))
+	for i, m := range matches {
+		result[i] = passages[m.index]
+	}
+	return result
+}
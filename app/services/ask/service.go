@@ -0,0 +1,111 @@
+package ask
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"yt-text/errors"
+	"yt-text/logger"
+	"yt-text/models"
+	"yt-text/repository"
+
+	"github.com/rs/zerolog"
+)
+
+type service struct {
+	repo     repository.VideoRepository
+	provider Provider
+	config   Config
+	logger   zerolog.Logger
+}
+
+func NewService(repo repository.VideoRepository, provider Provider, config Config) Service {
+	return &service{
+		repo:     repo,
+		provider: provider,
+		config:   config,
+		logger:   zerolog.New(zerolog.NewConsoleWriter()),
+	}
+}
+
+// citationRef matches the [n] an answer cites a passage with
+var citationRef = regexp.MustCompile(`\[(\d+)\]`)
+
+func (s *service) Ask(ctx context.Context, videoID, question string) (*models.Answer, error) {
+	const op = "AskService.Ask"
+
+	question = strings.TrimSpace(question)
+	if videoID == "" {
+		return nil, errors.InvalidInput(op, nil, "ID is required")
+	}
+	if question == "" {
+		return nil, errors.InvalidInput(op, nil, "Question is required")
+	}
+
+	video, err := s.repo.Find(ctx, videoID)
+	if err != nil {
+		return nil, errors.NotFound(op, err, "Transcription not found")
+	}
+	if !video.IsCompleted() {
+		return nil, errors.Conflict(op, nil, "Transcription is not completed yet")
+	}
+
+	// Answers cite timestamps, so only timed transcripts can be asked about
+	segments, _ := video.SegmentsFrom(video.Source)
+	if len(segments) == 0 {
+		return nil, errors.InvalidInput(op, nil, "Transcription has no timestamps to cite")
+	}
+	matches := rank(models.Passages(segments, s.config.PassageDuration), question, s.config.Passages)
+	if len(matches) == 0 {
+		return nil, errors.NotFound(op, nil, "No part of the transcript matches the question")
+	}
+
+	logger := s.logger.With().
+		Str("operation", op).
+		Str("video_id", videoID).
+		Str("request_id", logger.RequestID(ctx)).
+		Str("provider", s.provider.Name()).
+		Logger()
+
+	start := time.Now()
+	result, err := s.provider.Answer(ctx, question, matches)
+	if err != nil {
+		logger.Error().Err(err).Dur("duration", time.Since(start)).Msg("Answering failed")
+		return nil, errors.Internal(op, err, "Failed to answer the question")
+	}
+	logger.Info().
+		Str("model", result.Model).
+		Int("passages", len(matches)).
+		Dur("duration", time.Since(start)).
+		Int("prompt_tokens", result.PromptTokens).
+		Int("completion_tokens", result.CompletionTokens).
+		Float64("cost_usd", result.Cost).
+		Msg("Question answered")
+
+	return &models.Answer{
+		VideoID:   videoID,
+		Question:  question,
+		Answer:    result.Text,
+		Citations: citations(result.Text, matches),
+		Source:    video.Source,
+		Model:     result.Model,
+	}, nil
+}
+
+// citations returns the passages an answer cites, in the order it first
+// cites them. References to passages that weren't given are ignored.
+func citations(answer string, passages []models.Segment) []models.Citation {
+	cited := []models.Citation{}
+	seen := make(map[int]bool)
+	for _, match := range citationRef.FindAllStringSubmatch(answer, -1) {
+		ref, err := strconv.Atoi(match[1])
+		if err != nil || ref < 1 || ref > len(passages) || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		cited = append(cited, models.Citation{Ref: ref, Segment: passages[ref-1]})
+	}
+	return cited
+}
@@ -0,0 +1,38 @@
+package search
+
+import (
+	"context"
+	"time"
+	"yt-text/models"
+)
+
+type Service interface {
+	// Search returns up to limit passages closest in meaning to query, best
+	// first
+	Search(ctx context.Context, query string, limit int) (*models.SearchResponse, error)
+
+	// Index embeds the passages of a completed transcription, replacing its
+	// earlier embeddings
+	Index(ctx context.Context, videoID string) error
+
+	// RunIndexer indexes transcriptions as their jobs complete, and sweeps
+	// for any it missed every SweepInterval, until ctx is done
+	RunIndexer(ctx context.Context)
+}
+
+// Embedder turns texts into vectors. A chat completions client's
+// /embeddings endpoint is one.
+type Embedder interface {
+	Model() string
+	Embed(ctx context.Context, inputs []string) ([][]float32, int, error)
+}
+
+type Config struct {
+	// PassageDuration is how much of the video an embedded passage spans,
+	// at least
+	PassageDuration time.Duration `json:"passage_duration"`
+
+	// SweepInterval is how often transcriptions finished without an event
+	// reaching this process, such as those of other workers, are indexed
+	SweepInterval time.Duration `json:"sweep_interval"`
+}
@@ -0,0 +1,246 @@
+package search
+
+import (
+	"context"
+	stderrors "errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"yt-text/errors"
+	"yt-text/events"
+	"yt-text/logger"
+	"yt-text/models"
+	"yt-text/repository"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// embedBatch is how many passages are sent to the embedder at once
+	embedBatch = 64
+
+	// sweepBatch is how many transcriptions one sweep indexes at most
+	sweepBatch = 100
+)
+
+type service struct {
+	repo     repository.VideoRepository
+	embedder Embedder // nil when semantic search is disabled
+	events   *events.Bus
+	config   Config
+	logger   zerolog.Logger
+}
+
+// NewService searches with embedder's vectors. Without an embedder, search
+// is disabled and RunIndexer returns right away.
+func NewService(repo repository.VideoRepository, embedder Embedder, bus *events.Bus, config Config) Service {
+	return &service{
+		repo:     repo,
+		embedder: embedder,
+		events:   bus,
+		config:   config,
+		logger:   zerolog.New(zerolog.NewConsoleWriter()),
+	}
+}
+
+func (s *service) Search(ctx context.Context, query string, limit int) (*models.SearchResponse, error) {
+	const op = "SearchService.Search"
+
+	if s.embedder == nil {
+		return nil, errors.NotFound(op, nil, "Semantic search is not enabled")
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.InvalidInput(op, nil, "Query is required")
+	}
+
+	vectors, _, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, errors.Unavailable(op, err, "Failed to embed the query")
+	}
+	queryVector := vectors[0]
+	queryNorm := norm(queryVector)
+
+	// Keep the best matches in a short list sorted by score. Vectors of
+	// another size can't be compared and are skipped.
+	var best []scored
+	err = s.repo.ScanEmbeddings(ctx, s.embedder.Model(), func(e models.Embedding) error {
+		if len(e.Vector) != len(queryVector) {
+			return nil
+		}
+		score := cosine(queryVector, queryNorm, e.Vector)
+		if len(best) == limit && score <= best[len(best)-1].score {
+			return nil
+		}
+		i := sort.Search(len(best), func(i int) bool { return best[i].score < score })
+		best = append(best, scored{})
+		copy(best[i+1:], best[i:])
+		best[i] = scored{embedding: e, score: score}
+		if len(best) > limit {
+			best = best[:limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.SearchResult{}
+	for _, match := range best {
+		video, err := s.repo.Find(ctx, match.embedding.VideoID)
+		if err != nil {
+			continue // Deleted since it was embedded
+		}
+		results = append(results, models.SearchResult{
+			VideoID: video.ID,
+			Title:   video.Title,
+			URL:     video.URL,
+			Score:   match.score,
+			Segment: match.embedding.Segment,
+		})
+	}
+	return &models.SearchResponse{Query: query, Model: s.embedder.Model(), Results: results}, nil
+}
+
+type scored struct {
+	embedding models.Embedding
+	score     float64
+}
+
+func norm(v []float32) float64 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	return math.Sqrt(sum)
+}
+
+// cosine returns the cosine similarity of q, whose norm is given, and v
+func cosine(q []float32, qNorm float64, v []float32) float64 {
+	var dot float64
+	for i := range q {
+		dot += float64(q[i]) * float64(v[i])
+	}
+	vNorm := norm(v)
+	if qNorm == 0 || vNorm == 0 {
+		return 0
+	}
+	return dot / (qNorm * vNorm)
+}
+
+func (s *service) Index(ctx context.Context, videoID string) error {
+	const op = "SearchService.Index"
+
+	if s.embedder == nil {
+		return errors.NotFound(op, nil, "Semantic search is not enabled")
+	}
+
+	video, err := s.repo.Find(ctx, videoID)
+	if err != nil {
+		return errors.NotFound(op, err, "Transcription not found")
+	}
+	if !video.IsCompleted() {
+		return errors.Conflict(op, nil, "Transcription is not completed yet")
+	}
+	segments, _ := video.SegmentsFrom(video.Source)
+	passages := models.Passages(segments, s.config.PassageDuration)
+	if len(passages) == 0 {
+		return errors.InvalidInput(op, nil, "Transcription has no timed passages to embed")
+	}
+
+	start := time.Now()
+	embeddings := make([]models.Embedding, 0, len(passages))
+	tokens := 0
+	for i := 0; i < len(passages); i += embedBatch {
+		batch := passages[i:min(i+embedBatch, len(passages))]
+		texts := make([]string, len(batch))
+		for j, passage := range batch {
+			texts[j] = passage.Text
+		}
+
+		vectors, used, err := s.embedder.Embed(ctx, texts)
+		if err != nil {
+			return errors.Unavailable(op, err, "Failed to embed transcription")
+		}
+		tokens += used
+		for j, passage := range batch {
+			embeddings = append(embeddings, models.Embedding{
+				VideoID:   videoID,
+				Source:    video.Source,
+				Model:     s.embedder.Model(),
+				Passage:   i + j,
+				Segment:   passage,
+				Vector:    vectors[j],
+				CreatedAt: time.Now(),
+			})
+		}
+	}
+	if err := s.repo.SaveEmbeddings(ctx, videoID, s.embedder.Model(), embeddings); err != nil {
+		return err
+	}
+
+	s.logger.Info().
+		Str("operation", op).
+		Str("video_id", videoID).
+		Str("request_id", logger.RequestID(ctx)).
+		Str("model", s.embedder.Model()).
+		Int("passages", len(embeddings)).
+		Int("tokens", tokens).
+		Dur("duration", time.Since(start)).
+		Msg("Transcription embedded")
+	return nil
+}
+
+func (s *service) RunIndexer(ctx context.Context) {
+	if s.embedder == nil {
+		return
+	}
+
+	sub := s.events.Subscribe()
+	defer sub.Close()
+	ticker := time.NewTicker(s.config.SweepInterval)
+	defer ticker.Stop()
+
+	s.sweep(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if event.Type == models.JobCompleted {
+				s.index(ctx, event.VideoID)
+			}
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep indexes the transcriptions that haven't been embedded since they
+// last changed
+func (s *service) sweep(ctx context.Context) {
+	ids, err := s.repo.ListUnembedded(ctx, s.embedder.Model(), sweepBatch)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to list transcriptions to embed")
+		return
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		s.index(ctx, id)
+	}
+}
+
+// index embeds a transcription in the background, logging failures; the
+// next sweep retries it
+func (s *service) index(ctx context.Context, videoID string) {
+	err := s.Index(ctx, videoID)
+	if err != nil && !stderrors.Is(ctx.Err(), context.Canceled) {
+		s.logger.Warn().Err(err).Str("video_id", videoID).Msg("Failed to embed transcription")
+	}
+}
@@ -0,0 +1,75 @@
+package summary
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"yt-text/llm"
+	"yt-text/models"
+)
+
+// APIConfig configures an OpenAI-compatible chat completions endpoint
+type APIConfig struct {
+	BaseURL string // e.g. https://api.openai.com/v1
+	APIKey  string
+	Model   string
+	Timeout time.Duration
+
+	// MaxInputChars truncates longer transcripts to fit the model's context
+	MaxInputChars int
+
+	// Prices in US dollars per million tokens, for cost logging
+	InputCost  float64
+	OutputCost float64
+}
+
+// apiProvider summarizes with a remote language model
+type apiProvider struct {
+	chat          *llm.Client
+	maxInputChars int
+}
+
+// NewAPIProvider summarizes with the chat completions endpoint of cfg
+func NewAPIProvider(cfg APIConfig) Provider {
+	return &apiProvider{
+		chat: llm.New(llm.Config{
+			BaseURL:    cfg.BaseURL,
+			APIKey:     cfg.APIKey,
+			Model:      cfg.Model,
+			Timeout:    cfg.Timeout,
+			InputCost:  cfg.InputCost,
+			OutputCost: cfg.OutputCost,
+		}),
+		maxInputChars: cfg.MaxInputChars,
+	}
+}
+
+func (p *apiProvider) Name() string  { return "api" }
+func (p *apiProvider) Model() string { return p.chat.Model() }
+
+// instructions asks for a summary of the given style
+func instructions(style models.SummaryStyle, maxSentences int) string {
+	shape := fmt.Sprintf("a summary of at most %d sentences", maxSentences)
+	if style == models.StyleBullets {
+		shape = fmt.Sprintf("at most %d bullet points, one per line, each starting with \"- \"", maxSentences)
+	}
+	return "You summarize video transcripts. Reply with " + shape +
+		", in the language of the transcript, without any preamble."
+}
+
+func (p *apiProvider) Summarize(ctx context.Context, text string, style models.SummaryStyle, maxSentences int) (*Result, error) {
+	reply, err := p.chat.Complete(ctx, []llm.Message{
+		{Role: "system", Content: instructions(style, maxSentences)},
+		{Role: "user", Content: llm.Truncate(text, p.maxInputChars)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Text:             reply.Text,
+		Model:            reply.Model,
+		PromptTokens:     reply.PromptTokens,
+		CompletionTokens: reply.CompletionTokens,
+		Cost:             reply.Cost,
+	}, nil
+}
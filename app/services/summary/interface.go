@@ -0,0 +1,31 @@
+package summary
+
+import (
+	"context"
+	"yt-text/models"
+)
+
+type Service interface {
+	// Summarize returns the summary of a completed transcription in the
+	// requested style, producing it first if the configured model hasn't
+	// summarized the current transcript that way yet
+	Summarize(ctx context.Context, videoID string, opts Options) (*models.Summary, error)
+
+	// GetSummary retrieves a stored summary in the requested style
+	GetSummary(ctx context.Context, videoID string, opts Options) (*models.Summary, error)
+}
+
+// Options choose which of a video's summaries is wanted
+type Options struct {
+	// Style defaults to models.StyleAbstract
+	Style models.SummaryStyle
+
+	// Model picks the summary of one model for GetSummary, which otherwise
+	// returns the newest one. Summarize always uses the configured model.
+	Model string
+}
+
+type Config struct {
+	// MaxSentences is the length of a summary
+	MaxSentences int `json:"max_sentences"`
+}
@@ -0,0 +1,60 @@
+package summary
+
+import (
+	"context"
+	"time"
+	"yt-text/models"
+	"yt-text/scripts"
+)
+
+// Provider produces summaries. Each provider bounds its own runs, since a
+// remote model and a local script need very different timeouts.
+type Provider interface {
+	// Name identifies the provider in logs
+	Name() string
+
+	// Model names the model summaries are asked of. Stored summaries are
+	// kept per model, so switching models doesn't discard them.
+	Model() string
+
+	// Summarize condenses text in the abstract or bullets style; the
+	// service builds chapter summaries out of abstracts
+	Summarize(ctx context.Context, text string, style models.SummaryStyle, maxSentences int) (*Result, error)
+}
+
+// Result is a summary produced by a provider
+type Result struct {
+	Text  string
+	Model string
+
+	// Token usage and its estimated cost in US dollars, when the provider
+	// reports them
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// scriptProvider summarizes with the local Python script
+type scriptProvider struct {
+	scripts *scripts.ScriptRunner
+	timeout time.Duration
+}
+
+// NewScriptProvider summarizes locally, allowing each run timeout
+func NewScriptProvider(scriptRunner *scripts.ScriptRunner, timeout time.Duration) Provider {
+	return &scriptProvider{scripts: scriptRunner, timeout: timeout}
+}
+
+func (p *scriptProvider) Name() string  { return "local" }
+func (p *scriptProvider) Model() string { return scripts.SummaryModel }
+
+func (p *scriptProvider) Summarize(ctx context.Context, text string, style models.SummaryStyle, maxSentences int) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	result, err := p.scripts.Summarize(ctx, text, string(style), maxSentences)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Text: result.Summary, Model: result.ModelName}, nil
+}
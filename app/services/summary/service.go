@@ -0,0 +1,172 @@
+package summary
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"yt-text/errors"
+	"yt-text/formats"
+	"yt-text/logger"
+	"yt-text/models"
+	"yt-text/repository"
+
+	"github.com/rs/zerolog"
+)
+
+type service struct {
+	repo     repository.VideoRepository
+	provider Provider
+	config   Config
+	logger   zerolog.Logger
+}
+
+func NewService(repo repository.VideoRepository, provider Provider, config Config) Service {
+	return &service{
+		repo:     repo,
+		provider: provider,
+		config:   config,
+		logger:   zerolog.New(zerolog.NewConsoleWriter()),
+	}
+}
+
+// chapterSentences is the length of each chapter's part of a chapters
+// summary
+const chapterSentences = 2
+
+// parseStyle validates a requested style, defaulting to an abstract
+func parseStyle(op string, opts Options) (models.SummaryStyle, error) {
+	switch opts.Style {
+	case "":
+		return models.StyleAbstract, nil
+	case models.StyleAbstract, models.StyleBullets, models.StyleChapters:
+		return opts.Style, nil
+	default:
+		return "", errors.InvalidInput(op, nil, "Unknown summary style "+string(opts.Style))
+	}
+}
+
+func (s *service) Summarize(ctx context.Context, videoID string, opts Options) (*models.Summary, error) {
+	const op = "SummaryService.Summarize"
+
+	if videoID == "" {
+		return nil, errors.InvalidInput(op, nil, "ID is required")
+	}
+	style, err := parseStyle(op, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := s.repo.Find(ctx, videoID)
+	if err != nil {
+		return nil, errors.NotFound(op, err, "Transcription not found")
+	}
+	if !video.IsCompleted() {
+		return nil, errors.Conflict(op, nil, "Transcription is not completed yet")
+	}
+
+	// A summary made before the transcript last changed is out of date
+	model := s.provider.Model()
+	summary, err := s.repo.FindSummary(ctx, videoID, style, model)
+	if err == nil && summary.Source == video.Source && !summary.CreatedAt.Before(video.UpdatedAt) {
+		return summary, nil
+	}
+
+	// Chapters are summarized from their timed text
+	var chapters []models.Chapter
+	if style == models.StyleChapters {
+		chapters = video.ChaptersFrom(video.Source)
+		if !hasText(chapters) {
+			return nil, errors.InvalidInput(op, nil, "Transcription has no chapters to summarize")
+		}
+	}
+
+	logger := s.logger.With().
+		Str("operation", op).
+		Str("video_id", videoID).
+		Str("request_id", logger.RequestID(ctx)).
+		Str("provider", s.provider.Name()).
+		Str("style", string(style)).
+		Logger()
+	logger.Info().Int("transcript_length", len(video.Transcription)).Msg("Summarizing transcript")
+
+	start := time.Now()
+	var result *Result
+	if style == models.StyleChapters {
+		result, err = s.summarizeChapters(ctx, chapters)
+	} else {
+		result, err = s.provider.Summarize(ctx, video.Transcription, style, s.config.MaxSentences)
+	}
+	if err != nil {
+		logger.Error().Err(err).Dur("duration", time.Since(start)).Msg("Summarization failed")
+		return nil, errors.Internal(op, err, "Failed to summarize transcription")
+	}
+	logger.Info().
+		Str("model", result.Model).
+		Dur("duration", time.Since(start)).
+		Int("prompt_tokens", result.PromptTokens).
+		Int("completion_tokens", result.CompletionTokens).
+		Float64("cost_usd", result.Cost).
+		Msg("Summary generated")
+
+	summary = &models.Summary{
+		VideoID:   videoID,
+		Text:      result.Text,
+		Style:     style,
+		Source:    video.Source,
+		Model:     model,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.SaveSummary(ctx, summary); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// summarizeChapters writes a short abstract of each chapter under its title,
+// adding up the usage of every run
+func (s *service) summarizeChapters(ctx context.Context, chapters []models.Chapter) (*Result, error) {
+	total := &Result{}
+	for i, ch := range chapters {
+		if strings.TrimSpace(ch.Text) == "" {
+			continue
+		}
+		result, err := s.provider.Summarize(ctx, ch.Text, models.StyleAbstract, chapterSentences)
+		if err != nil {
+			return nil, fmt.Errorf("chapter %q: %w", ch.Title, err)
+		}
+		chapters[i].Text = result.Text
+
+		total.Model = result.Model
+		total.PromptTokens += result.PromptTokens
+		total.CompletionTokens += result.CompletionTokens
+		total.Cost += result.Cost
+	}
+	total.Text = formats.MarkdownChapters(chapters)
+	return total, nil
+}
+
+// hasText reports whether any chapter has text to summarize
+func hasText(chapters []models.Chapter) bool {
+	for _, ch := range chapters {
+		if strings.TrimSpace(ch.Text) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) GetSummary(ctx context.Context, videoID string, opts Options) (*models.Summary, error) {
+	const op = "SummaryService.GetSummary"
+
+	if videoID == "" {
+		return nil, errors.InvalidInput(op, nil, "ID is required")
+	}
+	style, err := parseStyle(op, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindSummary(ctx, videoID, style, opts.Model)
+}
@@ -0,0 +1,151 @@
+package video
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+	"yt-text/errors"
+	"yt-text/logger"
+	"yt-text/models"
+	"yt-text/scripts"
+	"yt-text/youtube"
+
+	"github.com/google/uuid"
+)
+
+func (s *service) TranscribeBatch(ctx context.Context, urls []string, opts TranscribeOptions) (*models.Batch, error) {
+	const op = "VideoService.TranscribeBatch"
+
+	if len(urls) == 0 {
+		return nil, errors.InvalidInput(op, nil, "At least one URL is required")
+	}
+	if s.config.MaxBatchSize > 0 && len(urls) > s.config.MaxBatchSize {
+		return nil, errors.InvalidInput(op, nil, fmt.Sprintf("A batch can contain at most %d URLs", s.config.MaxBatchSize))
+	}
+	if err := s.checkWhisperOptions(opts); err != nil {
+		return nil, err
+	}
+	if !s.queue.AcceptingJobs() {
+		return nil, errors.Unavailable(op, ErrIntakePaused, "Not accepting new transcriptions right now, please try again later")
+	}
+
+	return s.startBatch(ctx, newBatch(urls), opts)
+}
+
+// TranscribePlaylist expands a playlist or channel into its videos and
+// submits them as a batch, which acts as the parent job
+func (s *service) TranscribePlaylist(ctx context.Context, url string, opts TranscribeOptions) (*models.Batch, error) {
+	const op = "VideoService.TranscribePlaylist"
+
+	if !youtube.IsPlaylistURL(url) {
+		return nil, errors.InvalidInput(op, nil, "Not a YouTube playlist or channel URL")
+	}
+	if err := s.checkWhisperOptions(opts); err != nil {
+		return nil, err
+	}
+	if !s.queue.AcceptingJobs() {
+		return nil, errors.Unavailable(op, ErrIntakePaused, "Not accepting new transcriptions right now, please try again later")
+	}
+
+	limit := s.config.MaxBatchSize
+	if limit <= 0 {
+		limit = maxPlaylistVideos
+	}
+
+	var result scripts.PlaylistResult
+	err := s.withYouTubeThrottle(ctx, url, func() (err error) {
+		result, err = s.scripts.ExpandPlaylist(ctx, url, limit)
+		return err
+	})
+	if err != nil {
+		return nil, errors.InvalidInput(op, err, "Could not list the videos of this playlist or channel")
+	}
+
+	urls := make([]string, len(result.Videos))
+	for i, entry := range result.Videos {
+		urls[i] = entry.URL
+	}
+
+	batch := newBatch(urls)
+	batch.URL = url
+	if result.Title != nil {
+		batch.Title = *result.Title
+	}
+	return s.startBatch(ctx, batch, opts)
+}
+
+// maxPlaylistVideos caps playlist expansion when batches are unlimited
+const maxPlaylistVideos = 500
+
+func newBatch(urls []string) *models.Batch {
+	batch := &models.Batch{
+		ID:        uuid.New().String(),
+		Items:     make([]models.BatchItem, len(urls)),
+		CreatedAt: time.Now(),
+	}
+	for i, url := range urls {
+		batch.Items[i] = models.BatchItem{URL: url}
+	}
+	return batch
+}
+
+// startBatch stores a batch and submits its items in the background, since
+// validating every URL can take a while
+func (s *service) startBatch(ctx context.Context, batch *models.Batch, opts TranscribeOptions) (*models.Batch, error) {
+	if err := s.repo.CreateBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().
+		Str("batch_id", batch.ID).
+		Str("url", batch.URL).
+		Int("urls", len(batch.Items)).
+		Str("request_id", logger.RequestID(ctx)).
+		Msg("Batch created")
+
+	// The submitter works on its own copy of the items, since the caller
+	// still reads batch
+	submitCtx := logger.WithRequestID(context.Background(), logger.RequestID(ctx))
+	items := append([]models.BatchItem(nil), batch.Items...)
+	go s.submitBatch(submitCtx, batch.ID, items, opts)
+
+	return batch, nil
+}
+
+// submitBatch submits each item of a batch in order, recording the video it
+// created or why it was rejected
+func (s *service) submitBatch(ctx context.Context, batchID string, items []models.BatchItem, opts TranscribeOptions) {
+	for i := range items {
+		item := &items[i]
+
+		video, err := s.Transcribe(ctx, item.URL, opts)
+		if err != nil {
+			item.Error = submissionError(err)
+		} else {
+			item.VideoID = video.ID
+		}
+
+		if err := s.repo.UpdateBatchItem(ctx, batchID, i, *item); err != nil {
+			s.logger.Error().Err(err).Str("batch_id", batchID).Int("position", i).Msg("Failed to record batch item")
+		}
+	}
+}
+
+// submissionError returns the client-facing message of a rejected submission
+func submissionError(err error) string {
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		return appErr.Message
+	}
+	return "Failed to submit transcription"
+}
+
+func (s *service) GetBatch(ctx context.Context, id string) (*models.Batch, error) {
+	const op = "VideoService.GetBatch"
+
+	if id == "" {
+		return nil, errors.InvalidInput(op, nil, "ID is required")
+	}
+	return s.repo.FindBatch(ctx, id)
+}
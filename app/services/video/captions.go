@@ -0,0 +1,108 @@
+package video
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"yt-text/models"
+	"yt-text/scripts"
+	"yt-text/validation"
+	"yt-text/youtube"
+)
+
+// Kinds of caption track recorded with a transcript
+const (
+	captionKindManual = "manual"
+	captionKindAuto   = "auto"
+)
+
+// fetchYouTubeCaptions builds a transcript from an existing caption track.
+// With a Data API key the track is chosen via captions.list; without one, or
+// once the quota is spent, yt-dlp's subtitle extraction is used instead. It
+// returns youtube.ErrNoCaptions when the caption path doesn't apply or no
+// track meets the caption options.
+func (s *service) fetchYouTubeCaptions(ctx context.Context, video *models.Video, opts models.CaptionOptions) (*transcript, error) {
+	if !s.config.CaptionsEnabled || s.youtube == nil {
+		return nil, youtube.ErrNoCaptions
+	}
+
+	videoID, ok := validation.YouTubeID(video.URL)
+	if !ok {
+		return nil, youtube.ErrNoCaptions
+	}
+	s.recordEvent(ctx, video.ID, models.JobCaptionFetch, "")
+	enterStage(ctx, stageCaptionFetch)
+
+	if !s.youtube.HasAPIKey() {
+		return s.fetchCaptionsWithScript(ctx, video, opts)
+	}
+
+	tracks, err := s.youtube.ListCaptions(ctx, videoID)
+	if stderrors.Is(err, youtube.ErrQuotaExceeded) {
+		s.logger.Warn().Str("video_id", video.ID).Msg("YouTube API quota exhausted, fetching captions with yt-dlp")
+		return s.fetchCaptionsWithScript(ctx, video, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	track, ok := youtube.SelectTrack(tracks, youtube.TrackPreference{
+		Languages:  opts.Languages,
+		ManualOnly: opts.ManualOnly,
+	})
+	if !ok {
+		return nil, youtube.ErrNoCaptions
+	}
+
+	segments, err := s.youtube.FetchCaptions(ctx, videoID, track)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := captionKindManual
+	if track.IsAutoGenerated() {
+		kind = captionKindAuto
+	}
+	return &transcript{
+		Text:            youtube.CaptionText(segments),
+		Source:          models.SourceCaptions,
+		Segments:        fromCaptionSegments(segments),
+		CaptionLanguage: track.Language,
+		CaptionKind:     kind,
+	}, nil
+}
+
+// fetchCaptionsWithScript gets captions through the captions script, which
+// needs no API key
+func (s *service) fetchCaptionsWithScript(ctx context.Context, video *models.Video, opts models.CaptionOptions) (*transcript, error) {
+	var result scripts.CaptionsResult
+	err := s.withYouTubeThrottle(ctx, video.URL, func() (err error) {
+		result, err = s.scripts.FetchCaptions(ctx, video.URL, opts.Languages, opts.ManualOnly)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Segments) == 0 {
+		return nil, youtube.ErrNoCaptions
+	}
+
+	lines := make([]string, len(result.Segments))
+	for i, seg := range result.Segments {
+		lines[i] = seg.Text
+	}
+
+	t := &transcript{
+		Text:     strings.Join(lines, " "),
+		Source:   models.SourceCaptions,
+		Segments: fromScriptSegments(result.Segments),
+		Chapters: fromScriptChapters(result.Chapters),
+
+		CaptionLanguage: result.Language,
+		CaptionKind:     result.Kind,
+	}
+	if result.Title != nil {
+		t.Title = *result.Title
+	}
+	return t, nil
+}
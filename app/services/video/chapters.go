@@ -0,0 +1,296 @@
+package video
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"yt-text/models"
+	"yt-text/scripts"
+)
+
+const (
+	// chapterBlockSeconds is the span of transcript compared as one unit
+	// when looking for topic changes
+	chapterBlockSeconds = 30.0
+
+	// chapterWindow is how many blocks on each side of a gap are compared
+	chapterWindow = 4
+
+	// minChapterSeconds keeps detected chapters from being too short to be
+	// worth navigating to; shorter videos get no detected chapters
+	minChapterSeconds = 180.0
+
+	// maxChapters caps the chapters detected in long videos
+	maxChapters = 20
+
+	// minChapterDepth is how far similarity must dip at a gap for it to
+	// count as a topic change
+	minChapterDepth = 0.1
+
+	// chapterTitleWords is the number of keywords a detected chapter's title
+	// is made of
+	chapterTitleWords = 3
+)
+
+// stopwords are common English words that say nothing about a topic
+var stopwords = toSet(strings.Fields(`
+	about above after again against all also and any are because been before
+	being below between both but can could did does doing down during each
+	few for from further get got had has have having her here hers herself
+	him himself his how into its itself just know like more most myself nor
+	not now off once only other our ours out over own really right same say
+	she should some such than that the their theirs them themselves then
+	there these they thing things think this those through too under until
+	very was way well were what when where which while who whom why will with
+	would yeah you your yours yourself going gonna want okay actually
+`))
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// fromScriptChapters converts the uploader chapters reported by the scripts
+func fromScriptChapters(chapters []scripts.Chapter) []models.Chapter {
+	if len(chapters) == 0 {
+		return nil
+	}
+
+	out := make([]models.Chapter, len(chapters))
+	for i, ch := range chapters {
+		out[i] = models.Chapter{Title: ch.Title, Start: ch.Start, End: ch.End}
+	}
+	return out
+}
+
+// chaptersFor returns the chapters of a transcript: the uploader's when the
+// video has them, otherwise topics detected in the transcript's timing
+func chaptersFor(result *transcript) []models.Chapter {
+	if len(result.Chapters) > 0 {
+		return result.Chapters
+	}
+	return detectChapters(result.Segments)
+}
+
+// detectChapters splits a transcript where its vocabulary changes most,
+// comparing the words of neighbouring stretches of time (TextTiling). It
+// returns nil when the transcript is too short or shows no clear topic
+// changes.
+func detectChapters(segments []models.Segment) []models.Chapter {
+	if len(segments) == 0 {
+		return nil
+	}
+	end := segments[len(segments)-1].End
+	if end < 2*minChapterSeconds {
+		return nil
+	}
+
+	// Bag of words for each block of time
+	blocks := make([]map[string]int, int(end/chapterBlockSeconds)+1)
+	for i := range blocks {
+		blocks[i] = make(map[string]int)
+	}
+	for _, seg := range segments {
+		i := min(int(seg.Start/chapterBlockSeconds), len(blocks)-1)
+		for _, w := range topicWords(seg.Text) {
+			blocks[i][w]++
+		}
+	}
+
+	boundaries := pickBoundaries(blocks, end)
+	if len(boundaries) == 0 {
+		return nil
+	}
+
+	// Start chapters with the first segment after each boundary, so they
+	// don't begin mid-sentence
+	starts := []float64{0}
+	for _, b := range boundaries {
+		t := float64(b) * chapterBlockSeconds
+		i := sort.Search(len(segments), func(i int) bool { return segments[i].Start >= t })
+		if i < len(segments) {
+			t = segments[i].Start
+		}
+		starts = append(starts, t)
+	}
+
+	chapters := make([]models.Chapter, len(starts))
+	for i, start := range starts {
+		chEnd := end
+		if i+1 < len(starts) {
+			chEnd = starts[i+1]
+		}
+		chapters[i] = models.Chapter{Start: start, End: chEnd}
+	}
+	nameChapters(chapters, segments)
+	return chapters
+}
+
+// pickBoundaries returns the block indexes where a new chapter starts, in
+// order. A gap's depth is how far the similarity across it dips below the
+// peaks on either side; the deepest gaps that leave every chapter long
+// enough win.
+func pickBoundaries(blocks []map[string]int, end float64) []int {
+	n := len(blocks)
+	if n < 2 {
+		return nil
+	}
+
+	// similarity[g] compares the blocks before gap g with those after it
+	similarity := make([]float64, n)
+	for g := 1; g < n; g++ {
+		before := mergeBlocks(blocks[max(0, g-chapterWindow):g])
+		after := mergeBlocks(blocks[g:min(n, g+chapterWindow)])
+		similarity[g] = cosine(before, after)
+	}
+
+	depth := make([]float64, n)
+	var sum, sumSquares float64
+	for g := 1; g < n; g++ {
+		left := g
+		for left > 1 && similarity[left-1] >= similarity[left] {
+			left--
+		}
+		right := g
+		for right < n-1 && similarity[right+1] >= similarity[right] {
+			right++
+		}
+		depth[g] = (similarity[left] - similarity[g]) + (similarity[right] - similarity[g])
+		sum += depth[g]
+		sumSquares += depth[g] * depth[g]
+	}
+	gaps := float64(n - 1)
+	mean := sum / gaps
+	cutoff := mean - math.Sqrt(math.Max(sumSquares/gaps-mean*mean, 0))/2
+
+	var candidates []int
+	for g := 1; g < n; g++ {
+		if depth[g] >= minChapterDepth && depth[g] > cutoff {
+			candidates = append(candidates, g)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return depth[candidates[i]] > depth[candidates[j]] })
+
+	var chosen []int
+	for _, g := range candidates {
+		if len(chosen) == maxChapters-1 {
+			break
+		}
+		t := float64(g) * chapterBlockSeconds
+		if t < minChapterSeconds || end-t < minChapterSeconds {
+			continue
+		}
+		fits := true
+		for _, c := range chosen {
+			if math.Abs(float64(g-c))*chapterBlockSeconds < minChapterSeconds {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			chosen = append(chosen, g)
+		}
+	}
+
+	sort.Ints(chosen)
+	return chosen
+}
+
+// nameChapters titles detected chapters with the words most particular to
+// each of them
+func nameChapters(chapters []models.Chapter, segments []models.Segment) {
+	counts := make([]map[string]int, len(chapters))
+	total := make(map[string]int)
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+	for _, seg := range segments {
+		i := sort.Search(len(chapters), func(i int) bool { return chapters[i].Start > seg.Start }) - 1
+		i = max(i, 0)
+		for _, w := range topicWords(seg.Text) {
+			counts[i][w]++
+			total[w]++
+		}
+	}
+
+	for i := range chapters {
+		type scored struct {
+			word  string
+			score float64
+		}
+		var words []scored
+		for w, c := range counts[i] {
+			if c < 2 {
+				continue
+			}
+			// Frequent in this chapter and rare elsewhere
+			words = append(words, scored{w, float64(c) * float64(c) / float64(total[w])})
+		}
+		sort.Slice(words, func(a, b int) bool {
+			if words[a].score != words[b].score {
+				return words[a].score > words[b].score
+			}
+			return words[a].word < words[b].word
+		})
+
+		var title []string
+		for _, w := range words[:min(len(words), chapterTitleWords)] {
+			title = append(title, w.word)
+		}
+		if len(title) == 0 {
+			chapters[i].Title = "Part " + strconv.Itoa(i+1)
+			continue
+		}
+		first := []rune(title[0])
+		first[0] = unicode.ToUpper(first[0])
+		title[0] = string(first)
+		chapters[i].Title = strings.Join(title, ", ")
+	}
+}
+
+// topicWords returns the words of text that can indicate a topic
+func topicWords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	out := words[:0]
+	for _, w := range words {
+		w = strings.Trim(w, "'")
+		if len([]rune(w)) > 2 && !stopwords[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func mergeBlocks(blocks []map[string]int) map[string]int {
+	merged := make(map[string]int)
+	for _, block := range blocks {
+		for w, c := range block {
+			merged[w] += c
+		}
+	}
+	return merged
+}
+
+// cosine is the cosine similarity of two word counts
+func cosine(a, b map[string]int) float64 {
+	var dot, normA, normB float64
+	for w, c := range a {
+		normA += float64(c * c)
+		dot += float64(c * b[w])
+	}
+	for _, c := range b {
+		normB += float64(c * c)
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
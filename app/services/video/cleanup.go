@@ -0,0 +1,214 @@
+package video
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+func (s *service) UpdateRetention(ctx context.Context, id, userID string, update RetentionUpdate) (*models.Video, error) {
+	const op = "VideoService.UpdateRetention"
+
+	if id == "" {
+		return nil, errors.InvalidInput(op, nil, "ID is required")
+	}
+	if update.Pinned == nil && update.TTL == nil {
+		return nil, errors.InvalidInput(op, nil, "Nothing to update; set pinned or ttl")
+	}
+	if update.TTL != nil && *update.TTL < 0 {
+		return nil, errors.InvalidInput(op, nil, "TTL must not be negative")
+	}
+	if _, err := s.userVideo(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	if update.Pinned != nil {
+		if err := s.repo.SetPinned(ctx, id, *update.Pinned); err != nil {
+			return nil, err
+		}
+	}
+	if update.TTL != nil {
+		var expiresAt *time.Time
+		if *update.TTL > 0 {
+			t := time.Now().Add(*update.TTL).UTC()
+			expiresAt = &t
+		}
+		if err := s.repo.SetExpiry(ctx, id, expiresAt); err != nil {
+			return nil, err
+		}
+	}
+
+	event := s.logger.Info().Str("video_id", id)
+	if userID != "" {
+		event = event.Str("user_id", userID)
+	}
+	if update.Pinned != nil {
+		event = event.Bool("pinned", *update.Pinned)
+	}
+	if update.TTL != nil {
+		event = event.Dur("ttl", *update.TTL)
+	}
+	event.Msg("Video retention changed")
+	return s.GetTranscription(ctx, id)
+}
+
+func (s *service) RunCleanup(ctx context.Context) {
+	for {
+		s.cleanupExpired(ctx)
+
+		wait := s.config.CleanupInterval
+		if s.config.CleanupJitter > 0 {
+			wait += rand.N(s.config.CleanupJitter)
+		}
+		s.cleanups.schedule(time.Now().Add(wait))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// cleanupExpired deletes finished, unpinned videos past their own expiry or
+// older than the retention window
+func (s *service) cleanupExpired(ctx context.Context) {
+	if _, err := s.CleanupExpired(ctx, s.settings.Load().Retention, s.config.CleanupDryRun); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to clean up expired transcriptions")
+	}
+}
+
+func (s *service) CleanupExpired(ctx context.Context, retention time.Duration, dryRun bool) (int64, error) {
+	const op = "VideoService.CleanupExpired"
+
+	if retention < 0 {
+		return 0, errors.InvalidInput(op, nil, "Retention must not be negative")
+	}
+	now := time.Now()
+	var cutoff time.Time
+	if retention > 0 {
+		cutoff = now.Add(-retention)
+	}
+
+	var deleted int64
+	var err error
+	if dryRun {
+		deleted, err = s.repo.CountExpiredTranscriptions(ctx, cutoff, now)
+		if limit := int64(s.config.CleanupMaxPerRun); limit > 0 {
+			deleted = min(deleted, limit)
+		}
+	} else {
+		deleted, err = s.deleteExpired(ctx, cutoff, now)
+	}
+	s.cleanups.record(CleanupRun{
+		StartedAt: now.UTC().Truncate(time.Second),
+		Seconds:   seconds(time.Since(now)),
+		Deleted:   deleted,
+		DryRun:    dryRun,
+	}, err)
+	if err != nil {
+		return deleted, err
+	}
+
+	if dryRun {
+		s.logger.Info().
+			Int64("expired", deleted).
+			Time("cutoff", cutoff).
+			Msg("Dry run: would clean up expired transcriptions")
+	} else if deleted > 0 {
+		s.logger.Info().
+			Int64("deleted", deleted).
+			Time("cutoff", cutoff).
+			Msg("Cleaned up expired transcriptions")
+	}
+	return deleted, nil
+}
+
+// deleteExpired deletes expired videos a batch at a time, so no single
+// statement holds the database for long, until none are left or the
+// per-run limit is reached
+func (s *service) deleteExpired(ctx context.Context, cutoff, now time.Time) (int64, error) {
+	batchSize := max(s.config.CleanupBatchSize, 1)
+	limit := int64(s.config.CleanupMaxPerRun)
+
+	var deleted int64
+	for limit <= 0 || deleted < limit {
+		batch := int64(batchSize)
+		if limit > 0 {
+			batch = min(batch, limit-deleted)
+		}
+
+		n, err := s.repo.CleanupExpiredTranscriptions(ctx, cutoff, now, int(batch))
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+		if n < batch {
+			break
+		}
+	}
+	return deleted, nil
+}
+
+// CleanupRun is the outcome of one cleanup pass
+type CleanupRun struct {
+	StartedAt time.Time `json:"started_at"`
+	Seconds   float64   `json:"seconds"`
+	Deleted   int64     `json:"deleted"` // Videos that would have gone, on a dry run
+	DryRun    bool      `json:"dry_run,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// CleanupStats sums up the cleanup passes of a process
+type CleanupStats struct {
+	Runs    int64       `json:"runs"`
+	Deleted int64       `json:"deleted"`
+	Errors  int64       `json:"errors"`
+	LastRun *CleanupRun `json:"last_run,omitempty"`
+	NextRun *time.Time  `json:"next_run,omitempty"`
+}
+
+// cleanupCounter counts cleanup passes for the stats endpoint
+type cleanupCounter struct {
+	mu    sync.Mutex
+	stats CleanupStats
+}
+
+func (c *cleanupCounter) record(run CleanupRun, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.Runs++
+	if !run.DryRun {
+		c.stats.Deleted += run.Deleted
+	}
+	if err != nil {
+		c.stats.Errors++
+		run.Error = err.Error()
+	}
+	c.stats.LastRun = &run
+}
+
+// schedule records when the next background pass is due
+func (c *cleanupCounter) schedule(next time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	next = next.UTC().Truncate(time.Second)
+	c.stats.NextRun = &next
+}
+
+func (c *cleanupCounter) snapshot() CleanupStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	if stats.LastRun != nil {
+		run := *stats.LastRun
+		stats.LastRun = &run
+	}
+	return stats
+}
@@ -0,0 +1,64 @@
+package video
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// depthInterval is how often the queue's depth is sampled
+	depthInterval = 5 * time.Minute
+
+	// depthSamples is how many samples are kept, a day's worth
+	depthSamples = int(24 * time.Hour / depthInterval)
+)
+
+// QueueSample is the queue's depth at one point in time
+type QueueSample struct {
+	Time    time.Time `json:"time"`
+	Waiting int       `json:"waiting"`
+	Active  int       `json:"active"`
+}
+
+// depthHistory keeps the most recent queue samples
+type depthHistory struct {
+	mu      sync.Mutex
+	samples []QueueSample // Oldest first
+}
+
+func (h *depthHistory) add(sample QueueSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) == depthSamples {
+		h.samples = append(h.samples[:0], h.samples[1:]...)
+	}
+	h.samples = append(h.samples, sample)
+}
+
+func (h *depthHistory) list() []QueueSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := make([]QueueSample, len(h.samples))
+	copy(samples, h.samples)
+	return samples
+}
+
+// sampleQueue records the queue's depth every depthInterval, starting now
+func (s *service) sampleQueue(ctx context.Context) {
+	ticker := time.NewTicker(depthInterval)
+	defer ticker.Stop()
+
+	for {
+		status := s.queue.Status()
+		s.depths.add(QueueSample{Time: time.Now().UTC().Truncate(time.Second), Waiting: status.Waiting, Active: status.Active})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
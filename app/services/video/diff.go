@@ -0,0 +1,244 @@
+package video
+
+import (
+	"strings"
+	"yt-text/models"
+)
+
+// maxDiffEdits bounds the word diff, whose memory grows with the square of
+// the number of edits. Past it, the differing middle of the texts is
+// reported as replaced as a whole.
+const maxDiffEdits = 1000
+
+type editOp int
+
+const (
+	editEqual editOp = iota
+	editDelete
+	editInsert
+)
+
+// wordEdit is one step of turning one list of words into another. Equal
+// and deleted words have their index in the old list, equal and inserted
+// ones their index in the new list.
+type wordEdit struct {
+	op     editOp
+	oldPos int
+	newPos int
+}
+
+// diffWords returns the shortest edit script turning a into b (Myers'
+// algorithm), after setting aside the words both start and end with
+func diffWords(a, b []string) []wordEdit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]wordEdit, 0, max(len(a), len(b)))
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, wordEdit{editEqual, i, i})
+	}
+	middle, ok := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if !ok {
+		middle = nil
+		for i := prefix; i < len(a)-suffix; i++ {
+			middle = append(middle, wordEdit{editDelete, i - prefix, 0})
+		}
+		for j := prefix; j < len(b)-suffix; j++ {
+			middle = append(middle, wordEdit{editInsert, 0, j - prefix})
+		}
+	}
+	for _, e := range middle {
+		edits = append(edits, wordEdit{e.op, e.oldPos + prefix, e.newPos + prefix})
+	}
+	for i := 0; i < suffix; i++ {
+		edits = append(edits, wordEdit{editEqual, len(a) - suffix + i, len(b) - suffix + i})
+	}
+	return edits
+}
+
+// myers returns the edit script of a and b, or false when it needs more
+// than maxDiffEdits edits
+func myers(a, b []string) ([]wordEdit, bool) {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] holds v for diagonals -d..d after d edits, up to the last
+	// one before the end is reached
+	var trace [][]int
+	for d := 0; d <= min(n+m, maxDiffEdits); d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Insertion
+			} else {
+				x = v[offset+k-1] + 1 // Deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m), true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil, false
+}
+
+// backtrack walks the trace of myers back from the end of both lists
+func backtrack(trace [][]int, n, m int) []wordEdit {
+	var edits []wordEdit
+	x, y := n, m
+	for d := len(trace); d > 0; d-- {
+		prev := trace[d-1] // Diagonals -(d-1)..d-1
+		at := func(k int) int { return prev[k+d-1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, wordEdit{editEqual, x, y})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, wordEdit{editInsert, x, y})
+		} else {
+			x--
+			edits = append(edits, wordEdit{editDelete, x, y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, wordEdit{editEqual, x, y})
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// changes merges the edits turning one text into another into runs of
+// words, counting the words inserted and deleted
+func changes(oldText, newText string) (runs []models.Change, inserted, deleted int) {
+	a, b := strings.Fields(oldText), strings.Fields(newText)
+
+	runs = []models.Change{}
+	var words []string
+	op := ""
+	flush := func() {
+		if len(words) > 0 {
+			runs = append(runs, models.Change{Op: op, Text: strings.Join(words, " ")})
+		}
+		words = words[:0]
+	}
+	for _, e := range diffWords(a, b) {
+		var next, word string
+		switch e.op {
+		case editEqual:
+			next, word = models.ChangeEqual, b[e.newPos]
+		case editDelete:
+			next, word = models.ChangeDelete, a[e.oldPos]
+			deleted++
+		case editInsert:
+			next, word = models.ChangeInsert, b[e.newPos]
+			inserted++
+		}
+		if next != op {
+			flush()
+			op = next
+		}
+		words = append(words, word)
+	}
+	flush()
+	return runs, inserted, deleted
+}
+
+// realign fits corrected text onto the timing of segments. Words kept stay
+// in their segment, and words replacing others go to the segments of those
+// they replace, spread evenly; other new words join the segment of the
+// word before them. Segments left without words are dropped, and those
+// whose words changed lose Whisper's confidence in them.
+func realign(segments []models.Segment, text string) []models.Segment {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	var a []string
+	var owner []int // Segment of each old word
+	for i, seg := range segments {
+		for _, w := range strings.Fields(seg.Text) {
+			a = append(a, w)
+			owner = append(owner, i)
+		}
+	}
+	b := strings.Fields(text)
+
+	words := make([][]string, len(segments))
+	changed := make([]bool, len(segments))
+	current := 0
+	var replaced []int // Segments of the deleted words awaiting replacements
+	var inserted []string
+	place := func() {
+		for i, w := range inserted {
+			seg := current
+			if len(replaced) > 0 {
+				seg = replaced[i*len(replaced)/len(inserted)]
+			}
+			words[seg] = append(words[seg], w)
+			changed[seg] = true
+		}
+		replaced, inserted = replaced[:0], inserted[:0]
+	}
+	for _, e := range diffWords(a, b) {
+		switch e.op {
+		case editEqual:
+			place()
+			current = owner[e.oldPos]
+			words[current] = append(words[current], b[e.newPos])
+		case editDelete:
+			if len(inserted) > 0 {
+				place()
+			}
+			current = owner[e.oldPos]
+			replaced = append(replaced, current)
+			changed[current] = true
+		case editInsert:
+			inserted = append(inserted, b[e.newPos])
+		}
+	}
+	place()
+
+	out := make([]models.Segment, 0, len(segments))
+	for i, seg := range segments {
+		if len(words[i]) == 0 {
+			continue
+		}
+		seg.Text = strings.Join(words[i], " ")
+		if changed[i] {
+			seg.AvgLogprob, seg.NoSpeechProb = nil, nil
+		}
+		out = append(out, seg)
+	}
+	return out
+}
@@ -0,0 +1,173 @@
+package video
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"yt-text/models"
+)
+
+const (
+	// maxKeywords and maxEntities cap what is kept of a transcript, so
+	// listings carrying it stay small
+	maxKeywords = 10
+	maxEntities = 20
+	maxURLs     = 20
+
+	// minKeywordCount is how often a word must come up to be a keyword
+	minKeywordCount = 2
+)
+
+var (
+	// topLevelDomains are those a bare domain is recognized with, since
+	// most words followed by a dot and a few letters aren't links
+	topLevelDomains = `com|org|net|io|dev|ai|co|gov|edu|app|tv|me|gg|ly|xyz|info|us|uk|ca|de|fr`
+
+	urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"']+|\b(?:[a-z0-9-]+\.)+(?:` + topLevelDomains + `)\b(?:/[^\s<>"']*)?`)
+
+	// spokenDomain matches domains read out the way captions write them,
+	// such as "example dot com"
+	spokenDomain = regexp.MustCompile(`(?i)\b([a-z0-9-]+) dot (` + topLevelDomains + `)\b`)
+
+	sentenceEnd = regexp.MustCompile(`[.!?]+(?:\s+|$)`)
+
+	// nameParticles are lowercase words that can join the parts of a name,
+	// as in "Guido van Rossum" or "Bank of America"
+	nameParticles = toSet(strings.Fields(`van von der den de da del di du la le of`))
+)
+
+// enrich extracts the keywords, named entities and URLs of a transcript,
+// including domains read out as "example dot com". It returns nil for an
+// empty transcript.
+func enrich(text string) *models.Enrichment {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	text = spokenDomain.ReplaceAllString(text, "$1.$2")
+	return &models.Enrichment{
+		// Links would otherwise make keywords of "com" and "https"
+		Keywords: keywords(urlPattern.ReplaceAllString(text, " ")),
+		Entities: entities(text),
+		URLs:     urls(text),
+	}
+}
+
+// keywords returns the topic words of text that come up most often
+func keywords(text string) []string {
+	counts := make(map[string]int)
+	for _, w := range topicWords(text) {
+		counts[w]++
+	}
+
+	words := make([]string, 0, len(counts))
+	for w, c := range counts {
+		if c >= minKeywordCount {
+			words = append(words, w)
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	return words[:min(len(words), maxKeywords)]
+}
+
+// entities finds names by their capitals: runs of capitalized words, such
+// as "New York" or "Ada Lovelace". A word opening a sentence only counts
+// when it is also capitalized elsewhere, so ordinary words starting
+// sentences aren't taken for names. Transcripts without capitals, such as
+// most automatic captions, yield none.
+func entities(text string) []models.Entity {
+	sentences := sentenceEnd.Split(text, -1)
+	tokenized := make([][]string, len(sentences))
+	midSentence := make(map[string]bool)
+	for i, sentence := range sentences {
+		tokenized[i] = strings.Fields(sentence)
+		for j, token := range tokenized[i] {
+			if word := nameWord(token); word != "" && j > 0 {
+				midSentence[word] = true
+			}
+		}
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	add := func(run []string) {
+		if len(run) == 0 {
+			return
+		}
+		name := strings.Join(run, " ")
+		if counts[name] == 0 {
+			order = append(order, name)
+		}
+		counts[name]++
+	}
+	for _, tokens := range tokenized {
+		var run []string
+		for j, token := range tokens {
+			word := nameWord(token)
+			if word == "" && len(run) > 0 && nameParticles[token] && j+1 < len(tokens) && nameWord(tokens[j+1]) != "" {
+				run = append(run, token)
+				continue
+			}
+			if word == "" || (j == 0 && !midSentence[word]) {
+				add(run)
+				run = nil
+				continue
+			}
+			run = append(run, word)
+			// Punctuation after a word ends the name
+			if strings.TrimRightFunc(token, unicode.IsPunct) != token && !strings.HasSuffix(token, "'s") {
+				add(run)
+				run = nil
+			}
+		}
+		add(run)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	out := make([]models.Entity, 0, min(len(order), maxEntities))
+	for _, name := range order[:min(len(order), maxEntities)] {
+		out = append(out, models.Entity{Name: name, Mentions: counts[name]})
+	}
+	return out
+}
+
+// nameWord returns token without surrounding punctuation or a possessive
+// when it is a capitalized word that can be part of a name, or ""
+func nameWord(token string) string {
+	word := strings.TrimFunc(token, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	word = strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "’s")
+	first := []rune(word)
+	if len(first) < 2 || !unicode.IsUpper(first[0]) {
+		return "" // Also skips "I"
+	}
+	lower := strings.ToLower(word)
+	if stopwords[lower] || strings.HasPrefix(lower, "i'") {
+		return ""
+	}
+	return word
+}
+
+// urls returns the links and domains mentioned in text, in which domains
+// read out have been written out
+func urls(text string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, match := range urlPattern.FindAllString(text, -1) {
+		match = strings.TrimRight(match, ".,;:!?)]}'\"")
+		key := strings.ToLower(match)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, match)
+		if len(out) == maxURLs {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,206 @@
+package video
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/validation"
+	"yt-text/youtube"
+)
+
+// defaultRealtimeFactors are seconds of processing per second of audio for
+// each model size, used until this process has timed runs of its own. They
+// include the download and are deliberately on the slow side for CPUs.
+var defaultRealtimeFactors = map[string]float64{
+	"tiny":   0.1,
+	"base":   0.2,
+	"small":  0.5,
+	"medium": 1.2,
+	"large":  2.5,
+}
+
+const (
+	// captionsEstimate is how long fetching official captions usually takes
+	captionsEstimate = 5 * time.Second
+
+	// throughputWeight is the weight of the newest run in the moving averages
+	throughputWeight = 0.3
+
+	// Bounds of the Retry-After sent when the queue is full, and its value
+	// before any job has completed
+	minQueueRetryAfter     = 5 * time.Second
+	maxQueueRetryAfter     = 10 * time.Minute
+	defaultQueueRetryAfter = 30 * time.Second
+)
+
+// throughput keeps moving averages of how fast jobs have run, for estimates
+type throughput struct {
+	mu      sync.Mutex
+	factors map[string]float64 // Realtime factor per model
+	job     time.Duration      // Average run time of a completed job
+}
+
+func newThroughput() *throughput {
+	return &throughput{factors: make(map[string]float64)}
+}
+
+// record adds a Whisper run of audio seconds of audio that took elapsed
+func (t *throughput) record(model string, audio, elapsed time.Duration) {
+	if audio <= 0 {
+		return
+	}
+	factor := elapsed.Seconds() / audio.Seconds()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if previous, ok := t.factors[model]; ok {
+		factor = previous + throughputWeight*(factor-previous)
+	}
+	t.factors[model] = factor
+}
+
+// recordJob adds the run time of a completed job
+func (t *throughput) recordJob(elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.job == 0 {
+		t.job = elapsed
+		return
+	}
+	t.job += time.Duration(throughputWeight * float64(elapsed-t.job))
+}
+
+// whisper estimates how long a model takes to transcribe audio of the given
+// length, preferring the measured factor of the model
+func (t *throughput) whisper(model string, audio time.Duration) time.Duration {
+	t.mu.Lock()
+	factor, ok := t.factors[model]
+	t.mu.Unlock()
+	if !ok {
+		factor = defaultFactor(model)
+	}
+	return time.Duration(factor * float64(audio))
+}
+
+// averageJob returns the average run time of completed jobs, or zero before
+// any has completed
+func (t *throughput) averageJob() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.job
+}
+
+// defaultFactor maps a model name to its size's default factor, so
+// "small.en" and "large-v3" use the "small" and "large" factors
+func defaultFactor(model string) float64 {
+	size := strings.TrimSuffix(model, ".en")
+	size, _, _ = strings.Cut(size, "-")
+	if factor, ok := defaultRealtimeFactors[size]; ok {
+		return factor
+	}
+	return defaultRealtimeFactors["large"]
+}
+
+// secondsDuration converts seconds as reported by the scripts to a duration
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (s *service) Estimate(ctx context.Context, url string, opts TranscribeOptions) (*models.Estimate, error) {
+	const op = "VideoService.Estimate"
+
+	opts.Source = s.sourcePolicy(opts.Source)
+	if opts.Source == SourceCaptionsOnly && (opts.Input == InputAudioURL || !youtube.IsYouTubeURL(url)) {
+		return nil, errors.InvalidInput(op, nil, "Captions are only available for YouTube videos")
+	}
+	if err := s.checkWhisperOptions(opts); err != nil {
+		return nil, err
+	}
+
+	details, err := s.lookUp(ctx, url, opts.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &models.Estimate{
+		Title:             details.Title,
+		DurationSeconds:   details.Duration.Seconds(),
+		CaptionsAvailable: s.captionsAvailable(ctx, url, opts),
+	}
+
+	// Mirrors doProcessVideo. Without knowing whether there are captions,
+	// the slower Whisper run is assumed.
+	captions := estimate.CaptionsAvailable != nil && *estimate.CaptionsAvailable
+	var processing time.Duration
+	switch {
+	case opts.Source == SourceCaptionsOnly && estimate.CaptionsAvailable != nil && !captions:
+		return nil, errors.InvalidInput(op, youtube.ErrNoCaptions, failureMessages[models.ErrorNoCaptions])
+	case opts.Source == SourceCaptionsOnly, opts.Source != SourceWhisperOnly && captions && opts.Whisper.Task != models.TaskTranslate:
+		estimate.Source = models.SourceCaptions
+		processing = captionsEstimate
+	default:
+		estimate.Source = models.SourceWhisper
+	}
+	// Whisper may also run after captions, to compare them or to replace
+	// captions that turn out poor
+	whisper := s.config.CompareCaptions && opts.Source == SourceCaptionsThenWhisper || opts.Source == SourceWhisperIfCaptionsPoor
+	if estimate.Source == models.SourceWhisper || whisper {
+		_, estimate.Model = s.whisperModel(details.Language, opts)
+		if details.Duration > 0 {
+			processing += s.throughput.whisper(estimate.Model, details.Duration)
+		} else {
+			processing = 0 // Unknown
+		}
+	}
+	estimate.ProcessingSeconds = processing.Round(time.Second).Seconds()
+
+	// Jobs ahead share the workers; each is assumed to take as long as the
+	// average completed job, or as this one when none has completed yet
+	status := s.queue.Status()
+	estimate.QueueDepth = status.Waiting
+	if ahead := status.Waiting + status.Active; ahead >= s.config.Workers && s.config.Workers > 0 {
+		perJob := s.throughput.averageJob()
+		if perJob == 0 {
+			perJob = processing
+		}
+		wait := time.Duration(float64(perJob) * float64(ahead-s.config.Workers+1) / float64(s.config.Workers))
+		estimate.QueueWaitSeconds = wait.Round(time.Second).Seconds()
+	}
+	return estimate, nil
+}
+
+// queueRetryAfter estimates how soon a full queue has room again: a slot
+// frees up whenever a worker takes the next job, which happens about every
+// average job run time divided by the workers
+func (s *service) queueRetryAfter() time.Duration {
+	perJob := s.throughput.averageJob()
+	if perJob == 0 {
+		return defaultQueueRetryAfter
+	}
+	wait := perJob / time.Duration(max(s.config.Workers, 1))
+	return min(max(wait, minQueueRetryAfter), maxQueueRetryAfter).Round(time.Second)
+}
+
+// captionsAvailable reports whether a YouTube video has official captions,
+// or nil when it can't be told without fetching them
+func (s *service) captionsAvailable(ctx context.Context, url string, opts TranscribeOptions) *bool {
+	if opts.Input == InputAudioURL || !youtube.IsYouTubeURL(url) || !s.config.CaptionsEnabled || s.youtube == nil {
+		available := false
+		return &available
+	}
+	videoID, ok := validation.YouTubeID(url)
+	if !ok || !s.youtube.HasAPIKey() {
+		return nil
+	}
+
+	meta, err := s.youtube.Metadata(ctx, videoID)
+	if err != nil {
+		s.logger.Debug().Err(err).Str("url", url).Msg("Caption check for estimate failed")
+		return nil
+	}
+	return &meta.HasCaptions
+}
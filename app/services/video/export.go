@@ -0,0 +1,145 @@
+package video
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"yt-text/formats"
+	"yt-text/models"
+	"yt-text/repository"
+)
+
+// exportPageSize is how many videos an export reads from the repository at
+// a time, which bounds how much of the store it holds in memory
+const exportPageSize = 50
+
+// ExportOptions filter ExportTranscriptions. Empty filters match every
+// completed transcription.
+type ExportOptions struct {
+	Language string
+
+	// UserID exports only the videos a user submitted
+	UserID string
+}
+
+// ExportEntry describes one exported transcription in the archive's
+// manifest
+type ExportEntry struct {
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	Title     string          `json:"title,omitempty"`
+	Platform  models.Platform `json:"platform,omitempty"`
+	Language  string          `json:"language,omitempty"`
+	Source    models.Source   `json:"source"`
+	CreatedAt time.Time       `json:"created_at"`
+	Files     []string        `json:"files"`
+}
+
+// exportManifest is the archive's manifest.json
+type exportManifest struct {
+	ExportedAt     time.Time     `json:"exported_at"`
+	Language       string        `json:"language,omitempty"`
+	Transcriptions []ExportEntry `json:"transcriptions"`
+}
+
+func (s *service) ExportTranscriptions(ctx context.Context, w io.Writer, opts ExportOptions) error {
+	archive := zip.NewWriter(w)
+	manifest := exportManifest{
+		ExportedAt:     time.Now().UTC(),
+		Language:       opts.Language,
+		Transcriptions: []ExportEntry{},
+	}
+
+	// Videos created during the export shift the pages, so one may come up
+	// twice
+	seen := make(map[string]bool)
+	for offset := 0; ; offset += exportPageSize {
+		videos, _, err := s.repo.List(ctx, repository.VideoFilter{
+			Status:   models.StatusCompleted,
+			Language: opts.Language,
+			UserID:   opts.UserID,
+			Limit:    exportPageSize,
+			Offset:   offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, video := range videos {
+			if seen[video.ID] {
+				continue
+			}
+			seen[video.ID] = true
+
+			entry, err := s.exportVideo(ctx, archive, video)
+			if err != nil {
+				return err
+			}
+			manifest.Transcriptions = append(manifest.Transcriptions, entry)
+		}
+		if len(videos) < exportPageSize {
+			break
+		}
+	}
+
+	f, err := archive.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+
+	s.logger.Info().Int("transcriptions", len(manifest.Transcriptions)).Msg("Transcriptions exported")
+	return archive.Close()
+}
+
+// exportVideo adds a video's primary transcript to the archive as text,
+// streamed from wherever it's stored, and as SRT when it has timing
+func (s *service) exportVideo(ctx context.Context, archive *zip.Writer, video *models.Video) (ExportEntry, error) {
+	entry := ExportEntry{
+		ID:        video.ID,
+		URL:       video.URL,
+		Title:     video.Title,
+		Platform:  video.Platform,
+		Language:  video.Language,
+		Source:    video.Source,
+		CreatedAt: video.CreatedAt,
+	}
+
+	text, err := s.repo.TranscriptionReader(ctx, video.ID, video.Source)
+	if err != nil {
+		return ExportEntry{}, err
+	}
+	name := video.ID + formats.Text.Extension()
+	f, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: video.UpdatedAt})
+	if err == nil {
+		_, err = io.Copy(f, text)
+	}
+	if closer, ok := text.(io.Closer); ok {
+		closer.Close()
+	}
+	if err != nil {
+		return ExportEntry{}, fmt.Errorf("failed to export %s: %w", video.ID, err)
+	}
+	entry.Files = append(entry.Files, name)
+
+	if len(video.Segments) > 0 {
+		name := video.ID + formats.SRT.Extension()
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: video.UpdatedAt})
+		if err == nil {
+			_, err = io.WriteString(f, formats.SRTSubtitles(video.Segments))
+		}
+		if err != nil {
+			return ExportEntry{}, fmt.Errorf("failed to export %s: %w", video.ID, err)
+		}
+		entry.Files = append(entry.Files, name)
+	}
+
+	return entry, nil
+}
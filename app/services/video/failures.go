@@ -0,0 +1,126 @@
+package video
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"yt-text/models"
+	"yt-text/youtube"
+)
+
+// failureRules map fragments of yt-dlp, faster-whisper and OS error output to
+// error codes. Rules are checked in order, so more specific ones come first.
+var failureRules = []struct {
+	code     models.ErrorCode
+	patterns []string
+}{
+	{models.ErrorVideoPrivate, []string{
+		"private video",
+		"video is private",
+	}},
+	{models.ErrorAgeRestricted, []string{
+		"sign in to confirm your age",
+		"age-restricted",
+		"age restricted",
+		"inappropriate for some users",
+	}},
+	{models.ErrorGeoBlocked, []string{
+		"not available in your country",
+		"not made this video available in your country",
+		"geo restriction",
+		"geo-restricted",
+		"blocked it in your country",
+	}},
+	{models.ErrorLiveOnly, []string{
+		"this live event will begin",
+		"premieres in",
+		"is a live stream",
+		"live event",
+		"is live",
+		"is_live",
+	}},
+	{models.ErrorVideoUnavailable, []string{
+		"video unavailable",
+		"has been removed",
+		"does not exist",
+		"account associated with this video has been terminated",
+		"http error 404",
+		"unsupported url",
+	}},
+	{models.ErrorTooLong, []string{
+		"exceeds maximum allowed",
+		"video too long",
+	}},
+	{models.ErrorNoSpeech, []string{
+		"no speech detected",
+	}},
+	{models.ErrorOutOfMemory, []string{
+		"out of memory",
+		"memoryerror",
+		"cannot allocate memory",
+		"signal: killed",
+	}},
+	{models.ErrorNetwork, []string{
+		"timed out",
+		"connection reset",
+		"connection refused",
+		"temporary failure in name resolution",
+		"unable to download webpage",
+		"http error 5",
+		"http error 429",
+		"network is unreachable",
+	}},
+}
+
+var failureMessages = map[models.ErrorCode]string{
+	models.ErrorVideoPrivate:       "This video is private and can't be transcribed.",
+	models.ErrorVideoUnavailable:   "This video is unavailable. It may have been deleted or the link is wrong.",
+	models.ErrorGeoBlocked:         "This video isn't available in the server's region.",
+	models.ErrorAgeRestricted:      "This video is age-restricted and can't be downloaded without signing in.",
+	models.ErrorLiveOnly:           "Live streams and upcoming premieres can't be transcribed until they have ended.",
+	models.ErrorTooLong:            "This video is longer than the maximum allowed duration.",
+	models.ErrorNoSpeech:           "No speech was detected in this video.",
+	models.ErrorOutOfMemory:        "The server ran out of memory while transcribing. Please try again later.",
+	models.ErrorNetwork:            "A network error occurred while fetching the video. Please try again.",
+	models.ErrorTimeout:            "Transcription took too long and was stopped.",
+	models.ErrorServiceUnavailable: "The service is busy. Please try again later.",
+	models.ErrorQueueFull:          "The transcription queue was full. Please try again later.",
+	models.ErrorNoCaptions:         "This video has no captions. Submit it with source=captions_then_whisper or whisper_only to transcribe the audio.",
+	models.ErrorCancelled:          "Transcription was cancelled by an operator.",
+	models.ErrorRejected:           "This video can't be transcribed.",
+	models.ErrorUnknown:            "Transcription failed due to an unexpected error.",
+}
+
+// classifyFailure maps a raw backend error to an error code and a message
+// that is safe to show to users
+func classifyFailure(err error) (models.ErrorCode, string) {
+	code := models.ErrorUnknown
+	switch {
+	case err == nil:
+	case stderrors.Is(err, context.DeadlineExceeded):
+		code = models.ErrorTimeout
+	case stderrors.Is(err, context.Canceled):
+		// Job contexts are only cancelled by an operator
+		code = models.ErrorCancelled
+	case stderrors.Is(err, youtube.ErrNoCaptions):
+		code = models.ErrorNoCaptions
+	case stderrors.Is(err, ErrLiveStream):
+		code = models.ErrorLiveOnly
+	default:
+		code = classifyMessage(err.Error())
+	}
+	return code, failureMessages[code]
+}
+
+// classifyMessage maps raw error text to an error code
+func classifyMessage(raw string) models.ErrorCode {
+	raw = strings.ToLower(raw)
+	for _, rule := range failureRules {
+		for _, pattern := range rule.patterns {
+			if strings.Contains(raw, pattern) {
+				return rule.code
+			}
+		}
+	}
+	return models.ErrorUnknown
+}
@@ -0,0 +1,59 @@
+package video
+
+import (
+	"context"
+	"time"
+	"yt-text/models"
+)
+
+// recordEvent appends to a video's job history. History is informational,
+// so a failed write is logged rather than failing the job.
+func (s *service) recordEvent(ctx context.Context, videoID string, eventType models.JobEventType, detail string) {
+	event := models.JobEvent{
+		VideoID:   videoID,
+		Type:      eventType,
+		Detail:    detail,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.AddJobEvent(ctx, event); err != nil {
+		s.logger.Error().Err(err).
+			Str("video_id", videoID).
+			Str("event", string(eventType)).
+			Msg("Failed to record job event")
+	}
+	s.events.Publish(event)
+}
+
+// publishProgress tells subscribers a job's progress or queue position
+// changed
+func (s *service) publishProgress(videoID string) {
+	s.events.Publish(models.JobEvent{
+		VideoID:   videoID,
+		Type:      models.JobProgress,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *service) JobHistory(ctx context.Context, id, userID string) ([]models.JobEvent, error) {
+	if _, err := s.userVideo(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListJobEvents(ctx, id)
+}
+
+// recordResult records how a job run ended. Runs stopped by an operator are
+// recorded as cancelled rather than failed.
+func (s *service) recordResult(ctx context.Context, videoID string, err error) {
+	if err == nil {
+		s.recordEvent(ctx, videoID, models.JobCompleted, "")
+		return
+	}
+
+	code, _ := classifyFailure(err)
+	if code == models.ErrorCancelled {
+		s.recordEvent(ctx, videoID, models.JobCancelled, "")
+		return
+	}
+	s.recordEvent(ctx, videoID, models.JobFailed, string(code))
+}
@@ -2,26 +2,424 @@ package video
 
 import (
 	"context"
+	"io"
 	"time"
 	"yt-text/models"
+	"yt-text/youtube"
 )
 
 type Service interface {
 	// Transcribe initiates a new transcription or returns existing one
-	Transcribe(ctx context.Context, url string) (*models.Video, error)
+	Transcribe(ctx context.Context, url string, opts TranscribeOptions) (*models.Video, error)
+
+	// TranscribeBatch creates a batch of URLs and submits each of them in
+	// the background
+	TranscribeBatch(ctx context.Context, urls []string, opts TranscribeOptions) (*models.Batch, error)
+
+	// TranscribePlaylist expands a playlist or channel URL into a batch of
+	// its videos
+	TranscribePlaylist(ctx context.Context, url string, opts TranscribeOptions) (*models.Batch, error)
+
+	// Estimate validates a URL without queueing it and estimates how long
+	// transcribing it would take
+	Estimate(ctx context.Context, url string, opts TranscribeOptions) (*models.Estimate, error)
+
+	// GetBatch retrieves a batch with the current status of its videos
+	GetBatch(ctx context.Context, id string) (*models.Batch, error)
 
 	// GetTranscription retrieves a transcription by ID
 	GetTranscription(ctx context.Context, id string) (*models.Video, error)
+
+	// GetUserTranscription is GetTranscription for a signed-in user, who
+	// only has access to the videos they submitted
+	GetUserTranscription(ctx context.Context, id, userID string) (*models.Video, error)
+
+	// ListTranscriptions returns a page of stored videos, newest first, and
+	// the total number matching the filter
+	ListTranscriptions(ctx context.Context, opts ListOptions) ([]*models.Video, int, error)
+
+	// Progress reports how far along a transcription is
+	Progress(ctx context.Context, id string) (*models.ProgressUpdate, error)
+
+	// JobHistory lists the state changes of a video's jobs, oldest first.
+	// A signed-in user only sees the history of videos they submitted.
+	JobHistory(ctx context.Context, id, userID string) ([]models.JobEvent, error)
+
+	// PartialTranscript returns the transcript segments a running job has
+	// produced so far, from offset on
+	PartialTranscript(ctx context.Context, id string, offset int) ([]models.Segment, error)
+
+	// TranscriptionText streams a stored transcript without loading it into
+	// memory. An empty source selects the primary transcript.
+	TranscriptionText(ctx context.Context, id string, source models.Source) (io.Reader, error)
+
+	// EncodedTranscriptionText is TranscriptionText for clients accepting
+	// compressed content. A transcript stored compressed with one of the
+	// accepted encodings is streamed as it is and its encoding returned;
+	// anything else streams as plain text with an empty encoding. A
+	// signed-in user can only read the videos they submitted.
+	EncodedTranscriptionText(ctx context.Context, id, userID string, source models.Source, accepted []string) (io.Reader, string, error)
+
+	// ExportTranscriptions writes completed transcriptions to w as a ZIP
+	// archive of text and SRT files with a manifest.json, reading a page of
+	// them at a time
+	ExportTranscriptions(ctx context.Context, w io.Writer, opts ExportOptions) error
+
+	// PrioritizeJob moves a waiting job into the priority lane of the queue
+	// and returns its position among prioritized jobs
+	PrioritizeJob(ctx context.Context, id string) (int, error)
+
+	// DeprioritizeJob moves a prioritized job back to the normal lane
+	DeprioritizeJob(ctx context.Context, id string) error
+
+	// CancelJob stops a waiting or running job and reports whether it was
+	// running
+	CancelJob(ctx context.Context, id string) (bool, error)
+
+	// ListJobs lists running and waiting jobs
+	ListJobs(ctx context.Context) []JobInfo
+
+	// QueueStatus reports queue depth and pause state
+	QueueStatus(ctx context.Context) QueueStatus
+
+	// PauseQueue pauses intake, workers, or both
+	PauseQueue(ctx context.Context, scope PauseScope) error
+
+	// ResumeQueue resumes intake, workers, or both
+	ResumeQueue(ctx context.Context, scope PauseScope) error
+
+	// Stats summarizes service activity and external quota usage
+	Stats(ctx context.Context) (*Stats, error)
+
+	// ActiveJobs lists the jobs workers are processing right now
+	ActiveJobs(ctx context.Context) []ActiveJob
+
+	// RecentFailures returns the most recently failed videos
+	RecentFailures(ctx context.Context, limit int) ([]*models.Video, error)
+
+	// RetryTranscription resubmits a failed video, optionally with another
+	// source preference or Whisper model
+	RetryTranscription(ctx context.Context, id string, opts TranscribeOptions) (*models.Video, error)
+
+	// DeleteTranscription removes a finished, unpinned video and its
+	// transcripts. For a signed-in user it takes the video off their list,
+	// and only removes it once no other user has it.
+	DeleteTranscription(ctx context.Context, id, userID string) error
+
+	// UpdateRetention pins a video or sets how long it's kept. A signed-in
+	// user can only change the videos they submitted.
+	UpdateRetention(ctx context.Context, id, userID string, update RetentionUpdate) (*models.Video, error)
+
+	// CorrectTranscript replaces a completed video's primary transcript
+	// with a corrected one, stored as its next revision. The transcript as
+	// transcribed is kept as a revision before its first correction.
+	CorrectTranscript(ctx context.Context, id string, correction Correction) (*models.Revision, error)
+
+	// ListRevisions lists the revisions of a video's transcript, oldest
+	// first, without their text. A signed-in user only sees the revisions
+	// of videos they submitted.
+	ListRevisions(ctx context.Context, id, userID string) (*models.RevisionList, error)
+
+	// CompareRevisions returns a revision with the words changed since an
+	// earlier one; an against of 0 compares it with the revision before it
+	CompareRevisions(ctx context.Context, id, userID string, number, against int) (*models.RevisionDiff, error)
+
+	// RunCleanup deletes expired videos periodically until ctx is cancelled
+	RunCleanup(ctx context.Context)
+
+	// CleanupExpired deletes finished, unpinned videos past their own
+	// expiry, or without one and last updated more than retention ago, and
+	// returns how many were removed. Zero retention keeps videos without an
+	// expiry. A dry run deletes nothing and returns how many would go.
+	CleanupExpired(ctx context.Context, retention time.Duration, dryRun bool) (int64, error)
+
+	// ClaimJobs takes stored jobs no other process holds whenever a worker
+	// is idle, until ctx is cancelled
+	ClaimJobs(ctx context.Context)
+
+	// UpdateSettings applies reloaded settings to jobs started after it
+	UpdateSettings(settings Settings)
+
+	// Drain stops taking jobs and waits for running ones until ctx is done,
+	// then interrupts the rest. Interrupted and waiting jobs are left stored
+	// for the next process to claim. It returns how many were interrupted.
+	Drain(ctx context.Context) int
+}
+
+// Stats is returned by the stats endpoint
+type Stats struct {
+	Queue        QueueStatus         `json:"queue"`
+	YouTubeQuota youtube.QuotaStatus `json:"youtube_quota"`
+	Storage      StorageStats        `json:"storage"`
+
+	// CaptionQuality is only reported when caption comparison is enabled
+	CaptionQuality *models.CaptionQuality `json:"caption_quality,omitempty"`
+
+	Totals *models.VideoStats `json:"totals"`
+
+	// QueueHistory is this process's queue depth over the last day, oldest
+	// first. Processes that don't run jobs have none.
+	QueueHistory []QueueSample `json:"queue_history"`
+
+	// Cleanup counts this process's cleanup passes since it started
+	Cleanup CleanupStats `json:"cleanup"`
+}
+
+// TranscribeOptions tune the pipeline for a single submission
+type TranscribeOptions struct {
+	Source  SourcePreference
+	Input   InputType
+	Model   string // Whisper model overriding the language route, if set
+	Whisper models.WhisperOptions
+
+	// Captions narrows which caption tracks may be used
+	Captions models.CaptionOptions
+
+	// UserID is the signed-in user submitting the URL, whose list the
+	// video is added to; empty for anonymous submissions
+	UserID string
+}
+
+// InputType says what a submitted URL points at
+type InputType string
+
+const (
+	// InputVideo is a page yt-dlp extracts the audio from
+	InputVideo InputType = "video"
+	// InputAudioURL is a direct link to an MP3 or WAV file, downloaded
+	// without yt-dlp
+	InputAudioURL InputType = "audio_url"
+)
+
+// RetentionUpdate changes how long a video is kept. Nil fields are left
+// unchanged.
+type RetentionUpdate struct {
+	Pinned *bool
+	// TTL is how long from now until cleanup removes the video, in place of
+	// the retention window; zero clears it
+	TTL *time.Duration
 }
 
+// Correction is an edit of a transcript: either its whole corrected text,
+// or new text for some of its segments
+type Correction struct {
+	// Text replaces the transcript. Its words are fitted onto the timing of
+	// the segments they replace.
+	Text     *string
+	Segments []SegmentCorrection
+	Note     string
+
+	// BaseRevision, when set, is the revision the correction was made
+	// against; the correction is refused if the transcript moved on since
+	BaseRevision *int
+
+	// UserID is the signed-in user making the correction, who must have
+	// submitted the video; empty for anonymous corrections
+	UserID string
+}
+
+// SegmentCorrection replaces the text of the segment at Index. Empty text
+// removes the segment.
+type SegmentCorrection struct {
+	Index int
+	Text  string
+}
+
+// ListOptions filter and paginate ListTranscriptions. Empty filters match
+// every video; pages are numbered from 1.
+type ListOptions struct {
+	Status   models.Status
+	Language string
+	Page     int
+	PageSize int
+
+	// UserID lists only the videos a user submitted, most recently
+	// submitted first
+	UserID string
+
+	// Keyword and Entity list only the videos whose transcripts were found
+	// to be about the keyword or to mention the name, in any case
+	Keyword string
+	Entity  string
+}
+
+// Page size bounds for ListTranscriptions
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// SourcePreference selects where a transcript may come from
+type SourcePreference string
+
+const (
+	// SourceAuto follows the instance's source policy
+	SourceAuto SourcePreference = "auto"
+	// SourceCaptionsOnly fails instead of falling back to Whisper
+	SourceCaptionsOnly SourcePreference = "captions_only"
+	// SourceWhisperOnly skips captions
+	SourceWhisperOnly SourcePreference = "whisper_only"
+	// SourceCaptionsThenWhisper uses YouTube captions when available,
+	// otherwise Whisper
+	SourceCaptionsThenWhisper SourcePreference = "captions_then_whisper"
+	// SourceWhisperIfCaptionsPoor uses captions unless they look poor, in
+	// which case Whisper runs and the captions are kept as the secondary
+	// transcript
+	SourceWhisperIfCaptionsPoor SourcePreference = "whisper_if_captions_poor"
+)
+
+// ComparePick decides which transcript becomes the primary one when
+// captions were compared with Whisper; the other is kept as the secondary
+// transcript
+type ComparePick string
+
+const (
+	// PickWhisper keeps the Whisper transcript. It is the default.
+	PickWhisper ComparePick = "whisper"
+	// PickCaptions keeps the captions, Whisper only scoring them
+	PickCaptions ComparePick = "captions"
+	// PickCaptionsIfGood keeps the captions when their word error rate is
+	// within the threshold, and Whisper otherwise
+	PickCaptionsIfGood ComparePick = "captions_if_good"
+)
+
+// ParseSourcePreference parses a request value, defaulting to SourceAuto
+func ParseSourcePreference(value string) (SourcePreference, bool) {
+	switch pref := SourcePreference(value); pref {
+	case "":
+		return SourceAuto, true
+	case SourceAuto, SourceCaptionsOnly, SourceWhisperOnly, SourceCaptionsThenWhisper, SourceWhisperIfCaptionsPoor:
+		return pref, true
+	default:
+		return "", false
+	}
+}
+
+// StorageStats reports disk usage
+type StorageStats struct {
+	DatabaseBytes   int64 `json:"database_bytes"`
+	TranscriptBytes int64 `json:"transcript_bytes"` // Transcripts held in the database, as stored
+
+	// Transcript files of the local storage backend, when it is used: their
+	// size, the cap on it (0 for none), and whether new submissions are
+	// turned away for lack of room
+	FileBytes       *int64 `json:"file_bytes,omitempty"`
+	FileLimitBytes  int64  `json:"file_limit_bytes,omitempty"`
+	FileStorageFull bool   `json:"file_storage_full,omitempty"`
+}
+
+// PauseScope selects which side of the queue a pause or resume applies to
+type PauseScope string
+
+const (
+	// PauseIntake rejects new submissions while queued jobs keep processing
+	PauseIntake PauseScope = "intake"
+	// PauseWorkers lets submissions queue up without starting new jobs
+	PauseWorkers PauseScope = "workers"
+	// PauseAll combines both
+	PauseAll PauseScope = "all"
+)
+
 type Config struct {
 	// ProcessTimeout is the maximum time allowed for a single transcription
 	ProcessTimeout time.Duration `json:"process_timeout"`
 
 	// Max file size and duration limits
 	MaxDuration time.Duration `json:"max_duration"`
-	// MaxFileSize int64         `json:"max_file_size"`
+	MaxFileSize int64         `json:"max_file_size"`
+
+	// TempDir holds direct audio downloads while they are transcribed
+	TempDir string `json:"temp_dir"`
 
 	// Model configuration
 	DefaultModel string `json:"default_model"`
+
+	// ModelRoutes maps audio languages to Whisper models, e.g. "en" to
+	// "base.en". The "*" route applies to any other known language; videos
+	// without a language hint use DefaultModel.
+	ModelRoutes map[string]string `json:"model_routes"`
+
+	// Queue configuration
+	Workers   int `json:"workers"`
+	QueueSize int `json:"queue_size"`
+
+	// MemoryLimitMB caps the memory expected of the jobs running at once,
+	// so large models wait rather than exhaust the host. 0 uses the memory
+	// available at startup; a negative value disables the limit.
+	// ModelMemoryMB overrides the expected memory of a model or model size.
+	MemoryLimitMB int            `json:"memory_limit_mb"`
+	ModelMemoryMB map[string]int `json:"model_memory_mb"`
+
+	// OEmbedPrecheck enables the cheap YouTube existence check
+	OEmbedPrecheck bool `json:"oembed_precheck"`
+
+	// CaptionsEnabled lets existing YouTube captions replace Whisper
+	CaptionsEnabled bool `json:"captions_enabled"`
+
+	// CompareCaptions also runs Whisper when captions were found, scores
+	// the captions against it and keeps both transcripts, the one
+	// ComparePick picks as the primary one
+	CompareCaptions bool        `json:"compare_captions"`
+	ComparePick     ComparePick `json:"compare_pick"`
+
+	// CaptionWERThreshold is the word error rate at or below which captions
+	// are reported as good enough to skip Whisper
+	CaptionWERThreshold float64 `json:"caption_wer_threshold"`
+
+	// MaxBatchSize limits the URLs of one batch submission
+	MaxBatchSize int `json:"max_batch_size"`
+
+	// Retention is how long finished, unpinned videos without their own
+	// expiry are kept before cleanup deletes them; 0 keeps them
+	Retention time.Duration `json:"retention"`
+
+	// CleanupInterval is how often RunCleanup deletes expired videos, with
+	// a random delay of up to CleanupJitter added to each wait. Videos are
+	// deleted CleanupBatchSize at a time, at most CleanupMaxPerRun per pass
+	// (0 for no limit). CleanupDryRun counts them and deletes nothing.
+	CleanupInterval  time.Duration `json:"cleanup_interval"`
+	CleanupJitter    time.Duration `json:"cleanup_jitter"`
+	CleanupBatchSize int           `json:"cleanup_batch_size"`
+	CleanupMaxPerRun int           `json:"cleanup_max_per_run"`
+	CleanupDryRun    bool          `json:"cleanup_dry_run"`
+
+	// SourcePolicy is what SourceAuto stands for; empty means
+	// SourceCaptionsThenWhisper
+	SourcePolicy SourcePreference `json:"source_policy"`
+
+	// LiveRetryInterval schedules jobs for live streams and upcoming
+	// premieres to run after they end, checking again this often; 0 rejects
+	// them. LiveRetryLimit is how long such a job waits before it fails.
+	LiveRetryInterval time.Duration `json:"live_retry_interval"`
+	LiveRetryLimit    time.Duration `json:"live_retry_limit"`
+
+	// LookupCacheTTL is how long a video's lookup is reused; 0 disables
+	// the cache
+	LookupCacheTTL time.Duration `json:"lookup_cache_ttl"`
+
+	// Enrich extracts keywords, named entities and URLs from completed
+	// transcripts
+	Enrich bool `json:"enrich"`
+
+	// Mode selects whether this process runs the jobs it accepts
+	Mode Mode `json:"mode"`
 }
+
+// Settings are the parts of Config that can be changed without a restart
+type Settings struct {
+	DefaultModel string
+	ModelRoutes  map[string]string
+	Retention    time.Duration
+}
+
+// Mode selects which side of the job queue a process serves
+type Mode string
+
+const (
+	// ModeAll accepts submissions and runs them in the same process
+	ModeAll Mode = "all"
+	// ModeAPI accepts submissions and leaves them to worker processes
+	ModeAPI Mode = "api"
+	// ModeWorker runs jobs claimed from the shared repository
+	ModeWorker Mode = "worker"
+)
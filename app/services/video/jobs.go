@@ -0,0 +1,229 @@
+package video
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+)
+
+// queuedJob is the stored form of a job
+func queuedJob(job *Job) models.QueuedJob {
+	return models.QueuedJob{
+		VideoID:   job.Video.ID,
+		Source:    string(job.Source),
+		Model:     job.Model,
+		Whisper:   job.Whisper,
+		Captions:  job.Captions,
+		Priority:  job.Priority,
+		RequestID: job.RequestID,
+		QueuedAt:  job.QueuedAt,
+		LookUp:    job.LookUp,
+	}
+}
+
+// forgetJob removes a finished or rejected job from storage
+func (s *service) forgetJob(ctx context.Context, videoID string) {
+	if err := s.repo.DeleteJob(ctx, videoID); err != nil {
+		s.logger.Error().Err(err).Str("video_id", videoID).Msg("Failed to delete stored job")
+	}
+}
+
+const (
+	// claimInterval is how often stored jobs are looked for while a worker
+	// is idle
+	claimInterval = 5 * time.Second
+
+	// claimTTL is how long a job's claim lasts without renewal. Jobs of a
+	// process that stopped, including this one before a restart, are taken
+	// over after this long.
+	claimTTL = time.Minute
+)
+
+func (s *service) ClaimJobs(ctx context.Context) {
+	ticker := time.NewTicker(claimInterval)
+	defer ticker.Stop()
+
+	for {
+		s.claimJobs(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimJobs claims stored jobs while workers are idle
+func (s *service) claimJobs(ctx context.Context) {
+	for s.queue.Idle() {
+		stored, err := s.repo.ClaimJob(ctx, s.owner, time.Now().Add(-claimTTL))
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to claim job")
+			return
+		}
+		if stored == nil {
+			return
+		}
+		s.resumeJob(ctx, *stored)
+	}
+}
+
+// renewClaims keeps the claims on this process's jobs from lapsing for as
+// long as it runs
+func (s *service) renewClaims(ctx context.Context) {
+	ticker := time.NewTicker(claimTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.repo.RenewJobClaims(ctx, s.owner); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to renew job claims")
+		}
+	}
+}
+
+func (s *service) Drain(ctx context.Context) int {
+	interrupted := s.queue.Drain(ctx)
+	for _, job := range interrupted {
+		s.logger.Warn().Str("video_id", job.Video.ID).Msg("Interrupted job left for restart")
+	}
+
+	// Let the next process claim this one's jobs without waiting for the
+	// claims to lapse
+	releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.ReleaseJobClaims(releaseCtx, s.owner); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to release job claims")
+	}
+	return len(interrupted)
+}
+
+// resumeJob puts a claimed job in the queue. Jobs whose video is gone or no
+// longer processing are dropped; a video whose job doesn't fit is marked
+// failed so clients stop waiting for it.
+func (s *service) resumeJob(ctx context.Context, sj models.QueuedJob) {
+	video, err := s.repo.Find(ctx, sj.VideoID)
+	if err != nil || video.Status != models.StatusProcessing {
+		s.forgetJob(ctx, sj.VideoID)
+		return
+	}
+
+	// Restart the staleness clock, so resubmitting the URL doesn't start
+	// a second job
+	video.UpdatedAt = time.Now()
+	if err := s.repo.Save(ctx, video); err != nil {
+		s.logger.Error().Err(err).Str("video_id", video.ID).Msg("Failed to save resumed video")
+	}
+
+	job := &Job{
+		Video:     video,
+		Source:    SourcePreference(sj.Source),
+		Model:     sj.Model,
+		Whisper:   sj.Whisper,
+		Captions:  sj.Captions,
+		Priority:  sj.Priority,
+		RequestID: sj.RequestID,
+		QueuedAt:  sj.QueuedAt,
+		LookUp:    sj.LookUp,
+	}
+	if err := s.queue.Submit(job); err != nil {
+		s.logger.Warn().Err(err).Str("video_id", video.ID).Msg("Could not resume job")
+		s.forgetJob(ctx, video.ID)
+
+		video.Status = models.StatusFailed
+		video.Error = "Transcription was interrupted, please submit it again"
+		video.ErrorCode = models.ErrorServiceUnavailable
+		video.UpdatedAt = time.Now()
+		if err := s.repo.Save(ctx, video); err != nil {
+			s.logger.Error().Err(err).Str("video_id", video.ID).Msg("Failed to save interrupted video")
+		}
+		s.recordEvent(ctx, video.ID, models.JobFailed, string(video.ErrorCode))
+		return
+	}
+	s.recordEvent(ctx, video.ID, models.JobQueued, "resumed")
+
+	s.logger.Info().Str("video_id", video.ID).Msg("Claimed stored job")
+}
+
+func (s *service) ListJobs(ctx context.Context) []JobInfo {
+	return s.queue.Jobs()
+}
+
+// DeprioritizeJob moves a prioritized job back to the normal lane
+func (s *service) DeprioritizeJob(ctx context.Context, id string) error {
+	const op = "VideoService.DeprioritizeJob"
+
+	if id == "" {
+		return errors.InvalidInput(op, nil, "ID is required")
+	}
+
+	err := s.queue.Deprioritize(id)
+	if stderrors.Is(err, ErrJobNotQueued) {
+		return errors.NotFound(op, err, "Job is not waiting in the queue")
+	}
+	if err != nil {
+		return errors.Internal(op, err, "Failed to deprioritize job")
+	}
+
+	if job := s.queue.find(id); job != nil {
+		stored := queuedJob(job)
+		stored.Priority = false
+		if err := s.repo.SaveJob(ctx, stored); err != nil {
+			s.logger.Error().Err(err).Str("video_id", id).Msg("Failed to persist job priority")
+		}
+	}
+	s.publishProgress(id)
+
+	s.logger.Info().Str("video_id", id).Msg("Job moved to normal lane")
+	return nil
+}
+
+// CancelJob stops a video's job. A waiting job is failed right away; a
+// running one is stopped and failed by its worker. It reports whether the
+// job was running.
+func (s *service) CancelJob(ctx context.Context, id string) (bool, error) {
+	const op = "VideoService.CancelJob"
+
+	if id == "" {
+		return false, errors.InvalidInput(op, nil, "ID is required")
+	}
+
+	job, err := s.queue.Cancel(id)
+	if stderrors.Is(err, ErrNoJob) {
+		return false, errors.NotFound(op, err, "Video has no waiting or running job")
+	}
+	if err != nil {
+		return false, errors.Internal(op, err, "Failed to cancel job")
+	}
+	if job == nil {
+		s.logger.Info().Str("video_id", id).Msg("Running job cancelled")
+		return true, nil
+	}
+
+	s.forgetJob(ctx, id)
+
+	video := job.Video
+	video.Status = models.StatusFailed
+	video.ErrorCode = models.ErrorCancelled
+	video.Error = failureMessages[models.ErrorCancelled]
+	video.UpdatedAt = time.Now()
+	if video.Transcription != "" {
+		// An additional source was cancelled; the stored transcript stays usable
+		video.Status = models.StatusCompleted
+	}
+	if err := s.repo.Save(ctx, video); err != nil {
+		return false, saveError(op, err, "Failed to save cancelled video")
+	}
+	s.recordEvent(ctx, id, models.JobCancelled, "")
+
+	s.logger.Info().Str("video_id", id).Msg("Waiting job cancelled")
+	return false, nil
+}
@@ -0,0 +1,103 @@
+package video
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/scripts"
+)
+
+// ErrLiveStream is returned for live streams and upcoming premieres, which
+// have no recording to transcribe until they end
+var ErrLiveStream = stderrors.New("video is a live stream or upcoming premiere")
+
+// liveStatuses are the yt-dlp live statuses of videos without a finished
+// recording yet
+var liveStatuses = map[string]bool{"is_live": true, "is_upcoming": true, "post_live": true}
+
+// isLiveInfo reports whether a validation result is for a live stream or
+// premiere. yt-dlp fails on upcoming ones, so the error counts too.
+func isLiveInfo(info scripts.VideoInfo) bool {
+	return liveStatuses[info.LiveStatus] || !info.Valid && classifyMessage(info.Error) == models.ErrorLiveOnly
+}
+
+// liveVideo handles a live video found during validation: it is rejected
+// with the live_only code, or accepted to run once it has ended when live
+// retries are enabled
+func (s *service) liveVideo(op, url string, details videoDetails) (videoDetails, error) {
+	if s.config.LiveRetryInterval <= 0 {
+		s.logger.Info().Str("url", url).Msg("Rejected live stream")
+		err := errors.InvalidInput(op, ErrLiveStream, failureMessages[models.ErrorLiveOnly])
+		err.Details = map[string]models.ErrorCode{"error_code": models.ErrorLiveOnly}
+		return videoDetails{}, err
+	}
+	details.Live = true
+	return details, nil
+}
+
+// stillLive checks again whether a scheduled video is live. Other
+// validation errors let the job run, to fail there if they persist.
+func (s *service) stillLive(ctx context.Context, video *models.Video) bool {
+	var info scripts.VideoInfo
+	err := s.withYouTubeThrottle(ctx, video.URL, func() (err error) {
+		info, err = s.scripts.Validate(ctx, video.URL)
+		return err
+	})
+	if err != nil {
+		code, _ := classifyFailure(err)
+		return code == models.ErrorLiveOnly
+	}
+	return isLiveInfo(info)
+}
+
+// scheduleLive stores a job to run at the video's retry time instead of
+// queueing it. Any process running jobs claims it once it is due.
+func (s *service) scheduleLive(ctx context.Context, job *Job) error {
+	stored := queuedJob(job)
+	stored.NotBefore = *job.Video.RetryAt
+	if err := s.repo.SaveJob(ctx, stored); err != nil {
+		return err
+	}
+	s.recordEvent(ctx, job.Video.ID, models.JobScheduled, stored.NotBefore.UTC().Format(time.RFC3339))
+	s.logger.Info().
+		Str("video_id", job.Video.ID).
+		Time("retry_at", stored.NotBefore).
+		Msg("Live video scheduled")
+	return nil
+}
+
+// rescheduleLive puts a job whose video is still live back in storage for
+// another check later, or fails it once it has waited too long
+func (s *service) rescheduleLive(ctx context.Context, job *Job) {
+	video := job.Video
+	logger := s.logger.With().Str("video_id", video.ID).Logger()
+	s.forgetJob(ctx, video.ID)
+
+	video.UpdatedAt = time.Now()
+	if s.config.LiveRetryInterval <= 0 || time.Since(video.CreatedAt) > s.config.LiveRetryLimit {
+		logger.Info().Msg("Live video did not end in time")
+		video.Status = models.StatusFailed
+		video.ErrorCode = models.ErrorLiveOnly
+		video.Error = failureMessages[models.ErrorLiveOnly]
+		video.RetryAt = nil
+		if video.Transcription != "" {
+			video.Status = models.StatusCompleted
+		}
+		if err := s.repo.Save(ctx, video); err != nil {
+			logger.Error().Err(err).Msg("Failed to save live video")
+		}
+		s.recordEvent(ctx, video.ID, models.JobFailed, string(models.ErrorLiveOnly))
+		return
+	}
+
+	retryAt := time.Now().Add(s.config.LiveRetryInterval)
+	video.RetryAt = &retryAt
+	if err := s.repo.Save(ctx, video); err != nil {
+		logger.Error().Err(err).Msg("Failed to save live video")
+	}
+	if err := s.scheduleLive(ctx, job); err != nil {
+		logger.Error().Err(err).Msg("Failed to reschedule live video")
+	}
+}
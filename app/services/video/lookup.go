@@ -0,0 +1,227 @@
+package video
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sync"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/repository"
+	"yt-text/validation"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// lookupRejectionTTL caps how long a rejected video is remembered, since
+	// videos that were private or missing can be published later
+	lookupRejectionTTL = 10 * time.Minute
+
+	// lookupCacheSize bounds the lookups remembered at once
+	lookupCacheSize = 10000
+)
+
+// lookupResult is the outcome of looking a video up: its details, or the
+// error it was rejected with
+type lookupResult struct {
+	details videoDetails
+	err     error
+}
+
+// lookupCache remembers recent lookups by video, so a video submitted again
+// isn't looked up again. Expired entries are dropped lazily on access and
+// when the cache is written.
+type lookupCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]lookupEntry
+}
+
+type lookupEntry struct {
+	result    lookupResult
+	expiresAt time.Time
+}
+
+func newLookupCache(ttl time.Duration) *lookupCache {
+	return &lookupCache{ttl: ttl, entries: make(map[string]lookupEntry)}
+}
+
+func (c *lookupCache) get(key string) (lookupResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return lookupResult{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return lookupResult{}, false
+	}
+	return entry.result, true
+}
+
+// set remembers a lookup. Only outcomes that would come out the same when
+// repeated are kept: live videos may have ended by the next submission, and
+// errors other than rejections may not happen again.
+func (c *lookupCache) set(key string, result lookupResult) {
+	ttl := c.ttl
+	if result.err != nil {
+		var appErr *errors.AppError
+		if !stderrors.As(result.err, &appErr) || appErr.Code != http.StatusBadRequest ||
+			stderrors.Is(result.err, context.DeadlineExceeded) || stderrors.Is(result.err, context.Canceled) {
+			return
+		}
+		ttl = min(ttl, lookupRejectionTTL)
+	}
+	if ttl <= 0 || result.details.Live {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	if len(c.entries) >= lookupCacheSize {
+		return
+	}
+	c.entries[key] = lookupEntry{result: result, expiresAt: now.Add(ttl)}
+}
+
+// lookupKey identifies the video behind a URL: its platform ID when the
+// platform is known, so every link to a video shares one entry, and
+// otherwise the URL itself
+func lookupKey(url string, input InputType) string {
+	if input == InputAudioURL {
+		return "audio:" + url
+	}
+	if media, ok := validation.IdentifyMedia(url); ok {
+		return string(media.Platform) + ":" + media.ID
+	}
+	return "url:" + validation.CanonicalURL(url)
+}
+
+// lookUp checks that a URL can be transcribed and returns what is known
+// about its video, reusing a recent lookup of the same video
+func (s *service) lookUp(ctx context.Context, url string, input InputType) (videoDetails, error) {
+	key := lookupKey(url, input)
+	if found, ok := s.lookups.get(key); ok {
+		return found.details, found.err
+	}
+
+	var details videoDetails
+	var err error
+	if input == InputAudioURL {
+		details, err = s.validateAudioURL(ctx, url)
+	} else {
+		details, err = s.validateNewVideo(ctx, url)
+	}
+	s.lookups.set(key, lookupResult{details: details, err: err})
+	return details, err
+}
+
+// needsLookup reports whether the job of a stored video must look it up
+// first. A video that never completed may have been turned away by its
+// lookup, so it is looked up again unless a recent lookup found it fine. A
+// remembered rejection is returned as the error.
+func (s *service) needsLookup(video *models.Video) (bool, error) {
+	if video.Status == models.StatusCompleted {
+		return false, nil
+	}
+	found, cached := s.lookups.get(lookupKey(video.URL, videoInput(video)))
+	return !cached, found.err
+}
+
+// videoInput is the kind of URL a stored video was submitted as
+func videoInput(video *models.Video) InputType {
+	if video.Platform == models.PlatformDirect {
+		return InputAudioURL
+	}
+	return InputVideo
+}
+
+// lookUpJob looks up the video of a job accepted without a recent lookup.
+// A video that can't be transcribed fails the job, and a live one is
+// scheduled for later; either way the job doesn't run now, which the
+// result reports.
+func (s *service) lookUpJob(ctx context.Context, job *Job, logger zerolog.Logger) bool {
+	video := job.Video
+	s.queue.ReportProgress(video.ID, models.StageValidating, 0)
+	s.publishProgress(video.ID)
+
+	details, err := s.lookUp(ctx, video.URL, videoInput(video))
+
+	// The run's context may have timed out or been cancelled, so the
+	// outcome is saved without it
+	saveCtx := jobContext(job)
+	if err != nil && s.queue.wasInterrupted(job) {
+		logger.Warn().Msg("Lookup interrupted by shutdown")
+		s.recordEvent(saveCtx, video.ID, models.JobInterrupted, "")
+		return false
+	}
+	if err != nil {
+		logger.Info().Err(err).Msg("Video lookup failed")
+		video.Status = models.StatusFailed
+		video.ErrorCode, video.Error = lookupFailure(err)
+		video.FailureLog = failureLog(err)
+		video.Timings = runTimings(ctx)
+		video.UpdatedAt = time.Now()
+
+		s.forgetJob(saveCtx, video.ID)
+		if video.ErrorCode == models.ErrorCancelled {
+			s.recordEvent(saveCtx, video.ID, models.JobCancelled, "")
+		} else {
+			s.recordEvent(saveCtx, video.ID, models.JobFailed, string(video.ErrorCode))
+		}
+		if err := s.repo.Save(saveCtx, video); err != nil {
+			logger.Error().Err(err).Msg("Failed to save rejected video")
+		}
+		return false
+	}
+
+	job.LookUp = false
+	if video.Title == "" {
+		video.Title = details.Title
+	}
+	if video.Language == "" {
+		video.Language = details.Language
+	}
+	if video.Uploader == "" {
+		video.Uploader = details.Uploader
+	}
+	if details.Live {
+		logger.Info().Msg("Video is live, scheduling it for later")
+		s.rescheduleLive(saveCtx, job)
+		return false
+	}
+
+	// Saved now so the title shows while the job runs
+	video.UpdatedAt = time.Now()
+	if err := s.repo.Save(saveCtx, video); err != nil {
+		if stderrors.Is(err, repository.ErrConflict) {
+			logger.Warn().Msg("Video changed while it was looked up, dropping the job")
+			return false
+		}
+		logger.Error().Err(err).Msg("Failed to save video details")
+	}
+	return true
+}
+
+// lookupFailure maps a lookup error to an error code and message. The
+// message of a rejection is kept when no code describes it better, since it
+// was written for users.
+func lookupFailure(err error) (models.ErrorCode, string) {
+	code, message := classifyFailure(err)
+	var appErr *errors.AppError
+	if code == models.ErrorUnknown && stderrors.As(err, &appErr) && appErr.Code == http.StatusBadRequest {
+		return models.ErrorRejected, appErr.Message
+	}
+	return code, message
+}
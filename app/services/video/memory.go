@@ -0,0 +1,146 @@
+package video
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultModelMemoryMB is the memory a Whisper run is expected to peak at
+// for each model size, including the Python process and decoded audio. The
+// figures are for faster-whisper on CPU with some headroom.
+var defaultModelMemoryMB = map[string]int{
+	"tiny":   500,
+	"base":   700,
+	"small":  1200,
+	"medium": 2600,
+	"large":  4600,
+}
+
+const (
+	// captionsMemoryMB is what a job that only fetches captions needs
+	captionsMemoryMB = 100
+
+	// memoryReserveMB is left for the server itself when the limit is taken
+	// from the memory available at startup
+	memoryReserveMB = 256
+)
+
+// memoryLimit resolves the configured limit: a positive value is used as it
+// is, 0 detects the memory available now, and a negative value or a failed
+// detection disables the limit
+func memoryLimit(configured int) int {
+	switch {
+	case configured > 0:
+		return configured
+	case configured < 0:
+		return 0
+	}
+	available, ok := availableMemoryMB()
+	if !ok || available <= memoryReserveMB {
+		return 0
+	}
+	return available - memoryReserveMB
+}
+
+// cgroupMemoryFiles are the memory limit and usage of the process's cgroup,
+// under cgroup v2 and then v1
+var cgroupMemoryFiles = [][2]string{
+	{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory.current"},
+	{"/sys/fs/cgroup/memory/memory.limit_in_bytes", "/sys/fs/cgroup/memory/memory.usage_in_bytes"},
+}
+
+// availableMemoryMB reads MemAvailable from /proc/meminfo, lowered to what's
+// left under the cgroup's memory limit when a container sets one. It reports
+// false where neither is available, as on anything but Linux.
+func availableMemoryMB() (int, bool) {
+	available, ok := meminfoAvailableMB()
+	if limit, limited := cgroupAvailableMB(); limited && (!ok || limit < available) {
+		return limit, true
+	}
+	return available, ok
+}
+
+func meminfoAvailableMB() (int, bool) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// MemAvailable:    8123456 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+		kb, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return 0, false
+		}
+		return kb / 1024, true
+	}
+	return 0, false
+}
+
+// cgroupAvailableMB returns the cgroup's memory limit less its usage. It
+// reports false when there's no limit: v2 writes "max", and v1 a number
+// close to the largest int64.
+func cgroupAvailableMB() (int, bool) {
+	for _, files := range cgroupMemoryFiles {
+		limit, ok := readCgroupBytes(files[0])
+		if !ok {
+			continue
+		}
+		if limit >= 1<<62 {
+			return 0, false
+		}
+		usage, _ := readCgroupBytes(files[1])
+		if usage > limit {
+			usage = limit
+		}
+		return int((limit - usage) >> 20), true
+	}
+	return 0, false
+}
+
+func readCgroupBytes(path string) (int64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// modelMemory returns the memory a model is expected to need. The configured
+// table is consulted for the model and then its size, so "large=6000" also
+// covers "large-v3", before the defaults.
+func (s *service) modelMemory(model string) int {
+	size := strings.TrimSuffix(model, ".en")
+	size, _, _ = strings.Cut(size, "-")
+	for _, table := range []map[string]int{s.config.ModelMemoryMB, defaultModelMemoryMB} {
+		if mb, ok := table[model]; ok {
+			return mb
+		}
+		if mb, ok := table[size]; ok {
+			return mb
+		}
+	}
+	return defaultModelMemoryMB["large"]
+}
+
+// jobMemory is the memory the queue reserves for a job while it runs. Jobs
+// that may fall back to Whisper reserve the model's memory.
+func (s *service) jobMemory(job *Job) int {
+	if s.sourcePolicy(job.Source) == SourceCaptionsOnly {
+		return captionsMemoryMB
+	}
+	_, model := s.whisperModel(job.Video.Language, TranscribeOptions{Model: job.Model, Whisper: job.Whisper})
+	return s.modelMemory(model)
+}
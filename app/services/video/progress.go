@@ -0,0 +1,26 @@
+package video
+
+import (
+	"yt-text/models"
+	"yt-text/scripts"
+)
+
+// stageSpan is the part of a Whisper run that a reported stage covers.
+// Downloading is quick next to transcribing, so it gets a small share.
+var stageSpan = map[models.Stage]struct{ start, end float64 }{
+	models.StageDownloading:  {0, 0.2},
+	models.StageTranscribing: {0.2, 1},
+}
+
+// overallProgress maps the progress the transcription script reports for a
+// stage onto the whole run
+func overallProgress(p scripts.Progress) (models.Stage, float64) {
+	stage := models.Stage(p.Stage)
+	span, ok := stageSpan[stage]
+	if !ok {
+		return models.StageRunning, 0
+	}
+
+	progress := min(max(p.Progress, 0), 1)
+	return stage, span.start + (span.end-span.start)*progress
+}
@@ -0,0 +1,166 @@
+package video
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"yt-text/models"
+
+	"github.com/rs/zerolog"
+)
+
+// maxCompareWords bounds the word error rate computation, which is quadratic
+// in transcript length. A prefix of this size is plenty for a rough metric.
+const maxCompareWords = 10000
+
+// minCaptionWordsPerMinute is the speech density below which captions are
+// considered too sparse to trust; normal speech runs well above 100
+const minCaptionWordsPerMinute = 40
+
+// poorCaptions returns why a caption transcript looks too poor to keep as
+// the primary transcript, or "" when it looks fine. Auto-generated tracks
+// are YouTube's own speech recognition, usually worse than Whisper's, and
+// sparse tracks tend to caption only part of what is said.
+func poorCaptions(captions *transcript) string {
+	if captions.CaptionKind == captionKindAuto {
+		return "auto-generated"
+	}
+	if len(captions.Segments) == 0 {
+		return ""
+	}
+	span := captions.Segments[len(captions.Segments)-1].End - captions.Segments[0].Start
+	if span <= 60 {
+		return "" // Too short to judge
+	}
+	words := len(strings.Fields(captions.Text))
+	if float64(words)/(span/60) < minCaptionWordsPerMinute {
+		return "sparse"
+	}
+	return ""
+}
+
+// comparePick returns the instance's compare policy. Captions that already
+// looked poor are only kept when the comparison finds them good after all.
+func (s *service) comparePick(poor bool) ComparePick {
+	switch {
+	case s.config.ComparePick == "":
+		return PickWhisper
+	case poor && s.config.ComparePick == PickCaptions:
+		return PickCaptionsIfGood
+	default:
+		return s.config.ComparePick
+	}
+}
+
+// compareWithWhisper runs Whisper on a video that already has captions,
+// scores the captions against it and returns the transcript pick picks,
+// keeping the other one as its secondary transcript. If Whisper fails the
+// captions are used as they are.
+func (s *service) compareWithWhisper(
+	ctx context.Context,
+	video *models.Video,
+	opts TranscribeOptions,
+	captions *transcript,
+	pick ComparePick,
+	logger zerolog.Logger,
+) *transcript {
+	whisper, err := s.transcribeWithWhisper(ctx, video, opts)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Whisper comparison run failed, keeping captions")
+		return captions
+	}
+
+	comparison := s.compareCaptions(captions, whisper, pick)
+	logger.Info().
+		Float64("caption_wer", comparison.WER).
+		Bool("good_enough", comparison.GoodEnough).
+		Str("picked", string(comparison.Picked)).
+		Msg("Compared captions against Whisper")
+
+	primary, secondary := whisper, captions
+	if comparison.Picked == models.SourceCaptions {
+		primary, secondary = captions, whisper
+	}
+	primary.CaptionWER = &comparison.WER
+	primary.Comparison = comparison
+	primary.Secondary = secondary
+	if primary.Title == "" {
+		primary.Title = secondary.Title
+	}
+	if len(primary.Chapters) == 0 {
+		primary.Chapters = secondary.Chapters
+	}
+	return primary
+}
+
+// compareCaptions scores captions against a Whisper transcript of the same
+// video and picks the one to keep as the primary transcript. Word counts
+// cover the whole texts, though the score only compares the first
+// maxCompareWords words.
+func (s *service) compareCaptions(captions, whisper *transcript, pick ComparePick) *models.CaptionComparison {
+	wer := wordErrorRate(whisper.Text, captions.Text)
+
+	comparison := &models.CaptionComparison{
+		WER:          wer,
+		Similarity:   max(0, 1-wer),
+		Threshold:    s.config.CaptionWERThreshold,
+		GoodEnough:   wer <= s.config.CaptionWERThreshold,
+		CaptionKind:  captions.CaptionKind,
+		CaptionWords: len(strings.Fields(captions.Text)),
+		WhisperWords: len(strings.Fields(whisper.Text)),
+		Policy:       string(pick),
+		Picked:       models.SourceWhisper,
+	}
+	if pick == PickCaptions || pick == PickCaptionsIfGood && comparison.GoodEnough {
+		comparison.Picked = models.SourceCaptions
+	}
+	return comparison
+}
+
+// wordErrorRate returns the word-level edit distance between hypothesis and
+// reference divided by the reference length. Case and punctuation are
+// ignored. The result can exceed 1 when the hypothesis is much longer.
+func wordErrorRate(reference, hypothesis string) float64 {
+	ref := normalizeWords(reference)
+	hyp := normalizeWords(hypothesis)
+
+	if len(ref) == 0 {
+		if len(hyp) == 0 {
+			return 0
+		}
+		return 1
+	}
+
+	// Levenshtein distance over words, keeping two rows
+	prev := make([]int, len(hyp)+1)
+	curr := make([]int, len(hyp)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ref); i++ {
+		curr[0] = i
+		for j := 1; j <= len(hyp); j++ {
+			cost := 1
+			if ref[i-1] == hyp[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return float64(prev[len(hyp)]) / float64(len(ref))
+}
+
+// normalizeWords lowercases text, strips punctuation and splits it into at
+// most maxCompareWords words
+func normalizeWords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+	if len(words) > maxCompareWords {
+		words = words[:maxCompareWords]
+	}
+	return words
+}
@@ -0,0 +1,646 @@
+package video
+
+import (
+	"context"
+	stderrors "errors"
+	"sort"
+	"sync"
+	"time"
+	"yt-text/models"
+)
+
+const (
+	// drainPollInterval is how often a drain checks for running jobs
+	drainPollInterval = 100 * time.Millisecond
+
+	// interruptGrace bounds the wait for interrupted jobs to stop
+	interruptGrace = 10 * time.Second
+)
+
+var (
+	// ErrQueueFull is returned by Submit when no more jobs can be accepted
+	ErrQueueFull = stderrors.New("job queue is full")
+
+	// ErrJobNotQueued is returned when a job is not waiting in the queue
+	ErrJobNotQueued = stderrors.New("job is not waiting in the queue")
+
+	// ErrIntakePaused is returned by Submit while intake is paused
+	ErrIntakePaused = stderrors.New("job intake is paused")
+
+	// ErrStorageFull is returned by Transcribe while transcript storage is at
+	// its limit and nothing is evicted to make room
+	ErrStorageFull = stderrors.New("transcript storage is full")
+
+	// ErrNoJob is returned when a video has no waiting or running job
+	ErrNoJob = stderrors.New("video has no job in the queue")
+)
+
+// Job is a unit of work waiting for or being processed by a worker
+type Job struct {
+	Video     *models.Video
+	Source    SourcePreference
+	Model     string // Whisper model overriding the language route, if set
+	Whisper   models.WhisperOptions
+	Captions  models.CaptionOptions
+	Priority  bool
+	RequestID string // ID of the HTTP request that submitted the job
+	QueuedAt  time.Time
+	StartedAt time.Time // Set when a worker takes the job
+
+	// LookUp has the job look its video up before running, for jobs
+	// accepted without a recent lookup
+	LookUp bool
+
+	// memory is what the job reserved of the queue's memory limit while
+	// it runs, in MB
+	memory int
+
+	// Latest progress reported while running, and the transcript segments
+	// produced so far, guarded by the queue's lock
+	stage    models.Stage
+	progress float64
+	segments []models.Segment
+
+	// Cancellation of a running job, guarded by the queue's lock.
+	// interrupted is set when a drain stopped the job rather than a user.
+	cancel      context.CancelFunc
+	cancelled   bool
+	interrupted bool
+}
+
+// JobProgress is where a video's job stands in the queue
+type JobProgress struct {
+	Stage    models.Stage
+	Position int     // 1-based position among waiting jobs, priority lane first
+	Progress float64 // Overall fraction done, while running
+	Segments int     // Transcript segments produced so far, while running
+}
+
+// ActiveJob describes a job a worker is currently processing
+type ActiveJob struct {
+	VideoID   string        `json:"video_id"`
+	URL       string        `json:"url"`
+	Priority  bool          `json:"priority"`
+	QueuedAt  time.Time     `json:"queued_at"`
+	StartedAt time.Time     `json:"started_at"`
+	Running   time.Duration `json:"-"`
+}
+
+// JobInfo describes a waiting or running job for operators
+type JobInfo struct {
+	VideoID        string       `json:"video_id"`
+	URL            string       `json:"url"`
+	Stage          models.Stage `json:"stage"`
+	Progress       float64      `json:"progress"`
+	Position       int          `json:"position,omitempty"` // 1-based, among waiting jobs
+	Priority       bool         `json:"priority"`
+	QueuedAt       time.Time    `json:"queued_at"`
+	StartedAt      *time.Time   `json:"started_at,omitempty"`
+	ElapsedSeconds float64      `json:"elapsed_seconds"` // Since started, or since queued while waiting
+}
+
+// QueueStatus is a point-in-time snapshot of the queue
+type QueueStatus struct {
+	Waiting       int  `json:"waiting"`
+	Prioritized   int  `json:"prioritized"`
+	Active        int  `json:"active"`
+	Capacity      int  `json:"capacity"`
+	IntakePaused  bool `json:"intake_paused"`
+	WorkersPaused bool `json:"workers_paused"`
+
+	// Memory reserved by running jobs and the limit on it, in MB, when the
+	// queue has one
+	MemoryUsedMB  int `json:"memory_used_mb,omitempty"`
+	MemoryLimitMB int `json:"memory_limit_mb,omitempty"`
+}
+
+// JobQueue is a bounded FIFO with a separate priority lane. Workers always
+// drain the priority lane before taking jobs from the normal lane. With a
+// memory limit, the next job also waits until the memory it needs is free.
+type JobQueue struct {
+	mu       sync.Mutex
+	normal   []*Job
+	priority []*Job
+	capacity int
+	workers  int
+	active   map[string]*Job // by video ID
+
+	// memoryLimit caps the summed memory of running jobs as estimated by
+	// memoryCost, in MB; 0 means no limit
+	memoryLimit int
+	memoryUsed  int
+	memoryCost  func(*Job) int
+
+	intakePaused  bool
+	workersPaused bool
+
+	notify  chan struct{}
+	quit    chan struct{}
+	wg      sync.WaitGroup
+	handler func(*Job)
+}
+
+// NewJobQueue creates a queue holding at most capacity waiting jobs and starts
+// the given number of workers, each calling handler for every job it takes.
+func NewJobQueue(workers, capacity int, handler func(*Job)) *JobQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	q := &JobQueue{
+		capacity: capacity,
+		workers:  workers,
+		active:   make(map[string]*Job),
+		notify:   make(chan struct{}, capacity),
+		quit:     make(chan struct{}),
+		handler:  handler,
+	}
+
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+
+	return q
+}
+
+// SetMemoryLimit makes workers wait to start a job until its memory, as
+// estimated by cost, fits within limit MB alongside the running jobs. A job
+// needing more than the whole limit runs on its own. A limit of 0 removes
+// the restriction.
+func (q *JobQueue) SetMemoryLimit(limit int, cost func(*Job) int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.memoryLimit = limit
+	q.memoryCost = cost
+	for i := 0; i < len(q.normal)+len(q.priority); i++ {
+		q.signal()
+	}
+}
+
+// Submit adds a job to the queue
+func (q *JobQueue) Submit(job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.intakePaused {
+		return ErrIntakePaused
+	}
+	if len(q.normal)+len(q.priority) >= q.capacity {
+		return ErrQueueFull
+	}
+
+	if job.QueuedAt.IsZero() {
+		job.QueuedAt = time.Now()
+	}
+	if job.Priority {
+		q.priority = append(q.priority, job)
+	} else {
+		q.normal = append(q.normal, job)
+	}
+
+	q.signal()
+	return nil
+}
+
+// Prioritize moves a waiting job into the priority lane and returns its new
+// position (1-based) among prioritized jobs.
+func (q *JobQueue) Prioritize(videoID string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, job := range q.priority {
+		if job.Video.ID == videoID {
+			return i + 1, nil
+		}
+	}
+
+	for i, job := range q.normal {
+		if job.Video.ID == videoID {
+			q.normal = append(q.normal[:i], q.normal[i+1:]...)
+			job.Priority = true
+			q.priority = append(q.priority, job)
+			return len(q.priority), nil
+		}
+	}
+
+	return 0, ErrJobNotQueued
+}
+
+// Deprioritize moves a job from the priority lane back to the normal lane,
+// where it takes its place by submission time
+func (q *JobQueue) Deprioritize(videoID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, job := range q.priority {
+		if job.Video.ID != videoID {
+			continue
+		}
+		q.priority = append(q.priority[:i], q.priority[i+1:]...)
+		job.Priority = false
+
+		at := sort.Search(len(q.normal), func(j int) bool {
+			return q.normal[j].QueuedAt.After(job.QueuedAt)
+		})
+		q.normal = append(q.normal, nil)
+		copy(q.normal[at+1:], q.normal[at:])
+		q.normal[at] = job
+		return nil
+	}
+
+	for _, job := range q.normal {
+		if job.Video.ID == videoID {
+			return nil
+		}
+	}
+	return ErrJobNotQueued
+}
+
+// Cancel stops a video's job. A waiting job is removed from the queue and
+// returned, so the caller can record the cancellation; a running job has its
+// context cancelled and nil is returned, leaving the worker to record it.
+func (q *JobQueue) Cancel(videoID string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.active[videoID]; ok {
+		job.cancelled = true
+		if job.cancel != nil {
+			job.cancel()
+		}
+		return nil, nil
+	}
+
+	for _, lane := range []*[]*Job{&q.priority, &q.normal} {
+		for i, job := range *lane {
+			if job.Video.ID == videoID {
+				*lane = append((*lane)[:i], (*lane)[i+1:]...)
+				return job, nil
+			}
+		}
+	}
+	return nil, ErrNoJob
+}
+
+// bindCancel lets Cancel stop a running job. A job cancelled before its
+// worker got this far is cancelled right away.
+func (q *JobQueue) bindCancel(job *Job, cancel context.CancelFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.cancel = cancel
+	if job.cancelled {
+		cancel()
+	}
+}
+
+// Jobs lists running jobs, longest running first, followed by waiting jobs
+// in the order workers will take them
+func (q *JobQueue) Jobs() []JobInfo {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	jobs := make([]JobInfo, 0, len(q.active)+len(q.priority)+len(q.normal))
+	for _, job := range q.active {
+		started := job.StartedAt
+		info := JobInfo{
+			VideoID:        job.Video.ID,
+			URL:            job.Video.URL,
+			Stage:          models.StageRunning,
+			Progress:       job.progress,
+			Priority:       job.Priority,
+			QueuedAt:       job.QueuedAt,
+			StartedAt:      &started,
+			ElapsedSeconds: now.Sub(started).Seconds(),
+		}
+		if job.stage != "" {
+			info.Stage = job.stage
+		}
+		jobs = append(jobs, info)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(*jobs[j].StartedAt)
+	})
+
+	for i, job := range append(q.priority[:len(q.priority):len(q.priority)], q.normal...) {
+		jobs = append(jobs, JobInfo{
+			VideoID:        job.Video.ID,
+			URL:            job.Video.URL,
+			Stage:          models.StageQueued,
+			Position:       i + 1,
+			Priority:       job.Priority,
+			QueuedAt:       job.QueuedAt,
+			ElapsedSeconds: now.Sub(job.QueuedAt).Seconds(),
+		})
+	}
+	return jobs
+}
+
+// Len returns the number of jobs waiting to be processed
+func (q *JobQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.normal) + len(q.priority)
+}
+
+// Idle reports whether a worker is free with no job waiting for it
+func (q *JobQueue) Idle() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return !q.workersPaused && len(q.normal)+len(q.priority) == 0 && len(q.active) < q.workers
+}
+
+// Status returns a snapshot of queue depth and pause state
+func (q *JobQueue) Status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return QueueStatus{
+		Waiting:       len(q.normal) + len(q.priority),
+		Prioritized:   len(q.priority),
+		Active:        len(q.active),
+		Capacity:      q.capacity,
+		IntakePaused:  q.intakePaused,
+		WorkersPaused: q.workersPaused,
+		MemoryUsedMB:  q.memoryUsed,
+		MemoryLimitMB: q.memoryLimit,
+	}
+}
+
+// ActiveJobs lists the jobs currently being processed, longest running first
+func (q *JobQueue) ActiveJobs() []ActiveJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	jobs := make([]ActiveJob, 0, len(q.active))
+	for _, job := range q.active {
+		jobs = append(jobs, ActiveJob{
+			VideoID:   job.Video.ID,
+			URL:       job.Video.URL,
+			Priority:  job.Priority,
+			QueuedAt:  job.QueuedAt,
+			StartedAt: job.StartedAt,
+			Running:   now.Sub(job.StartedAt),
+		})
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+// JobState reports whether a video's job is waiting or being processed and
+// how far along it is. ok is false when the video has no job in the queue.
+func (q *JobQueue) JobState(videoID string) (state JobProgress, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, running := q.active[videoID]; running {
+		state = JobProgress{Stage: models.StageRunning, Progress: job.progress, Segments: len(job.segments)}
+		if job.stage != "" {
+			state.Stage = job.stage
+		}
+		return state, true
+	}
+	for i, job := range append(q.priority[:len(q.priority):len(q.priority)], q.normal...) {
+		if job.Video.ID == videoID {
+			return JobProgress{Stage: models.StageQueued, Position: i + 1}, true
+		}
+	}
+	return JobProgress{}, false
+}
+
+// find returns a video's waiting or running job, or nil
+func (q *JobQueue) find(videoID string) *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.active[videoID]; ok {
+		return job
+	}
+	for _, job := range append(q.priority[:len(q.priority):len(q.priority)], q.normal...) {
+		if job.Video.ID == videoID {
+			return job
+		}
+	}
+	return nil
+}
+
+// ReportProgress records the stage and overall progress of a running job
+func (q *JobQueue) ReportProgress(videoID string, stage models.Stage, progress float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.active[videoID]; ok {
+		job.stage = stage
+		job.progress = progress
+	}
+}
+
+// AddSegment appends to the partial transcript of a running job
+func (q *JobQueue) AddSegment(videoID string, segment models.Segment) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.active[videoID]; ok {
+		job.segments = append(job.segments, segment)
+	}
+}
+
+// Segments returns a running job's partial transcript from offset on. ok is
+// false when the video has no running job.
+func (q *JobQueue) Segments(videoID string, offset int) (segments []models.Segment, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.active[videoID]
+	if !ok {
+		return nil, false
+	}
+	if offset < 0 || offset > len(job.segments) {
+		offset = len(job.segments)
+	}
+	return append([]models.Segment(nil), job.segments[offset:]...), true
+}
+
+// AcceptingJobs reports whether Submit would currently accept new jobs
+func (q *JobQueue) AcceptingJobs() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return !q.intakePaused
+}
+
+// SetIntakePaused stops or resumes accepting new jobs. Jobs already waiting
+// are unaffected.
+func (q *JobQueue) SetIntakePaused(paused bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.intakePaused = paused
+}
+
+// SetWorkersPaused stops or resumes workers taking new jobs. Workers finish
+// the job they are currently processing.
+func (q *JobQueue) SetWorkersPaused(paused bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.workersPaused = paused
+	if !paused {
+		for i := 0; i < len(q.normal)+len(q.priority); i++ {
+			q.signal()
+		}
+	}
+}
+
+// Drain stops accepting jobs and workers taking them, then waits for
+// running jobs to finish. Jobs still running when ctx is done are
+// interrupted, and returned once their workers let go of them. Waiting jobs
+// stay in the queue.
+func (q *JobQueue) Drain(ctx context.Context) []*Job {
+	q.SetIntakePaused(true)
+	q.SetWorkersPaused(true)
+
+	if q.waitIdle(ctx) {
+		return nil
+	}
+
+	q.mu.Lock()
+	interrupted := make([]*Job, 0, len(q.active))
+	for _, job := range q.active {
+		job.interrupted = true
+		if job.cancel != nil {
+			job.cancel()
+		}
+		interrupted = append(interrupted, job)
+	}
+	q.mu.Unlock()
+
+	graceCtx, cancel := context.WithTimeout(context.Background(), interruptGrace)
+	defer cancel()
+	q.waitIdle(graceCtx)
+	return interrupted
+}
+
+// waitIdle waits until no job is running, reporting false if ctx ended
+// first
+func (q *JobQueue) waitIdle(ctx context.Context) bool {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.mu.Lock()
+		idle := len(q.active) == 0
+		q.mu.Unlock()
+		if idle {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// wasInterrupted reports whether a drain stopped a job
+func (q *JobQueue) wasInterrupted(job *Job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return job.interrupted
+}
+
+// Close stops the workers once they finish their current job
+func (q *JobQueue) Close() {
+	close(q.quit)
+	q.wg.Wait()
+}
+
+// signal wakes one idle worker. Callers must hold q.mu.
+func (q *JobQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next pops the next job, preferring the priority lane. It returns nil when
+// the queue is empty, workers are paused or the next job's memory isn't
+// free yet. Jobs keep their order, so a large job isn't starved by smaller
+// ones passing it.
+func (q *JobQueue) next() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.workersPaused {
+		return nil
+	}
+
+	lane := &q.normal
+	if len(q.priority) > 0 {
+		lane = &q.priority
+	}
+	if len(*lane) == 0 {
+		return nil
+	}
+	job := (*lane)[0]
+
+	if q.memoryLimit > 0 && q.memoryCost != nil {
+		job.memory = q.memoryCost(job)
+		if len(q.active) > 0 && q.memoryUsed+job.memory > q.memoryLimit {
+			return nil
+		}
+		q.memoryUsed += job.memory
+	}
+
+	*lane = (*lane)[1:]
+	job.StartedAt = time.Now()
+	q.active[job.Video.ID] = job
+	return job
+}
+
+// done marks a job taken by next as finished, waking a worker for a job
+// that may have been waiting for its memory
+func (q *JobQueue) done(job *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.active, job.Video.ID)
+	if job.memory > 0 {
+		q.memoryUsed -= job.memory
+		job.memory = 0
+		if len(q.normal)+len(q.priority) > 0 {
+			q.signal()
+		}
+	}
+}
+
+func (q *JobQueue) worker() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.quit:
+			return
+		case <-q.notify:
+		}
+
+		for job := q.next(); job != nil; job = q.next() {
+			q.handler(job)
+			q.done(job)
+
+			select {
+			case <-q.quit:
+				return
+			default:
+			}
+		}
+	}
+}
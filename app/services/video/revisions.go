@@ -0,0 +1,206 @@
+package video
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"time"
+	"yt-text/errors"
+	"yt-text/models"
+	"yt-text/repository"
+)
+
+// transcribedNote describes the revision recording a transcript as it was
+// transcribed, before its first correction
+const transcribedNote = "As transcribed"
+
+func (s *service) CorrectTranscript(ctx context.Context, id string, correction Correction) (*models.Revision, error) {
+	const op = "VideoService.CorrectTranscript"
+
+	if (correction.Text == nil) == (len(correction.Segments) == 0) {
+		return nil, errors.InvalidInput(op, nil, "Set either text or segments")
+	}
+
+	video, err := s.GetTranscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if video.Status != models.StatusCompleted || video.Transcription == "" {
+		return nil, errors.Conflict(op, nil, "Only completed transcriptions can be corrected")
+	}
+	if correction.UserID != "" {
+		submitted, err := s.repo.HasUserVideo(ctx, correction.UserID, id)
+		if err != nil {
+			return nil, err
+		}
+		if !submitted {
+			return nil, errors.Forbidden(op, nil, "Only users who submitted the video can correct it")
+		}
+	}
+	if correction.BaseRevision != nil && *correction.BaseRevision != video.Revision {
+		return nil, errors.Conflict(op, nil, "Transcript was corrected since that revision")
+	}
+
+	if len(correction.Segments) > 0 {
+		if len(video.Segments) == 0 {
+			return nil, errors.InvalidInput(op, nil, "Transcript has no segments; correct its text instead")
+		}
+		for _, fix := range correction.Segments {
+			if fix.Index < 0 || fix.Index >= len(video.Segments) {
+				return nil, errors.InvalidInput(op, nil, "Segment index out of range")
+			}
+		}
+	}
+
+	text, segments := applyCorrection(video, correction)
+	if text == "" {
+		return nil, errors.InvalidInput(op, nil, "Correction leaves the transcript empty")
+	}
+	if text == video.Transcription {
+		return nil, errors.InvalidInput(op, nil, "Correction doesn't change the transcript")
+	}
+
+	revisions, err := s.repo.ListRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	number := 1
+	if len(revisions) > 0 {
+		number = revisions[len(revisions)-1].Number + 1
+	}
+
+	// The transcript as transcribed becomes a revision of its own, so the
+	// first correction can be compared against it
+	var original *models.Revision
+	if video.Revision == 0 {
+		original = &models.Revision{
+			VideoID:   id,
+			Number:    number,
+			Source:    video.Source,
+			Text:      video.Transcription,
+			Segments:  video.Segments,
+			Note:      transcribedNote,
+			CreatedAt: video.UpdatedAt,
+		}
+		number++
+	}
+	now := time.Now()
+	revision := &models.Revision{
+		VideoID:   id,
+		Number:    number,
+		Source:    video.Source,
+		Text:      text,
+		Segments:  segments,
+		Note:      correction.Note,
+		UserID:    correction.UserID,
+		CreatedAt: now,
+	}
+
+	video.Transcription = text
+	video.Segments = segments
+	if s.config.Enrich {
+		video.Enrichment = enrich(text)
+	}
+	video.Revision = number
+	video.UpdatedAt = now
+
+	// The video and its revisions are saved together, so of concurrent
+	// corrections of the same revision the one losing the race stores
+	// nothing
+	saved := []*models.Revision{revision}
+	if original != nil {
+		saved = []*models.Revision{original, revision}
+	}
+	if err := s.repo.SaveCorrection(ctx, video, saved); err != nil {
+		if stderrors.Is(err, repository.ErrConflict) {
+			return nil, errors.Conflict(op, err, "Transcript was corrected by another request")
+		}
+		return nil, err
+	}
+
+	s.logger.Info().
+		Str("video_id", id).
+		Int("revision", number).
+		Str("user_id", correction.UserID).
+		Msg("Transcript corrected")
+
+	revision.Text, revision.Segments = "", nil
+	return revision, nil
+}
+
+// applyCorrection returns the text and segments of a video's transcript
+// once corrected, whose segment indexes have been checked. Corrected
+// segments lose Whisper's confidence in them, and those corrected to
+// nothing are dropped.
+func applyCorrection(video *models.Video, correction Correction) (string, []models.Segment) {
+	if correction.Text != nil {
+		text := strings.Join(strings.Fields(*correction.Text), " ")
+		return text, realign(video.Segments, text)
+	}
+
+	segments := append([]models.Segment(nil), video.Segments...)
+	for _, fix := range correction.Segments {
+		seg := &segments[fix.Index]
+		seg.Text = strings.Join(strings.Fields(fix.Text), " ")
+		seg.AvgLogprob, seg.NoSpeechProb = nil, nil
+	}
+
+	kept := segments[:0]
+	texts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if seg.Text == "" {
+			continue
+		}
+		kept = append(kept, seg)
+		texts = append(texts, seg.Text)
+	}
+	return strings.Join(texts, " "), kept
+}
+
+func (s *service) ListRevisions(ctx context.Context, id, userID string) (*models.RevisionList, error) {
+	video, err := s.userVideo(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.repo.ListRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RevisionList{
+		VideoID:   id,
+		Current:   video.Revision,
+		Revisions: revisions,
+	}, nil
+}
+
+func (s *service) CompareRevisions(ctx context.Context, id, userID string, number, against int) (*models.RevisionDiff, error) {
+	const op = "VideoService.CompareRevisions"
+
+	if against < 0 || (against > 0 && against >= number) {
+		return nil, errors.InvalidInput(op, nil, "Compare against an earlier revision")
+	}
+	if _, err := s.userVideo(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	revision, err := s.repo.FindRevision(ctx, id, number)
+	if err != nil {
+		return nil, err
+	}
+	if against == 0 {
+		against = number - 1
+	}
+
+	diff := &models.RevisionDiff{Revision: revision, Changes: []models.Change{}}
+	if against == 0 {
+		return diff, nil
+	}
+	earlier, err := s.repo.FindRevision(ctx, id, against)
+	if err != nil {
+		return nil, err
+	}
+	diff.Against = against
+	diff.Changes, diff.Inserted, diff.Deleted = changes(earlier.Text, revision.Text)
+	return diff, nil
+}
@@ -0,0 +1,50 @@
+package video
+
+import "strings"
+
+// anyLanguage is the ModelRoutes key matching every known language without
+// a route of its own
+const anyLanguage = "*"
+
+// whisperModels are the model names faster-whisper can load
+var whisperModels = map[string]bool{
+	"tiny": true, "tiny.en": true,
+	"base": true, "base.en": true,
+	"small": true, "small.en": true,
+	"medium": true, "medium.en": true,
+	"large": true, "large-v1": true, "large-v2": true, "large-v3": true,
+}
+
+// modelFor picks the Whisper model for a video's language hint. Regional
+// variants fall back to their base language ("en-GB" uses the "en" route).
+func (s *service) modelFor(language string) string {
+	settings := s.settings.Load()
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" || len(settings.ModelRoutes) == 0 {
+		return settings.DefaultModel
+	}
+
+	if model, ok := settings.ModelRoutes[language]; ok {
+		return model
+	}
+	if base, _, found := strings.Cut(language, "-"); found {
+		if model, ok := settings.ModelRoutes[base]; ok {
+			return model
+		}
+	}
+	if model, ok := settings.ModelRoutes[anyLanguage]; ok {
+		return model
+	}
+
+	// English-only models are never right for a known non-English video
+	if strings.HasSuffix(settings.DefaultModel, ".en") && !strings.HasPrefix(language, "en") {
+		return strings.TrimSuffix(settings.DefaultModel, ".en")
+	}
+	return settings.DefaultModel
+}
+
+// isEnglish reports whether a language hint is English or unknown
+func isEnglish(language string) bool {
+	language = strings.ToLower(strings.TrimSpace(language))
+	return language == "" || language == "en" || strings.HasPrefix(language, "en-")
+}
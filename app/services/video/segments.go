@@ -0,0 +1,43 @@
+package video
+
+import (
+	"yt-text/models"
+	"yt-text/scripts"
+	"yt-text/youtube"
+)
+
+// fromScriptSegments converts the segments reported by the Python scripts
+func fromScriptSegments(segments []scripts.Segment) []models.Segment {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	out := make([]models.Segment, len(segments))
+	for i, seg := range segments {
+		out[i] = models.Segment{
+			Start:        seg.Start,
+			End:          seg.End,
+			Text:         seg.Text,
+			AvgLogprob:   seg.AvgLogprob,
+			NoSpeechProb: seg.NoSpeechProb,
+		}
+	}
+	return out
+}
+
+// fromCaptionSegments converts caption lines fetched through the Data API
+func fromCaptionSegments(segments []youtube.CaptionSegment) []models.Segment {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	out := make([]models.Segment, len(segments))
+	for i, seg := range segments {
+		out[i] = models.Segment{
+			Start: seg.Start.Seconds(),
+			End:   seg.End.Seconds(),
+			Text:  seg.Text,
+		}
+	}
+	return out
+}
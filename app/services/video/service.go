@@ -2,12 +2,23 @@ package video
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 	"yt-text/errors"
+	"yt-text/events"
+	"yt-text/logger"
 	"yt-text/models"
 	"yt-text/repository"
 	"yt-text/scripts"
 	"yt-text/validation"
+	"yt-text/youtube"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -16,65 +27,202 @@ import (
 type Repository = repository.VideoRepository
 
 type service struct {
-	repo      Repository
-	scripts   *scripts.ScriptRunner
-	validator *validation.Validator
-	config    Config
-	logger    zerolog.Logger
+	repo       Repository
+	scripts    *scripts.ScriptRunner
+	validator  *validation.Validator
+	youtube    *youtube.Client
+	fetcher    *http.Client // Downloads direct audio links, refusing private addresses
+	lookups    *lookupCache
+	depths     *depthHistory // Samples of the queue's depth, for stats
+	cleanups   *cleanupCounter
+	config     Config
+	queue      *JobQueue
+	throughput *throughput
+	settings   atomic.Pointer[Settings] // Parts of config that can change while running
+	events     *events.Bus
+	owner      string // Identifies this process on the jobs it claims
+	logger     zerolog.Logger
 }
 
 func NewService(
 	repo Repository,
 	scriptRunner *scripts.ScriptRunner,
 	validator *validation.Validator,
+	youtubeClient *youtube.Client,
+	bus *events.Bus,
 	config Config,
 ) Service {
-	return &service{
-		repo:      repo,
-		scripts:   scriptRunner,
-		validator: validator,
-		config:    config,
-		logger:    zerolog.New(zerolog.NewConsoleWriter()),
+	s := &service{
+		repo:       repo,
+		scripts:    scriptRunner,
+		validator:  validator,
+		youtube:    youtubeClient,
+		fetcher:    validator.NewHTTPClient(0),
+		lookups:    newLookupCache(config.LookupCacheTTL),
+		depths:     &depthHistory{},
+		cleanups:   &cleanupCounter{},
+		events:     bus,
+		config:     config,
+		throughput: newThroughput(),
+		logger:     zerolog.New(zerolog.NewConsoleWriter()),
 	}
+	s.settings.Store(&Settings{
+		DefaultModel: config.DefaultModel,
+		ModelRoutes:  config.ModelRoutes,
+		Retention:    config.Retention,
+	})
+	s.queue = NewJobQueue(config.Workers, config.QueueSize, s.processVideo)
+	if limit := memoryLimit(config.MemoryLimitMB); limit > 0 {
+		s.queue.SetMemoryLimit(limit, s.jobMemory)
+		s.logger.Info().Int("memory_limit_mb", limit).Msg("Limiting concurrent jobs by memory")
+	}
+
+	hostname, _ := os.Hostname()
+	s.owner = hostname + "-" + uuid.New().String()[:8]
+	if config.Mode != ModeAPI {
+		go s.renewClaims(context.Background())
+		go s.sampleQueue(context.Background())
+	}
+	return s
 }
 
-func (s *service) Transcribe(ctx context.Context, url string) (*models.Video, error) {
+func (s *service) Transcribe(ctx context.Context, url string, opts TranscribeOptions) (*models.Video, error) {
+	video, err := s.transcribe(ctx, url, opts)
+	if err != nil || opts.UserID == "" {
+		return video, err
+	}
+
+	// The video is shared; failing to list it for the user doesn't fail
+	// the submission
+	if err := s.repo.AddUserVideo(ctx, opts.UserID, video.ID, time.Now()); err != nil {
+		s.logger.Error().Err(err).Str("video_id", video.ID).Str("user_id", opts.UserID).Msg("Failed to add video to the user's list")
+	}
+	return video, nil
+}
+
+func (s *service) transcribe(ctx context.Context, url string, opts TranscribeOptions) (*models.Video, error) {
 	const op = "VideoService.Transcribe"
 	logger := s.logger.With().
 		Str("operation", op).
 		Str("url", url).
+		Str("source", string(opts.Source)).
+		Str("request_id", logger.RequestID(ctx)).
 		Logger()
 	logger.Info().Msg("Starting transcription request")
 
+	opts.Source = s.sourcePolicy(opts.Source)
+	if opts.Source == SourceCaptionsOnly && (opts.Input == InputAudioURL || !youtube.IsYouTubeURL(url)) {
+		return nil, errors.InvalidInput(op, nil, "Captions are only available for YouTube videos")
+	}
+	if err := s.checkWhisperOptions(opts); err != nil {
+		return nil, err
+	}
+
+	// Links to the same video share one record
+	if opts.Input != InputAudioURL {
+		url = validation.CanonicalURL(url)
+	}
+
 	// Check for existing transcription first
-	video, err := s.repo.FindByURL(ctx, url)
+	video, err := s.findExisting(ctx, url, opts)
 	if err == nil {
 		// Handle existing video
-		if shouldProcessExisting(video, s.config.ProcessTimeout) {
-			return s.startProcessing(ctx, video)
+		if shouldProcessExisting(video, opts.Source, s.config.ProcessTimeout) {
+			lookUp, err := s.needsLookup(video)
+			if err != nil {
+				return nil, err
+			}
+			return s.startProcessing(ctx, video, opts, lookUp)
 		}
 		return video, nil
 	}
 
-	// For new videos, validate and create
-	if err := s.validateNewVideo(ctx, url); err != nil {
+	// Don't spend a validation run on a job we can't accept
+	if !s.queue.AcceptingJobs() {
+		return nil, errors.Unavailable(op, ErrIntakePaused, "Not accepting new transcriptions right now, please try again later")
+	}
+	if meter, ok := s.repo.(repository.StorageMeter); ok && meter.StorageFull() {
+		return nil, errors.Unavailable(op, ErrStorageFull, "Transcript storage is full, please try again later")
+	}
+
+	// Only checks that make no requests run here. The video is looked up
+	// by its job, unless a recent lookup of it can be reused.
+	if opts.Input == InputAudioURL {
+		err = s.validator.ValidateAudioURLSyntax(url)
+	} else {
+		err = s.validator.ValidateURL(url)
+	}
+	if err != nil {
 		return nil, err
 	}
+	found, cached := s.lookups.get(lookupKey(url, opts.Input))
+	if found.err != nil {
+		return nil, found.err
+	}
+	details := found.details
 
 	// Create new video record
 	video = &models.Video{
 		ID:        uuid.New().String(),
 		URL:       url,
+		Title:     details.Title,
+		Language:  details.Language,
+		Platform:  models.PlatformOther,
+		Uploader:  details.Uploader,
 		CreatedAt: time.Now(),
 	}
+	if opts.Input == InputAudioURL {
+		video.Platform = models.PlatformDirect
+	} else if media, ok := validation.IdentifyMedia(url); ok {
+		video.Platform = media.Platform
+		video.MediaID = media.ID
+	}
+	if details.Live {
+		retryAt := time.Now().Add(s.config.LiveRetryInterval)
+		video.RetryAt = &retryAt
+	}
 
-	return s.startProcessing(ctx, video)
+	return s.startProcessing(ctx, video, opts, !cached)
 }
 
-func shouldProcessExisting(video *models.Video, timeout time.Duration) bool {
+// findExisting looks up the video stored for a URL. A video stored before
+// URLs were made canonical is found by its platform ID instead.
+func (s *service) findExisting(ctx context.Context, url string, opts TranscribeOptions) (*models.Video, error) {
+	video, err := s.repo.FindByURL(ctx, url)
+	if err == nil || opts.Input == InputAudioURL {
+		return video, err
+	}
+	media, ok := validation.IdentifyMedia(url)
+	if !ok {
+		return nil, err
+	}
+	return s.repo.FindByMedia(ctx, media.Platform, media.ID)
+}
+
+// checkWhisperOptions rejects Whisper and caption options that are invalid
+// or don't apply to the requested source
+func (s *service) checkWhisperOptions(opts TranscribeOptions) error {
+	const op = "VideoService.checkWhisperOptions"
+
+	if err := s.validator.ValidateWhisperOptions(opts.Whisper); err != nil {
+		return err
+	}
+	if err := s.validator.ValidateCaptionOptions(opts.Captions); err != nil {
+		return err
+	}
+	if opts.Source == SourceCaptionsOnly && opts.Whisper.Task == models.TaskTranslate {
+		return errors.InvalidInput(op, nil, "Captions can't be translated, use the captions_then_whisper or whisper_only source")
+	}
+	if strings.HasSuffix(opts.Model, ".en") && (opts.Whisper.Task == models.TaskTranslate || !isEnglish(opts.Whisper.Language)) {
+		return errors.InvalidInput(op, nil, "English-only model "+opts.Model+" can only transcribe English")
+	}
+	return nil
+}
+
+func shouldProcessExisting(video *models.Video, pref SourcePreference, timeout time.Duration) bool {
 	switch video.Status {
 	case models.StatusCompleted:
-		return false
+		return !hasRequestedSource(video, pref)
 	case models.StatusProcessing:
 		return video.IsStale(timeout)
 	case models.StatusFailed:
@@ -84,48 +232,358 @@ func shouldProcessExisting(video *models.Video, timeout time.Duration) bool {
 	}
 }
 
-func (s *service) validateNewVideo(ctx context.Context, url string) error {
+// videoDetails is what validation learns about a video up front
+type videoDetails struct {
+	Title    string
+	Language string // Audio language hint, used for model routing
+	Uploader string
+	Duration time.Duration // Zero when unknown
+	Live     bool          // A live stream or upcoming premiere, to run once it has ended
+}
+
+// validateNewVideo checks that a URL can be transcribed and returns the
+// video's title and language when they are known up front
+func (s *service) validateNewVideo(ctx context.Context, url string) (videoDetails, error) {
 	const op = "VideoService.validateNewVideo"
 
 	// Basic URL validation
 	if err := s.validator.ValidateURL(url); err != nil {
 		s.logger.Info().Err(err).Msg("URL validation failed")
-		return err
+		return videoDetails{}, err
+	}
+	if err := s.validator.CheckHost(ctx, url); err != nil {
+		s.logger.Info().Err(err).Msg("URL host check failed")
+		return videoDetails{}, err
+	}
+
+	// Cheap existence check before the expensive validation script
+	details, err := s.precheck(ctx, url)
+	if err != nil {
+		return videoDetails{}, err
+	}
+	if details.Live {
+		return s.liveVideo(op, url, details)
 	}
 
 	// Validate video metadata
-	info, err := s.scripts.Validate(ctx, url)
+	var info scripts.VideoInfo
+	err = s.withYouTubeThrottle(ctx, url, func() (err error) {
+		info, err = s.scripts.Validate(ctx, url)
+		return err
+	})
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Video validation script failed")
-		return errors.InvalidInput(op, err, "Failed to validate video")
+		if stderrors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return videoDetails{}, errors.Unavailable(op, err, "Looking up the video took too long, please try again later")
+		}
+		code, message := classifyFailure(err)
+		if code == models.ErrorLiveOnly {
+			return s.liveVideo(op, url, details)
+		}
+		if code != models.ErrorUnknown {
+			return videoDetails{}, errors.InvalidInput(op, err, message)
+		}
+		return videoDetails{}, errors.InvalidInput(op, err, "Failed to validate video")
 	}
 
+	if isLiveInfo(info) {
+		return s.liveVideo(op, url, details)
+	}
 	if !info.Valid {
 		s.logger.Info().Str("error", info.Error).Msg("Video validation failed")
-		return errors.InvalidInput(op, nil, info.Error)
+		if code := classifyMessage(info.Error); code != models.ErrorUnknown {
+			return videoDetails{}, errors.InvalidInput(op, nil, failureMessages[code])
+		}
+		return videoDetails{}, errors.InvalidInput(op, nil, info.Error)
+	}
+	if err := s.checkLimits(url, info); err != nil {
+		return videoDetails{}, err
+	}
+
+	if details.Language == "" {
+		details.Language = info.Language
+	}
+	if details.Uploader == "" {
+		details.Uploader = info.Uploader
+	}
+	if details.Duration == 0 {
+		details.Duration = secondsDuration(info.Duration)
+	}
+	return details, nil
+}
+
+// checkLimits rejects videos known to exceed the duration or download size
+// limits before a job is queued. Limits that can't be checked up front are
+// enforced during processing instead.
+func (s *service) checkLimits(url string, info scripts.VideoInfo) error {
+	const op = "VideoService.checkLimits"
+
+	duration := secondsDuration(info.Duration)
+	switch {
+	case s.config.MaxDuration <= 0:
+	case info.Duration <= 0:
+		s.logger.Warn().Str("url", url).Msg("Video duration unknown, not checked against the limit")
+	case duration > s.config.MaxDuration:
+		return errors.InvalidInput(op, nil, fmt.Sprintf(
+			"Video is too long: %s (max %s)", duration.Round(time.Second), s.config.MaxDuration))
 	}
 
+	switch {
+	case s.config.MaxFileSize <= 0:
+	case info.FileSize <= 0:
+		s.logger.Debug().Str("url", url).Msg("Audio size unknown, not checked against the limit")
+	case info.FileSize > s.config.MaxFileSize:
+		return errors.InvalidInput(op, nil, fmt.Sprintf(
+			"Audio is too large: %d MB (max %d MB)", info.FileSize>>20, s.config.MaxFileSize>>20))
+	}
 	return nil
 }
 
-func (s *service) startProcessing(ctx context.Context, video *models.Video) (*models.Video, error) {
+// precheck asks YouTube's oEmbed endpoint whether a video exists. Only a
+// definite "not found" rejects the URL; any other problem (restricted
+// embeds, network errors) falls through to the full validation script.
+func (s *service) precheck(ctx context.Context, url string) (videoDetails, error) {
+	const op = "VideoService.precheck"
+
+	if !s.config.OEmbedPrecheck || s.youtube == nil || !youtube.IsYouTubeURL(url) {
+		return videoDetails{}, nil
+	}
+
+	// The Data API is more reliable when a key is configured; its responses
+	// are cached, and the caption path uses the same metadata later
+	if videoID, ok := validation.YouTubeID(url); ok && s.youtube.HasAPIKey() {
+		meta, err := s.youtube.Metadata(ctx, videoID)
+		switch {
+		case err == nil:
+			return videoDetails{
+				Title:    meta.Title,
+				Language: meta.Language,
+				Uploader: meta.ChannelTitle,
+				Duration: meta.Duration,
+				Live:     meta.LiveBroadcastContent == "live" || meta.LiveBroadcastContent == "upcoming",
+			}, nil
+		case stderrors.Is(err, youtube.ErrVideoNotFound):
+			s.logger.Info().Str("url", url).Msg("Metadata precheck: video not found")
+			return videoDetails{}, errors.InvalidInput(op, err, failureMessages[models.ErrorVideoUnavailable])
+		default:
+			s.logger.Debug().Err(err).Str("url", url).Msg("Metadata precheck failed, trying oEmbed")
+		}
+	}
+
+	info, err := s.youtube.OEmbed(ctx, url)
+	switch {
+	case err == nil:
+		return videoDetails{Title: info.Title, Uploader: info.AuthorName}, nil
+	case stderrors.Is(err, youtube.ErrVideoNotFound):
+		s.logger.Info().Str("url", url).Msg("oEmbed precheck: video not found")
+		return videoDetails{}, errors.InvalidInput(op, err, failureMessages[models.ErrorVideoUnavailable])
+	default:
+		s.logger.Debug().Err(err).Str("url", url).Msg("oEmbed precheck inconclusive")
+		return videoDetails{}, nil
+	}
+}
+
+// hasRequestedSource reports whether a completed video already satisfies a
+// source preference. A video known to have no captions is not retried for
+// captions_only.
+func hasRequestedSource(video *models.Video, pref SourcePreference) bool {
+	switch pref {
+	case SourceCaptionsOnly:
+		_, ok := video.TranscriptionFrom(models.SourceCaptions)
+		return ok || video.ErrorCode == models.ErrorNoCaptions
+	case SourceWhisperOnly:
+		_, ok := video.TranscriptionFrom(models.SourceWhisper)
+		return ok
+	default:
+		return true
+	}
+}
+
+// startProcessing queues a job for a video. lookUp has the job look the
+// video up first.
+func (s *service) startProcessing(ctx context.Context, video *models.Video, opts TranscribeOptions, lookUp bool) (*models.Video, error) {
 	const op = "VideoService.startProcessing"
 
+	if !s.queue.AcceptingJobs() {
+		return nil, errors.Unavailable(op, ErrIntakePaused, "Not accepting new transcriptions right now, please try again later")
+	}
+
 	// Update status and timestamp
 	video.Status = models.StatusProcessing
 	video.UpdatedAt = time.Now()
 	video.Error = "" // Clear any previous error
+	video.ErrorCode = ""
+	video.FailureLog = ""
 
 	if err := s.repo.Save(ctx, video); err != nil {
-		return nil, errors.Internal(op, err, "Failed to save video")
+		return nil, saveError(op, err, "Failed to save video")
+	}
+	s.recordEvent(ctx, video.ID, models.JobQueued, "")
+
+	// Hand off to the worker pool, recording the job first so a restart
+	// can't lose it. In API mode the stored job is all there is, left for a
+	// worker process to claim.
+	job := &Job{
+		Video:     video,
+		Source:    opts.Source,
+		Model:     opts.Model,
+		Whisper:   opts.Whisper,
+		Captions:  opts.Captions,
+		RequestID: logger.RequestID(ctx),
+		QueuedAt:  time.Now(),
+		LookUp:    lookUp,
+	}
+	if video.RetryAt != nil && video.RetryAt.After(time.Now()) {
+		if err := s.scheduleLive(ctx, job); err != nil {
+			return nil, s.rejectJob(ctx, video, err, "Failed to schedule transcription, please try again later")
+		}
+		return video, nil
+	}
+
+	stored := queuedJob(job)
+	if s.config.Mode != ModeAPI {
+		stored.ClaimedBy = s.owner
+		stored.ClaimedAt = time.Now()
+	}
+	err := s.repo.SaveJob(ctx, stored)
+	switch {
+	case err != nil && s.config.Mode == ModeAPI:
+		return nil, s.rejectJob(ctx, video, err, "Failed to queue transcription, please try again later")
+	case err != nil:
+		s.logger.Error().Err(err).Str("video_id", video.ID).Msg("Failed to persist job")
+	case s.config.Mode == ModeAPI:
+		return video, nil
 	}
 
-	// Start processing in background
-	go s.processVideo(video)
+	if err := s.queue.Submit(job); err != nil {
+		s.forgetJob(ctx, video.ID)
+
+		if stderrors.Is(err, ErrQueueFull) {
+			return nil, s.rejectFullQueue(ctx, video)
+		}
+		return nil, s.rejectJob(ctx, video, err, "Not accepting new transcriptions right now, please try again later")
+	}
 
 	return video, nil
 }
 
+// saveError reports a failed save. One that lost to another request
+// answers 409, so the client can read the video again and retry.
+func saveError(op string, err error, message string) error {
+	if stderrors.Is(err, repository.ErrConflict) {
+		return errors.Conflict(op, err, "Video was changed by another request, please try again")
+	}
+	return errors.Internal(op, err, message)
+}
+
+// rejectFullQueue fails a video whose job found the queue full and returns
+// a 429 telling the submitter when a slot is likely to free up
+func (s *service) rejectFullQueue(ctx context.Context, video *models.Video) error {
+	const op = "VideoService.startProcessing"
+
+	message := "Transcription queue is full, please try again later"
+	video.Status = models.StatusFailed
+	video.Error = failureMessages[models.ErrorQueueFull]
+	video.ErrorCode = models.ErrorQueueFull
+	video.UpdatedAt = time.Now()
+	if err := s.repo.Save(ctx, video); err != nil {
+		s.logger.Error().Err(err).Str("video_id", video.ID).Msg("Failed to save rejected video")
+	}
+	s.recordEvent(ctx, video.ID, models.JobFailed, string(video.ErrorCode))
+
+	err := errors.TooManyRequests(op, ErrQueueFull, message)
+	err.Details = map[string]models.ErrorCode{"error_code": models.ErrorQueueFull}
+	err.RetryAfter = s.queueRetryAfter()
+	err.Limit = s.queue.Status().Capacity
+	return err
+}
+
+// rejectJob fails a video whose job couldn't be queued, so clients don't
+// wait for it, and returns the error for the submitter
+func (s *service) rejectJob(ctx context.Context, video *models.Video, err error, message string) error {
+	const op = "VideoService.startProcessing"
+
+	video.Status = models.StatusFailed
+	video.Error = message
+	video.ErrorCode = models.ErrorServiceUnavailable
+	video.UpdatedAt = time.Now()
+	if saveErr := s.repo.Save(ctx, video); saveErr != nil {
+		s.logger.Error().Err(saveErr).Str("video_id", video.ID).Msg("Failed to save rejected video")
+	}
+	s.recordEvent(ctx, video.ID, models.JobFailed, string(video.ErrorCode))
+	return errors.Unavailable(op, err, message)
+}
+
+// RetryTranscription resubmits a failed video. Unlike submitting its URL
+// again, it can change the source preference and Whisper model.
+func (s *service) RetryTranscription(ctx context.Context, id string, opts TranscribeOptions) (*models.Video, error) {
+	const op = "VideoService.RetryTranscription"
+
+	if id == "" {
+		return nil, errors.InvalidInput(op, nil, "ID is required")
+	}
+	if opts.Model != "" && !whisperModels[opts.Model] {
+		return nil, errors.InvalidInput(op, nil, "Unknown Whisper model "+opts.Model)
+	}
+	opts.Source = s.sourcePolicy(opts.Source)
+	if opts.Model != "" && opts.Source == SourceCaptionsOnly {
+		return nil, errors.InvalidInput(op, nil, "A model can't be chosen for captions_only")
+	}
+	if err := s.checkWhisperOptions(opts); err != nil {
+		return nil, err
+	}
+
+	video, err := s.repo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if video.Status != models.StatusFailed {
+		return nil, errors.Conflict(op, nil, "Only failed transcriptions can be retried")
+	}
+	if opts.Source == SourceCaptionsOnly && !youtube.IsYouTubeURL(video.URL) {
+		return nil, errors.InvalidInput(op, nil, "Captions are only available for YouTube videos")
+	}
+
+	s.logger.Info().
+		Str("video_id", id).
+		Str("source", string(opts.Source)).
+		Str("model", opts.Model).
+		Msg("Retrying failed transcription")
+	lookUp, err := s.needsLookup(video)
+	if err != nil {
+		return nil, err
+	}
+	return s.startProcessing(ctx, video, opts, lookUp)
+}
+
+func (s *service) PrioritizeJob(ctx context.Context, id string) (int, error) {
+	const op = "VideoService.PrioritizeJob"
+
+	if id == "" {
+		return 0, errors.InvalidInput(op, nil, "ID is required")
+	}
+
+	position, err := s.queue.Prioritize(id)
+	if stderrors.Is(err, ErrJobNotQueued) {
+		return 0, errors.NotFound(op, err, "Job is not waiting in the queue")
+	}
+	if err != nil {
+		return 0, errors.Internal(op, err, "Failed to prioritize job")
+	}
+
+	if job := s.queue.find(id); job != nil {
+		stored := queuedJob(job)
+		stored.Priority = true
+		if err := s.repo.SaveJob(ctx, stored); err != nil {
+			s.logger.Error().Err(err).Str("video_id", id).Msg("Failed to persist job priority")
+		}
+	}
+	s.publishProgress(id)
+
+	s.logger.Info().Str("video_id", id).Int("position", position).Msg("Job moved to priority lane")
+	return position, nil
+}
+
 func (s *service) GetTranscription(ctx context.Context, id string) (*models.Video, error) {
 	const op = "VideoService.GetTranscription"
 
@@ -141,31 +599,357 @@ func (s *service) GetTranscription(ctx context.Context, id string) (*models.Vide
 	return video, nil
 }
 
-func (s *service) processVideo(video *models.Video) {
-	logger := s.logger.With().Str("video_id", video.ID).Logger()
-	ctx, cancel := context.WithTimeout(context.Background(), s.config.ProcessTimeout)
+// userVideo retrieves a video for a signed-in user, who only has access to
+// the videos they submitted; others are reported as not found. An empty
+// userID, without accounts, has access to every video.
+func (s *service) userVideo(ctx context.Context, id, userID string) (*models.Video, error) {
+	const op = "VideoService.userVideo"
+
+	video, err := s.GetTranscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUserVideo(ctx, op, id, userID); err != nil {
+		return nil, err
+	}
+	return video, nil
+}
+
+// checkUserVideo is userVideo for callers that don't need the video
+func (s *service) checkUserVideo(ctx context.Context, op, id, userID string) error {
+	if userID == "" {
+		return nil
+	}
+	submitted, err := s.repo.HasUserVideo(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !submitted {
+		return errors.NotFound(op, nil, "Transcription not found")
+	}
+	return nil
+}
+
+func (s *service) GetUserTranscription(ctx context.Context, id, userID string) (*models.Video, error) {
+	return s.userVideo(ctx, id, userID)
+}
+
+func (s *service) ListTranscriptions(ctx context.Context, opts ListOptions) ([]*models.Video, int, error) {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.PageSize < 1 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.PageSize > MaxPageSize {
+		opts.PageSize = MaxPageSize
+	}
+
+	return s.repo.List(ctx, repository.VideoFilter{
+		Status:   opts.Status,
+		Language: opts.Language,
+		Limit:    opts.PageSize,
+		Offset:   (opts.Page - 1) * opts.PageSize,
+		UserID:   opts.UserID,
+		Keyword:  opts.Keyword,
+		Entity:   opts.Entity,
+	})
+}
+
+func (s *service) DeleteTranscription(ctx context.Context, id, userID string) error {
+	const op = "VideoService.DeleteTranscription"
+
+	video, err := s.userVideo(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	// The worker would write the row back when it finishes
+	processing := video.IsProcessing() && !video.IsStale(s.config.ProcessTimeout)
+
+	// Videos are shared between the users who submitted them, so a user
+	// deleting one only takes it off their list while others have it or
+	// it can't be removed; retention cleanup deals with it then
+	if userID != "" {
+		shared, err := s.repo.RemoveUserVideo(ctx, userID, id)
+		if err != nil {
+			return err
+		}
+		if shared || processing || video.Pinned {
+			s.logger.Info().Str("video_id", id).Str("user_id", userID).Msg("Transcription removed from the user's list")
+			return nil
+		}
+	}
+
+	if processing {
+		return errors.Conflict(op, nil, "Transcription is still processing")
+	}
+	if video.Pinned {
+		return errors.Conflict(op, nil, "Transcription is pinned; unpin it before deleting")
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info().Str("video_id", id).Msg("Transcription deleted")
+	return nil
+}
+
+func (s *service) Progress(ctx context.Context, id string) (*models.ProgressUpdate, error) {
+	video, err := s.GetTranscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &models.ProgressUpdate{
+		ID:        video.ID,
+		Status:    video.Status,
+		Error:     video.Error,
+		ErrorCode: video.ErrorCode,
+	}
+	if !video.IsProcessing() {
+		update.Stage = models.StageDone
+		update.Progress = 1
+		return update, nil
+	}
+
+	if state, ok := s.queue.JobState(video.ID); ok {
+		update.Stage = state.Stage
+		update.QueuePosition = state.Position
+		update.Progress = state.Progress
+		update.Segments = state.Segments
+	} else if video.RetryAt != nil {
+		update.Stage = models.StageScheduled
+	}
+	return update, nil
+}
+
+func (s *service) PartialTranscript(ctx context.Context, id string, offset int) ([]models.Segment, error) {
+	const op = "VideoService.PartialTranscript"
+
+	if id == "" {
+		return nil, errors.InvalidInput(op, nil, "ID is required")
+	}
+
+	segments, ok := s.queue.Segments(id, offset)
+	if !ok {
+		return nil, errors.NotFound(op, ErrNoJob, "Video has no running job")
+	}
+	return segments, nil
+}
+
+func (s *service) TranscriptionText(ctx context.Context, id string, source models.Source) (io.Reader, error) {
+	const op = "VideoService.TranscriptionText"
+
+	if id == "" {
+		return nil, errors.InvalidInput(op, nil, "ID is required")
+	}
+
+	return s.repo.TranscriptionReader(ctx, id, source)
+}
+
+func (s *service) EncodedTranscriptionText(ctx context.Context, id, userID string, source models.Source, accepted []string) (io.Reader, string, error) {
+	const op = "VideoService.EncodedTranscriptionText"
+
+	if id == "" {
+		return nil, "", errors.InvalidInput(op, nil, "ID is required")
+	}
+	if err := s.checkUserVideo(ctx, op, id, userID); err != nil {
+		return nil, "", err
+	}
+
+	if encoded, ok := s.repo.(repository.EncodedTranscriptReader); ok && len(accepted) > 0 {
+		return encoded.EncodedTranscriptionReader(ctx, id, source, accepted)
+	}
+	text, err := s.repo.TranscriptionReader(ctx, id, source)
+	return text, "", err
+}
+
+func (s *service) QueueStatus(ctx context.Context) QueueStatus {
+	return s.queue.Status()
+}
+
+func (s *service) Stats(ctx context.Context) (*Stats, error) {
+	const op = "VideoService.Stats"
+
+	stats := &Stats{Queue: s.queue.Status()}
+	if s.youtube != nil {
+		stats.YouTubeQuota = s.youtube.QuotaStatus()
+	}
+
+	size, err := s.repo.DatabaseSize(ctx)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to load storage usage")
+	}
+	stats.Storage.DatabaseBytes = size
+
+	totals, err := s.repo.VideoStats(ctx)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to load video totals")
+	}
+	stats.Totals = totals
+	stats.Storage.TranscriptBytes = totals.TranscriptBytes
+	stats.QueueHistory = s.depths.list()
+	stats.Cleanup = s.cleanups.snapshot()
+	if meter, ok := s.repo.(repository.StorageMeter); ok {
+		if used, limit, ok := meter.StorageUsage(); ok {
+			stats.Storage.FileBytes = &used
+			stats.Storage.FileLimitBytes = limit
+			stats.Storage.FileStorageFull = meter.StorageFull()
+		}
+	}
+
+	if s.config.CompareCaptions {
+		quality, err := s.repo.CaptionQuality(ctx, s.config.CaptionWERThreshold)
+		if err != nil {
+			return nil, errors.Internal(op, err, "Failed to load caption quality")
+		}
+		stats.CaptionQuality = quality
+	}
+
+	return stats, nil
+}
+
+func (s *service) ActiveJobs(ctx context.Context) []ActiveJob {
+	return s.queue.ActiveJobs()
+}
+
+func (s *service) RecentFailures(ctx context.Context, limit int) ([]*models.Video, error) {
+	const op = "VideoService.RecentFailures"
+
+	videos, err := s.repo.FindRecentByStatus(ctx, models.StatusFailed, limit)
+	if err != nil {
+		return nil, errors.Internal(op, err, "Failed to load recent failures")
+	}
+	return videos, nil
+}
+
+func (s *service) PauseQueue(ctx context.Context, scope PauseScope) error {
+	return s.setQueuePaused(scope, true)
+}
+
+func (s *service) ResumeQueue(ctx context.Context, scope PauseScope) error {
+	return s.setQueuePaused(scope, false)
+}
+
+func (s *service) setQueuePaused(scope PauseScope, paused bool) error {
+	const op = "VideoService.setQueuePaused"
+
+	switch scope {
+	case PauseIntake:
+		s.queue.SetIntakePaused(paused)
+	case PauseWorkers:
+		s.queue.SetWorkersPaused(paused)
+	case PauseAll:
+		s.queue.SetIntakePaused(paused)
+		s.queue.SetWorkersPaused(paused)
+	default:
+		return errors.InvalidInput(op, nil, "Scope must be one of: intake, workers, all")
+	}
+
+	s.logger.Info().Str("scope", string(scope)).Bool("paused", paused).Msg("Queue state changed")
+	return nil
+}
+
+func (s *service) UpdateSettings(settings Settings) {
+	s.settings.Store(&settings)
+	s.logger.Info().
+		Str("default_model", settings.DefaultModel).
+		Interface("model_routes", settings.ModelRoutes).
+		Dur("retention", settings.Retention).
+		Msg("Settings updated")
+}
+
+func (s *service) processVideo(job *Job) {
+	video := job.Video
+	logger := s.logger.With().
+		Str("video_id", video.ID).
+		Str("request_id", job.RequestID).
+		Logger()
+	ctx, cancel := context.WithTimeout(jobContext(job), s.config.ProcessTimeout)
 	defer cancel()
+	s.queue.bindCancel(job, cancel)
+	timer := newStageTimer()
+	ctx = withStageTimer(ctx, timer)
+	ctx = scripts.WithProgress(ctx, func(p scripts.Progress) {
+		if stage, ok := scriptStages[models.Stage(p.Stage)]; ok {
+			timer.enter(stage)
+		}
+		if p.Segment != nil {
+			seg := p.Segment
+			s.queue.AddSegment(video.ID, models.Segment{Start: seg.Start, End: seg.End, Text: seg.Text})
+		} else {
+			stage, progress := overallProgress(p)
+			s.queue.ReportProgress(video.ID, stage, progress)
+		}
+		s.publishProgress(video.ID)
+	})
 
-	logger.Info().Msg("Starting transcription process")
+	if job.LookUp {
+		timer.enter(stageLookup)
+		if !s.lookUpJob(ctx, job, logger) {
+			return
+		}
+		timer.stop()
+	}
 
-	// Set up transcription options
-	opts := map[string]string{
-		"model": s.config.DefaultModel,
+	// A video scheduled while live runs once it has a recording
+	if video.RetryAt != nil {
+		if s.stillLive(ctx, video) {
+			logger.Info().Msg("Video is still live")
+			s.rescheduleLive(jobContext(job), job)
+			return
+		}
+		video.RetryAt = nil
 	}
 
-	// Perform transcription
-	result, err := s.scripts.Transcribe(ctx, video.URL, opts, true)
+	logger.Info().Msg("Starting transcription process")
+	s.recordEvent(ctx, video.ID, models.JobStarted, "")
+
+	opts := TranscribeOptions{Source: job.Source, Model: job.Model, Whisper: job.Whisper, Captions: job.Captions}
+	started := time.Now()
+	result, err := s.doProcessVideo(ctx, video, opts, logger)
+	timer.enter(stagePostProcessing)
+	if err == nil {
+		s.throughput.recordJob(time.Since(started))
+	}
+	if err != nil && s.queue.wasInterrupted(job) {
+		// Leave the video processing and its job stored, to be run again
+		// after the restart
+		logger.Warn().Msg("Transcription interrupted by shutdown")
+		s.recordEvent(jobContext(job), video.ID, models.JobInterrupted, "")
+		return
+	}
 	if err != nil {
 		logger.Error().Err(err).Msg("Transcription failed")
-		video.Status = models.StatusFailed
-		video.Error = err.Error()
+
+		// A failed run for an additional source leaves the transcript
+		// that was already stored usable, so the video stays completed
+		// and the failure is only kept in its job history
+		if video.Transcription != "" {
+			video.Status = models.StatusCompleted
+		} else {
+			video.Status = models.StatusFailed
+			video.ErrorCode, video.Error = classifyFailure(err)
+			video.FailureLog = failureLog(err)
+		}
 	} else {
-		logger.Info().Msg("Transcription completed successfully")
+		logger.Info().Str("source", string(result.Source)).Msg("Transcription completed successfully")
+		storeTranscript(video, result)
+		video.Chapters = chaptersFor(result)
+		video.Enrichment = nil
+		if s.config.Enrich {
+			video.Enrichment = enrich(video.Transcription)
+		}
 		video.Status = models.StatusCompleted
-		video.Transcription = result.Text
-		if result.Title != nil {
-			video.Title = *result.Title
-		} else {
+		if result.CaptionWER != nil {
+			video.CaptionWER = result.CaptionWER
+			video.CaptionComparison = result.Comparison
+		}
+		if result.Title != "" {
+			video.Title = result.Title
+		} else if video.Title == "" {
 			video.Title = video.URL
 		}
 
@@ -178,10 +962,22 @@ func (s *service) processVideo(video *models.Video) {
 	}
 
 	video.UpdatedAt = time.Now()
+	video.Timings = timer.timings()
 
-	// Update video record
-	if err := s.repo.Save(ctx, video); err != nil {
-		logger.Error().Err(err).Msg("Failed to save transcription result")
+	// Update video record. The run's context may have timed out or been
+	// cancelled, so the result is saved without it.
+	saveCtx := jobContext(job)
+	saveErr := s.repo.Save(saveCtx, video)
+	if stderrors.Is(saveErr, repository.ErrConflict) {
+		// The video was cancelled, restarted or finished elsewhere while this
+		// run went on. That change stands, along with any job it stored.
+		logger.Warn().Msg("Video changed while the job ran, discarding the result")
+		return
+	}
+	s.forgetJob(saveCtx, video.ID)
+	s.recordResult(saveCtx, video.ID, err)
+	if saveErr != nil {
+		logger.Error().Err(saveErr).Msg("Failed to save transcription result")
 	} else {
 		// Add debug logging after save
 		logger.Info().
@@ -192,3 +988,224 @@ func (s *service) processVideo(video *models.Video) {
 			Msg("Saved video with transcription")
 	}
 }
+
+// transcript is the outcome of a successful pipeline run
+type transcript struct {
+	Text       string
+	Title      string
+	Source     models.Source
+	Model      string                    // Whisper model, for transcripts from Whisper
+	Segments   []models.Segment          // Timing, when the source provided it
+	Chapters   []models.Chapter          // Chapters the uploader marked, if the source reported them
+	CaptionWER *float64                  // Set when captions were compared against Whisper
+	Comparison *models.CaptionComparison // Details of that comparison
+
+	// Language and kind ("manual" or "auto") of the caption track, for
+	// transcripts from captions
+	CaptionLanguage string
+	CaptionKind     string
+
+	// Secondary is a transcript from the other source produced by the same
+	// run, kept alongside the primary one
+	Secondary *transcript
+}
+
+// storeTranscript makes result the video's primary transcript. A transcript
+// from the other source, either produced by this run or stored by an earlier
+// one, is kept as the secondary transcript.
+func storeTranscript(video *models.Video, result *transcript) {
+	switch {
+	case result.Secondary != nil:
+		video.SecondaryTranscription = result.Secondary.Text
+		video.SecondarySource = result.Secondary.Source
+		video.SecondarySegments = result.Secondary.Segments
+	case video.Transcription != "" && video.Source != result.Source:
+		video.SecondaryTranscription = video.Transcription
+		video.SecondarySource = video.Source
+		video.SecondarySegments = video.Segments
+	}
+
+	video.Transcription = result.Text
+	video.Source = result.Source
+	video.Segments = result.Segments
+	video.Revision = 0 // Corrections were made to the transcript replaced
+
+	switch {
+	case result.Source == models.SourceWhisper:
+		video.Model = result.Model
+	case result.Secondary != nil && result.Secondary.Source == models.SourceWhisper:
+		video.Model = result.Secondary.Model
+	case video.SecondarySource != models.SourceWhisper:
+		video.Model = ""
+	}
+
+	// The caption track is described for whichever transcript came from
+	// captions, and forgotten once neither does
+	switch {
+	case result.Source == models.SourceCaptions:
+		video.CaptionLanguage, video.CaptionKind = result.CaptionLanguage, result.CaptionKind
+	case result.Secondary != nil && result.Secondary.Source == models.SourceCaptions:
+		video.CaptionLanguage, video.CaptionKind = result.Secondary.CaptionLanguage, result.Secondary.CaptionKind
+	case video.SecondarySource != models.SourceCaptions:
+		video.CaptionLanguage, video.CaptionKind = "", ""
+	}
+}
+
+// sourcePolicy resolves SourceAuto to the instance's source policy. Other
+// preferences are returned as they are.
+func (s *service) sourcePolicy(pref SourcePreference) SourcePreference {
+	if pref != "" && pref != SourceAuto {
+		return pref
+	}
+	if s.config.SourcePolicy != "" && s.config.SourcePolicy != SourceAuto {
+		return s.config.SourcePolicy
+	}
+	return SourceCaptionsThenWhisper
+}
+
+// doProcessVideo produces a transcript for a video following the source
+// policy. By default it uses official YouTube captions when they are
+// available and falls back to Whisper; the policy can restrict it to either
+// one, or run Whisper when the captions look poor. Translations and direct
+// audio files always go to Whisper.
+func (s *service) doProcessVideo(
+	ctx context.Context,
+	video *models.Video,
+	opts TranscribeOptions,
+	logger zerolog.Logger,
+) (*transcript, error) {
+	policy := s.sourcePolicy(opts.Source)
+	switch {
+	case policy == SourceWhisperOnly, opts.Whisper.Task == models.TaskTranslate,
+		video.Platform == models.PlatformDirect:
+		return s.transcribeWithWhisper(ctx, video, opts)
+	case policy == SourceCaptionsOnly:
+		return s.fetchYouTubeCaptions(ctx, video, opts.Captions)
+	}
+
+	result, err := s.fetchYouTubeCaptions(ctx, video, opts.Captions)
+	switch {
+	case err == nil && policy == SourceWhisperIfCaptionsPoor:
+		if reason := poorCaptions(result); reason != "" {
+			logger.Info().Str("reason", reason).Msg("Captions look poor, running Whisper")
+			return s.compareWithWhisper(ctx, video, opts, result, s.comparePick(true), logger), nil
+		}
+		return result, nil
+	case err == nil && s.config.CompareCaptions:
+		return s.compareWithWhisper(ctx, video, opts, result, s.comparePick(false), logger), nil
+	case err == nil:
+		return result, nil
+	case stderrors.Is(err, youtube.ErrQuotaExceeded):
+		logger.Warn().Msg("YouTube API quota exhausted, using Whisper only")
+	case stderrors.Is(err, youtube.ErrNoCaptions):
+		logger.Debug().Msg("No captions available, using Whisper")
+	default:
+		logger.Warn().Err(err).Msg("Caption fetch failed, falling back to Whisper")
+	}
+
+	return s.transcribeWithWhisper(ctx, video, opts)
+}
+
+// transcribeWithWhisper runs the transcription script with the requested
+// model, or with the model routed to the language when none was requested.
+// The requested language takes precedence over the video's language hint.
+func (s *service) transcribeWithWhisper(ctx context.Context, video *models.Video, opts TranscribeOptions) (*transcript, error) {
+	language, model := s.whisperModel(video.Language, opts)
+	s.logger.Debug().
+		Str("video_id", video.ID).
+		Str("language", language).
+		Str("model", model).
+		Msg("Selected Whisper model")
+	s.recordEvent(ctx, video.ID, models.JobWhisperStart, model)
+	// Until the script reports the transcribing stage
+	enterStage(ctx, stageDownload)
+
+	var result scripts.TranscriptionResult
+	var err error
+	started := time.Now()
+	if video.Platform == models.PlatformDirect {
+		result, err = s.transcribeAudioURL(ctx, video.URL, scriptOptions(model, opts.Whisper))
+	} else {
+		err = s.withYouTubeThrottle(ctx, video.URL, func() (err error) {
+			result, err = s.scripts.Transcribe(ctx, video.URL, scriptOptions(model, opts.Whisper), true)
+			return err
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n := len(result.Segments); n > 0 {
+		s.throughput.record(model, secondsDuration(result.Segments[n-1].End), time.Since(started))
+	}
+
+	t := &transcript{
+		Text:     result.Text,
+		Source:   models.SourceWhisper,
+		Model:    model,
+		Segments: fromScriptSegments(result.Segments),
+		Chapters: fromScriptChapters(result.Chapters),
+	}
+	if result.Title != nil {
+		t.Title = *result.Title
+	}
+	return t, nil
+}
+
+// whisperModel returns the language a Whisper run is for and the model it
+// uses: the requested one, or the one routed to the language
+func (s *service) whisperModel(videoLanguage string, opts TranscribeOptions) (string, string) {
+	language := opts.Whisper.Language
+	if language == "" {
+		language = videoLanguage
+	}
+
+	model := opts.Model
+	if model == "" {
+		model = s.modelFor(language)
+		// Routes may pick an English-only model, which can't translate
+		if opts.Whisper.Task == models.TaskTranslate {
+			model = strings.TrimSuffix(model, ".en")
+		}
+	}
+	return language, model
+}
+
+// scriptOptions builds the transcription script's arguments. Unset options
+// are left out so the script's defaults apply.
+func scriptOptions(model string, whisper models.WhisperOptions) map[string]string {
+	opts := map[string]string{
+		"model": model,
+	}
+	if whisper.Language != "" {
+		// Whisper has no regional variants
+		base, _, _ := strings.Cut(whisper.Language, "-")
+		opts["language"] = strings.ToLower(base)
+	}
+	if whisper.Task != "" {
+		opts["task"] = string(whisper.Task)
+	}
+	if whisper.Temperature != nil {
+		opts["temperature"] = strconv.FormatFloat(*whisper.Temperature, 'f', -1, 64)
+	}
+	if whisper.BeamSize > 0 {
+		opts["beam_size"] = strconv.Itoa(whisper.BeamSize)
+	}
+	return opts
+}
+
+// jobContext returns a background context carrying the job's correlation IDs
+func jobContext(job *Job) context.Context {
+	ctx := logger.WithJobID(context.Background(), job.Video.ID)
+	if job.RequestID != "" {
+		ctx = logger.WithRequestID(ctx, job.RequestID)
+	}
+	return ctx
+}
+
+// failureLog combines the raw error with the backend output captured for it
+func failureLog(err error) string {
+	if tail := scripts.OutputTail(err); tail != "" {
+		return err.Error() + "\n\n" + tail
+	}
+	return err.Error()
+}
@@ -0,0 +1,38 @@
+package video
+
+import (
+	"context"
+	"strings"
+	"yt-text/scripts"
+	"yt-text/youtube"
+)
+
+// withYouTubeThrottle runs a script that talks to YouTube (validation,
+// captions, downloads) under the YouTube client's shared rate limit, so a
+// large batch spread over several workers can't hammer YouTube at once.
+// A rate-limited failure pauses all outbound YouTube traffic.
+func (s *service) withYouTubeThrottle(ctx context.Context, url string, run func() error) error {
+	if s.youtube == nil || !youtube.IsYouTubeURL(url) {
+		return run()
+	}
+
+	if err := s.youtube.Wait(ctx); err != nil {
+		return err
+	}
+
+	err := run()
+	if err != nil && isRateLimited(err) {
+		pause := s.youtube.ReportRateLimited()
+		s.logger.Warn().Dur("pause", pause).Msg("YouTube rate limited a script, pausing outbound requests")
+	} else if err == nil {
+		s.youtube.ReportSuccess()
+	}
+	return err
+}
+
+// isRateLimited reports whether a script failed because YouTube answered
+// HTTP 429
+func isRateLimited(err error) bool {
+	text := strings.ToLower(err.Error() + "\n" + scripts.OutputTail(err))
+	return strings.Contains(text, "http error 429") || strings.Contains(text, "too many requests")
+}
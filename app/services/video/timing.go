@@ -0,0 +1,114 @@
+package video
+
+import (
+	"context"
+	"sync"
+	"time"
+	"yt-text/models"
+)
+
+// timedStage is a part of a job's run whose duration is recorded
+type timedStage int
+
+const (
+	stageLookup timedStage = iota
+	stageCaptionFetch
+	stageDownload
+	stageTranscription
+	stagePostProcessing
+	timedStages
+)
+
+// stageTimer adds up the time a run spends in each stage. Stages are timed
+// back to back: entering one ends the one before.
+type stageTimer struct {
+	mu      sync.Mutex
+	started time.Time
+	spent   [timedStages]time.Duration
+	current timedStage
+	since   time.Time // Zero while no stage is being timed
+}
+
+func newStageTimer() *stageTimer {
+	return &stageTimer{started: time.Now()}
+}
+
+func (t *stageTimer) enter(stage timedStage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.end(now)
+	t.current, t.since = stage, now
+}
+
+// stop ends the stage being timed, for steps that belong to no stage
+func (t *stageTimer) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.end(time.Now())
+}
+
+func (t *stageTimer) end(now time.Time) {
+	if !t.since.IsZero() {
+		t.spent[t.current] += now.Sub(t.since)
+		t.since = time.Time{}
+	}
+}
+
+// timings returns the time spent so far, counting the stage being timed up
+// to now
+func (t *stageTimer) timings() *models.StageTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	spent := t.spent
+	if !t.since.IsZero() {
+		spent[t.current] += now.Sub(t.since)
+	}
+	return &models.StageTimings{
+		LookupSeconds:         seconds(spent[stageLookup]),
+		CaptionFetchSeconds:   seconds(spent[stageCaptionFetch]),
+		DownloadSeconds:       seconds(spent[stageDownload]),
+		TranscriptionSeconds:  seconds(spent[stageTranscription]),
+		PostProcessingSeconds: seconds(spent[stagePostProcessing]),
+		TotalSeconds:          seconds(now.Sub(t.started)),
+	}
+}
+
+// seconds rounds a duration to milliseconds and returns it in seconds
+func seconds(d time.Duration) float64 {
+	return d.Round(time.Millisecond).Seconds()
+}
+
+// scriptStages are the timed stages of the stages scripts report
+var scriptStages = map[models.Stage]timedStage{
+	models.StageDownloading:  stageDownload,
+	models.StageTranscribing: stageTranscription,
+}
+
+type timerKey struct{}
+
+// withStageTimer returns a context whose run is timed by t
+func withStageTimer(ctx context.Context, t *stageTimer) context.Context {
+	return context.WithValue(ctx, timerKey{}, t)
+}
+
+// enterStage starts timing a stage of the run ctx belongs to. Outside of a
+// timed run it does nothing.
+func enterStage(ctx context.Context, stage timedStage) {
+	if t, ok := ctx.Value(timerKey{}).(*stageTimer); ok {
+		t.enter(stage)
+	}
+}
+
+// runTimings returns the timings of the run ctx belongs to, or nil outside
+// of a timed run
+func runTimings(ctx context.Context) *models.StageTimings {
+	if t, ok := ctx.Value(timerKey{}).(*stageTimer); ok {
+		return t.timings()
+	}
+	return nil
+}
@@ -29,7 +29,7 @@ document
 			const formData = new URLSearchParams();
 			formData.append("url", url);
 
-			const response = await fetch("/api/transcribe", {
+			const response = await fetch("/api/v2/transcribe", {
 				method: "POST",
 				headers: {
 					"Content-Type": "application/x-www-form-urlencoded",
@@ -40,13 +40,19 @@ document
 			const responseData = await response.json();
 
 			if (!response.ok) {
-				throw new Error(responseData.error || "Failed to process video");
+				throw new Error(responseData.error?.message || "Failed to process video");
 			}
 
 			const videoData = responseData.data;
 			// Debugging: Uncomment the line below if needed
 			// console.log("Initial response:", videoData);
 
+			// Playlists and channels come back as a batch of videos
+			if (Array.isArray(videoData.items)) {
+				showBatch(videoData, statusDiv, responseDiv);
+				return;
+			}
+
 			if (videoData.status === "completed") {
 				showTranscription(videoData, statusDiv, responseDiv);
 			} else {
@@ -60,6 +66,23 @@ document
 		}
 	});
 
+/**
+ * Shows that a playlist was queued as a batch.
+ * @param {Object} batch - The batch data.
+ * @param {HTMLElement} statusDiv - The DIV showing the status.
+ * @param {HTMLElement} responseDiv - The DIV to display the batch.
+ */
+function showBatch(batch, statusDiv, responseDiv) {
+	hideElement(statusDiv);
+	const title = batch.title ? `"${escapeHTML(batch.title)}"` : "Playlist";
+	responseDiv.innerHTML = `
+        <div class="bg-gray-700 p-4 rounded-md">
+            <p>${title} queued: ${batch.total} videos.</p>
+            <p class="text-sm text-gray-400">Batch ID: ${escapeHTML(batch.id)}</p>
+        </div>
+    `;
+}
+
 /**
  * Resets the UI elements to their default state.
  * @param {HTMLElement} responseDiv - The DIV to display responses.
@@ -175,11 +198,11 @@ async function pollTranscriptionStatus(id, statusDiv, responseDiv) {
 		const pollingInterval = Math.min(attempts * baseInterval, maxInterval);
 
 		try {
-			const response = await fetch(`/api/transcribe/${id}`);
+			const response = await fetch(`/api/v2/transcribe/${id}`);
 			const responseData = await response.json();
 
 			if (!response.ok) {
-				throw new Error(responseData.error || "Failed to check status");
+				throw new Error(responseData.error?.message || "Failed to check status");
 			}
 
 			const data = responseData.data;
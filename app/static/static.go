@@ -0,0 +1,10 @@
+// Package static holds the web frontend, embedded so the binary serves it
+// wherever it runs
+package static
+
+import "embed"
+
+// Files is the frontend, with index.html at its root
+//
+//go:embed *.html *.js *.ico
+var Files embed.FS
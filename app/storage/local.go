@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Local stores objects as files under a root directory. It keeps count of
+// the bytes under the root, measured when it is opened and updated by its
+// own writes and deletes, and can hold them under a limit.
+type Local struct {
+	root     string
+	maxBytes int64
+
+	mu   sync.Mutex
+	used int64
+}
+
+// NewLocal opens the backend at root. A maxBytes of 0 is unlimited.
+func NewLocal(root string, maxBytes int64) (*Local, error) {
+	if root == "" {
+		return nil, fmt.Errorf("storage path is required for the local backend")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	l := &Local{root: root, maxBytes: maxBytes}
+	err := filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		l.used += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure storage directory: %w", err)
+	}
+	return l, nil
+}
+
+func (l *Local) Usage() (used, limit int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.used, l.maxBytes
+}
+
+// reserve counts delta more bytes as used, failing when that would go over
+// the limit. Shrinking always succeeds.
+func (l *Local) reserve(delta int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if delta > 0 && l.maxBytes > 0 && l.used+delta > l.maxBytes {
+		return fmt.Errorf("%w: %d of %d bytes used, %d more needed", ErrQuotaExceeded, l.used, l.maxBytes, delta)
+	}
+	l.used += delta
+	return nil
+}
+
+// size returns the size of the file at path, or 0 when there is none
+func size(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// Put writes to a temporary file first, so readers never see a partial
+// object. Replacing an object only counts the difference in size.
+func (l *Local) Put(_ context.Context, key string, data []byte) (err error) {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	delta := int64(len(data)) - size(path)
+	if err := l.reserve(delta); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			l.reserve(-delta)
+		}
+	}()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete succeeds when the object doesn't exist
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	n := size(path)
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	l.reserve(-n)
+	return nil
+}
+
+func (l *Local) Stat(_ context.Context, key string) (ObjectInfo, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// List walks the files under the directory prefix names. Keys are
+// slash-separated whatever the platform.
+func (l *Local) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	dir, err := l.path(prefix)
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return nil // Deleted since, or nothing was ever stored under prefix
+		}
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		return fn(ObjectInfo{Key: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+	})
+}
+
+// path maps a key to a file, refusing keys that would escape the root
+func (l *Local) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if clean == "." || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(l.root, clean), nil
+}
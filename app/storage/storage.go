@@ -0,0 +1,78 @@
+// Package storage keeps large transcripts outside the database, on local
+// disk or in an S3-compatible object store such as DigitalOcean Spaces.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Backends selectable with STORAGE_BACKEND
+const (
+	BackendDB     = "db" // Transcripts stay inline in the video rows
+	BackendLocal  = "local"
+	BackendSpaces = "spaces"
+)
+
+// ErrNotFound is returned when an object doesn't exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrQuotaExceeded is returned by Put when the object would take a backend
+// over its size limit
+var ErrQuotaExceeded = errors.New("storage: quota exceeded")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Backend stores objects under slash-separated keys. Get and Stat return
+// ErrNotFound for missing objects; Delete of a missing object succeeds.
+type Backend interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+}
+
+// Lister is implemented by backends that can enumerate their objects
+type Lister interface {
+	// List calls fn with each object whose key starts with prefix,
+	// stopping at the first error fn returns
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+}
+
+// Metered is implemented by backends that keep count of the bytes they hold
+type Metered interface {
+	// Usage returns the bytes held and the limit on them, 0 when unlimited
+	Usage() (used, limit int64)
+}
+
+type Config struct {
+	Backend string
+	Path    string // Root directory of the local backend
+	Spaces  SpacesConfig
+
+	// MaxBytes limits the bytes the local backend holds; 0 is unlimited
+	MaxBytes int64
+}
+
+// New returns the backend selected by cfg, or nil when transcripts stay in
+// the database
+func New(cfg Config) (Backend, error) {
+	switch cfg.Backend {
+	case BackendDB, "":
+		return nil, nil
+	case BackendLocal:
+		return NewLocal(cfg.Path, cfg.MaxBytes)
+	case BackendSpaces:
+		return NewSpaces(cfg.Spaces)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
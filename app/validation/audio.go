@@ -0,0 +1,115 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"yt-text/errors"
+)
+
+// audioCheckTimeout bounds the request that inspects a direct audio URL
+const audioCheckTimeout = 10 * time.Second
+
+// audioExtensions maps the accepted audio content types to the file
+// extension the downloaded file is given
+var audioExtensions = map[string]string{
+	"audio/mpeg":     ".mp3",
+	"audio/mp3":      ".mp3",
+	"audio/wav":      ".wav",
+	"audio/x-wav":    ".wav",
+	"audio/wave":     ".wav",
+	"audio/vnd.wave": ".wav",
+}
+
+// AudioFile describes a direct audio download
+type AudioFile struct {
+	Name      string // File name from the URL's path
+	Extension string // ".mp3" or ".wav"
+	Size      int64  // Content length, or -1 when the server didn't send one
+}
+
+// ValidateAudioURLSyntax checks the form of a direct audio link without
+// fetching it, for requests that can't wait on the link's server
+func (v *Validator) ValidateAudioURLSyntax(urlStr string) error {
+	const op = "Validator.ValidateAudioURLSyntax"
+
+	_, err := parseHTTPURL(op, urlStr)
+	return err
+}
+
+// ValidateAudioURL checks that a URL serves an MP3 or WAV file within the
+// size limit. Servers that don't answer HEAD are asked with a GET whose
+// body is not read.
+func (v *Validator) ValidateAudioURL(ctx context.Context, urlStr string) (AudioFile, error) {
+	const op = "Validator.ValidateAudioURL"
+
+	parsedURL, err := parseHTTPURL(op, urlStr)
+	if err != nil {
+		return AudioFile{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, audioCheckTimeout)
+	defer cancel()
+
+	if err := v.checkHost(ctx, op, parsedURL); err != nil {
+		return AudioFile{}, err
+	}
+
+	resp, err := v.audioRequest(ctx, http.MethodHead, urlStr)
+	if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+		resp.Body.Close()
+		resp, err = v.audioRequest(ctx, http.MethodGet, urlStr)
+	}
+	if err != nil {
+		return AudioFile{}, errors.InvalidInput(op, err, "Audio URL could not be reached")
+	}
+	defer resp.Body.Close()
+
+	return v.CheckAudioResponse(resp)
+}
+
+func (v *Validator) audioRequest(ctx context.Context, method, urlStr string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	return v.client.Do(req)
+}
+
+// CheckAudioResponse checks the status, content type and length of a
+// response serving a direct audio file. Downloads call it again, since the
+// file may have changed since it was validated.
+func (v *Validator) CheckAudioResponse(resp *http.Response) (AudioFile, error) {
+	const op = "Validator.CheckAudioResponse"
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return AudioFile{}, errors.InvalidInput(op, nil, fmt.Sprintf("Audio URL returned HTTP error %d", resp.StatusCode))
+	}
+
+	file := AudioFile{Size: resp.ContentLength}
+	if name := path.Base(resp.Request.URL.Path); name != "." && name != "/" {
+		file.Name = name
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	file.Extension = audioExtensions[strings.ToLower(contentType)]
+	if file.Extension == "" && (contentType == "" || contentType == "application/octet-stream") {
+		// Generic file servers don't know the type; trust the extension
+		if ext := strings.ToLower(path.Ext(file.Name)); ext == ".mp3" || ext == ".wav" {
+			file.Extension = ext
+		}
+	}
+	if file.Extension == "" {
+		return AudioFile{}, errors.InvalidInput(op, nil, "Audio URL must serve an MP3 or WAV file")
+	}
+
+	if limit := v.config.Load().Video.MaxFileSize; limit > 0 && file.Size > limit {
+		return AudioFile{}, errors.InvalidInput(op, nil, fmt.Sprintf("Audio file is too large (limit %.3g MB)", float64(limit)/(1<<20)))
+	}
+
+	return file, nil
+}
@@ -0,0 +1,159 @@
+package validation
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"yt-text/errors"
+)
+
+// maxRedirects bounds the redirects followed when fetching a submitted URL
+const maxRedirects = 5
+
+// errPrivateAddress is returned when a submitted URL leads somewhere that
+// isn't publicly routable
+var errPrivateAddress = stderrors.New("address is not publicly routable")
+
+// nonPublicPrefixes are the ranges a submitted URL may not lead to unless
+// VIDEO_ALLOW_PRIVATE_NETWORKS is set: loopback, private (RFC 1918, unique
+// local), link-local, carrier-grade NAT, and reserved or multicast space.
+// IPv4-mapped IPv6 addresses are unmapped before they are checked, and the
+// IPv4 addresses embedded in NAT64 and 6to4 ones are checked as well.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+var (
+	// nat64Prefix is the well-known NAT64 prefix; the last 32 bits are the
+	// IPv4 address a gateway translates to
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	// sixToFourPrefix is 6to4, whose bits 16-47 are the relay's IPv4 address
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+// isPublic reports whether addr is outside the non-public ranges
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	bytes := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		return isPublic(netip.AddrFrom4([4]byte(bytes[12:16])))
+	case sixToFourPrefix.Contains(addr):
+		return isPublic(netip.AddrFrom4([4]byte(bytes[2:6])))
+	}
+	return true
+}
+
+// checkHost resolves a URL's host and fails unless every address it
+// resolves to is public, so a name with one private record can't be used to
+// reach inside the network
+func (v *Validator) checkHost(ctx context.Context, op string, u *url.URL) error {
+	if v.allowPrivate {
+		return nil
+	}
+
+	host := strings.TrimSuffix(u.Hostname(), ".")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !isPublic(addr) {
+			return errors.InvalidInput(op, errPrivateAddress, "URL must point to a public address")
+		}
+		return nil
+	}
+	if numericHost(host) {
+		// Forms like 2130706433 or 0x7f.1, which some clients read as
+		// 127.0.0.1 and the resolver doesn't
+		return errors.InvalidInput(op, nil, "Invalid URL host")
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return errors.InvalidInput(op, err, "URL host could not be resolved")
+	}
+	for _, addr := range addrs {
+		if !isPublic(addr) {
+			return errors.InvalidInput(op, fmt.Errorf("%s resolves to %s: %w", host, addr, errPrivateAddress), "URL must point to a public address")
+		}
+	}
+	return nil
+}
+
+// numericHost reports whether a host's last label is a number, which no
+// top-level domain is, so the host can only be an IPv4 address in one of
+// the shorthand forms
+func numericHost(host string) bool {
+	label := host[strings.LastIndex(host, ".")+1:]
+	if hex, ok := strings.CutPrefix(strings.ToLower(label), "0x"); ok {
+		return strings.Trim(hex, "0123456789abcdef") == ""
+	}
+	return label != "" && strings.Trim(label, "0123456789") == ""
+}
+
+// NewHTTPClient returns a client for fetching submitted URLs. The address
+// of every connection is checked as it is dialed, which also covers
+// redirects and names re-resolved to something else since validation. At
+// most maxRedirects redirects are followed, and only to HTTP(S) URLs.
+// Proxy settings are ignored, since the proxy's address would be checked
+// in place of the target's. A timeout of 0 leaves requests to their
+// context.
+func (v *Validator) NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	if !v.allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublic(addrPort.Addr()) {
+				return fmt.Errorf("dial %s: %w", address, errPrivateAddress)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package validation
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"10.0.0.1", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"::ffff:127.0.0.1", false},
+		{"2606:2800:220:1::", true},
+		{"::1", false},
+		{"fd00::1", false},
+
+		// NAT64 and 6to4 addresses are as public as the IPv4 ones in them
+		{"64:ff9b::5db8:d822", true},
+		{"64:ff9b::a00:1", false},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"2002:5db8:d822::1", true},
+		{"2002:a00:1::1", false},
+		{"2002:7f00:1::", false},
+		{"2002:c0a8:101:1::1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
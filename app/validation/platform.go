@@ -0,0 +1,237 @@
+package validation
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"yt-text/models"
+)
+
+// Media identifies a video or track on one of the supported platforms
+type Media struct {
+	Platform models.Platform
+	ID       string
+}
+
+// platformValidator checks URLs of one platform. extractID returns the ID
+// of the media a URL points at, or a message explaining why the URL can't
+// be transcribed. canonical builds the platform's standard URL for an ID;
+// it's nil where the ID alone isn't enough to fetch the media.
+type platformValidator struct {
+	platform  models.Platform
+	hosts     []string
+	extractID func(u *url.URL) (id string, problem string)
+	canonical func(id string) string
+}
+
+var (
+	numericID = regexp.MustCompile(`^[0-9]+$`)
+	slugID    = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+	// youTubeVideoID matches YouTube's 11-character video IDs
+	youTubeVideoID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+)
+
+var platformValidators = []platformValidator{
+	{
+		platform: models.PlatformYouTube,
+		hosts: []string{"youtube.com", "www.youtube.com", "m.youtube.com", "music.youtube.com",
+			"youtube-nocookie.com", "www.youtube-nocookie.com", "youtu.be"},
+		extractID: youTubeID,
+		canonical: func(id string) string { return "https://www.youtube.com/watch?v=" + id },
+	},
+	{
+		platform:  models.PlatformVimeo,
+		hosts:     []string{"vimeo.com", "www.vimeo.com", "player.vimeo.com"},
+		extractID: vimeoID,
+	},
+	{
+		platform:  models.PlatformTwitch,
+		hosts:     []string{"twitch.tv", "www.twitch.tv", "m.twitch.tv", "clips.twitch.tv"},
+		extractID: twitchID,
+		canonical: canonicalTwitchURL,
+	},
+	{
+		platform:  models.PlatformSoundCloud,
+		hosts:     []string{"soundcloud.com", "www.soundcloud.com", "m.soundcloud.com"},
+		extractID: soundCloudID,
+		canonical: func(id string) string { return "https://soundcloud.com/" + id },
+	},
+}
+
+// platformFor returns the validator for a URL's host
+func platformFor(u *url.URL) (platformValidator, bool) {
+	host := strings.ToLower(u.Hostname())
+	for _, p := range platformValidators {
+		for _, h := range p.hosts {
+			if host == h {
+				return p, true
+			}
+		}
+	}
+	return platformValidator{}, false
+}
+
+// IdentifyMedia returns the platform and media ID of a URL. It reports
+// false for URLs of other sites and for platform URLs that don't point at a
+// single video or track.
+func IdentifyMedia(rawURL string) (Media, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Media{}, false
+	}
+	p, ok := platformFor(u)
+	if !ok {
+		return Media{}, false
+	}
+	id, problem := p.extractID(u)
+	if problem != "" {
+		return Media{}, false
+	}
+	return Media{Platform: p.platform, ID: id}, true
+}
+
+// CanonicalURL returns the standard form of a platform URL, so the
+// different links to one video (youtu.be/<id>, m.youtube.com, a watch URL
+// with a start time) are stored once. Other URLs are returned unchanged.
+func CanonicalURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	p, ok := platformFor(u)
+	if !ok || p.canonical == nil {
+		return rawURL
+	}
+	id, problem := p.extractID(u)
+	if problem != "" {
+		return rawURL
+	}
+	return p.canonical(id)
+}
+
+// pathSegments splits a URL path into its non-empty segments
+func pathSegments(u *url.URL) []string {
+	var segments []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// youTubeIDPaths are the youtube.com paths followed by a video ID
+var youTubeIDPaths = map[string]bool{"shorts": true, "live": true, "embed": true, "v": true, "e": true}
+
+// youTubeID accepts youtube.com/watch?v=<id>, youtu.be/<id> and the
+// shorts, live and embed paths. Other query parameters (t, si, feature,
+// list) don't change the video.
+func youTubeID(u *url.URL) (string, string) {
+	segments := pathSegments(u)
+	var id string
+	switch {
+	case strings.ToLower(u.Hostname()) == "youtu.be":
+		if len(segments) != 1 {
+			return "", "Invalid YouTube short URL format"
+		}
+		id = segments[0]
+	case len(segments) == 1 && segments[0] == "watch":
+		id = u.Query().Get("v")
+		if id == "" {
+			return "", "Missing YouTube video ID"
+		}
+	case len(segments) == 2 && youTubeIDPaths[segments[0]]:
+		id = segments[1]
+	default:
+		return "", "Invalid YouTube URL format"
+	}
+
+	if !youTubeVideoID.MatchString(id) {
+		return "", "Invalid YouTube video ID"
+	}
+	return id, ""
+}
+
+// YouTubeID returns the video ID of a YouTube video URL
+func YouTubeID(rawURL string) (string, bool) {
+	media, ok := IdentifyMedia(rawURL)
+	if !ok || media.Platform != models.PlatformYouTube {
+		return "", false
+	}
+	return media.ID, true
+}
+
+// vimeoID accepts vimeo.com paths ending in a numeric ID (vimeo.com/<id>,
+// vimeo.com/channels/<name>/<id>) and player.vimeo.com/video/<id>
+func vimeoID(u *url.URL) (string, string) {
+	segments := pathSegments(u)
+	if u.Hostname() == "player.vimeo.com" {
+		if len(segments) != 2 || segments[0] != "video" || !numericID.MatchString(segments[1]) {
+			return "", "Invalid Vimeo player URL format"
+		}
+		return segments[1], ""
+	}
+
+	// Unlisted videos carry a hash after the ID (vimeo.com/<id>/<hash>),
+	// which yt-dlp needs but isn't part of the ID
+	for i := len(segments) - 1; i >= 0 && i >= len(segments)-2; i-- {
+		if numericID.MatchString(segments[i]) {
+			return segments[i], ""
+		}
+	}
+	return "", "Vimeo URL must point to a video"
+}
+
+// twitchID accepts VODs (twitch.tv/videos/<id>) and clips
+// (clips.twitch.tv/<slug> or twitch.tv/<channel>/clip/<slug>). Live
+// channels are rejected since a stream has no end to transcribe.
+func twitchID(u *url.URL) (string, string) {
+	segments := pathSegments(u)
+	if u.Hostname() == "clips.twitch.tv" {
+		if len(segments) != 1 || !slugID.MatchString(segments[0]) {
+			return "", "Invalid Twitch clip URL format"
+		}
+		return segments[0], ""
+	}
+
+	switch {
+	case len(segments) == 2 && segments[0] == "videos":
+		if !numericID.MatchString(segments[1]) {
+			return "", "Invalid Twitch video ID"
+		}
+		return "v" + segments[1], ""
+	case len(segments) == 3 && segments[1] == "clip":
+		if !slugID.MatchString(segments[2]) {
+			return "", "Invalid Twitch clip ID"
+		}
+		return segments[2], ""
+	default:
+		return "", "Only Twitch videos and clips can be transcribed, not live channels"
+	}
+}
+
+// canonicalTwitchURL builds the URL of a VOD ("v<id>") or clip ID
+func canonicalTwitchURL(id string) string {
+	if vod, ok := strings.CutPrefix(id, "v"); ok && numericID.MatchString(vod) {
+		return "https://www.twitch.tv/videos/" + vod
+	}
+	return "https://clips.twitch.tv/" + id
+}
+
+// soundCloudID accepts soundcloud.com/<user>/<track>. The ID is the
+// user/track path, since numeric track IDs only appear in the API.
+func soundCloudID(u *url.URL) (string, string) {
+	segments := pathSegments(u)
+	if len(segments) != 2 {
+		return "", "SoundCloud URL must point to a track"
+	}
+	switch segments[1] {
+	case "sets", "albums", "tracks", "reposts", "likes", "followers", "following":
+		return "", "SoundCloud URL must point to a track, not a playlist or profile page"
+	}
+	if !slugID.MatchString(segments[0]) || !slugID.MatchString(segments[1]) {
+		return "", "Invalid SoundCloud track URL format"
+	}
+	return segments[0] + "/" + segments[1], ""
+}
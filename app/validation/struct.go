@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError describes why a single request field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Struct checks a request struct against its `validate` tags and returns one
+// FieldError per invalid field. Field names come from the json tag.
+//
+// Supported rules, comma separated:
+//
+//	required      the value must not be the zero value
+//	omitempty     skip the remaining rules when the value is empty
+//	url           an absolute http or https URL
+//	oneof=a b c   one of the space separated values
+//	min=n, max=n  length bounds for strings and slices, value bounds for numbers
+func Struct(v interface{}) []FieldError {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+
+		if message := checkField(rv.Field(i), tag); message != "" {
+			errs = append(errs, FieldError{Field: fieldName(field), Message: message})
+		}
+	}
+	return errs
+}
+
+// checkField applies the rules of one tag, returning the first failure
+func checkField(value reflect.Value, tag string) string {
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "required":
+			if value.IsZero() {
+				return "is required"
+			}
+		case "omitempty":
+			if value.IsZero() {
+				return ""
+			}
+		case "url":
+			if !isHTTPURL(value.String()) {
+				return "must be an http or https URL"
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			if !contains(options, fmt.Sprint(value.Interface())) {
+				return "must be one of " + strings.Join(options, ", ")
+			}
+		case "min", "max":
+			if message := checkBound(value, name, arg); message != "" {
+				return message
+			}
+		default:
+			panic(fmt.Sprintf("validation: unknown rule %q", name))
+		}
+	}
+	return ""
+}
+
+func checkBound(value reflect.Value, name, arg string) string {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid %s bound %q", name, arg))
+	}
+
+	var n float64
+	unit := ""
+	switch value.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Map:
+		n, unit = float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		panic(fmt.Sprintf("validation: %s does not apply to %s", name, value.Kind()))
+	}
+
+	switch {
+	case name == "min" && n < limit:
+		return "must be at least " + arg + unit
+	case name == "max" && n > limit:
+		return "must be at most " + arg + unit
+	}
+	return ""
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func contains(options []string, value string) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
@@ -1,93 +1,190 @@
 package validation
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"yt-text/config"
 	"yt-text/errors"
+	"yt-text/models"
 )
 
 type Validator struct {
-	config *config.Config
+	config atomic.Pointer[config.Config] // Swapped when the config is reloaded
+	client *http.Client
+
+	// allowPrivate lets submitted URLs lead to private and loopback
+	// addresses. It is fixed at startup since the clients built with it
+	// outlive a reload.
+	allowPrivate bool
 }
 
 func NewValidator(cfg *config.Config) *Validator {
-	return &Validator{config: cfg}
+	v := &Validator{allowPrivate: cfg.Video.AllowPrivateNetworks}
+	v.client = v.NewHTTPClient(audioCheckTimeout)
+	v.config.Store(cfg)
+	return v
+}
+
+// SetConfig applies a reloaded configuration to later validations
+func (v *Validator) SetConfig(cfg *config.Config) {
+	v.config.Store(cfg)
 }
 
-// ValidateURL performs basic URL validation and YouTube-specific checks
+// ValidateURL performs basic URL validation and the checks of the platform
+// the URL belongs to. URLs of other sites are left to yt-dlp when
+// AllowOtherSites is set, once CheckHost has passed them. It makes no
+// requests, so it can run while a request is handled.
 func (v *Validator) ValidateURL(urlStr string) error {
 	const op = "Validator.ValidateURL"
 
+	parsedURL, err := parseHTTPURL(op, urlStr)
+	if err != nil {
+		return err
+	}
+
+	p, ok := platformFor(parsedURL)
+	if !ok {
+		if video := v.config.Load().Video; !video.AllowOtherSites {
+			return errors.InvalidInput(op, nil, "URL must be from one of: "+strings.Join(video.Platforms, ", "))
+		}
+		return nil
+	}
+
+	if !v.platformEnabled(p.platform) {
+		return errors.InvalidInput(op, nil, fmt.Sprintf("URLs from %s are not accepted", p.platform))
+	}
+	if _, problem := p.extractID(parsedURL); problem != "" {
+		return errors.InvalidInput(op, nil, problem)
+	}
+
+	return nil
+}
+
+// CheckHost makes sure the host of a URL outside the known platforms
+// resolves to public addresses. yt-dlp makes its own requests for such
+// URLs, so this is the only check they get.
+func (v *Validator) CheckHost(ctx context.Context, urlStr string) error {
+	const op = "Validator.CheckHost"
+
+	parsedURL, err := parseHTTPURL(op, urlStr)
+	if err != nil {
+		return err
+	}
+	if _, ok := platformFor(parsedURL); ok {
+		return nil
+	}
+	return v.checkHost(ctx, op, parsedURL)
+}
+
+// parseHTTPURL parses a URL that must use HTTP or HTTPS
+func parseHTTPURL(op, urlStr string) (*url.URL, error) {
 	if urlStr == "" {
-		return errors.InvalidInput(op, nil, "URL is required")
+		return nil, errors.InvalidInput(op, nil, "URL is required")
 	}
 
 	// Parse URL
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
-		return errors.InvalidInput(op, err, "Invalid URL format")
+		return nil, errors.InvalidInput(op, err, "Invalid URL format")
 	}
 
 	// Protocol validation
 	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return errors.InvalidInput(op, nil, "URL must use HTTP or HTTPS")
-	}
-
-	// If it's a YouTube URL, perform additional validation
-	if isYouTubeDomain(parsedURL.Hostname()) {
-		if err := v.validateYouTubeURL(parsedURL); err != nil {
-			return err
-		}
+		return nil, errors.InvalidInput(op, nil, "URL must use HTTP or HTTPS")
 	}
 
-	return nil
+	return parsedURL, nil
 }
 
-// isYouTubeDomain checks if the hostname is a valid YouTube domain
-func isYouTubeDomain(host string) bool {
-	validDomains := []string{
-		"youtube.com",
-		"www.youtube.com",
-		"youtu.be",
-	}
-
-	for _, domain := range validDomains {
-		if host == domain {
+// platformEnabled reports whether URLs of a platform are accepted
+func (v *Validator) platformEnabled(platform models.Platform) bool {
+	for _, name := range v.config.Load().Video.Platforms {
+		if strings.EqualFold(strings.TrimSpace(name), string(platform)) {
 			return true
 		}
 	}
 	return false
 }
 
-// validateYouTubeURL performs YouTube-specific URL validation
-func (v *Validator) validateYouTubeURL(parsedURL *url.URL) error {
-	const op = "Validator.validateYouTubeURL"
+// Bounds for the Whisper decoding options
+const (
+	maxTemperature = 1.0
+	maxBeamSize    = 10
 
-	// Handle youtu.be format
-	if parsedURL.Host == "youtu.be" {
-		if parsedURL.Path == "" || parsedURL.Path == "/" {
-			return errors.InvalidInput(op, nil, "Invalid YouTube short URL format")
-		}
-		return nil
+	maxCaptionLanguages = 5
+)
+
+// ValidateWhisperOptions checks the Whisper options of a transcription
+// request. Language is an ISO 639 code, optionally with a region ("pt-BR"),
+// which is ignored since Whisper doesn't distinguish regional variants.
+func (v *Validator) ValidateWhisperOptions(opts models.WhisperOptions) error {
+	const op = "Validator.ValidateWhisperOptions"
+
+	if opts.Language != "" && !isLanguageCode(opts.Language) {
+		return errors.InvalidInput(op, nil, "Language must be a language code such as en or pt-BR")
+	}
+
+	switch opts.Task {
+	case "", models.TaskTranscribe, models.TaskTranslate:
+	default:
+		return errors.InvalidInput(op, nil, "Task must be one of: transcribe, translate")
 	}
 
-	// Handle youtube.com format
-	if parsedURL.Path != "/watch" {
-		return errors.InvalidInput(op, nil, "Invalid YouTube URL format")
+	if t := opts.Temperature; t != nil && (*t < 0 || *t > maxTemperature) {
+		return errors.InvalidInput(op, nil, fmt.Sprintf("Temperature must be between 0 and %g", maxTemperature))
 	}
 
-	query := parsedURL.Query()
-	videoID := query.Get("v")
-	if videoID == "" {
-		return errors.InvalidInput(op, nil, "Missing YouTube video ID")
+	if opts.BeamSize < 0 || opts.BeamSize > maxBeamSize {
+		return errors.InvalidInput(op, nil, fmt.Sprintf("Beam size must be between 1 and %d", maxBeamSize))
 	}
 
 	return nil
 }
 
+// ValidateCaptionOptions checks the wanted caption languages
+func (v *Validator) ValidateCaptionOptions(opts models.CaptionOptions) error {
+	const op = "Validator.ValidateCaptionOptions"
+
+	if len(opts.Languages) > maxCaptionLanguages {
+		return errors.InvalidInput(op, nil, fmt.Sprintf("At most %d caption languages can be given", maxCaptionLanguages))
+	}
+	for _, language := range opts.Languages {
+		if !isLanguageCode(language) {
+			return errors.InvalidInput(op, nil, "Caption languages must be language codes such as en or pt-BR")
+		}
+	}
+	return nil
+}
+
+// isLanguageCode accepts a 2-3 letter language code with an optional
+// alphanumeric region or script subtag
+func isLanguageCode(code string) bool {
+	lang, region, hasRegion := strings.Cut(code, "-")
+	if len(lang) < 2 || len(lang) > 3 || !isAlnum(lang, false) {
+		return false
+	}
+	if hasRegion {
+		return len(region) >= 2 && len(region) <= 8 && isAlnum(region, true)
+	}
+	return true
+}
+
+func isAlnum(s string, digits bool) bool {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case digits && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // RequestValidationOpts holds options for request validation
 type RequestValidationOpts struct {
 	MaxContentLength int64
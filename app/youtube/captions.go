@@ -0,0 +1,160 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const timedTextEndpoint = "https://www.youtube.com/api/timedtext"
+
+// CaptionSegment is one timed line of a caption track
+type CaptionSegment struct {
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+	Text  string        `json:"text"`
+}
+
+// TrackPreference narrows the choice of a caption track
+type TrackPreference struct {
+	// Languages lists the wanted languages, most wanted first. Tracks in
+	// other languages are skipped; without any, English is preferred.
+	Languages []string
+	// ManualOnly skips auto-generated tracks
+	ManualOnly bool
+}
+
+// SelectTrack picks the caption track to use for a transcript. Wanted
+// languages come first in their order, then human-made tracks win over
+// auto-generated ones; without wanted languages, human-made tracks win and
+// English is preferred.
+func SelectTrack(tracks []CaptionTrack, pref TrackPreference) (CaptionTrack, bool) {
+	best, bestScore := CaptionTrack{}, -1
+	for _, track := range tracks {
+		if track.Kind == "forced" || pref.ManualOnly && track.IsAutoGenerated() {
+			continue
+		}
+
+		manual := 0
+		if !track.IsAutoGenerated() {
+			manual = 1
+		}
+
+		var score int
+		if len(pref.Languages) == 0 {
+			score = 2 * manual
+			if strings.HasPrefix(strings.ToLower(track.Language), "en") {
+				score++
+			}
+		} else {
+			rank := LanguageRank(track.Language, pref.Languages)
+			if rank < 0 {
+				continue
+			}
+			score = 2*(len(pref.Languages)-rank) + manual
+		}
+		if score > bestScore {
+			best, bestScore = track, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// LanguageRank returns the position in wanted of the first language a
+// track's language matches, or -1. Regional variants match their base
+// language either way round, so "en-GB" matches "en" and "en" matches
+// "en-US".
+func LanguageRank(language string, wanted []string) int {
+	language = strings.ToLower(language)
+	base, _, _ := strings.Cut(language, "-")
+	for i, want := range wanted {
+		want = strings.ToLower(strings.TrimSpace(want))
+		wantBase, _, _ := strings.Cut(want, "-")
+		if language == want || base == want || language == wantBase {
+			return i
+		}
+	}
+	return -1
+}
+
+// FetchCaptions downloads a caption track through YouTube's public timedtext
+// endpoint, which does not consume Data API quota
+func (c *Client) FetchCaptions(ctx context.Context, videoID string, track CaptionTrack) ([]CaptionSegment, error) {
+	params := url.Values{
+		"v":    {videoID},
+		"lang": {track.Language},
+		"fmt":  {"json3"},
+	}
+	if track.IsAutoGenerated() {
+		params.Set("kind", "asr")
+	} else if track.Name != "" {
+		params.Set("name", track.Name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, timedTextEndpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("timedtext: %s", resp.Status)
+	}
+
+	var body struct {
+		Events []struct {
+			StartMs    int64 `json:"tStartMs"`
+			DurationMs int64 `json:"dDurationMs"`
+			Segs       []struct {
+				Text string `json:"utf8"`
+			} `json:"segs"`
+		} `json:"events"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		// An empty body means the track exists but isn't served publicly
+		return nil, ErrNoCaptions
+	}
+
+	var segments []CaptionSegment
+	for _, event := range body.Events {
+		var text strings.Builder
+		for _, seg := range event.Segs {
+			text.WriteString(seg.Text)
+		}
+
+		line := strings.TrimSpace(strings.ReplaceAll(text.String(), "\n", " "))
+		if line == "" {
+			continue
+		}
+
+		start := time.Duration(event.StartMs) * time.Millisecond
+		segments = append(segments, CaptionSegment{
+			Start: start,
+			End:   start + time.Duration(event.DurationMs)*time.Millisecond,
+			Text:  line,
+		})
+	}
+
+	if len(segments) == 0 {
+		return nil, ErrNoCaptions
+	}
+	return segments, nil
+}
+
+// CaptionText joins caption segments into a single transcript
+func CaptionText(segments []CaptionSegment) string {
+	lines := make([]string, len(segments))
+	for i, seg := range segments {
+		lines[i] = seg.Text
+	}
+	return strings.Join(lines, " ")
+}
@@ -0,0 +1,191 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrVideoNotFound is returned when YouTube reports the video doesn't exist
+	ErrVideoNotFound = errors.New("youtube video not found")
+
+	// ErrVideoRestricted is returned when YouTube refuses to describe the
+	// video, usually because it is private or embedding is disabled
+	ErrVideoRestricted = errors.New("youtube video is restricted")
+
+	// ErrNoAPIKey is returned by Data API calls when no key is configured
+	ErrNoAPIKey = errors.New("youtube data api key not configured")
+
+	// ErrQuotaExceeded is returned once the daily Data API quota is spent
+	ErrQuotaExceeded = errors.New("youtube data api quota exceeded")
+
+	// ErrNoCaptions is returned when a video has no usable caption track
+	ErrNoCaptions = errors.New("no captions available")
+)
+
+const oembedEndpoint = "https://www.youtube.com/oembed"
+
+// Config holds the configuration for the YouTube client
+type Config struct {
+	Timeout           time.Duration // Timeout for each outbound request
+	APIKey            string        // YouTube Data API key; Data API calls are disabled without one
+	DailyQuota        int           // Data API units available per day
+	MetadataCacheTTL  time.Duration // How long videos.list responses are cached
+	CaptionsCacheTTL  time.Duration // How long captions.list responses are cached
+	RequestsPerMinute int           // Average outbound request rate, shared by all callers
+	Burst             int           // Requests allowed in a burst
+	MaxBackoff        time.Duration // Longest pause after YouTube rate limits us
+}
+
+// Client talks to YouTube's public HTTP endpoints and the Data API
+type Client struct {
+	http     *http.Client
+	apiKey   string
+	quota    *quotaTracker
+	throttle *Throttle
+
+	metadata     *ttlCache[*VideoMetadata]
+	captionLists *ttlCache[[]CaptionTrack]
+}
+
+func NewClient(cfg Config) *Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	dailyQuota := cfg.DailyQuota
+	if dailyQuota <= 0 {
+		dailyQuota = 10000
+	}
+
+	return &Client{
+		http:         &http.Client{Timeout: timeout},
+		apiKey:       cfg.APIKey,
+		quota:        newQuotaTracker(dailyQuota),
+		throttle:     NewThrottle(cfg.RequestsPerMinute, cfg.Burst, cfg.MaxBackoff),
+		metadata:     newTTLCache[*VideoMetadata](cfg.MetadataCacheTTL),
+		captionLists: newTTLCache[[]CaptionTrack](cfg.CaptionsCacheTTL),
+	}
+}
+
+// Wait blocks until outbound YouTube traffic is allowed. Callers that reach
+// YouTube by other means (yt-dlp scripts) use it to share the rate limit.
+func (c *Client) Wait(ctx context.Context) error {
+	return c.throttle.Wait(ctx)
+}
+
+// ReportRateLimited tells the client that YouTube rejected a request with
+// HTTP 429, pausing all outbound traffic for a while
+func (c *Client) ReportRateLimited() time.Duration {
+	return c.throttle.Backoff()
+}
+
+// ReportSuccess resets any backoff after a request went through
+func (c *Client) ReportSuccess() {
+	c.throttle.Success()
+}
+
+// do sends a throttled request and feeds the response status back into the
+// throttle
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.throttle.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		c.throttle.Backoff()
+	} else {
+		c.throttle.Success()
+	}
+	return resp, nil
+}
+
+// OEmbedInfo is the subset of YouTube's oEmbed response we use
+type OEmbedInfo struct {
+	Title      string `json:"title"`
+	AuthorName string `json:"author_name"`
+}
+
+// OEmbed fetches oEmbed metadata for a video URL. It is far cheaper than a
+// yt-dlp extraction and is used to weed out dead links early.
+func (c *Client) OEmbed(ctx context.Context, videoURL string) (*OEmbedInfo, error) {
+	query := url.Values{"url": {videoURL}, "format": {"json"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, oembedEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusBadRequest:
+		return nil, ErrVideoNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, ErrVideoRestricted
+	default:
+		return nil, fmt.Errorf("unexpected oEmbed status: %s", resp.Status)
+	}
+
+	var info OEmbedInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode oEmbed response: %w", err)
+	}
+	return &info, nil
+}
+
+// IsPlaylistURL reports whether rawURL is a YouTube playlist or channel
+// rather than a single video. Watch URLs that carry a playlist are videos.
+func IsPlaylistURL(rawURL string) bool {
+	if !IsYouTubeURL(rawURL) {
+		return false
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	path := parsed.Path
+	switch {
+	case path == "/playlist":
+		return parsed.Query().Get("list") != ""
+	case strings.HasPrefix(path, "/@"),
+		strings.HasPrefix(path, "/channel/"),
+		strings.HasPrefix(path, "/c/"),
+		strings.HasPrefix(path, "/user/"):
+		return true
+	default:
+		return false
+	}
+}
+
+// IsYouTubeURL reports whether rawURL points at a YouTube host
+func IsYouTubeURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(parsed.Hostname()) {
+	case "youtube.com", "www.youtube.com", "m.youtube.com", "music.youtube.com",
+		"youtube-nocookie.com", "www.youtube-nocookie.com", "youtu.be":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,225 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const dataAPIEndpoint = "https://www.googleapis.com/youtube/v3"
+
+// VideoMetadata is the subset of a videos.list resource we use
+type VideoMetadata struct {
+	ID           string        `json:"id"`
+	Title        string        `json:"title"`
+	ChannelTitle string        `json:"channel_title"`
+	Duration     time.Duration `json:"duration"`
+	// LiveBroadcastContent is "none", "live" or "upcoming"
+	LiveBroadcastContent string `json:"live_broadcast_content"`
+	// HasCaptions is true when the video has non-automatic captions
+	HasCaptions bool `json:"has_captions"`
+	// Language is the uploader-declared audio language (BCP-47), if any
+	Language string `json:"language,omitempty"`
+}
+
+// CaptionTrack describes one caption track from captions.list
+type CaptionTrack struct {
+	ID       string `json:"id"`
+	Language string `json:"language"`
+	Name     string `json:"name"`
+	// Kind is "standard", "asr" (auto-generated) or "forced"
+	Kind string `json:"kind"`
+}
+
+// IsAutoGenerated reports whether the track is YouTube's speech recognition
+func (t CaptionTrack) IsAutoGenerated() bool {
+	return t.Kind == "asr"
+}
+
+// HasAPIKey reports whether Data API calls are possible
+func (c *Client) HasAPIKey() bool {
+	return c.apiKey != ""
+}
+
+// QuotaStatus returns the current Data API quota estimate
+func (c *Client) QuotaStatus() QuotaStatus {
+	if !c.HasAPIKey() {
+		return QuotaStatus{}
+	}
+	return c.quota.status()
+}
+
+// Metadata returns title, duration, live status and caption availability for
+// a video. Responses are cached.
+func (c *Client) Metadata(ctx context.Context, videoID string) (*VideoMetadata, error) {
+	if cached, ok := c.metadata.get(videoID); ok {
+		return cached, nil
+	}
+
+	var resp struct {
+		Items []struct {
+			ID      string `json:"id"`
+			Snippet struct {
+				Title                string `json:"title"`
+				ChannelTitle         string `json:"channelTitle"`
+				LiveBroadcastContent string `json:"liveBroadcastContent"`
+				DefaultAudioLanguage string `json:"defaultAudioLanguage"`
+				DefaultLanguage      string `json:"defaultLanguage"`
+			} `json:"snippet"`
+			ContentDetails struct {
+				Duration string `json:"duration"`
+				Caption  string `json:"caption"`
+			} `json:"contentDetails"`
+		} `json:"items"`
+	}
+
+	params := url.Values{"id": {videoID}, "part": {"snippet,contentDetails"}}
+	if err := c.callDataAPI(ctx, "videos", params, costVideosList, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Items) == 0 {
+		return nil, ErrVideoNotFound
+	}
+
+	item := resp.Items[0]
+	meta := &VideoMetadata{
+		ID:                   item.ID,
+		Title:                item.Snippet.Title,
+		ChannelTitle:         item.Snippet.ChannelTitle,
+		Duration:             parseISODuration(item.ContentDetails.Duration),
+		LiveBroadcastContent: item.Snippet.LiveBroadcastContent,
+		HasCaptions:          item.ContentDetails.Caption == "true",
+		Language:             item.Snippet.DefaultAudioLanguage,
+	}
+	if meta.Language == "" {
+		meta.Language = item.Snippet.DefaultLanguage
+	}
+
+	c.metadata.set(videoID, meta)
+	return meta, nil
+}
+
+// ListCaptions returns the caption tracks available for a video. Responses
+// are cached since the call is expensive in quota terms.
+func (c *Client) ListCaptions(ctx context.Context, videoID string) ([]CaptionTrack, error) {
+	if cached, ok := c.captionLists.get(videoID); ok {
+		return cached, nil
+	}
+
+	var resp struct {
+		Items []struct {
+			ID      string `json:"id"`
+			Snippet struct {
+				Language  string `json:"language"`
+				Name      string `json:"name"`
+				TrackKind string `json:"trackKind"`
+			} `json:"snippet"`
+		} `json:"items"`
+	}
+
+	params := url.Values{"videoId": {videoID}, "part": {"snippet"}}
+	if err := c.callDataAPI(ctx, "captions", params, costCaptionsList, &resp); err != nil {
+		return nil, err
+	}
+
+	tracks := make([]CaptionTrack, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		tracks = append(tracks, CaptionTrack{
+			ID:       item.ID,
+			Language: item.Snippet.Language,
+			Name:     item.Snippet.Name,
+			Kind:     normalizeTrackKind(item.Snippet.TrackKind),
+		})
+	}
+
+	c.captionLists.set(videoID, tracks)
+	return tracks, nil
+}
+
+// callDataAPI performs a Data API GET, charging cost units against the quota
+func (c *Client) callDataAPI(ctx context.Context, resource string, params url.Values, cost int, out interface{}) error {
+	if !c.HasAPIKey() {
+		return ErrNoAPIKey
+	}
+	if err := c.quota.reserve(cost); err != nil {
+		return err
+	}
+
+	params.Set("key", c.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dataAPIEndpoint+"/"+resource+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+				Errors  []struct {
+					Reason string `json:"reason"`
+				} `json:"errors"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+
+		for _, e := range apiErr.Error.Errors {
+			if e.Reason == "quotaExceeded" || e.Reason == "dailyLimitExceeded" {
+				c.quota.markExhausted()
+				return ErrQuotaExceeded
+			}
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return ErrVideoNotFound
+		}
+		return fmt.Errorf("youtube data api %s: %s: %s", resource, resp.Status, apiErr.Error.Message)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", resource, err)
+	}
+	return nil
+}
+
+func normalizeTrackKind(kind string) string {
+	switch kind {
+	case "asr", "ASR":
+		return "asr"
+	case "forced", "FORCED":
+		return "forced"
+	default:
+		return "standard"
+	}
+}
+
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseISODuration parses the ISO 8601 durations used by the Data API
+// (e.g. PT1H2M3S). Unparseable values yield zero.
+func parseISODuration(s string) time.Duration {
+	m := isoDurationPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[i+1])
+		d += time.Duration(n) * unit
+	}
+	return d
+}
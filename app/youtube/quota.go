@@ -0,0 +1,97 @@
+package youtube
+
+import (
+	"sync"
+	"time"
+)
+
+// Quota costs of the YouTube Data API methods we call
+const (
+	costVideosList   = 1
+	costCaptionsList = 50
+)
+
+// QuotaStatus reports Data API quota consumption for the current day
+type QuotaStatus struct {
+	Enabled   bool      `json:"enabled"`
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	Exhausted bool      `json:"exhausted"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// quotaTracker keeps a local estimate of Data API quota usage. YouTube resets
+// quotas at midnight Pacific time; the tracker also honours quotaExceeded
+// responses, since other consumers of the same key spend from the same pool.
+type quotaTracker struct {
+	mu        sync.Mutex
+	limit     int
+	used      int
+	exhausted bool
+	resetAt   time.Time
+}
+
+func newQuotaTracker(limit int) *quotaTracker {
+	return &quotaTracker{limit: limit, resetAt: nextQuotaReset(time.Now())}
+}
+
+// reserve records units as spent, failing if that would exceed the quota
+func (q *quotaTracker) reserve(units int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	if q.exhausted || q.used+units > q.limit {
+		return ErrQuotaExceeded
+	}
+	q.used += units
+	return nil
+}
+
+// markExhausted treats the quota as spent until the next reset
+func (q *quotaTracker) markExhausted() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.exhausted = true
+}
+
+func (q *quotaTracker) status() QuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	remaining := q.limit - q.used
+	if q.exhausted || remaining < 0 {
+		remaining = 0
+	}
+	return QuotaStatus{
+		Enabled:   true,
+		Limit:     q.limit,
+		Used:      q.used,
+		Remaining: remaining,
+		Exhausted: q.exhausted || remaining == 0,
+		ResetAt:   q.resetAt,
+	}
+}
+
+// rollover resets usage once the quota day has ended. Callers must hold q.mu.
+func (q *quotaTracker) rollover() {
+	now := time.Now()
+	if now.Before(q.resetAt) {
+		return
+	}
+	q.used = 0
+	q.exhausted = false
+	q.resetAt = nextQuotaReset(now)
+}
+
+// nextQuotaReset returns the next midnight in Pacific time
+func nextQuotaReset(now time.Time) time.Time {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		loc = time.FixedZone("PST", -8*60*60)
+	}
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+}
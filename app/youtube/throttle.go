@@ -0,0 +1,118 @@
+package youtube
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const minBackoff = 30 * time.Second
+
+// Throttle rate limits outbound YouTube traffic for the whole process. It is
+// a token bucket that additionally pauses all traffic with exponential
+// backoff after YouTube signals rate limiting (HTTP 429), to avoid IP bans
+// when large batches are submitted.
+type Throttle struct {
+	mu       sync.Mutex
+	interval time.Duration // time to earn one token
+	burst    float64
+	tokens   float64
+	last     time.Time
+
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	blockedUntil time.Time
+}
+
+// NewThrottle allows perMinute requests per minute on average with bursts of
+// up to burst requests. A non-positive perMinute disables rate limiting but
+// keeps the backoff behaviour.
+func NewThrottle(perMinute, burst int, maxBackoff time.Duration) *Throttle {
+	if burst < 1 {
+		burst = 1
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+
+	var interval time.Duration
+	if perMinute > 0 {
+		interval = time.Minute / time.Duration(perMinute)
+	}
+
+	return &Throttle{
+		interval:   interval,
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		last:       time.Now(),
+		maxBackoff: maxBackoff,
+	}
+}
+
+// Wait blocks until a request may be made or ctx is done
+func (t *Throttle) Wait(ctx context.Context) error {
+	for {
+		delay := t.reserve()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available, otherwise it returns how long
+// to wait before trying again
+func (t *Throttle) reserve() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(t.blockedUntil) {
+		return t.blockedUntil.Sub(now)
+	}
+	if t.interval <= 0 {
+		return 0
+	}
+
+	t.tokens += float64(now.Sub(t.last)) / float64(t.interval)
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+	t.last = now
+
+	if t.tokens >= 1 {
+		t.tokens--
+		return 0
+	}
+	return time.Duration((1 - t.tokens) * float64(t.interval))
+}
+
+// Backoff pauses all traffic, doubling the pause on each consecutive call
+func (t *Throttle) Backoff() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.backoff *= 2
+	if t.backoff < minBackoff {
+		t.backoff = minBackoff
+	}
+	if t.backoff > t.maxBackoff {
+		t.backoff = t.maxBackoff
+	}
+	t.blockedUntil = time.Now().Add(t.backoff)
+	return t.backoff
+}
+
+// Success resets the backoff after a request went through
+func (t *Throttle) Success() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.backoff = 0
+}
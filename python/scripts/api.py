@@ -2,15 +2,33 @@ import argparse
 import json
 import sys
 
+from logs import get_logger
 from transcription import Transcriber
 
+logger = get_logger("api")
+
 
 def main():
     parser = argparse.ArgumentParser(description="Transcribe media")
-    parser.add_argument(
-        "--url", type=str, required=True, help="Media URL(s), comma-separated"
+    source = parser.add_mutually_exclusive_group(required=True)
+    source.add_argument("--url", type=str, help="Media URL(s), comma-separated")
+    source.add_argument(
+        "--file", type=str, help="Downloaded audio file to transcribe directly"
     )
     parser.add_argument("--model", default="base.en", help="Whisper model to use")
+    parser.add_argument(
+        "--language", default=None, help="Audio language code; detected if unset"
+    )
+    parser.add_argument(
+        "--task",
+        choices=["transcribe", "translate"],
+        default="transcribe",
+        help="Transcribe, or translate to English",
+    )
+    parser.add_argument(
+        "--temperature", type=float, default=0.2, help="Sampling temperature"
+    )
+    parser.add_argument("--beam_size", type=int, default=3, help="Beam search width")
     parser.add_argument(
         "--enable_constraints",
         action="store_true",
@@ -18,12 +36,17 @@ def main():
     )
     args = parser.parse_args()
 
-    # Split URLs by comma and clean whitespace
-    urls = [url.strip() for url in args.url.split(",") if url.strip()]
+    # Split URLs by comma and clean whitespace. A file is transcribed in
+    # place of a single URL.
+    if args.file:
+        urls = [args.file]
+    else:
+        urls = [url.strip() for url in args.url.split(",") if url.strip()]
 
     if not urls:
         error_response = {
             "text": None,
+            "segments": [],
             "model_name": args.model,
             "duration": 0,
             "error": "No valid URLs provided.",
@@ -44,11 +67,21 @@ def main():
             model_name=args.model,
             max_video_duration=4 * 3600 if args.enable_constraints else None,
             max_file_size=100 * 1024 * 1024 if args.enable_constraints else None,
+            language=args.language,
+            task=args.task,
+            temperature=args.temperature,
+            beam_size=args.beam_size,
         )
 
         results = []
         for url in urls:
-            result = transcriber.process_url(url)
+            logger.info("Transcribing %s with model %s", url, args.model)
+            if args.file:
+                result = transcriber.process_file(url)
+            else:
+                result = transcriber.process_url(url)
+            if result.get("error"):
+                logger.error("Transcription of %s failed: %s", url, result["error"])
             results.append(result)
 
         transcriber.close()
@@ -63,10 +96,12 @@ def main():
         if isinstance(output, dict):
             formatted_result = {
                 "text": output.get("text"),
+                "segments": output.get("segments") or [],
                 "model_name": output.get("model_name"),
                 "duration": output.get("duration", 0),
                 "error": output.get("error"),
                 "title": output.get("title"),
+                "chapters": output.get("chapters") or [],
                 "url": output.get("url"),
             }
         else:
@@ -75,18 +110,22 @@ def main():
             for item in output:
                 formatted_item = {
                     "text": item.get("text"),
+                    "segments": item.get("segments") or [],
                     "model_name": item.get("model_name"),
                     "duration": item.get("duration", 0),
                     "error": item.get("error"),
                     "title": item.get("title"),
+                    "chapters": item.get("chapters") or [],
                     "url": item.get("url"),
                 }
                 formatted_result.append(formatted_item)
 
     except Exception as e:
+        logger.exception("Unexpected error during transcription")
         # Standardize error output format
         formatted_result = {
             "text": None,
+            "segments": [],
             "model_name": args.model,
             "duration": 0,
             "error": f"Unexpected error: {e}",
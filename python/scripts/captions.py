@@ -0,0 +1,212 @@
+import argparse
+import json
+import re
+import sys
+
+import yt_dlp
+
+from chapters import chapters_from_info
+from logs import get_logger
+
+logger = get_logger("captions")
+
+
+class NullLogger:
+    """A logger class that does nothing. Used to suppress yt_dlp output."""
+
+    def debug(self, msg):
+        pass
+
+    def warning(self, msg):
+        pass
+
+    def error(self, msg):
+        pass
+
+
+class CaptionsError(Exception):
+    """Raised when no usable caption track can be fetched."""
+
+    pass
+
+
+def language_rank(language: str, wanted: list[str]) -> int:
+    """
+    Return the position in wanted of the first language that matches, or -1.
+    Regional variants match their base language either way round.
+    """
+    language = language.lower()
+    base = language.split("-")[0]
+    for i, want in enumerate(wanted):
+        want = want.strip().lower()
+        if language == want or base == want or language == want.split("-")[0]:
+            return i
+    return -1
+
+
+def pick_track(
+    info: dict, languages: list[str] | None = None, manual_only: bool = False
+) -> tuple[str, str, list]:
+    """
+    Choose a caption track from the extracted video info.
+
+    Wanted languages come first in their order, skipping tracks in other
+    languages, then manual subtitles over automatic captions. Without wanted
+    languages, manual subtitles are preferred, and English over other
+    languages. Returns (language, kind, formats).
+    """
+    candidates = []
+    sources = [("manual", info.get("subtitles") or {})]
+    if not manual_only:
+        sources.append(("auto", info.get("automatic_captions") or {}))
+    for kind, tracks in sources:
+        manual = 1 if kind == "manual" else 0
+        for language, formats in tracks.items():
+            # Skip yt-dlp's machine-translated variants of auto captions
+            if kind == "auto" and "-" in language and not language.startswith("en"):
+                continue
+            if languages:
+                rank = language_rank(language, languages)
+                if rank < 0:
+                    continue
+                score = 2 * (len(languages) - rank) + manual
+            else:
+                score = 2 * manual + (1 if language.startswith("en") else 0)
+            candidates.append((score, language, kind, formats))
+
+    if not candidates:
+        raise CaptionsError("No captions available")
+
+    candidates.sort(key=lambda c: c[0], reverse=True)
+    _, language, kind, formats = candidates[0]
+    return language, kind, formats
+
+
+def parse_json3(data: str) -> list[dict]:
+    """Parse YouTube's json3 caption format into segments."""
+    segments = []
+    for event in json.loads(data).get("events", []):
+        text = "".join(seg.get("utf8", "") for seg in event.get("segs") or [])
+        text = text.replace("\n", " ").strip()
+        if not text:
+            continue
+        start = event.get("tStartMs", 0) / 1000
+        end = start + event.get("dDurationMs", 0) / 1000
+        segments.append({"start": start, "end": end, "text": text})
+    return segments
+
+
+VTT_TIMING = re.compile(
+    r"(\d+:)?(\d{2}):(\d{2})\.(\d{3}) --> (\d+:)?(\d{2}):(\d{2})\.(\d{3})"
+)
+
+
+def _vtt_seconds(hours, minutes, seconds, millis) -> float:
+    hours = int(hours[:-1]) if hours else 0
+    return hours * 3600 + int(minutes) * 60 + int(seconds) + int(millis) / 1000
+
+
+def parse_vtt(data: str) -> list[dict]:
+    """Parse a WebVTT document into segments."""
+    segments = []
+    for block in data.split("\n\n"):
+        lines = [line for line in block.strip().splitlines() if line.strip()]
+        for i, line in enumerate(lines):
+            match = VTT_TIMING.search(line)
+            if not match:
+                continue
+            text = " ".join(re.sub(r"<[^>]+>", "", t) for t in lines[i + 1 :]).strip()
+            if text:
+                g = match.groups()
+                segments.append(
+                    {
+                        "start": _vtt_seconds(*g[:4]),
+                        "end": _vtt_seconds(*g[4:]),
+                        "text": text,
+                    }
+                )
+            break
+    return segments
+
+
+def fetch_captions(
+    url: str, languages: list[str] | None = None, manual_only: bool = False
+) -> dict:
+    """Fetch the best caption track for a URL without downloading media."""
+    ydl_opts = {
+        "quiet": True,
+        "no_warnings": True,
+        "skip_download": True,
+        "logger": NullLogger(),  # Suppress yt_dlp logs
+    }
+
+    with yt_dlp.YoutubeDL(ydl_opts) as ydl:
+        info = ydl.extract_info(url, download=False)
+        if not isinstance(info, dict):
+            raise CaptionsError("Failed to extract video information.")
+
+        language, kind, formats = pick_track(info, languages, manual_only)
+        by_ext = {f.get("ext"): f for f in formats if f.get("url")}
+
+        for ext, parser in (("json3", parse_json3), ("vtt", parse_vtt)):
+            if ext not in by_ext:
+                continue
+            data = ydl.urlopen(by_ext[ext]["url"]).read().decode("utf-8")
+            segments = parser(data)
+            if segments:
+                return {
+                    "segments": segments,
+                    "language": language,
+                    "kind": kind,
+                    "title": info.get("title"),
+                    "chapters": chapters_from_info(info),
+                    "error": None,
+                }
+
+    raise CaptionsError(f"Caption track '{language}' has no usable content")
+
+
+def main():
+    parser = argparse.ArgumentParser(description="Fetch existing captions")
+    parser.add_argument("--url", type=str, required=True, help="Media URL")
+    parser.add_argument(
+        "--languages",
+        type=str,
+        default="",
+        help="Wanted caption languages, comma-separated, most wanted first",
+    )
+    parser.add_argument(
+        "--manual_only", action="store_true", help="Skip auto-generated captions"
+    )
+    args = parser.parse_args()
+    languages = [lang for lang in args.languages.split(",") if lang.strip()]
+
+    result = {
+        "segments": [],
+        "language": None,
+        "kind": None,
+        "title": None,
+        "chapters": [],
+        "error": None,
+    }
+
+    try:
+        result = fetch_captions(args.url.strip(), languages, args.manual_only)
+        logger.info(
+            "Fetched %d caption segments",
+            len(result["segments"]),
+            extra={"fields": {"language": result["language"], "kind": result["kind"]}},
+        )
+    except CaptionsError as e:
+        result["error"] = str(e)
+        logger.info("No captions for %s: %s", args.url, e)
+    except Exception as e:
+        result["error"] = f"Unexpected error: {e}"
+        logger.exception("Caption fetch failed")
+
+    sys.stdout.write(json.dumps(result))
+    sys.stdout.flush()
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,22 @@
+"""Chapter metadata shared by the scripts that extract video information."""
+
+
+def chapters_from_info(info: dict) -> list[dict]:
+    """
+    Return the uploader's chapters from yt-dlp video info, with times in
+    seconds. Videos without chapters yield an empty list.
+    """
+    chapters = []
+    for chapter in info.get("chapters") or []:
+        start = chapter.get("start_time")
+        end = chapter.get("end_time")
+        if start is None or end is None or end <= start:
+            continue
+        chapters.append(
+            {
+                "start": float(start),
+                "end": float(end),
+                "title": (chapter.get("title") or "").strip(),
+            }
+        )
+    return chapters
@@ -0,0 +1,89 @@
+"""Logging shared by the scripts invoked from the Go service.
+
+The Go side passes the originating HTTP request ID and the transcription job
+ID through the environment so that everything a script writes to stderr can
+be correlated with the request, the job, and user reports.
+
+Records are written to stderr as one JSON object per line, which the Go
+runner parses and re-emits through its own logger. Extra structured fields
+can be attached with ``logger.info("msg", extra={"fields": {...}})``.
+"""
+
+import json
+import logging
+import os
+import sys
+from datetime import datetime, timezone
+
+REQUEST_ID = os.environ.get("YT_TEXT_REQUEST_ID", "")
+JOB_ID = os.environ.get("YT_TEXT_JOB_ID", "")
+
+
+class CorrelationFilter(logging.Filter):
+    """Attach the request and job IDs to every log record."""
+
+    def filter(self, record):
+        record.request_id = REQUEST_ID or "-"
+        record.job_id = JOB_ID or "-"
+        return True
+
+
+class JSONFormatter(logging.Formatter):
+    """Format records as single-line JSON objects."""
+
+    def format(self, record):
+        entry = {
+            "time": datetime.fromtimestamp(record.created, timezone.utc).isoformat(),
+            "level": record.levelname.lower(),
+            "logger": record.name,
+            "message": record.getMessage(),
+            "request_id": getattr(record, "request_id", "-"),
+            "job_id": getattr(record, "job_id", "-"),
+        }
+        fields = getattr(record, "fields", None)
+        if isinstance(fields, dict):
+            for key, value in fields.items():
+                entry.setdefault(key, value)
+        if record.exc_info:
+            entry["exception"] = self.formatException(record.exc_info)
+        return json.dumps(entry, default=str)
+
+
+def report_progress(stage: str, progress: float) -> None:
+    """
+    Report how far the current stage is, as a fraction from 0 to 1.
+
+    Progress lines share stderr with log records; the Go runner recognizes
+    them by their "event" key and forwards them to the job queue.
+    """
+    entry = {"event": "progress", "stage": stage, "progress": round(progress, 3)}
+    sys.stderr.write(json.dumps(entry) + "\n")
+    sys.stderr.flush()
+
+
+def report_segment(start: float, end: float, text: str) -> None:
+    """
+    Report a transcript segment as soon as it is decoded, so clients can
+    read a long transcription while it runs. Sent like progress lines.
+    """
+    entry = {
+        "event": "segment",
+        "start": round(start, 3),
+        "end": round(end, 3),
+        "text": text,
+    }
+    sys.stderr.write(json.dumps(entry) + "\n")
+    sys.stderr.flush()
+
+
+def get_logger(name: str) -> logging.Logger:
+    """Return a logger that writes to stderr, leaving stdout for JSON results."""
+    logger = logging.getLogger(name)
+    if not logger.handlers:
+        handler = logging.StreamHandler(sys.stderr)
+        handler.addFilter(CorrelationFilter())
+        handler.setFormatter(JSONFormatter())
+        logger.addHandler(handler)
+        logger.setLevel(logging.INFO)
+        logger.propagate = False
+    return logger
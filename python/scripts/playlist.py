@@ -0,0 +1,111 @@
+import argparse
+import json
+import sys
+
+import yt_dlp
+
+from logs import get_logger
+
+logger = get_logger("playlist")
+
+
+class NullLogger:
+    """A logger class that does nothing. Used to suppress yt_dlp output."""
+
+    def debug(self, msg):
+        pass
+
+    def warning(self, msg):
+        pass
+
+    def error(self, msg):
+        pass
+
+
+class PlaylistError(Exception):
+    """Raised when a URL can't be expanded into videos."""
+
+    pass
+
+
+def entry_url(entry: dict) -> str | None:
+    """Return a watchable URL for a flat playlist entry."""
+    url = entry.get("url") or ""
+    if url.startswith("http"):
+        return url
+    if entry.get("id"):
+        return f"https://www.youtube.com/watch?v={entry['id']}"
+    return None
+
+
+def flatten(info: dict, limit: int, videos: list) -> None:
+    """
+    Collect video entries, descending into nested playlists such as the
+    Videos and Shorts tabs of a channel.
+    """
+    for entry in info.get("entries") or []:
+        if len(videos) >= limit:
+            return
+        if not isinstance(entry, dict):
+            continue
+        if entry.get("_type") == "playlist" or entry.get("entries"):
+            flatten(entry, limit, videos)
+            continue
+        url = entry_url(entry)
+        if url:
+            videos.append({"url": url, "title": entry.get("title")})
+
+
+def expand_playlist(url: str, limit: int) -> dict:
+    """Enumerate the videos of a playlist or channel without downloading."""
+    ydl_opts = {
+        "quiet": True,
+        "no_warnings": True,
+        "extract_flat": "in_playlist",
+        "playlistend": limit,
+        "logger": NullLogger(),  # Suppress yt_dlp logs
+    }
+
+    with yt_dlp.YoutubeDL(ydl_opts) as ydl:
+        info = ydl.extract_info(url, download=False)
+        if not isinstance(info, dict):
+            raise PlaylistError("Failed to extract playlist information.")
+
+        videos = []
+        flatten(info, limit, videos)
+        if not videos:
+            raise PlaylistError("Playlist has no videos")
+
+        return {
+            "videos": videos,
+            "title": info.get("title"),
+            "error": None,
+        }
+
+
+def main():
+    parser = argparse.ArgumentParser(description="Expand a playlist or channel")
+    parser.add_argument("--url", type=str, required=True, help="Playlist URL")
+    parser.add_argument(
+        "--limit", type=int, default=50, help="Maximum number of videos"
+    )
+    args = parser.parse_args()
+
+    result = {"videos": [], "title": None, "error": None}
+
+    try:
+        result = expand_playlist(args.url.strip(), args.limit)
+        logger.info("Expanded %s into %d videos", args.url, len(result["videos"]))
+    except PlaylistError as e:
+        result["error"] = str(e)
+        logger.info("Could not expand %s: %s", args.url, e)
+    except Exception as e:
+        result["error"] = f"Unexpected error: {e}"
+        logger.exception("Playlist expansion failed")
+
+    sys.stdout.write(json.dumps(result))
+    sys.stdout.flush()
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,131 @@
+import argparse
+import json
+import math
+import re
+import sys
+from collections import Counter
+
+from logs import get_logger
+
+logger = get_logger("summarize")
+
+MODEL_NAME = "extractive"
+
+# Common English words that say nothing about a transcript's topic
+STOPWORDS = frozenset(
+    """
+    a about above after again against all also am an and any are as at be
+    because been before being below between both but by can could did do does
+    doing down during each few for from further get got had has have having he
+    her here hers herself him himself his how i if in into is it its itself
+    just know like me more most my myself no nor not now of off on once only
+    or other our ours ourselves out over own really right same say she should
+    so some such than that the their theirs them themselves then there these
+    they this those through to too under until up very was we well were what
+    when where which while who whom why will with would yeah you your yours
+    yourself yourselves
+    """.split()
+)
+
+SENTENCE_END = re.compile(r"(?<=[.!?])\s+")
+WORD = re.compile(r"[a-zA-Z']+")
+
+
+class SummaryError(Exception):
+    """Raised when a transcript can't be summarized."""
+
+    pass
+
+
+def split_sentences(text: str) -> list[str]:
+    """
+    Split a transcript into sentences. Whisper output without punctuation is
+    cut into fixed-size word runs instead.
+    """
+    sentences = [s.strip() for s in SENTENCE_END.split(text) if s.strip()]
+    if len(sentences) > 1:
+        return sentences
+
+    words = text.split()
+    return [" ".join(words[i : i + 25]) for i in range(0, len(words), 25)]
+
+
+def key_sentences(text: str, max_sentences: int) -> list[str]:
+    """
+    Pick the sentences whose words are most frequent across the transcript
+    and return them in their original order.
+    """
+    sentences = split_sentences(text)
+    if not sentences:
+        raise SummaryError("Transcript is empty")
+    if len(sentences) <= max_sentences:
+        return sentences
+
+    frequencies = Counter(
+        w for w in (w.lower() for w in WORD.findall(text)) if w not in STOPWORDS
+    )
+    if not frequencies:
+        return sentences[:max_sentences]
+    top = max(frequencies.values())
+
+    def score(sentence: str) -> float:
+        words = [w.lower() for w in WORD.findall(sentence)]
+        if not words:
+            return 0.0
+        total = sum(frequencies.get(w, 0) for w in words) / top
+        # Dampen the advantage of long sentences
+        return total / math.sqrt(len(words))
+
+    ranked = sorted(
+        range(len(sentences)), key=lambda i: score(sentences[i]), reverse=True
+    )
+    chosen = sorted(ranked[:max_sentences])
+    return [sentences[i] for i in chosen]
+
+
+def summarize(text: str, max_sentences: int, style: str = "abstract") -> str:
+    """
+    Join the key sentences into a paragraph, or list them one per line for
+    the bullets style.
+    """
+    sentences = key_sentences(text, max_sentences)
+    if style == "bullets":
+        return "\n".join("- " + s for s in sentences)
+    return " ".join(sentences)
+
+
+def main():
+    parser = argparse.ArgumentParser(description="Summarize a transcript")
+    parser.add_argument(
+        "--max_sentences", type=int, default=5, help="Sentences in the summary"
+    )
+    parser.add_argument(
+        "--style",
+        choices=["abstract", "bullets"],
+        default="abstract",
+        help="A paragraph, or one sentence per line",
+    )
+    args = parser.parse_args()
+
+    result = {"summary": None, "model_name": MODEL_NAME, "error": None}
+
+    try:
+        # The transcript comes on stdin, since it can exceed argument limits
+        text = sys.stdin.read()
+        result["summary"] = summarize(text, max(args.max_sentences, 1), args.style)
+        logger.info(
+            "Summarized %d characters into %d", len(text), len(result["summary"])
+        )
+    except SummaryError as e:
+        result["error"] = str(e)
+        logger.info("Could not summarize: %s", e)
+    except Exception as e:
+        result["error"] = f"Unexpected error: {e}"
+        logger.exception("Summarization failed")
+
+    sys.stdout.write(json.dumps(result))
+    sys.stdout.flush()
+
+
+if __name__ == "__main__":
+    main()
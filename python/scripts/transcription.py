@@ -7,6 +7,9 @@ import torch
 import yt_dlp
 from faster_whisper import WhisperModel
 
+from chapters import chapters_from_info
+from logs import report_progress, report_segment
+
 
 class TranscriptionError(Exception):
     """Base exception for transcription errors"""
@@ -27,6 +30,20 @@ class NullLogger:
         pass
 
 
+class ProgressReporter:
+    """Report a stage's progress, skipping updates smaller than one percent."""
+
+    def __init__(self, stage: str):
+        self.stage = stage
+        self.last = -1.0
+
+    def update(self, progress: float):
+        progress = min(max(progress, 0.0), 1.0)
+        if progress - self.last >= 0.01 or (progress == 1.0 and self.last < 1.0):
+            self.last = progress
+            report_progress(self.stage, progress)
+
+
 class Transcriber:
     def __init__(
         self,
@@ -35,6 +52,10 @@ class Transcriber:
         compute_type: Optional[str] = None,
         max_video_duration: Optional[int] = None,
         max_file_size: Optional[int] = None,
+        language: Optional[str] = None,
+        task: str = "transcribe",
+        temperature: float = 0.2,
+        beam_size: int = 3,
     ):
         self.model_name = model_name
         self.device = device or ("cuda" if torch.cuda.is_available() else "cpu")
@@ -44,6 +65,14 @@ class Transcriber:
         self.max_video_duration = max_video_duration
         self.max_file_size = max_file_size
 
+        # English-only models can't detect or translate other languages
+        if language is None and model_name.endswith(".en"):
+            language = "en"
+        self.language = language
+        self.task = task
+        self.temperature = temperature
+        self.beam_size = beam_size
+
         self.model = WhisperModel(
             self.model_name,
             device=self.device,
@@ -55,14 +84,17 @@ class Transcriber:
         """Process a single URL and return transcription result."""
         try:
             with tempfile.TemporaryDirectory() as temp_dir:
-                # Download audio and retrieve media title
-                audio_path, media_title = self._download_audio(url, temp_dir)
+                # Download audio and retrieve media title and chapters
+                audio_path, media_title, chapters = self._download_audio(
+                    url, temp_dir
+                )
 
                 # Transcribe
                 transcription = self._transcribe(audio_path)
 
                 # Include title and URL in the result
                 transcription["title"] = media_title
+                transcription["chapters"] = chapters
                 transcription["url"] = url
 
                 return transcription
@@ -71,6 +103,7 @@ class Transcriber:
             return {
                 "error": str(te),
                 "text": None,
+                "segments": [],
                 "model_name": self.model_name,
                 "duration": 0,
                 "title": None,
@@ -80,15 +113,50 @@ class Transcriber:
             return {
                 "error": f"Unexpected error: {e}",
                 "text": None,
+                "segments": [],
                 "model_name": self.model_name,
                 "duration": 0,
                 "title": None,
                 "url": url,
             }
 
-    def _download_audio(self, url: str, temp_dir: str) -> tuple[str, str]:
-        """Download audio from URL and retrieve media title."""
+    def process_file(self, path: str) -> Dict:
+        """Transcribe an audio file that was already downloaded."""
+        try:
+            transcription = self._transcribe(path)
+            transcription["title"] = None
+            transcription["chapters"] = []
+            transcription["url"] = path
+            return transcription
+
+        except TranscriptionError as te:
+            error = str(te)
+        except Exception as e:
+            error = f"Unexpected error: {e}"
+
+        return {
+            "error": error,
+            "text": None,
+            "segments": [],
+            "model_name": self.model_name,
+            "duration": 0,
+            "title": None,
+            "url": path,
+        }
+
+    def _download_audio(self, url: str, temp_dir: str) -> tuple[str, str, list]:
+        """Download audio from URL and retrieve media title and chapters."""
+        reporter = ProgressReporter("downloading")
+
+        def on_progress(status: dict):
+            total = status.get("total_bytes") or status.get("total_bytes_estimate")
+            if status.get("status") == "finished":
+                reporter.update(1.0)
+            elif total:
+                reporter.update(status.get("downloaded_bytes", 0) / total)
+
         ydl_opts = {
+            "progress_hooks": [on_progress],
             "format": "bestaudio/best",
             "outtmpl": os.path.join(temp_dir, "%(id)s.%(ext)s"),
             "quiet": True,
@@ -106,6 +174,7 @@ class Transcriber:
 
                 # Retrieve media title
                 media_title = info.get("title") or url
+                chapters = chapters_from_info(info)
 
                 # Validate duration if constraint is set
                 duration = info.get("duration", 0)
@@ -128,7 +197,7 @@ class Transcriber:
                         f"Downloaded file size ({file_size} bytes) exceeds maximum allowed ({self.max_file_size} bytes)"
                     )
 
-                return downloaded_file, media_title
+                return downloaded_file, media_title, chapters
 
         except TranscriptionError:
             raise
@@ -141,24 +210,47 @@ class Transcriber:
             start_time = time.time()
             segments, info = self.model.transcribe(
                 audio_path,
-                beam_size=3,
-                temperature=0.2,
+                beam_size=self.beam_size,
+                temperature=self.temperature,
                 best_of=1,
                 condition_on_previous_text=True,
                 vad_filter=True,
                 vad_parameters=dict(min_silence_duration_ms=500),
-                language="en",
+                language=self.language,
+                task=self.task,
             )
 
-            segments = list(segments)
-            if not segments:
+            # Segments are decoded lazily, so progress is how far into the
+            # audio the latest segment ends
+            reporter = ProgressReporter("transcribing")
+            reporter.update(0.0)
+            timed = []
+            for seg in segments:
+                if info.duration:
+                    reporter.update(seg.end / info.duration)
+                if seg.text.strip():
+                    timed.append(
+                        {
+                            "start": seg.start,
+                            "end": seg.end,
+                            "text": seg.text.strip(),
+                            # Confidence, for flagging questionable text
+                            "avg_logprob": round(seg.avg_logprob, 4),
+                            "no_speech_prob": round(seg.no_speech_prob, 4),
+                        }
+                    )
+                    report_segment(seg.start, seg.end, seg.text.strip())
+            reporter.update(1.0)
+
+            if not timed:
                 raise TranscriptionError("No speech detected")
 
             # Combine segments into single text
-            text = " ".join(seg.text.strip() for seg in segments if seg.text.strip())
+            text = " ".join(seg["text"] for seg in timed)
 
             return {
                 "text": text,
+                "segments": timed,
                 "model_name": self.model_name,
                 "duration": time.time() - start_time,
                 "error": None,
@@ -4,6 +4,10 @@ import sys
 
 import yt_dlp
 
+from logs import get_logger
+
+logger = get_logger("validate")
+
 
 class ValidationError(Exception):
     """Base exception for validation errors."""
@@ -24,9 +28,6 @@ class NullLogger:
         pass
 
 
-MAX_VIDEO_DURATION = 4 * 3600  # 4 hours in seconds
-
-
 def validate_url(url: str) -> dict:
     """
     Validate the media URL.
@@ -35,12 +36,18 @@ def validate_url(url: str) -> dict:
         url (str): The URL to validate.
 
     Returns:
-        dict: Validation result containing 'valid', 'duration', 'format', and 'error'.
+        dict: Validation result containing 'valid', 'duration', 'filesize',
+            'format', 'language', 'uploader', 'live_status' and 'error'.
+            Duration and size limits and live streams are left to the caller.
     """
     result = {
         "valid": False,
         "duration": 0,
+        "filesize": 0,
         "format": "",
+        "language": "",
+        "uploader": "",
+        "live_status": "",
         "error": "",
         "url": url,
     }
@@ -58,22 +65,21 @@ def validate_url(url: str) -> dict:
             if not isinstance(info, dict):
                 raise ValidationError("Failed to extract video information.")
 
-            duration = info.get("duration", 0)
+            duration = info.get("duration") or 0
             format_ext = info.get("ext", "")
 
-            if duration > MAX_VIDEO_DURATION:
-                raise ValidationError(
-                    f"Video too long: {duration} seconds (max: {MAX_VIDEO_DURATION} seconds)"
-                )
-
-            # Additional validations can be added here (e.g., supported formats)
-
             # If all validations pass
             result.update(
                 {
                     "valid": True,
                     "duration": duration,
+                    "filesize": audio_size(info),
                     "format": format_ext,
+                    "language": info.get("language") or "",
+                    "uploader": info.get("uploader") or info.get("channel") or "",
+                    # "is_live", "is_upcoming" or "post_live" while there is
+                    # no finished recording to download yet
+                    "live_status": info.get("live_status") or "",
                     "error": "",
                 }
             )
@@ -85,9 +91,28 @@ def validate_url(url: str) -> dict:
     except Exception as e:
         result["error"] = f"Unexpected error: {str(e)}"
 
+    if result["error"]:
+        logger.warning("Validation of %s failed: %s", url, result["error"])
+
     return result
 
 
+def audio_size(info: dict) -> int:
+    """
+    Estimate the size in bytes of the audio a transcription downloads: the
+    largest audio-only format, as yt-dlp picks the best one. Returns 0 when
+    the site doesn't report sizes.
+    """
+    sizes = [
+        f.get("filesize") or f.get("filesize_approx") or 0
+        for f in info.get("formats") or []
+        if f.get("acodec") not in (None, "none") and f.get("vcodec") in (None, "none")
+    ]
+    if sizes:
+        return int(max(sizes))
+    return int(info.get("filesize") or info.get("filesize_approx") or 0)
+
+
 def main():
     parser = argparse.ArgumentParser(description="Validate Media URL")
     parser.add_argument("--url", type=str, required=True, help="URL to validate")
@@ -115,7 +140,11 @@ def main():
         formatted_result = {
             "valid": result["valid"],
             "duration": result["duration"],
+            "filesize": result["filesize"],
             "format": result["format"],
+            "language": result["language"],
+            "uploader": result["uploader"],
+            "live_status": result["live_status"],
             "error": result["error"],
             "url": result["url"],
         }